@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/java-startup-analyzer/internal/analyzer"
+	"github.com/user/java-startup-analyzer/internal/report"
+)
+
+// 分析结论对应的进程退出码，1 保留给分析器自身的错误
+const (
+	exitStarted  = 0
+	exitFailed   = 2
+	exitDegraded = 3
+	exitUnknown  = 4
+)
+
+// analyzeCmd represents the analyze command
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "非交互式分析日志，适用于脚本和CI",
+	Long: `以非交互方式分析Java启动日志，将诊断结果流式输出到标准输出。
+
+不需要终端，也不要求配置启动命令，适合在 Jenkins、部署脚本中使用。
+API密钥等配置可以来自配置文件、命令行标志或环境变量。
+
+输出格式 (--format)：
+  markdown  诊断文本直接输出到标准输出（默认）
+  json      结构化报告：结论、根因分类、带文件和行号的证据、修复建议
+  sarif     SARIF 2.1.0，便于在代码扫描平台和流水线中展示
+使用 json/sarif 时，诊断过程输出到标准错误，标准输出只包含报告。
+
+退出码：
+  0  应用启动成功
+  1  分析器自身出错（配置错误、LLM调用失败等）
+  2  应用启动失败
+  3  应用启动成功但存在问题
+  4  无法从分析结果中判断启动结论`,
+	Example: `  java-analyzer analyze --log /var/log/app/app.log
+  java-analyzer analyze --log app.log --log 'logs/gc*.log' --log nohup.out
+  java-analyzer analyze --config config.yaml --timeout 5m
+  java-analyzer analyze --log app.log --format json > report.json`,
+	RunE: runAnalyze,
+}
+
+var (
+	analyzeLogPaths []string
+	analyzeTimeout  time.Duration
+	analyzeFormat   string
+)
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.Flags().StringSliceVar(&analyzeLogPaths, "log", nil, "要分析的日志文件路径或glob模式，可重复指定 (覆盖配置中的 log_path)")
+	analyzeCmd.Flags().DurationVar(&analyzeTimeout, "timeout", 10*time.Minute, "分析的最长时间")
+	analyzeCmd.Flags().StringVar(&analyzeFormat, "format", "markdown", "输出格式 (markdown, json, sarif)")
+}
+
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	// 创建分析器配置
+	analyzerConfig := newAnalyzerConfig()
+	if len(analyzeLogPaths) > 0 {
+		analyzerConfig.LogPaths = analyzeLogPaths
+	}
+
+	switch analyzeFormat {
+	case "markdown", "json", "sarif":
+	default:
+		return fmt.Errorf("不支持的输出格式: %s (可选 markdown, json, sarif)", analyzeFormat)
+	}
+
+	// 验证配置
+	if err := analyzerConfig.ValidateForAnalyze(); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+	printConfigWarnings(analyzerConfig)
+	cmd.SilenceUsage = true
+
+	javaAnalyzer, err := analyzer.NewJavaAnalyzer(analyzerConfig)
+	if err != nil {
+		return fmt.Errorf("创建分析器失败: %w", err)
+	}
+	defer javaAnalyzer.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, analyzeTimeout)
+	defer cancel()
+
+	streamReader, err := javaAnalyzer.ChatStream(ctx, map[string]any{"log_paths": analyzerConfig.LogPaths})
+	if err != nil {
+		return fmt.Errorf("分析失败: %w", err)
+	}
+	defer streamReader.Close()
+
+	// 结构化格式下标准输出只保留报告
+	var out io.Writer = os.Stdout
+	if analyzeFormat != "markdown" {
+		out = os.Stderr
+	}
+
+	// 边接收边输出，同时保留完整内容用于提取结论
+	var answer strings.Builder
+	for {
+		message, err := streamReader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("分析失败: %w", err)
+		}
+		answer.WriteString(message.Content)
+		fmt.Fprint(out, message.Content)
+	}
+	fmt.Fprintln(out)
+
+	if logPath := javaAnalyzer.GetLogPath(); logPath != "" {
+		fmt.Fprintf(os.Stderr, "详细分析日志已保存到: %s\n", logPath)
+	}
+
+	verdict := analyzer.ParseVerdict(answer.String())
+	if analyzeFormat != "markdown" {
+		r, err := javaAnalyzer.Report(ctx, answer.String())
+		if err != nil {
+			return err
+		}
+		if err := report.Write(os.Stdout, analyzeFormat, r); err != nil {
+			return fmt.Errorf("输出报告失败: %w", err)
+		}
+		// 分析结果中没有结论标记时，采用报告整理出的结论
+		if verdict == analyzer.VerdictUnknown {
+			verdict = analyzer.Verdict(r.Verdict)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "分析结论: %s\n", verdict)
+	return verdictExit(cmd, verdict)
+}
+
+// verdictExit 将分析结论转换为进程退出码
+func verdictExit(cmd *cobra.Command, verdict analyzer.Verdict) error {
+	code := exitUnknown
+	switch verdict {
+	case analyzer.VerdictStarted:
+		code = exitStarted
+	case analyzer.VerdictFailed:
+		code = exitFailed
+	case analyzer.VerdictDegraded:
+		code = exitDegraded
+	}
+	if code == exitStarted {
+		return nil
+	}
+
+	// 结论已经输出，不再重复打印错误信息
+	cmd.SilenceErrors = true
+	return &exitError{code: code, verdict: verdict}
+}
+
+// exitError 携带指定退出码的错误
+type exitError struct {
+	code    int
+	verdict analyzer.Verdict
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("分析结论: %s", e.verdict)
+}
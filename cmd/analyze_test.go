@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/user/java-startup-analyzer/internal/analyzer"
+)
+
+func TestVerdictExit(t *testing.T) {
+	tests := []struct {
+		text    string
+		verdict analyzer.Verdict
+		code    int
+	}{
+		{"应用已正常启动。\n\nVERDICT: STARTED", analyzer.VerdictStarted, exitStarted},
+		{"数据源连接池告警。\nVERDICT: DEGRADED\n", analyzer.VerdictDegraded, exitDegraded},
+		{"端口被占用。\nverdict: failed", analyzer.VerdictFailed, exitFailed},
+		{"VERDICT：FAILED", analyzer.VerdictFailed, exitFailed},
+		{"VERDICT: **DEGRADED**", analyzer.VerdictDegraded, exitDegraded},
+		{"**VERDICT: STARTED**", analyzer.VerdictStarted, exitStarted},
+		// 以最后一个结论标记为准
+		{"初步判断 VERDICT: FAILED，重试后恢复。\nVERDICT: STARTED", analyzer.VerdictStarted, exitStarted},
+		{"VERDICT: MAYBE", analyzer.VerdictUnknown, exitUnknown},
+		{"没有结论标记的回答", analyzer.VerdictUnknown, exitUnknown},
+		{"", analyzer.VerdictUnknown, exitUnknown},
+	}
+	for _, tt := range tests {
+		verdict := analyzer.ParseVerdict(tt.text)
+		if verdict != tt.verdict {
+			t.Errorf("ParseVerdict(%q) = %s, 期望 %s", tt.text, verdict, tt.verdict)
+			continue
+		}
+
+		cmd := &cobra.Command{}
+		err := verdictExit(cmd, verdict)
+		code := exitStarted
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		} else if err != nil {
+			t.Errorf("%s: 意外的错误 %v", verdict, err)
+		}
+		if code != tt.code {
+			t.Errorf("%s 的退出码为 %d, 期望 %d", verdict, code, tt.code)
+		}
+		// 非零退出码时结论已经输出，不再打印错误
+		if cmd.SilenceErrors != (tt.code != exitStarted) {
+			t.Errorf("%s: SilenceErrors = %v", verdict, cmd.SilenceErrors)
+		}
+	}
+}
@@ -5,8 +5,6 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"github.com/user/java-startup-analyzer/internal/analyzer"
 	"github.com/user/java-startup-analyzer/internal/ui"
 )
 
@@ -37,21 +35,13 @@ func runChat(cmd *cobra.Command, args []string) error {
 	}
 
 	// 创建分析器配置
-	analyzerConfig := &analyzer.Config{
-		Model:     viper.GetString("model"),
-		ModelName: viper.GetString("model_name"),
-		APIKey:    viper.GetString("api_key"),
-		BaseURL:   viper.GetString("base_url"),
-		Verbose:   viper.GetBool("verbose"),
-		StartCmd:  viper.GetString("start_cmd"),
-		LogPath:   viper.GetString("log_path"),
-		GitRepo:   viper.GetString("git_repo"),
-	}
+	analyzerConfig := newAnalyzerConfig()
 
 	// 验证配置
 	if err := analyzerConfig.Validate(); err != nil {
 		return fmt.Errorf("配置验证失败: %w", err)
 	}
+	printConfigWarnings(analyzerConfig)
 
 	// 创建聊天模型
 	chatModel, err := ui.NewChatModel(analyzerConfig)
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/user/java-startup-analyzer/internal/analyzer"
 )
 
 var cfgFile string
@@ -21,13 +23,18 @@ var rootCmd = &cobra.Command{
 并提供专业的诊断和修复建议。
 
 所有配置（包括模型选择、API密钥等）都通过配置文件进行设置。
-使用交互式聊天模式：java-analyzer chat --config config.yaml`,
+使用交互式聊天模式：java-analyzer chat --config config.yaml
+在脚本和CI中使用：java-analyzer analyze --log app.log`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -47,6 +54,9 @@ func init() {
 	viper.BindPFlag("api_key", rootCmd.PersistentFlags().Lookup("api-key"))
 	viper.BindPFlag("base_url", rootCmd.PersistentFlags().Lookup("base-url"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+
+	// 默认值
+	viper.SetDefault("hang_timeout", analyzer.DefaultConfig().HangTimeout)
 }
 
 // initConfig 读取配置文件和环境变量
@@ -72,3 +82,56 @@ func initConfig() {
 		}
 	}
 }
+
+// newAnalyzerConfig 从配置文件和命令行标志构建分析器配置
+func newAnalyzerConfig() *analyzer.Config {
+	return &analyzer.Config{
+		Model:     viper.GetString("model"),
+		ModelName: viper.GetString("model_name"),
+		APIKey:    viper.GetString("api_key"),
+		BaseURL:   viper.GetString("base_url"),
+		Verbose:   viper.GetBool("verbose"),
+		StartCmd:  viper.GetString("start_cmd"),
+		LogPaths:  configStringList("log_path"),
+		GitRepo:   viper.GetString("git_repo"),
+
+		AllowRoots:   configStringList("sandbox.allow_roots"),
+		DenyPatterns: configStringList("sandbox.deny"),
+
+		RedactPatterns:   configStringList("redact.patterns"),
+		DisableRedaction: viper.GetBool("redact.disabled"),
+
+		StartupTimeout: viper.GetDuration("startup_timeout"),
+		HangTimeout:    viper.GetDuration("hang_timeout"),
+	}
+}
+
+// printConfigWarnings 输出验证配置时发现的问题
+func printConfigWarnings(c *analyzer.Config) {
+	for _, warning := range c.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+	}
+}
+
+// configStringList 读取既可以是单个字符串也可以是列表的配置项
+func configStringList(key string) []string {
+	switch v := viper.Get(key).(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		var list []string
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+		return list
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
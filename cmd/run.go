@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"github.com/user/java-startup-analyzer/internal/jvm"
+	"github.com/user/java-startup-analyzer/internal/runner"
+	"github.com/user/java-startup-analyzer/internal/shell"
+	"github.com/user/java-startup-analyzer/internal/ui"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "启动Java程序并自动分析运行结果",
+	Long: `执行配置文件中的启动命令，并在进程结束、启动完成或卡死时自动分析。
+
+在运行模式中，工具会：
+- 启动前对照容器 (cgroup) 和主机的内存、CPU限制检查 -Xmx 等JVM参数
+- 在独立的进程组中执行 start_cmd
+- 将进程的标准输出和标准错误捕获到 log_path 所在目录
+- 实时监视日志中的启动完成标志 (Started X in N seconds) 和嵌入式服务器启动
+- 超过 hang_timeout 没有任何日志进展，或超过 startup_timeout 仍未启动完成时判定为卡死，
+  并通过 jcmd/jstack/kill -3 采集线程转储
+- 记录进程的退出码或终止信号
+- 自动进入聊天模式，结合运行结果和日志进行分析
+
+启动完成或卡死时进程会继续运行；运行期间按 Ctrl+C 会终止整个进程组并开始分析。`,
+	RunE: runRun,
+}
+
+var runQuiet bool
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().BoolVarP(&runQuiet, "quiet", "q", false, "不在终端回显进程输出")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	// 检查配置文件是否指定
+	if cfgFile == "" {
+		return fmt.Errorf("请指定配置文件，使用 --config 参数")
+	}
+
+	// 创建分析器配置
+	analyzerConfig := newAnalyzerConfig()
+
+	// 验证配置，此时日志文件可能尚未生成
+	if err := analyzerConfig.ValidateForRun(); err != nil {
+		return fmt.Errorf("配置验证失败: %w", err)
+	}
+
+	// Ctrl+C 或 SIGTERM 时终止进程组，然后继续分析
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := runner.Options{
+		Command:        analyzerConfig.StartCmd,
+		OutputDir:      analyzerConfig.RunOutputDir(),
+		LogPaths:       analyzerConfig.LogPatterns,
+		StartupTimeout: analyzerConfig.StartupTimeout,
+		HangTimeout:    analyzerConfig.HangTimeout,
+	}
+	if !runQuiet {
+		opts.Echo = os.Stdout
+	}
+
+	// 应用继承当前进程的环境变量，分析配置时以此为准
+	analyzerConfig.AppEnv = os.Environ()
+	preflightJVM(analyzerConfig.StartCmd)
+	fmt.Fprintf(os.Stderr, "▶ 启动: %s\n", analyzerConfig.StartCmd)
+	result, err := runner.Run(ctx, opts)
+	if err != nil && result == nil {
+		return fmt.Errorf("运行启动命令失败: %w", err)
+	}
+	stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "■ 进程 %d: %s (运行 %s)\n", result.PID, result.Status(), result.Duration.Round(time.Millisecond))
+	if result.State == runner.StateHung {
+		fmt.Fprintf(os.Stderr, "⚠️  %s，已采集 %d 份线程转储\n", result.HangReason, len(result.ThreadDumps))
+	}
+
+	// 重新展开日志路径以包含运行期间新生成的文件，没有日志时退回到分析捕获的标准输出
+	if err := analyzerConfig.ValidateForAnalyze(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v，改为分析标准输出: %s\n", err, result.StdoutPath)
+		analyzerConfig.LogPaths = []string{result.StdoutPath}
+		analyzerConfig.LogPath = result.StdoutPath
+	}
+	printConfigWarnings(analyzerConfig)
+
+	// 创建聊天模型
+	chatModel, err := ui.NewChatModel(analyzerConfig)
+	if err != nil {
+		return fmt.Errorf("创建聊天界面失败: %w", err)
+	}
+	chatModel.SetRunResult(result)
+
+	// 启动Bubble Tea程序
+	p := tea.NewProgram(chatModel, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("启动聊天界面失败: %w", err)
+	}
+
+	return nil
+}
+
+// preflightJVM 启动前对照容器和主机的资源限制检查JVM参数，只输出提示，不阻止启动
+func preflightJVM(command string) {
+	opts := jvm.ParseOptions(command, shell.Environ(os.Environ()))
+	report := jvm.Analyze(opts, jvm.ReadLimits("/"))
+	if !opts.Java() && report.Limits.MemoryLimit == 0 {
+		return
+	}
+
+	limit := "不限制"
+	if report.Limits.MemoryLimit > 0 {
+		limit = jvm.FormatSize(report.Limits.MemoryLimit)
+	}
+	fmt.Fprintf(os.Stderr, "▶ JVM: 最大堆 %s，预计占用 %s，内存限制 %s，%d 个处理器，%s GC\n",
+		jvm.FormatSize(report.Heap), jvm.FormatSize(report.Total), limit, report.Processors, report.GC)
+	for _, f := range report.Findings {
+		if f.Severity != jvm.SeverityInfo {
+			fmt.Fprintf(os.Stderr, "⚠️  %s。%s\n", f.Message, f.Advice)
+		}
+	}
+}
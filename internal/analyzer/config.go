@@ -4,19 +4,37 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/runner"
 )
 
 // Config 分析器配置
 type Config struct {
-	Model     string // LLM模型提供商
-	ModelName string // 具体模型名称 (如 gpt-4.1, gpt-3.5-turbo)
-	APIKey    string // API密钥
-	BaseURL   string // API基础URL
-	Verbose   bool   // 详细输出模式
-	StartCmd  string // 启动命令 (必需)
-	LogPath   string // 日志文件路径 (必需)
-	LogDir    string // 分析器日志目录 (可选，默认为 ./logs)
-	GitRepo   string // Git仓库路径 (可选)
+	Model     string   // LLM模型提供商
+	ModelName string   // 具体模型名称 (如 gpt-4.1, gpt-3.5-turbo)
+	APIKey    string   // API密钥
+	BaseURL   string   // API基础URL
+	Verbose   bool     // 详细输出模式
+	StartCmd  string   // 启动命令 (必需)
+	LogPath   string   // 主日志文件路径，验证后为 LogPaths 中的第一个文件
+	LogPaths  []string // 日志文件路径或glob模式列表 (与 LogPath 至少设置一个)，验证后为展开的绝对路径
+	LogDir    string   // 分析器日志目录 (可选，默认为 ./logs)
+	GitRepo   string   // Git仓库路径 (可选)
+
+	LogPatterns []string // 验证后为展开前的绝对路径模式，run 命令运行期间据此发现新生成的日志
+	Warnings    []string // 验证时发现但不影响分析的问题，如没有匹配任何文件的日志路径模式
+	AppEnv      []string // 应用进程继承的环境变量，仅 run 命令启动应用时设置；分析已有日志时只使用启动命令中的赋值
+
+	AllowRoots   []string // 工具可以访问的额外目录 (可选)，日志所在目录和Git仓库始终允许
+	DenyPatterns []string // 工具禁止访问的文件glob模式 (可选)，在内置的凭据文件模式之外追加
+
+	RedactPatterns   []string // 额外的脱敏正则 (可选)，含分组时只替换第一个分组
+	DisableRedaction bool     // 关闭发送给模型前的敏感信息脱敏 (可选)
+
+	StartupTimeout time.Duration // run 命令等待启动完成的最长时间 (可选，0 表示不限制)
+	HangTimeout    time.Duration // run 命令判定启动卡死的无进展时间 (可选，0 表示不检测)
 }
 
 // DefaultConfig 返回默认配置
@@ -26,51 +44,211 @@ func DefaultConfig() *Config {
 		ModelName: "gpt-3.5-turbo",
 		Verbose:   false,
 		LogDir:    "./logs", // 默认日志目录
+
+		HangTimeout: 3 * time.Minute,
 	}
 }
 
 // Validate 验证配置
 func (c *Config) Validate() error {
+	if err := c.validateRequired(true); err != nil {
+		return err
+	}
+	if err := c.resolveLogPath(true); err != nil {
+		return err
+	}
+	return c.resolveGitRepo()
+}
+
+// ValidateForRun 验证由 run 命令使用的配置
+// 进程启动前日志文件可能尚未生成，因此不检查其是否存在
+func (c *Config) ValidateForRun() error {
+	if err := c.validateRequired(true); err != nil {
+		return err
+	}
+	if err := c.resolveLogPath(false); err != nil {
+		return err
+	}
+	return c.resolveGitRepo()
+}
+
+// ValidateForAnalyze 验证仅分析已有日志时使用的配置，不要求启动命令
+func (c *Config) ValidateForAnalyze() error {
+	if err := c.validateRequired(false); err != nil {
+		return err
+	}
+	if err := c.resolveLogPath(true); err != nil {
+		return err
+	}
+	return c.resolveGitRepo()
+}
+
+// validateRequired 检查必填项
+func (c *Config) validateRequired(requireStartCmd bool) error {
 	if c.APIKey == "" {
 		return fmt.Errorf("api密钥不能为空")
 	}
-	if c.StartCmd == "" {
+	if requireStartCmd && c.StartCmd == "" {
 		return fmt.Errorf("启动命令不能为空")
 	}
-	if c.LogPath == "" {
+	if c.LogPath == "" && len(c.LogPaths) == 0 && len(c.LogPatterns) == 0 {
 		return fmt.Errorf("日志路径不能为空")
 	}
+	return nil
+}
+
+// resolveLogPath 展开日志路径中的glob模式并转换为绝对路径，按需检查文件是否存在
+func (c *Config) resolveLogPath(mustExist bool) error {
+	patterns := c.LogPatterns
+	if len(patterns) == 0 {
+		patterns = c.LogPaths
+	}
+	if len(patterns) == 0 {
+		patterns = []string{c.LogPath}
+	}
 
-	// 将相对路径转换为绝对路径
-	if !filepath.IsAbs(c.LogPath) {
-		absLogPath, err := filepath.Abs(c.LogPath)
+	var paths, absPatterns, unmatched []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		// 将相对路径转换为绝对路径
+		absPattern, err := filepath.Abs(pattern)
 		if err != nil {
 			return fmt.Errorf("无法解析日志文件路径: %w", err)
 		}
-		c.LogPath = absLogPath
+		absPatterns = append(absPatterns, absPattern)
+
+		matches := []string{absPattern}
+		if isGlobPattern(absPattern) {
+			if matches, err = globLogs(absPattern); err != nil {
+				return fmt.Errorf("无效的日志路径模式 %s: %w", pattern, err)
+			}
+			// 其他模式匹配到日志时仍可分析，全部没有匹配时才报错
+			if len(matches) == 0 {
+				unmatched = append(unmatched, pattern)
+			}
+		} else if mustExist {
+			// 检查日志文件是否存在
+			if _, err := os.Stat(absPattern); os.IsNotExist(err) {
+				return fmt.Errorf("日志文件不存在: %s", absPattern)
+			}
+		}
+
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				continue
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
 	}
 
-	// 检查日志文件是否存在
-	if _, err := os.Stat(c.LogPath); os.IsNotExist(err) {
-		return fmt.Errorf("日志文件不存在: %s", c.LogPath)
+	c.LogPatterns = absPatterns
+	c.Warnings = nil
+	if len(paths) == 0 {
+		if mustExist && len(unmatched) > 0 {
+			return fmt.Errorf("没有匹配的日志文件: %s", strings.Join(unmatched, ", "))
+		}
+		if mustExist {
+			return fmt.Errorf("没有可分析的日志文件")
+		}
+		// 进程启动前日志可能还没有生成，由 LogPatterns 确定输出目录
+		c.LogPaths = nil
+		c.LogPath = ""
+		return nil
+	}
+	if mustExist {
+		for _, pattern := range unmatched {
+			c.Warnings = append(c.Warnings, "没有匹配的日志文件: "+pattern)
+		}
 	}
+	c.LogPaths = paths
+	c.LogPath = paths[0]
+	return nil
+}
 
-	// 如果指定了Git仓库，检查是否存在
-	if c.GitRepo != "" {
-		// 将Git仓库相对路径转换为绝对路径
-		if !filepath.IsAbs(c.GitRepo) {
-			absGitPath, err := filepath.Abs(c.GitRepo)
-			if err != nil {
-				return fmt.Errorf("无法解析git仓库路径: %w", err)
-			}
-			c.GitRepo = absGitPath
+// SandboxRoots 返回工具可以访问的目录：日志文件所在目录、Git仓库和额外配置的目录
+func (c *Config) SandboxRoots() []string {
+	var roots []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			roots = append(roots, dir)
 		}
+	}
+	for _, path := range c.LogPaths {
+		add(filepath.Dir(path))
+	}
+	if c.LogPath != "" {
+		add(filepath.Dir(c.LogPath))
+	}
+	for _, pattern := range c.LogPatterns {
+		add(globBase(pattern))
+	}
+	add(c.GitRepo)
+	for _, root := range c.AllowRoots {
+		add(root)
+	}
+	return roots
+}
 
-		gitPath := filepath.Join(c.GitRepo, ".git")
-		if _, err := os.Stat(gitPath); os.IsNotExist(err) {
-			return fmt.Errorf("git仓库不存在: %s", c.GitRepo)
+// RunOutputDir 返回 run 命令存放输出捕获文件的目录：第一个日志路径中不含通配符的目录
+func (c *Config) RunOutputDir() string {
+	if len(c.LogPatterns) > 0 {
+		return globBase(c.LogPatterns[0])
+	}
+	return filepath.Dir(c.LogPath)
+}
+
+// isGlobPattern 判断路径是否包含glob通配符
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globBase 返回路径中不含通配符的目录部分，如 /var/log/*/app-*.log 为 /var/log
+func globBase(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for isGlobPattern(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// globLogs 展开日志路径模式，不包含分析器自身生成的输出捕获和线程转储文件
+func globLogs(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	logs := matches[:0]
+	for _, path := range matches {
+		if !runner.IsCaptureFile(path) {
+			logs = append(logs, path)
 		}
 	}
+	return logs, nil
+}
 
+// resolveGitRepo 如果指定了Git仓库，检查是否存在
+func (c *Config) resolveGitRepo() error {
+	if c.GitRepo == "" {
+		return nil
+	}
+
+	// 将Git仓库相对路径转换为绝对路径
+	if !filepath.IsAbs(c.GitRepo) {
+		absGitPath, err := filepath.Abs(c.GitRepo)
+		if err != nil {
+			return fmt.Errorf("无法解析git仓库路径: %w", err)
+		}
+		c.GitRepo = absGitPath
+	}
+
+	gitPath := filepath.Join(c.GitRepo, ".git")
+	if _, err := os.Stat(gitPath); os.IsNotExist(err) {
+		return fmt.Errorf("git仓库不存在: %s", c.GitRepo)
+	}
 	return nil
 }
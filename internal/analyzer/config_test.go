@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveLogPathGlob(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "*", "app-*.log")
+	c := &Config{APIKey: "key", StartCmd: "java -jar app.jar", LogPaths: []string{pattern}}
+
+	// 进程启动前没有匹配的日志，保留模式并由不含通配符的前缀确定输出目录
+	if err := c.ValidateForRun(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.LogPaths) != 0 || strings.Join(c.LogPatterns, ",") != pattern {
+		t.Fatalf("未保留日志路径模式: %v %v", c.LogPaths, c.LogPatterns)
+	}
+	if got := c.RunOutputDir(); got != dir {
+		t.Errorf("输出目录错误: %s，期望 %s", got, dir)
+	}
+	if roots := c.SandboxRoots(); len(roots) != 1 || roots[0] != dir {
+		t.Errorf("沙箱目录错误: %v", roots)
+	}
+
+	// 运行期间生成的日志在重新验证时被展开，分析器的捕获文件不算日志
+	for _, name := range []string{"node1/app-1.log", "node1/java-analyzer-run_2025-09-23_19-46-55.stdout.log", "node2/app-2.log"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("log\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "node1", "java-analyzer-run_x.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ValidateForAnalyze(); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "node1", "app-1.log") + "," + filepath.Join(dir, "node2", "app-2.log")
+	if strings.Join(c.LogPaths, ",") != want || c.LogPath != filepath.Join(dir, "node1", "app-1.log") {
+		t.Errorf("展开的日志错误: %v", c.LogPaths)
+	}
+}
+
+func TestResolveLogPathMissing(t *testing.T) {
+	dir := t.TempDir()
+	c := &Config{APIKey: "key", LogPaths: []string{filepath.Join(dir, "*.log")}}
+	if err := c.ValidateForAnalyze(); err == nil || !strings.Contains(err.Error(), "没有匹配的日志文件") {
+		t.Errorf("没有匹配的日志时应报错: %v", err)
+	}
+	c = &Config{APIKey: "key", StartCmd: "java -jar app.jar", LogPath: filepath.Join(dir, "logs", "app.log")}
+	if err := c.ValidateForRun(); err != nil {
+		t.Fatal(err)
+	}
+	if c.RunOutputDir() != filepath.Join(dir, "logs") {
+		t.Errorf("输出目录错误: %s", c.RunOutputDir())
+	}
+}
+
+func TestResolveLogPathPartialMatch(t *testing.T) {
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(appLog, []byte("log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gcPattern := filepath.Join(dir, "gc-*.log")
+
+	// 部分模式没有匹配时仍分析已匹配的日志，没有匹配的模式作为警告
+	c := &Config{APIKey: "key", LogPaths: []string{filepath.Join(dir, "app*.log"), gcPattern}}
+	if err := c.ValidateForAnalyze(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(c.LogPaths, ",") != appLog {
+		t.Errorf("展开的日志错误: %v", c.LogPaths)
+	}
+	if len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0], gcPattern) {
+		t.Errorf("应警告没有匹配的模式: %v", c.Warnings)
+	}
+
+	// 全部模式都没有匹配时报错，列出所有模式
+	heapPattern := filepath.Join(dir, "heap-*.txt")
+	c = &Config{APIKey: "key", LogPaths: []string{gcPattern, heapPattern}}
+	err := c.ValidateForAnalyze()
+	if err == nil || !strings.Contains(err.Error(), gcPattern) || !strings.Contains(err.Error(), heapPattern) {
+		t.Errorf("全部没有匹配时应报错: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+)
+
+// chatTurn 一轮对话
+type chatTurn struct {
+	ctx    context.Context    // 本轮代理执行的上下文，本轮结束或被打断时取消
+	cancel context.CancelFunc // 打断本轮的代理执行
+	done   chan struct{}      // 本轮的消息全部写入会话历史后关闭
+}
+
+// startTurn 开始新一轮对话，返回发送给代理的完整消息列表和本轮对话
+// 调用方读完回答时，上一轮的最后几条消息可能仍在写入会话历史，需要等待写入完成，
+// 否则新一轮会丢失上一轮的回答和工具结果；只有被打断的一轮不再等待，其后续消息被丢弃
+func (ja *JavaAnalyzer) startTurn(ctx context.Context, userMessage *schema.Message) ([]*schema.Message, *chatTurn) {
+	ja.mu.Lock()
+	previous := ja.turn
+	ja.mu.Unlock()
+	if previous != nil {
+		select {
+		case <-previous.done:
+		case <-previous.ctx.Done():
+		}
+	}
+
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+
+	turn := &chatTurn{done: make(chan struct{})}
+	turn.ctx, turn.cancel = context.WithCancel(ctx)
+	ja.turn = turn
+	ja.history = append(completeHistory(ja.history), userMessage)
+
+	messages := make([]*schema.Message, len(ja.history))
+	copy(messages, ja.history)
+	return messages, turn
+}
+
+// Interrupt 打断当前一轮对话，停止代理执行，本轮之后产生的消息不再写入会话历史
+func (ja *JavaAnalyzer) Interrupt() {
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+	if ja.turn != nil {
+		ja.turn.cancel()
+	}
+}
+
+// collectMessages 消费代理执行过程中产生的消息，写入会话历史并从工具结果中收集证据
+func (ja *JavaAnalyzer) collectMessages(future react.MessageFuture, turn *chatTurn) {
+	defer ja.collecting.Done()
+	defer turn.cancel()
+	defer close(turn.done)
+
+	iter := future.GetMessageStreams()
+	for {
+		stream, ok, err := iter.Next()
+		if !ok || err != nil {
+			return
+		}
+		msg, err := schema.ConcatMessageStream(stream)
+		if err != nil {
+			continue
+		}
+		if msg.Role == schema.Tool {
+			ja.addEvidence(toolEvidence(msg.ToolName, msg.Content))
+		}
+		ja.appendHistory(turn, msg)
+	}
+}
+
+// appendHistory 将本轮产生的消息追加到会话历史
+func (ja *JavaAnalyzer) appendHistory(turn *chatTurn, msg *schema.Message) {
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+
+	// 该轮已被打断
+	if turn.ctx.Err() != nil {
+		return
+	}
+	ja.history = append(ja.history, msg)
+}
+
+// completeHistory 去掉末尾没有得到全部工具结果的工具调用
+// 被打断的一轮可能只记录了助手的工具调用，直接发送会被模型接口拒绝
+func completeHistory(history []*schema.Message) []*schema.Message {
+	for i := len(history) - 1; i >= 0; i-- {
+		msg := history[i]
+		if msg.Role != schema.Assistant || len(msg.ToolCalls) == 0 {
+			continue
+		}
+
+		answered := make(map[string]bool)
+		for _, later := range history[i+1:] {
+			if later.Role == schema.Tool {
+				answered[later.ToolCallID] = true
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			if !answered[call.ID] {
+				return history[:i]
+			}
+		}
+		// 最近一次工具调用已经完整，更早的部分也是完整的
+		return history
+	}
+	return history
+}
@@ -0,0 +1,247 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/user/java-startup-analyzer/internal/logparse"
+)
+
+// stubModel 按顺序返回预设的回复，并记录每次收到的消息
+type stubModel struct {
+	mu       sync.Mutex
+	replies  []*schema.Message
+	received [][]*schema.Message
+	before   func(call int) // 可选，第 call 次（从 1 开始）调用返回前执行
+}
+
+func (m *stubModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received = append(m.received, input)
+	if m.before != nil {
+		m.before(len(m.received))
+	}
+	if len(m.received) > len(m.replies) {
+		return nil, fmt.Errorf("第 %d 次调用没有预设回复", len(m.received))
+	}
+	return m.replies[len(m.received)-1], nil
+}
+
+func (m *stubModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	reply, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{reply}), nil
+}
+
+func (m *stubModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// newTestAnalyzer 使用桩模型创建分析器，不脱敏
+func newTestAnalyzer(t *testing.T, chatModel model.ToolCallingChatModel) *JavaAnalyzer {
+	t.Helper()
+	agent, err := createAnalysisAgent(chatModel, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback, err := NewJavaAnalyzerCallback(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { callback.Close() })
+	return &JavaAnalyzer{
+		config:       DefaultConfig(),
+		agent:        agent,
+		chatModel:    chatModel,
+		callback:     callback,
+		history:      []*schema.Message{schema.SystemMessage(systemPrompt)},
+		evidenceSeen: make(map[string]bool),
+		timelines:    make(map[timelineKey]*logparse.Timeline),
+	}
+}
+
+// chat 执行一轮对话并读完回答
+func chat(t *testing.T, ja *JavaAnalyzer, input string) string {
+	t.Helper()
+	stream, err := ja.ChatStream(context.Background(), map[string]any{"input": input})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var answer strings.Builder
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer.WriteString(msg.Content)
+	}
+	return answer.String()
+}
+
+// firstTurnReplies 第一轮读取日志后给出结论，第二轮回答追问
+func firstTurnReplies(t *testing.T) (string, []*schema.Message) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte("Web server failed to start. Port 8080 was already in use.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return logPath, []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: schema.FunctionCall{Name: "read_file", Arguments: fmt.Sprintf(`{"absolute_path":%q}`, logPath)},
+		}}),
+		schema.AssistantMessage("端口 8080 被占用。\nVERDICT: FAILED", nil),
+		schema.AssistantMessage("停止占用端口的进程即可。", nil),
+	}
+}
+
+// roles 概括发送给模型的消息：角色、工具调用和内容
+func roles(t *testing.T, messages []*schema.Message) string {
+	t.Helper()
+	var got []string
+	for _, msg := range messages {
+		line := string(msg.Role)
+		switch {
+		case msg.Role == schema.System:
+		case len(msg.ToolCalls) > 0:
+			line += " call " + msg.ToolCalls[0].Function.Name
+		case msg.Role == schema.Tool:
+			line += " " + msg.ToolCallID
+			if !strings.Contains(msg.Content, "Port 8080 was already in use") {
+				t.Errorf("工具结果中没有日志内容: %s", msg.Content)
+			}
+		default:
+			line += " " + msg.Content
+		}
+		got = append(got, line)
+	}
+	return strings.Join(got, "\n")
+}
+
+// secondTurn 第二轮发送给模型的消息应包含第一轮的用户消息、工具调用、工具结果和回答
+func secondTurn(logPath string) string {
+	return strings.Join([]string{
+		"system",
+		"user 分析 " + logPath,
+		"assistant call read_file",
+		"tool call_1",
+		"assistant 端口 8080 被占用。\nVERDICT: FAILED",
+		"user 怎么修复？",
+	}, "\n")
+}
+
+func TestChatStreamKeepsHistory(t *testing.T) {
+	logPath, replies := firstTurnReplies(t)
+	stub := &stubModel{replies: replies}
+	ja := newTestAnalyzer(t, stub)
+
+	if answer := chat(t, ja, "分析 "+logPath); !strings.Contains(answer, "VERDICT: FAILED") {
+		t.Fatalf("第一轮回答错误: %q", answer)
+	}
+	if answer := chat(t, ja, "怎么修复？"); answer != "停止占用端口的进程即可。" {
+		t.Fatalf("第二轮回答错误: %q", answer)
+	}
+
+	if len(stub.received) != 3 {
+		t.Fatalf("模型应被调用 3 次: %d", len(stub.received))
+	}
+	if got, want := roles(t, stub.received[2]), secondTurn(logPath); got != want {
+		t.Errorf("第二轮的消息:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestChatStreamWaitsForPreviousTurn(t *testing.T) {
+	logPath, replies := firstTurnReplies(t)
+	var ja *JavaAnalyzer
+	stub := &stubModel{replies: replies}
+	// 给出第一轮结论时占住会话历史，使调用方读完回答时本轮消息仍未写入
+	stub.before = func(call int) {
+		if call == 2 {
+			ja.mu.Lock()
+		}
+	}
+	ja = newTestAnalyzer(t, stub)
+
+	if answer := chat(t, ja, "分析 "+logPath); !strings.Contains(answer, "VERDICT: FAILED") {
+		t.Fatalf("第一轮回答错误: %q", answer)
+	}
+	// 在上一轮的消息写入前发送追问
+	answers := make(chan string)
+	go func() {
+		stream, err := ja.ChatStream(context.Background(), map[string]any{"input": "怎么修复？"})
+		if err != nil {
+			answers <- err.Error()
+			return
+		}
+		defer stream.Close()
+		msg, err := schema.ConcatMessageStream(stream)
+		if err != nil {
+			answers <- err.Error()
+			return
+		}
+		answers <- msg.Content
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ja.mu.Unlock()
+
+	if answer := <-answers; answer != "停止占用端口的进程即可。" {
+		t.Fatalf("第二轮回答错误: %q", answer)
+	}
+	if got, want := roles(t, stub.received[2]), secondTurn(logPath); got != want {
+		t.Errorf("第二轮的消息:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestChatStreamInterrupt(t *testing.T) {
+	logPath, replies := firstTurnReplies(t)
+	var ja *JavaAnalyzer
+	stub := &stubModel{replies: replies}
+	// 读取日志后、得到结论前打断
+	stub.before = func(call int) {
+		if call == 2 {
+			ja.Interrupt()
+		}
+	}
+	ja = newTestAnalyzer(t, stub)
+
+	stream, err := ja.ChatStream(context.Background(), map[string]any{"input": "分析 " + logPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	done := make(chan struct{})
+	go func() {
+		chat(t, ja, "怎么修复？")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("被打断的一轮不应阻塞新的一轮")
+	}
+	// 打断之后得到的结论不写入会话历史，已写入的工具调用都有结果
+	last := stub.received[len(stub.received)-1]
+	if got := roles(t, last); strings.Contains(got, "VERDICT") || !strings.HasSuffix(got, "user 怎么修复？") {
+		t.Errorf("被打断后的消息:\n%s", got)
+	}
+	if completed := completeHistory(last); len(completed) != len(last) {
+		t.Errorf("会话历史中有未完成的工具调用:\n%s", roles(t, last))
+	}
+}
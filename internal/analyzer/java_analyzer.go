@@ -3,6 +3,7 @@ package analyzer
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
@@ -11,40 +12,81 @@ import (
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 	"github.com/user/java-startup-analyzer/internal/llm"
+	"github.com/user/java-startup-analyzer/internal/logparse"
+	"github.com/user/java-startup-analyzer/internal/redact"
+	"github.com/user/java-startup-analyzer/internal/runner"
 	"github.com/user/java-startup-analyzer/internal/tools"
 )
 
 // JavaAnalyzer Java启动分析器
 type JavaAnalyzer struct {
-	config   *Config
-	agent    *react.Agent
-	callback *JavaAnalyzerCallback
+	config    *Config
+	agent     *react.Agent
+	chatModel model.ToolCallingChatModel
+	callback  *JavaAnalyzerCallback
+
+	mu           sync.Mutex
+	collecting   sync.WaitGroup       // 正在处理的代理消息
+	history      []*schema.Message    // 会话历史：用户消息、助手回答、工具调用及其结果
+	turn         *chatTurn            // 当前一轮对话，被打断后不再写入历史
+	evidence     []tools.SearchResult // 搜索工具返回过的文件行，作为报告证据
+	evidenceSeen map[string]bool
+	timelines    map[timelineKey]*logparse.Timeline // 已提取的启动时间线
+	redactor     *redact.Redactor                   // 发送给模型前替换敏感信息，为 nil 时不脱敏
 }
 
 // modifyJavaAnalyzerMessages MessageModifier 函数，用于管理历史记录和消息长度限制
 func modifyJavaAnalyzerMessages(ctx context.Context, input []*schema.Message) []*schema.Message {
-	sum := 0
-	maxLimit := 50000 // 单个消息最大长度限制
-	maxMessages := 20 // 最大消息数量限制
+	maxLimit := 50000  // 单个消息最大长度限制
+	maxMessages := 60  // 最大消息数量限制
+	maxTotal := 200000 // 所有消息的总长度限制
+
+	// 系统消息始终保留
+	var system *schema.Message
+	if len(input) > 0 && input[0] != nil && input[0].Role == schema.System {
+		system = input[0]
+		input = input[1:]
+	}
 
 	// 如果消息数量超过限制，保留最新的消息
 	if len(input) > maxMessages {
 		input = input[len(input)-maxMessages:]
 	}
 
+	// 复制一份再截断，避免修改会话历史中的原始消息
+	messages := make([]*schema.Message, 0, len(input))
+	sum := 0
 	for i := range input {
 		if input[i] == nil {
 			continue
 		}
-		l := len(input[i].Content)
+		msg := input[i]
+		l := len(msg.Content)
 		if l > maxLimit {
 			// 截取消息末尾部分，保留最新的内容
-			input[i].Content = input[i].Content[l-maxLimit:]
+			truncated := *msg
+			truncated.Content = msg.Content[l-maxLimit:]
+			msg = &truncated
 		}
-		sum += len(input[i].Content)
+		messages = append(messages, msg)
+		sum += len(msg.Content)
+	}
+
+	// 总长度超限时从最早的消息开始丢弃，至少保留最后一条
+	for sum > maxTotal && len(messages) > 1 {
+		sum -= len(messages[0].Content)
+		messages = messages[1:]
+	}
+
+	// 截断后不能以工具结果开头，否则模型找不到对应的工具调用
+	for len(messages) > 1 && messages[0].Role == schema.Tool {
+		messages = messages[1:]
 	}
 
-	return input
+	if system != nil {
+		messages = append([]*schema.Message{system}, messages...)
+	}
+	return messages
 }
 
 // NewJavaAnalyzer 创建新的Java分析器
@@ -58,6 +100,28 @@ func NewJavaAnalyzer(config *Config) (*JavaAnalyzer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("创建LLM客户端失败: %w", err)
 	}
+	chatModel, ok := llmClient.GetChatModel().(model.ToolCallingChatModel)
+	if !ok {
+		return nil, fmt.Errorf("模型 %s 不支持工具调用", config.Model)
+	}
+
+	// 限制工具只能访问日志目录、Git仓库和配置的目录
+	sandbox, err := tools.NewSandbox(config.SandboxRoots(), config.DenyPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("创建文件访问沙箱失败: %w", err)
+	}
+	tools.SetSandbox(sandbox)
+	tools.SetGitRepo(config.GitRepo)
+	tools.SetStartCommand(config.StartCmd)
+	tools.SetAppEnvironment(config.AppEnv)
+
+	// 工具输出和日志内容发送给模型前替换其中的敏感信息
+	var redactor *redact.Redactor
+	if !config.DisableRedaction {
+		if redactor, err = redact.New(config.RedactPatterns); err != nil {
+			return nil, err
+		}
+	}
 
 	// 创建回调处理器
 	callback, err := NewJavaAnalyzerCallback(config.LogDir)
@@ -66,29 +130,51 @@ func NewJavaAnalyzer(config *Config) (*JavaAnalyzer, error) {
 	}
 
 	// 创建分析代理
-	agent, err := createAnalysisAgent(llmClient, callback)
+	agent, err := createAnalysisAgent(chatModel, redactor, config.GitRepo != "")
 	if err != nil {
 		callback.Close() // 清理资源
 		return nil, fmt.Errorf("创建分析代理失败: %w", err)
 	}
 
 	return &JavaAnalyzer{
-		config:   config,
-		agent:    agent,
-		callback: callback,
+		config:       config,
+		agent:        agent,
+		chatModel:    chatModel,
+		callback:     callback,
+		history:      []*schema.Message{schema.SystemMessage(systemPrompt)},
+		evidenceSeen: make(map[string]bool),
+		timelines:    make(map[timelineKey]*logparse.Timeline),
+		redactor:     redactor,
 	}, nil
 }
 
-// ChatStream 流式聊天方法
+// ChatStream 流式聊天方法，取消 ctx 或调用 Interrupt 打断本轮对话
 func (ja *JavaAnalyzer) ChatStream(ctx context.Context, input map[string]any) (*schema.StreamReader[*schema.Message], error) {
 	// 创建用户消息
 	var userMessage *schema.Message
 
 	// 根据输入类型创建相应的用户消息
+	logPaths, _ := input["log_paths"].([]string)
 	if logPath, ok := input["log_path"].(string); ok {
+		logPaths = append(logPaths, logPath)
+	}
+
+	if len(logPaths) > 0 {
+		content := describeLogFiles(logPaths)
+		// 由 run 命令启动时，附带进程的退出状态和输出捕获文件
+		if runResult, ok := input["run_result"].(*runner.Result); ok && runResult != nil {
+			content += "\n\n" + runResult.Describe()
+		}
+		if ja.config.GitRepo != "" {
+			content += "\n\n应用源码的Git仓库: " + ja.config.GitRepo + "，可以使用git_history工具查看最近的配置、构建文件和相关类的变更"
+		}
+		if timelines := describeTimelines(ja.StartupTimelines(logPaths)); timelines != "" {
+			content += "\n\n" + timelines
+		}
+		content += "\n\n" + verdictInstruction
 		userMessage = &schema.Message{
 			Role:    schema.User,
-			Content: fmt.Sprintf("请分析这个Java应用日志文件: %s", logPath),
+			Content: content,
 		}
 	} else if userInput, ok := input["input"].(string); ok {
 		// 处理用户输入（继续聊天）
@@ -109,26 +195,37 @@ func (ja *JavaAnalyzer) ChatStream(ctx context.Context, input map[string]any) (*
 		}
 	}
 
-	// 创建包含系统消息和用户消息的消息列表
-	// MessageModifier 会自动管理历史记录和消息长度
-	messages := []*schema.Message{
-		{
-			Role:    schema.System,
-			Content: systemPrompt,
-		},
-		userMessage,
-	}
+	// 用户消息中可能包含日志内容和进程输出，同样需要脱敏
+	userMessage.Content = ja.redactor.Redact(userMessage.Content)
+
+	// 在会话历史后追加本轮用户消息，MessageModifier 负责控制发送给模型的长度
+	messages, turn := ja.startTurn(ctx, userMessage)
 
-	// 使用回调系统记录执行过程
-	streamReader, err := ja.agent.Stream(ctx, messages,
-		agent.WithComposeOptions(compose.WithCallbacks(ja.callback)))
+	// 使用回调系统记录执行过程，同时收集本轮产生的消息写入会话历史
+	futureOption, future := react.WithMessageFuture()
+	streamReader, err := ja.agent.Stream(turn.ctx, messages,
+		agent.WithComposeOptions(compose.WithCallbacks(ja.callback)), futureOption)
 	if err != nil {
+		turn.cancel()
 		return nil, err
 	}
 
+	ja.collecting.Add(1)
+	go ja.collectMessages(future, turn)
+
 	return streamReader, nil
 }
 
+// RestoreSecrets 将模型输出中的脱敏占位符还原为原值，仅用于本地显示
+func (ja *JavaAnalyzer) RestoreSecrets(text string) string {
+	return ja.redactor.Restore(text)
+}
+
+// RedactedCount 返回已脱敏的不同敏感值数量
+func (ja *JavaAnalyzer) RedactedCount() int {
+	return ja.redactor.Count()
+}
+
 // GetLogPath 获取当前会话的日志文件路径
 func (ja *JavaAnalyzer) GetLogPath() string {
 	if ja.callback != nil {
@@ -151,6 +248,19 @@ const systemPrompt = `你是一个专业的Spring Boot应用程序启动问题
 你可以使用以下工具：
 - read_file: 读取指定文件的内容，支持分页读取大文件和反向读取
 - search_file_content: 在目录中搜索正则表达式模式，用于查找特定的错误信息或配置问题
+- filter_log_entries: 自动识别日志格式（Spring Boot、logback、log4j2、JSON、方括号字段、启动脚本前缀等），把日志解析为带时间、级别、线程、日志器的记录，并按级别、时间范围、日志器、线程和正则过滤
+- parse_stack_traces: 从日志文件中提取结构化的异常堆栈，包括异常类、消息、帧、Caused by 原因链和 Suppressed 异常，重复的堆栈会合并计数
+- dependency_tree: 检查Maven/Gradle项目的依赖，找出存在多个版本的构件、版本不一致的依赖组、类重复的构件以及提供某个包的构件
+- inspect_jar: 检查应用jar包或lib目录，找出重复类、拆分包、类所在的jar和字节码版本
+- spring_config: 按Spring Boot的优先级解析实际生效的配置，给出每个配置项的值、来源文件和行号以及被覆盖的值
+- port_owner: 查出本机占用TCP端口的进程（仅Linux）及其命令行、工作目录，判断是否为应用残留的旧实例
+- jvm_memory: 对照容器（cgroup）和主机的内存、CPU限制检查启动命令中的JVM参数，估算进程的总内存
+- crash_report: 查找并解析JVM致命错误日志（hs_err_pid<N>.log）
+- gc_summary: 分析GC日志，给出GC后的堆占用变化、停顿次数和时长、Full GC风暴和元空间增长
+- thread_dump: 分析jstack、jcmd或kill -3输出的线程转储，找出死锁和启动线程阻塞的位置
+- heap_histogram: 分析jmap -histo或jcmd GC.class_histogram的输出，找出占用内存最多的类
+- class_loading: 从-verbose:class或-Xlog:class+load的输出中查出类实际从哪个jar、由哪个类加载器加载
+- git_history: 查看应用Git仓库中配置文件、构建文件和相关类最近的提交，并blame匹配的行（仅在配置了Git仓库时可用）
 
 ## Spring Boot启动成功判断标准：
 
@@ -218,16 +328,154 @@ const systemPrompt = `你是一个专业的Spring Boot应用程序启动问题
   - "ERROR" - 错误信息
 - 示例：{"pattern": "Started.*in.*seconds", "include": "*.log"}
 
-### 4. 参数说明
+### 4. 按级别和时间过滤日志
+- 需要查看所有错误和警告时，优先使用filter_log_entries按级别过滤，而不是用正则猜测级别
+  - 示例：{"absolute_path": "/path/to/log", "min_level": "WARN", "tail": true}
+- 输出中的level_counts和first_time/last_time可以快速了解日志整体情况和启动耗时
+- 定位到问题发生的时间后，用since/until查看该时间段前后的所有记录
+  - 示例：{"absolute_path": "/path/to/log", "since": "2025-09-23 19:46:55", "until": "2025-09-23 19:47:05"}
+- 多行消息和异常堆栈会并入所属记录，line/end_line给出了记录的行号范围
+
+### 5. 异常堆栈分析
+- 日志中出现异常堆栈时，使用parse_stack_traces获取结构化的原因链，不要从read_file的原始文本中自行拼凑
+- 以每段堆栈的root_cause（最深层的Caused by）作为根因判断依据，BeanCreationException、UnsatisfiedDependencyException、ApplicationContextException 等外层异常通常只是包装
+- app_frame 指出了业务代码中出错的位置，occurrences 较大的堆栈往往是反复重试的结果
+- 示例：{"absolute_path": "/path/to/log", "max_traces": 5}
+
+### 6. 变更历史分析（仅在提供了Git仓库时可用）
+- 找到根因后，使用git_history确认是否由最近的变更引起：配置文件、pom.xml/build.gradle以及堆栈中app_frame所在的类
+- 把堆栈中的业务类名传给classes，把出错的配置项传给pattern，blame结果会指出最后修改该行的提交
+  - 示例：{"classes": ["com.example.order.OrderService"], "pattern": "spring\\.datasource\\.url", "include_diffs": true}
+- 结论中引用具体的提交，如"提交abc1234修改了spring.datasource.url后启动开始失败"
+
+### 7. 依赖冲突分析
+- 出现NoSuchMethodError、NoSuchFieldError、NoClassDefFoundError、ClassNotFoundException、AbstractMethodError时，使用dependency_tree检查依赖版本
+- 把异常中的类名传给package，providers列出提供该包的构件；conflicts是同一构件被请求的多个版本，resolved为实际进入classpath的版本
+- group_skew指出Spring、Jackson、Netty等需要版本一致的构件组混用了不同版本，duplicates指出包含相同类的不同构件
+  - 示例：{"package": "org.springframework.core.ResolvableType"}
+- 项目中没有保存的依赖树时，只能根据pom.xml推断版本，可建议用户执行 mvn dependency:tree -Dverbose > dependency-tree.txt 后重新分析
+- 能找到应用的jar包或lib目录时（如启动命令中的 -jar app.jar、-cp lib/*），使用inspect_jar证实冲突，而不是只凭推测
+  - class_locations列出包含该类的所有jar，crc不同说明是不同版本的类；duplicates中identical小于classes的jar组合最可疑
+  - UnsupportedClassVersionError时查看class_versions和jars中的java字段，确认哪个jar需要更高版本的Java
+  - 示例：{"path": "/opt/app/app.jar", "class_name": "org.springframework.core.ResolvableType"}
+- 应用使用-verbose:class或-Xlog:class+load运行过时，使用class_loading确认类实际是从哪个jar、由哪个类加载器加载的（见第15节）
+
+### 8. 配置问题分析
+- 出现"Failed to bind properties under ..."、"Could not resolve placeholder"、"Failed to configure a DataSource"或端口、地址错误时，使用spring_config查看应用实际生效的配置，不要只读application.yml
+- 它按Spring Boot的优先级合并命令行参数、-D系统属性、环境变量、profile配置文件和通用配置文件，并解析${...}占位符
+- source给出生效值所在的文件和行号，overrides是被覆盖的值，unresolved是启动时无法解析的占位符；查询的键不存在时similar给出相近的键
+- 传入log_path可以使用日志中"The following profiles are active"记录的profile
+  - 示例：{"property": "spring.datasource", "log_path": "/path/to/log"}
+
+### 9. 端口占用分析
+- 出现"Port 8080 was already in use"、"Address already in use"或BindException时，使用port_owner查出占用端口的进程，不要只建议"检查端口"
+- listeners给出监听该端口的进程号、命令行、工作目录和启动时间；matches_start_command=true说明是同一应用的旧实例未退出
+- 只有TIME_WAIT等连接而没有监听者时，端口通常很快会释放；没有pid说明进程属于其他用户，没有任何套接字说明占用者已退出或在容器中
+  - 示例：{"port": 8080}
+
+### 10. 内存与资源限制分析
+- 出现OOMKilled、退出码137、"Cannot allocate memory"、"os::commit_memory failed"或OutOfMemoryError时，使用jvm_memory对照容器（cgroup）限制检查JVM参数
+- 它按HotSpot的规则推算实际的最大堆、处理器数和垃圾收集器，并估算堆、Metaspace、代码缓存、线程栈和直接内存的总占用
+- 回答中引用具体数字（如"-Xmx1g 等于容器限制1G，建议 -Xmx640m"），不要只建议"增加内存"；oom_kills大于0说明进程被内核终止
+- OutOfMemoryError: Java heap space 通常需要更大的堆或排查内存泄漏，OutOfMemoryError: Metaspace 查看MaxMetaspaceSize
+  - 示例：{} 或 {"pid": 12345}
+
+### 11. JVM崩溃分析
+- 进程没有Java异常就退出、退出码134/139、被SIGABRT/SIGSEGV终止，或输出中有"A fatal error has been detected by the Java Runtime Environment"、"There is insufficient memory for the Java Runtime Environment to continue"时，使用crash_report查找并解析hs_err_pid<N>.log
+- 这些文件写在工作目录（或-XX:ErrorFile指定的位置、临时目录），不在日志目录中；inaccessible中的目录需要用户在sandbox.allow_roots中允许后才能查看
+- problematic_frame以C开头且outside_jvm=true时，崩溃发生在native_libraries中的JNI库（如netty、rocksdb），通常需要升级该库或换用匹配当前系统的版本；以V开头时是JVM自身的问题，考虑升级JDK
+- kind=native_oom时结合native_allocation、container和jvm_memory工具给出的内存估算分析
+  - 示例：{} 或 {"path": "/opt/app/hs_err_pid12345.log"}
+
+### 12. GC日志分析
+- 出现OutOfMemoryError: Java heap space、GC overhead limit exceeded、启动很慢或长时间停顿，且启动命令配置了-Xloggc或-Xlog:gc*时，使用gc_summary分析GC日志
+- full_gc_storms说明堆已耗尽，live_set是Full GC后仍存活的对象，持续增长说明内存泄漏，接近堆容量说明-Xmx太小
+- 结合findings引用具体数字（如"最后一次Full GC后堆仍占用499M / 512M"），并与jvm_memory给出的堆大小对照
+  - 示例：{} 或 {"absolute_path": "/var/log/app/gc.log"}
+
+### 13. 线程转储与死锁分析
+- 应用启动卡死、运行结果中有线程转储文件，或日志中出现"Full thread dump"时，使用thread_dump分析线程转储，不要逐行阅读转储
+- startup_threads给出main线程阻塞的位置和原因（kind：dns、config_center、datasource、init_lock、lock等），stuck=true说明多次转储之间没有进展；回答中引用frame和caller
+- deadlocks中的每个环列出互相等待的线程、等待的锁和持有者，结合各线程的栈帧指出加锁顺序不一致的代码
+  - 示例：{"absolute_paths": ["/tmp/app.threaddump-1.txt", "/tmp/app.threaddump-2.txt", "/tmp/app.threaddump-3.txt"]}
+
+### 14. 类直方图分析
+- 出现OutOfMemoryError且采集了jmap -histo或jcmd GC.class_histogram的输出时，使用heap_histogram找出占用内存最多的类
+- findings指出可疑模式：超大byte[]、作为无上限缓存的Map、重复创建的类加载器、被多次加载的类和占用较多的应用类；有两次直方图时用baseline_path比较增长
+- 回答中点名最可能的分配来源（如"com.example.OrderSnapshot有50万个实例，占用24M"），不要只建议增大-Xmx
+  - 示例：{"absolute_path": "/tmp/histo-2.txt", "baseline_path": "/tmp/histo-1.txt"}
+
+### 15. 类加载跟踪分析
+- 怀疑类冲突（NoSuchMethodError、LinkageError、ClassCastException、AbstractMethodError）且标准输出或日志中有-verbose:class、-Xlog:class+load的输出时，使用class_loading
+- 把异常中的类名传给class，matches给出实际加载该类的jar和类加载器；loader为空时可建议用-Xlog:class+load=debug重新运行
+- duplicates列出从多个来源或被多个类加载器加载的类（同名类被不同类加载器加载会导致ClassCastException和LinkageError），split_packages列出类来自多个jar的包，通常是同一个库的多个版本
+- 没有跟踪输出时，可建议用户在启动命令中加入-Xlog:class+load=debug（JDK 9+）或-verbose:class（JDK 8）后重新运行
+  - 示例：{"absolute_path": "/tmp/app.stdout.log", "class": "org.slf4j.LoggerFactory"}
+
+### 16. 参数说明
 - read_file工具：
   - absolute_path: 必须提供绝对路径
   - reverse: true=从末尾开始读取（推荐用于日志分析）
   - limit: 建议初始使用100行，避免一次性读取过多内容
   - offset: 0-based行号，reverse=true时从末尾计算
+  - 返回的start_line是第一行的行号；超大文件首次反向读取时total_lines可能为-1（后台正在建立行索引），可以稍后再查看
+  - rotated: true=将日志与其轮转文件（如app.log.2.gz、app.log.1、app.log）按时间顺序作为一个整体读取，segments说明每段内容来自哪个文件；上次启动的日志可能已被轮转
+  - gzip/zstd压缩文件会自动解压（zstd需要系统安装zstd命令，未安装时会返回错误）；zip中的文件使用"/path/archive.zip!/成员名"读取
 - search_file_content工具：
   - pattern: 正则表达式模式（必需）
   - path: 搜索目录路径（可选，默认为当前目录）
-  - include: 文件过滤模式（可选，如"*.log", "*.java"）
+  - include: 文件过滤模式（可选，如"*.log", "*.{log,out}", "logs/**/app-*.log"）
+  - before/after: 每个匹配前后返回的上下文行数（可选，最多10行），查看异常时建议after=5
+  - max_results: 返回的匹配数上限（可选，默认100）；truncated=true时file_matches给出每个文件的匹配数，应缩小模式或范围后再搜索
+- filter_log_entries工具：
+  - absolute_path: 日志文件的绝对路径（必需）
+  - min_level: 最低级别，TRACE/DEBUG/INFO/WARN/ERROR/FATAL（可选，设置后不含级别的启动脚本输出会被排除）
+  - since/until: 时间范围，如"2025-09-23 19:46:55"（可选）
+  - logger/thread: 日志器或线程名包含的子串（可选，不区分大小写）
+  - pattern: 消息需要匹配的正则（可选）
+  - max_entries: 返回的记录数上限（可选，默认50）；tail=true时返回最后的记录
+- parse_stack_traces工具：
+  - absolute_path: 日志文件的绝对路径（必需）
+  - max_traces: 返回的不同堆栈数量上限，按最近出现的顺序（可选，默认10）
+  - max_frames: 每个异常返回的帧数上限（可选，默认8）
+- git_history工具：
+  - classes: 堆栈中的全限定类名（可选），会查找对应的源文件
+  - paths: 仓库内的相对路径或glob（可选，默认为Spring配置文件、日志配置、pom.xml和Gradle构建文件）
+  - since: 只看该时间之后的提交，如"2025-09-20"、"2 weeks ago"（可选）
+  - pattern: 正则（可选），只列出增删了匹配行的提交，并blame当前文件中的匹配行
+  - blame_file/blame_lines: blame指定文件的行范围，如"40,60"（可选）
+  - max_commits: 返回的提交数上限（可选，默认20）；include_diffs: true=附带每个提交的差异（可选）
+- dependency_tree工具：
+  - path: 项目目录的绝对路径（可选，默认为Git仓库）
+  - package: 异常中的包名或全限定类名（可选）
+  - artifact: 只列出groupId:artifactId包含该文本的构件（可选，如"jackson"）
+  - tree_file: 项目目录之外保存的mvn dependency:tree或gradle dependencies输出的绝对路径（可选）
+- inspect_jar工具：
+  - path: jar/war文件或jar所在目录的绝对路径（必需），Spring Boot fat jar会同时检查BOOT-INF/lib中的jar
+  - class_name: 需要定位的全限定类名或包名（可选）
+- spring_config工具：
+  - path: 项目目录或部署的配置目录的绝对路径（可选，默认为Git仓库）
+  - property: 配置项或前缀（可选，如"spring.datasource"），支持宽松绑定
+  - profiles: 激活的profile（可选，默认从日志、启动命令、环境变量和配置文件中确定）
+  - log_path: 应用日志的绝对路径（可选），用于确定激活的profile
+- port_owner工具：
+  - port: 日志中报告被占用的TCP端口（必需），仅支持Linux
+- jvm_memory工具：
+  - command: 需要检查的启动命令（可选，默认为配置的启动命令）
+  - pid: 正在运行的JVM进程号（可选），检查其实际的命令行和环境变量
+- crash_report工具：
+  - path: hs_err文件或需要搜索的目录的绝对路径（可选，默认搜索日志目录、启动命令的工作目录、-XX:ErrorFile目录、当前目录和临时目录，并解析最新的文件）
+- gc_summary工具：
+  - absolute_path: GC日志的绝对路径（可选，默认为启动命令中-Xloggc或-Xlog:gc*:file=指定的文件），支持JDK 8 -XX:+PrintGCDetails和JDK 9+统一日志格式
+- thread_dump工具：
+  - absolute_paths: 包含线程转储的文件的绝对路径列表（必需），按采集顺序排列；可以是jstack/jcmd的输出，也可以是kill -3后写入了转储的日志或标准输出捕获文件
+- heap_histogram工具：
+  - absolute_path: jmap -histo或jcmd GC.class_histogram输出的绝对路径（必需）
+  - baseline_path: 同一进程较早的直方图的绝对路径（可选），用于比较增长
+  - top: 按字节和实例数列出的类的数量（可选，默认20，最多100）
+- class_loading工具：
+  - absolute_path: 包含-verbose:class或-Xlog:class+load输出的文件的绝对路径（必需），如标准输出捕获文件
+  - class: 要查询的类名（可选），以.或*结尾时按包名前缀查询
 
 ## 分析流程（必须执行多步分析）：
 1. **第一步**：使用read_file工具读取最后100行（必须至少查看100行）
@@ -245,7 +493,8 @@ const systemPrompt = `你是一个专业的Spring Boot应用程序启动问题
    - "WARN" - 警告信息
    - "failed.*to.*start" - 启动失败
    - "startup.*failed" - 启动失败
-6. **第六步**：识别常见的Spring Boot启动问题，如：
+6. **第六步**：如果日志中有异常堆栈，使用parse_stack_traces工具确认每段堆栈最深层的原因，并以它作为根因
+7. **第七步**：识别常见的Spring Boot启动问题，如：
    - 启动成功但有警告（依赖冲突、配置问题等）
    - OutOfMemoryError (内存不足)
    - ClassNotFoundException (类未找到)
@@ -257,7 +506,7 @@ const systemPrompt = `你是一个专业的Spring Boot应用程序启动问题
    - 启动完成时的错误
    - 超时问题
    - 死锁问题
-7. **第七步**：提供详细的诊断结果和具体的解决方案
+8. **第八步**：提供详细的诊断结果和具体的解决方案
    - 明确说明应用是否启动成功
    - 如果启动成功，列出所有警告和问题
    - 如果启动失败，指出失败原因
@@ -272,22 +521,46 @@ const systemPrompt = `你是一个专业的Spring Boot应用程序启动问题
 - **必须使用search_file_content工具进行深度搜索，这是分析流程的必需步骤**
 - 必须搜索"Started.*in.*seconds"等关键词，进行全面分析
 - 搜索工具可以帮助找到分散在多个文件中的相关错误信息
+- 日志中的密码、令牌等敏感信息已被替换为[REDACTED_PASSWORD_1]这样的占位符，同一占位符代表同一个值；可以在结论中引用占位符，不要要求用户提供原值
+- 工具只能访问日志文件所在目录、Git仓库和配置中允许的目录，凭据文件（如.auth目录、*.pem）禁止访问；工具返回"access denied"错误时不要重试该路径，也不要通过符号链接或其他路径绕过
 - 分析必须全面，不能遗漏任何可能的错误模式
 - 重点关注Spring Boot启动成功标志和启动失败的相关信息
 - **不要仅通过一次工具调用就得出结论，必须进行多步分析**
 - 对于启动成功但有问题的应用，要详细分析所有警告和错误信息`
 
 // createAnalysisAgent 创建分析代理
-func createAnalysisAgent(llmClient *llm.Client, callback *JavaAnalyzerCallback) (*react.Agent, error) {
+func createAnalysisAgent(chatModel model.ToolCallingChatModel, redactor *redact.Redactor, withGit bool) (*react.Agent, error) {
+	// 工具只能访问沙箱内的文件，输出在发送给模型前脱敏
+	wrap := func(t tool.InvokableTool) tool.BaseTool {
+		return tools.Redacted(tools.Sandboxed(t), redactor)
+	}
+	analysisTools := []tool.BaseTool{
+		wrap(tools.ReadFileTool),
+		wrap(tools.SearchFileContentTool),
+		wrap(tools.FilterLogEntriesTool),
+		wrap(tools.ParseStackTracesTool),
+		wrap(tools.DependencyTreeTool),
+		wrap(tools.InspectJarTool),
+		wrap(tools.SpringConfigTool),
+		wrap(tools.PortOwnerTool),
+		wrap(tools.JVMMemoryTool),
+		wrap(tools.CrashReportTool),
+		wrap(tools.GCSummaryTool),
+		wrap(tools.ThreadDumpTool),
+		wrap(tools.HeapHistogramTool),
+		wrap(tools.ClassLoadingTool),
+	}
+	// 配置了Git仓库时才提供变更历史工具
+	if withGit {
+		analysisTools = append(analysisTools, wrap(tools.GitHistoryTool))
+	}
+
 	// 直接创建代理，参考 react.go 例子的结构
 	reactAgent, err := react.NewAgent(context.Background(), &react.AgentConfig{
-		MaxStep:          10, // 设置最大步数，允许多次工具调用
-		ToolCallingModel: llmClient.GetChatModel().(model.ToolCallingChatModel),
+		MaxStep:          20, // 设置最大步数，允许多次工具调用
+		ToolCallingModel: chatModel,
 		ToolsConfig: compose.ToolsNodeConfig{
-			Tools: []tool.BaseTool{
-				tools.ReadFileTool,
-				tools.SearchFileContentTool,
-			},
+			Tools: analysisTools,
 		},
 		MessageModifier: modifyJavaAnalyzerMessages, // 添加消息修改器来管理历史记录
 	})
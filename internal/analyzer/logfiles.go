@@ -0,0 +1,45 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// describeLogFiles 生成首次分析的提示，列出每个日志文件的大小和修改时间
+func describeLogFiles(paths []string) string {
+	if len(paths) == 1 {
+		return fmt.Sprintf("请分析这个Java应用日志文件: %s%s", paths[0], fileStat(paths[0]))
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("请分析这次Java应用启动相关的 %d 个日志文件：\n", len(paths)))
+	for _, path := range paths {
+		b.WriteString(fmt.Sprintf("- %s%s\n", path, fileStat(path)))
+	}
+	b.WriteString("这些文件属于同一次启动（例如应用日志、错误日志、GC日志、nohup输出），请分别读取，并按时间把它们关联起来分析。")
+	return b.String()
+}
+
+// fileStat 返回文件大小和修改时间的描述
+func fileStat(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return " (无法读取文件信息)"
+	}
+	return fmt.Sprintf(" (大小 %s, 修改时间 %s)", formatSize(info.Size()), info.ModTime().Format("2006-01-02 15:04:05"))
+}
+
+// formatSize 将字节数格式化为易读的大小
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,210 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/user/java-startup-analyzer/internal/logparse"
+	"github.com/user/java-startup-analyzer/internal/report"
+	"github.com/user/java-startup-analyzer/internal/tools"
+)
+
+const (
+	maxEvidencePool       = 500 // 会话中最多保留的候选证据行
+	maxEvidenceCandidates = 60  // 生成报告时交给模型挑选的候选证据行
+	maxReportEvidence     = 10  // 报告中最多引用的证据行
+)
+
+// 优先作为候选证据的行
+var evidencePriorityPattern = regexp.MustCompile(`ERROR|FATAL|Exception|Error|Caused by|FAILED`)
+
+// addEvidence 将工具返回的文件行加入证据池
+func (ja *JavaAnalyzer) addEvidence(results []tools.SearchResult) {
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+
+	for _, result := range results {
+		key := fmt.Sprintf("%s:%d", result.FilePath, result.LineNumber)
+		if ja.evidenceSeen[key] {
+			continue
+		}
+		ja.evidenceSeen[key] = true
+		ja.evidence = append(ja.evidence, result)
+	}
+	if len(ja.evidence) > maxEvidencePool {
+		ja.evidence = ja.evidence[len(ja.evidence)-maxEvidencePool:]
+	}
+}
+
+// toolEvidence 从工具输出中提取可作为证据的文件行
+// search_file_content 的每个匹配行、parse_stack_traces 中每段堆栈最深层原因的异常头、
+// filter_log_entries 返回的 WARN 及以上级别的记录
+func toolEvidence(toolName, toolOutput string) []tools.SearchResult {
+	switch toolName {
+	case "search_file_content":
+		var output tools.SearchFileContentOutput
+		if err := json.Unmarshal([]byte(toolOutput), &output); err != nil {
+			return nil
+		}
+		return output.Results
+
+	case "parse_stack_traces":
+		var output tools.ParseStackTracesOutput
+		if err := json.Unmarshal([]byte(toolOutput), &output); err != nil {
+			return nil
+		}
+		var results []tools.SearchResult
+		for _, trace := range output.Traces {
+			content := trace.RootCause.Class
+			if trace.RootCause.Message != "" {
+				content += ": " + strings.SplitN(trace.RootCause.Message, "\n", 2)[0]
+			}
+			results = append(results, tools.SearchResult{
+				FilePath:   output.FilePath,
+				LineNumber: trace.RootCause.LineNumber,
+				Content:    content,
+			})
+		}
+		return results
+
+	case "filter_log_entries":
+		var output tools.FilterLogEntriesOutput
+		if err := json.Unmarshal([]byte(toolOutput), &output); err != nil {
+			return nil
+		}
+		var results []tools.SearchResult
+		for _, entry := range output.Entries {
+			if logparse.LevelRank(entry.Level) < logparse.LevelRank("WARN") {
+				continue
+			}
+			results = append(results, tools.SearchResult{
+				FilePath:   output.FilePath,
+				LineNumber: entry.Line,
+				Content:    fmt.Sprintf("%s %s %s - %s", entry.Time, entry.Level, entry.Logger, strings.SplitN(entry.Message, "\n", 2)[0]),
+			})
+		}
+		return results
+	}
+	return nil
+}
+
+// evidenceCandidates 挑选交给模型的候选证据，错误相关的行优先
+func (ja *JavaAnalyzer) evidenceCandidates() []tools.SearchResult {
+	ja.mu.Lock()
+	defer ja.mu.Unlock()
+
+	var preferred, others []tools.SearchResult
+	for _, e := range ja.evidence {
+		if evidencePriorityPattern.MatchString(e.Content) {
+			preferred = append(preferred, e)
+		} else {
+			others = append(others, e)
+		}
+	}
+	candidates := append(preferred, others...)
+	if len(candidates) > maxEvidenceCandidates {
+		candidates = candidates[:maxEvidenceCandidates]
+	}
+	return candidates
+}
+
+// reportResponse 模型输出的报告结构
+type reportResponse struct {
+	Verdict   string `json:"verdict"`
+	RootCause struct {
+		Category string `json:"category"`
+		Summary  string `json:"summary"`
+	} `json:"root_cause"`
+	Evidence           []int    `json:"evidence"`
+	RecommendedActions []string `json:"recommended_actions"`
+}
+
+// Report 将诊断文本整理为结构化报告
+// 证据只能引用工具实际返回过的文件行，避免模型编造行号
+func (ja *JavaAnalyzer) Report(ctx context.Context, analysis string) (*report.Report, error) {
+	// 等待本轮代理产生的消息处理完毕
+	ja.collecting.Wait()
+
+	candidates := ja.evidenceCandidates()
+	messages := []*schema.Message{
+		{Role: schema.System, Content: "你是一个严谨的诊断报告整理助手，只输出JSON。"},
+		{Role: schema.User, Content: buildReportPrompt(analysis, candidates)},
+	}
+
+	response, err := ja.chatModel.Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("生成结构化报告失败: %w", err)
+	}
+
+	var parsed reportResponse
+	if err := json.Unmarshal([]byte(extractJSON(response.Content)), &parsed); err != nil {
+		return nil, fmt.Errorf("解析结构化报告失败: %w", err)
+	}
+
+	r := &report.Report{
+		SchemaVersion:      report.SchemaVersion,
+		GeneratedAt:        time.Now(),
+		LogPaths:           ja.config.LogPaths,
+		Verdict:            string(ParseVerdict(analysis)),
+		RootCause:          report.RootCause{Category: parsed.RootCause.Category, Summary: parsed.RootCause.Summary},
+		Evidence:           []tools.SearchResult{},
+		RecommendedActions: parsed.RecommendedActions,
+		Analysis:           analysis,
+	}
+	// 以分析结果中的结论标记为准，与 analyze 命令的退出码一致；没有标记时才采用整理出的结论
+	if r.Verdict == string(VerdictUnknown) {
+		r.Verdict = string(ParseVerdict("VERDICT: " + parsed.Verdict))
+	}
+	if !report.IsCategory(r.RootCause.Category) {
+		r.RootCause.Category = report.CategoryUnknown
+	}
+	if r.RecommendedActions == nil {
+		r.RecommendedActions = []string{}
+	}
+	for _, i := range parsed.Evidence {
+		if i >= 0 && i < len(candidates) && len(r.Evidence) < maxReportEvidence {
+			r.Evidence = append(r.Evidence, candidates[i])
+		}
+	}
+
+	return r, nil
+}
+
+// buildReportPrompt 构建生成结构化报告的提示
+func buildReportPrompt(analysis string, candidates []tools.SearchResult) string {
+	var b strings.Builder
+	b.WriteString("请根据下面的诊断结果输出一个JSON对象，不要输出任何其他内容，也不要使用代码块：\n")
+	b.WriteString(`{"verdict": "started|degraded|failed", "root_cause": {"category": "<分类>", "summary": "<一句话说明根因>"}, "evidence": [<候选证据编号>], "recommended_actions": ["<具体的修复操作>"]}`)
+	b.WriteString("\n\n要求：\n")
+	b.WriteString(fmt.Sprintf("- root_cause.category 只能取以下值之一: %s\n", strings.Join(report.Categories, ", ")))
+	b.WriteString("- 应用启动成功且没有问题时，category 为 none\n")
+	b.WriteString(fmt.Sprintf("- evidence 只能引用下方候选证据的编号，选择最能证明根因的行，最多 %d 条；没有合适的行时为空数组\n", maxReportEvidence))
+	b.WriteString("- recommended_actions 按优先级排列，每条是一个可以直接执行的操作\n")
+
+	b.WriteString("\n## 诊断结果\n")
+	b.WriteString(analysis)
+
+	b.WriteString("\n\n## 候选证据\n")
+	if len(candidates) == 0 {
+		b.WriteString("（无）\n")
+	}
+	for i, c := range candidates {
+		b.WriteString(fmt.Sprintf("[%d] %s:%d %s\n", i, c.FilePath, c.LineNumber, c.Content))
+	}
+	return b.String()
+}
+
+// extractJSON 去掉模型输出中可能存在的代码块和多余文字
+func extractJSON(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return content
+	}
+	return content[start : end+1]
+}
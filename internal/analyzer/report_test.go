@@ -0,0 +1,35 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestReportVerdict(t *testing.T) {
+	tests := []struct {
+		analysis string
+		verdict  string // 整理报告时模型给出的结论
+		want     Verdict
+	}{
+		// 以分析结果中的结论标记为准，与退出码一致
+		{"端口 8080 被占用。\nVERDICT: FAILED", "degraded", VerdictFailed},
+		{"启动成功，但连接池告警。\nVERDICT: DEGRADED", "started", VerdictDegraded},
+		// 没有结论标记时采用整理出的结论
+		{"端口 8080 被占用。", "failed", VerdictFailed},
+		{"无法判断。", "", VerdictUnknown},
+	}
+	for _, tt := range tests {
+		stub := &stubModel{replies: []*schema.Message{
+			schema.AssistantMessage(`{"verdict":"`+tt.verdict+`","root_cause":{"category":"port_conflict","summary":"端口被占用"},"evidence":[],"recommended_actions":[]}`, nil),
+		}}
+		r, err := newTestAnalyzer(t, stub).Report(context.Background(), tt.analysis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Verdict != string(tt.want) {
+			t.Errorf("%q: 结论为 %s，期望 %s", tt.analysis, r.Verdict, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/logparse"
+)
+
+// phaseLabels 启动阶段的中文名称
+var phaseLabels = map[string]string{
+	logparse.PhaseScripts:        "启动脚本",
+	logparse.PhaseJVM:            "JVM启动",
+	logparse.PhaseContextRefresh: "Spring上下文刷新",
+	logparse.PhaseDataSource:     "数据源初始化",
+	logparse.PhaseWebContext:     "Web上下文初始化",
+	logparse.PhaseWebServer:      "嵌入式服务器启动",
+	logparse.PhaseStarted:        "启动完成",
+	logparse.PhaseFailed:         "启动失败",
+	logparse.PhaseFirstRequest:   "首个请求",
+}
+
+// PhaseLabel 返回启动阶段的中文名称
+func PhaseLabel(name string) string {
+	if label, ok := phaseLabels[name]; ok {
+		return label
+	}
+	return name
+}
+
+// maxTimelineBytes 提取时间线时最多读取的日志大小，启动阶段的标志日志都在进程启动后不久输出，
+// 不需要为此扫描整个大文件
+const maxTimelineBytes = 16 << 20
+
+// FileTimeline 一个日志文件的启动时间线
+type FileTimeline struct {
+	Path      string
+	Timeline  *logparse.Timeline
+	Truncated bool // 文件超过 maxTimelineBytes，只提取了开头部分
+}
+
+// timelineKey 缓存时间线的键，文件变化后重新提取
+type timelineKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// StartupTimelines 提取各日志文件的启动时间线，没有识别出任何阶段的文件不返回
+func (ja *JavaAnalyzer) StartupTimelines(paths []string) []FileTimeline {
+	var timelines []FileTimeline
+	for _, path := range paths {
+		if t, truncated := ja.startupTimeline(path); t != nil && len(t.Phases) > 0 {
+			timelines = append(timelines, FileTimeline{Path: path, Timeline: t, Truncated: truncated})
+		}
+	}
+	return timelines
+}
+
+// startupTimeline 提取单个文件开头 maxTimelineBytes 内的时间线，结果按文件大小和修改时间缓存
+func (ja *JavaAnalyzer) startupTimeline(path string) (*logparse.Timeline, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false
+	}
+	truncated := info.Size() > maxTimelineBytes
+
+	key := timelineKey{path: path, size: info.Size(), modTime: info.ModTime()}
+	ja.mu.Lock()
+	cached, ok := ja.timelines[key]
+	ja.mu.Unlock()
+	if ok {
+		return cached, truncated
+	}
+
+	t, err := logparse.BuildTimeline(io.LimitReader(file, maxTimelineBytes), info.ModTime())
+	if err != nil {
+		return nil, false
+	}
+	ja.mu.Lock()
+	ja.timelines[key] = t
+	ja.mu.Unlock()
+	return t, truncated
+}
+
+// describeTimelines 生成供分析代理使用的启动时间线描述
+func describeTimelines(timelines []FileTimeline) string {
+	if len(timelines) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("分析器从日志中确定性提取的启动阶段时间线如下（耗时为阶段开始到结束标志之间的时长，\"-\" 表示只有一个时间点）：\n")
+	for _, ft := range timelines {
+		t := ft.Timeline
+		b.WriteString(fmt.Sprintf("\n%s\n", ft.Path))
+		if ft.Truncated {
+			b.WriteString(fmt.Sprintf("（文件较大，只从前 %dM 中提取了时间线）\n", maxTimelineBytes>>20))
+		}
+		b.WriteString("| 阶段 | 开始时间 | 距开始 | 耗时 | 行号 | 标志日志 |\n")
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, p := range t.Phases {
+			b.WriteString(fmt.Sprintf("| %s | %s | +%s | %s | %d | %s |\n",
+				PhaseLabel(p.Name), p.Start.Format("15:04:05.000"), FormatDuration(p.Start.Sub(t.Start)),
+				PhaseDuration(p), p.StartLine, truncateText(p.Message, 120)))
+		}
+		b.WriteString(TimelineSummary(t) + "\n")
+		for _, g := range t.Gaps {
+			b.WriteString(fmt.Sprintf("- 日志静默 %s（第 %d 行到第 %d 行）：%s → %s\n",
+				FormatDuration(g.Duration), g.BeforeLine, g.AfterLine, truncateText(g.Before, 100), truncateText(g.After, 100)))
+		}
+	}
+	b.WriteString("\n用户询问启动耗时时，请以这份时间线为依据，指出耗时最长的阶段和日志静默区间，并读取对应行号附近的日志说明原因。")
+	return b.String()
+}
+
+// TimelineSummary 返回时间线的总体耗时描述
+func TimelineSummary(t *logparse.Timeline) string {
+	summary := fmt.Sprintf("日志时间跨度 %s", FormatDuration(t.End.Sub(t.Start)))
+	if t.ReportedStartup > 0 {
+		summary += fmt.Sprintf("，Spring Boot 报告启动耗时 %s", FormatDuration(t.ReportedStartup))
+	}
+	if t.JVMUptime > 0 {
+		summary += fmt.Sprintf("（此时 JVM 已运行 %s）", FormatDuration(t.JVMUptime))
+	}
+	return summary
+}
+
+// PhaseDuration 返回阶段耗时，只有一个时间点时为 "-"
+func PhaseDuration(p logparse.Phase) string {
+	if p.End.IsZero() {
+		return "-"
+	}
+	return FormatDuration(p.Duration())
+}
+
+// FormatDuration 以毫秒精度格式化时长
+func FormatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// truncateText 截断过长的单行文本
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/logparse"
+)
+
+const sampleLog = "../../examples/sample-java-error.log"
+
+func TestStartupTimelineSample(t *testing.T) {
+	ja := &JavaAnalyzer{timelines: make(map[timelineKey]*logparse.Timeline)}
+	timelines := ja.StartupTimelines([]string{sampleLog})
+	if len(timelines) != 1 || timelines[0].Truncated {
+		t.Fatalf("时间线提取错误: %+v", timelines)
+	}
+	tl := timelines[0].Timeline
+
+	var phases []string
+	for _, p := range tl.Phases {
+		phases = append(phases, p.Name)
+	}
+	want := []string{
+		logparse.PhaseScripts, logparse.PhaseJVM, logparse.PhaseContextRefresh, logparse.PhaseWebContext,
+		logparse.PhaseWebServer, logparse.PhaseDataSource, logparse.PhaseStarted,
+	}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Errorf("阶段错误: %v", phases)
+	}
+	started := tl.Phases[len(tl.Phases)-1]
+	if started.StartLine != 405 || tl.ReportedStartup != 24427*time.Millisecond || tl.JVMUptime != 26208*time.Millisecond {
+		t.Errorf("启动完成识别错误: %+v %s %s", started, tl.ReportedStartup, tl.JVMUptime)
+	}
+	if len(tl.Gaps) == 0 || tl.Gaps[0].BeforeLine != 213 || tl.Gaps[0].AfterLine != 214 {
+		t.Errorf("最长的静默区间错误: %+v", tl.Gaps)
+	}
+
+	description := describeTimelines(timelines)
+	if !strings.Contains(description, "| 启动完成 | 19:47:17.336 | +27.336s | - | 405 |") || strings.Contains(description, "只从前") {
+		t.Errorf("时间线描述错误:\n%s", description)
+	}
+
+	// 文件未变化时使用缓存
+	if again := ja.StartupTimelines([]string{sampleLog}); again[0].Timeline != tl {
+		t.Error("未使用缓存的时间线")
+	}
+}
+
+func TestStartupTimelineTruncated(t *testing.T) {
+	sample, err := os.ReadFile(sampleLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 启动完成后持续输出的日志超过读取上限，之后的再次启动不会被读取
+	var b strings.Builder
+	b.Write(sample)
+	filler := "2025-09-23 19:47:18.000  INFO 92 --- [           main] c.h.o.s.Scheduler                        : heartbeat " + strings.Repeat("x", 100) + "\n"
+	for b.Len() <= maxTimelineBytes {
+		b.WriteString(filler)
+	}
+	b.WriteString("2025-09-23 20:00:00.000  INFO 93 --- [           main] c.h.o.s.Application                      : Started Application in 99.9 seconds (JVM running for 101.2)\n")
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ja := &JavaAnalyzer{timelines: make(map[timelineKey]*logparse.Timeline)}
+	timelines := ja.StartupTimelines([]string{path})
+	if len(timelines) != 1 || !timelines[0].Truncated {
+		t.Fatalf("大文件应只读取开头: %+v", timelines)
+	}
+	if tl := timelines[0].Timeline; tl.ReportedStartup != 24427*time.Millisecond {
+		t.Errorf("不应读取上限之后的日志: %s", tl.ReportedStartup)
+	}
+	if description := describeTimelines(timelines); !strings.Contains(description, "只从前 16M 中提取了时间线") {
+		t.Errorf("描述中应说明只读取了开头:\n%s", description)
+	}
+}
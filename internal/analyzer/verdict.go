@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Verdict 应用启动结论
+type Verdict string
+
+const (
+	VerdictStarted  Verdict = "started"  // 启动成功
+	VerdictDegraded Verdict = "degraded" // 启动成功但存在问题
+	VerdictFailed   Verdict = "failed"   // 启动失败
+	VerdictUnknown  Verdict = "unknown"  // 无法判断
+)
+
+// verdictInstruction 要求代理在首次分析结束时输出结论标记
+const verdictInstruction = "分析结束时，请在回答的最后单独输出一行结论标记，格式为 `VERDICT: STARTED`、`VERDICT: DEGRADED` 或 `VERDICT: FAILED`，分别表示启动成功、启动成功但有问题、启动失败。"
+
+var verdictPattern = regexp.MustCompile(`(?i)VERDICT\s*[:：]\s*\**\s*(STARTED|DEGRADED|FAILED)`)
+
+// ParseVerdict 从分析结果中提取最后一个结论标记
+func ParseVerdict(text string) Verdict {
+	matches := verdictPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return VerdictUnknown
+	}
+	return Verdict(strings.ToLower(matches[len(matches)-1][1]))
+}
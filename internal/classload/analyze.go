@@ -0,0 +1,138 @@
+package classload
+
+import (
+	"sort"
+	"strings"
+)
+
+// Duplicate 从多个来源或被多个类加载器加载的类
+type Duplicate struct {
+	Class string
+	Loads []Load
+}
+
+// Source 一个来源加载的类的数量
+type Source struct {
+	Jar     string
+	Source  string
+	Classes int
+}
+
+// SplitPackage 同一个包的类来自多个 jar
+type SplitPackage struct {
+	Package string
+	Jars    map[string]int // 每个 jar 加载的该包的类的数量
+}
+
+// Find 查找类的加载记录，query 为类名，以 . 或 * 结尾时按包名前缀查找
+func (t *Trace) Find(query string) []Load {
+	prefix, isPrefix := strings.CutSuffix(query, "*")
+	isPrefix = isPrefix || strings.HasSuffix(query, ".")
+	var result []Load
+	for _, load := range t.Loads {
+		if load.Class == query || isPrefix && strings.HasPrefix(load.Class, prefix) {
+			result = append(result, load)
+		}
+	}
+	return result
+}
+
+// Duplicates 返回从多个来源或被多个类加载器加载的类，按类名排序；不含运行时生成的类
+func (t *Trace) Duplicates() []Duplicate {
+	type key struct{ source, loader string }
+	byClass := make(map[string][]Load)
+	seen := make(map[string]map[key]bool)
+	for _, load := range t.Loads {
+		if isGenerated(load) {
+			continue
+		}
+		k := key{load.Source, load.Loader}
+		if seen[load.Class] == nil {
+			seen[load.Class] = make(map[key]bool)
+		}
+		if !seen[load.Class][k] {
+			seen[load.Class][k] = true
+			byClass[load.Class] = append(byClass[load.Class], load)
+		}
+	}
+
+	var result []Duplicate
+	for class, loads := range byClass {
+		if len(loads) > 1 {
+			result = append(result, Duplicate{Class: class, Loads: loads})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Class < result[j].Class })
+	return result
+}
+
+// Sources 返回各来源加载的类的数量，从多到少；不含 JDK 自身和运行时生成的类
+func (t *Trace) Sources() []Source {
+	counts := make(map[string]int)
+	for _, load := range t.Loads {
+		if !IsJDK(load.Source) && !isGenerated(load) {
+			counts[load.Source]++
+		}
+	}
+	result := make([]Source, 0, len(counts))
+	for source, n := range counts {
+		result = append(result, Source{Jar: JarName(source), Source: source, Classes: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Classes != result[j].Classes {
+			return result[i].Classes > result[j].Classes
+		}
+		return result[i].Source < result[j].Source
+	})
+	return result
+}
+
+// JDKClasses 返回从 JDK 加载的类的数量
+func (t *Trace) JDKClasses() int {
+	n := 0
+	for _, load := range t.Loads {
+		if IsJDK(load.Source) {
+			n++
+		}
+	}
+	return n
+}
+
+// SplitPackages 返回类来自多个 jar 的包，按包名排序
+// 同一个包出现在多个 jar 中通常说明同一个库存在多个版本或被重新打包（如 javax.servlet、org.slf4j.impl）
+func (t *Trace) SplitPackages() []SplitPackage {
+	jars := make(map[string]map[string]int)
+	for _, load := range t.Loads {
+		if IsJDK(load.Source) || isGenerated(load) || strings.HasPrefix(load.Source, "instance of ") {
+			continue
+		}
+		i := strings.LastIndexByte(load.Class, '.')
+		if i < 0 {
+			continue
+		}
+		pkg := load.Class[:i]
+		if jars[pkg] == nil {
+			jars[pkg] = make(map[string]int)
+		}
+		jars[pkg][JarName(load.Source)]++
+	}
+
+	var result []SplitPackage
+	for pkg, counts := range jars {
+		if len(counts) > 1 {
+			result = append(result, SplitPackage{Package: pkg, Jars: counts})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Package < result[j].Package })
+	return result
+}
+
+// HasLoaders 判断日志中是否记录了类加载器
+func (t *Trace) HasLoaders() bool {
+	for _, load := range t.Loads {
+		if load.Loader != "" {
+			return true
+		}
+	}
+	return false
+}
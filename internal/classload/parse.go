@@ -0,0 +1,128 @@
+package classload
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format 类加载日志的格式
+type Format string
+
+const (
+	FormatUnified Format = "unified" // JDK 9+ -Xlog:class+load
+	FormatLegacy  Format = "jdk8"    // JDK 8 -verbose:class / -XX:+TraceClassLoading
+)
+
+// Load 一次类加载
+type Load struct {
+	Class  string
+	Source string        // 如 jar:file:/app/app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar!/、jrt:/java.base
+	Loader string        // 类加载器，只有 -Xlog:class+load=debug 或来源为 "instance of" 时才有
+	Uptime time.Duration // 距JVM启动的时间，只有统一日志格式才有
+}
+
+// Trace 类加载日志
+type Trace struct {
+	Format Format
+	Loads  []Load
+}
+
+var (
+	// [Loaded org.slf4j.LoggerFactory from file:/app/lib/slf4j-api-1.7.30.jar]
+	legacyPattern = regexp.MustCompile(`^\[Loaded (\S+) from (.*)\]\s*$`)
+	// [0.345s][info][class,load] org.slf4j.LoggerFactory source: jar:file:/app/app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar!/
+	unifiedPattern = regexp.MustCompile(`\[class,load\s*\]\s+(\S+) source: (.*?)\s*$`)
+	// [0.345s][debug][class,load] klass: 0x... super: 0x... loader: [loader data: 0x00007f... for instance a 'org/springframework/boot/loader/LaunchedURLClassLoader'{0x...}] bytes: 1234 checksum: 8f9f7a0e
+	loaderPattern = regexp.MustCompile(`\[class,load\s*\]\s+klass: .*?loader: \[loader data: \S+ (.*?)\] bytes:`)
+	// 统一日志的 uptime 修饰，如 [0.345s]
+	uptimePattern = regexp.MustCompile(`\[(\d+(?:\.\d+)?)s\]`)
+	quoted        = regexp.MustCompile(`'([^']+)'`)
+)
+
+// Parse 解析 -verbose:class 或 -Xlog:class+load 的输出，日志可以夹杂应用的其他输出
+func Parse(r io.Reader) (*Trace, error) {
+	trace := &Trace{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := legacyPattern.FindStringSubmatch(line); m != nil {
+			trace.Format = FormatLegacy
+			trace.Loads = append(trace.Loads, Load{Class: m[1], Source: m[2], Loader: instanceLoader(m[2])})
+			continue
+		}
+		if m := unifiedPattern.FindStringSubmatch(line); m != nil {
+			trace.Format = FormatUnified
+			load := Load{Class: m[1], Source: m[2], Loader: instanceLoader(m[2])}
+			if u := uptimePattern.FindStringSubmatch(line); u != nil {
+				seconds, _ := strconv.ParseFloat(u[1], 64)
+				load.Uptime = time.Duration(seconds * float64(time.Second))
+			}
+			trace.Loads = append(trace.Loads, load)
+			continue
+		}
+		// debug 级别的行紧跟在对应的 info 行之后
+		if m := loaderPattern.FindStringSubmatch(line); m != nil && len(trace.Loads) > 0 {
+			trace.Loads[len(trace.Loads)-1].Loader = loaderName(m[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// instanceLoader 来源为 "instance of X" 时返回类加载器 X
+func instanceLoader(source string) string {
+	if loader, ok := strings.CutPrefix(source, "instance of "); ok {
+		return loader
+	}
+	return ""
+}
+
+// loaderName 从 loader data 的说明中取出类加载器的名称：
+// "of 'bootstrap'" 为 bootstrap，"for instance a 'jdk/internal/loader/ClassLoaders$AppClassLoader'{0x...}" 为类名，
+// 命名的类加载器为 "'app' @1b6d3586 of 'jdk/internal/loader/ClassLoaders$AppClassLoader'" 时取类名
+func loaderName(desc string) string {
+	names := quoted.FindAllStringSubmatch(desc, -1)
+	if len(names) == 0 {
+		return strings.TrimSpace(desc)
+	}
+	name := names[len(names)-1][1]
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+// JarName 返回来源中的 jar 文件名或目录，如 jar:file:/app/app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar!/ 为 slf4j-api-1.7.36.jar
+func JarName(source string) string {
+	if IsJDK(source) || strings.HasPrefix(source, "__") || strings.HasPrefix(source, "instance of ") {
+		return source
+	}
+	s := strings.TrimSuffix(source, "!/")
+	// 嵌套的 jar 取最内层
+	if i := strings.LastIndex(s, "!/"); i >= 0 {
+		s = s[i+2:]
+	} else {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "jar:"), "file:")
+	}
+	s = strings.TrimSuffix(s, "/")
+	if strings.HasSuffix(s, ".jar") || strings.HasSuffix(s, ".war") {
+		return path.Base(s)
+	}
+	return s
+}
+
+// IsJDK 判断来源是否为 JDK 自身
+func IsJDK(source string) bool {
+	return strings.HasPrefix(source, "jrt:/") || strings.HasPrefix(source, "shared objects file") ||
+		strings.Contains(source, "/jre/lib/") || strings.HasSuffix(source, "/lib/modules")
+}
+
+// isGenerated 判断是否为运行时生成的类，如 lambda、动态代理和 JVM 重定义的类
+func isGenerated(load Load) bool {
+	return strings.HasPrefix(load.Source, "__") || strings.Contains(load.Class, "/0x") || strings.Contains(load.Class, "$$Lambda")
+}
@@ -0,0 +1,83 @@
+package classload
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const unifiedTrace = `[0.012s][info][class,load] java.lang.Object source: shared objects file
+[0.345s][info][class,load] org.slf4j.LoggerFactory source: jar:file:/app/app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar!/
+[0.345s][debug][class,load] klass: 0x0000000800c01000 super: 0x0000000800001000 loader: [loader data: 0x00007f8b2c1a3d30 for instance a 'org/springframework/boot/loader/LaunchedURLClassLoader'{0x000000008a0b1c20}] bytes: 12345 checksum: 8f9f7a0e
+2025-09-23 19:46:55.100  INFO 1 --- [main] c.e.App : Starting App
+[0.350s][info][class,load] org.slf4j.impl.StaticLoggerBinder source: jar:file:/app/app.jar!/BOOT-INF/lib/logback-classic-1.2.12.jar!/
+[0.351s][info][class,load] org.slf4j.impl.StaticMDCBinder source: jar:file:/app/app.jar!/BOOT-INF/lib/slf4j-log4j12-1.7.36.jar!/
+[1.200s][info][class,load] org.slf4j.LoggerFactory source: file:/opt/plugins/slf4j-api-1.7.25.jar
+[1.200s][debug][class,load] klass: 0x0000000800c02000 super: 0x0000000800001000 loader: [loader data: 0x00007f8b2c1a4000 of 'plugin' @6d06d69c of 'java/net/URLClassLoader'] bytes: 12000 checksum: 1a2b3c4d
+[1.300s][info][class,load] com.example.App$$Lambda$123/0x0000000800c03000 source: com.example.App
+`
+
+const legacyTrace = `[Opened /usr/lib/jvm/java-8-openjdk/jre/lib/rt.jar]
+[Loaded java.lang.Object from /usr/lib/jvm/java-8-openjdk/jre/lib/rt.jar]
+[Loaded org.slf4j.LoggerFactory from file:/app/lib/slf4j-api-1.7.30.jar]
+[Loaded com.example.Script1 from instance of groovy.lang.GroovyClassLoader$InnerLoader]
+[Loaded com.example.Script1 from __JVM_DefineClass__]
+`
+
+func TestParseUnified(t *testing.T) {
+	trace, err := Parse(strings.NewReader(unifiedTrace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Format != FormatUnified || len(trace.Loads) != 6 || !trace.HasLoaders() {
+		t.Fatalf("解析错误: %+v", trace)
+	}
+	loads := trace.Find("org.slf4j.LoggerFactory")
+	if len(loads) != 2 {
+		t.Fatalf("查找错误: %+v", loads)
+	}
+	if loads[0].Loader != "org.springframework.boot.loader.LaunchedURLClassLoader" || loads[0].Uptime != 345*time.Millisecond || JarName(loads[0].Source) != "slf4j-api-1.7.36.jar" {
+		t.Errorf("加载记录错误: %+v", loads[0])
+	}
+	if loads[1].Loader != "java.net.URLClassLoader" || JarName(loads[1].Source) != "slf4j-api-1.7.25.jar" {
+		t.Errorf("命名类加载器解析错误: %+v", loads[1])
+	}
+	if n := len(trace.Find("org.slf4j.")); n != 4 {
+		t.Errorf("按包名查找错误: %d", n)
+	}
+
+	dups := trace.Duplicates()
+	if len(dups) != 1 || dups[0].Class != "org.slf4j.LoggerFactory" || len(dups[0].Loads) != 2 {
+		t.Errorf("重复加载识别错误: %+v", dups)
+	}
+	split := trace.SplitPackages()
+	if len(split) != 2 || split[1].Package != "org.slf4j.impl" || split[1].Jars["logback-classic-1.2.12.jar"] != 1 || split[1].Jars["slf4j-log4j12-1.7.36.jar"] != 1 {
+		t.Errorf("拆分的包识别错误: %+v", split)
+	}
+	if trace.JDKClasses() != 1 || len(trace.Sources()) != 4 {
+		t.Errorf("来源统计错误: %d %+v", trace.JDKClasses(), trace.Sources())
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	trace, err := Parse(strings.NewReader(legacyTrace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Format != FormatLegacy || len(trace.Loads) != 4 {
+		t.Fatalf("解析错误: %+v", trace)
+	}
+	if !IsJDK(trace.Loads[0].Source) || JarName(trace.Loads[1].Source) != "slf4j-api-1.7.30.jar" {
+		t.Errorf("来源解析错误: %+v", trace.Loads[:2])
+	}
+	if trace.Loads[2].Loader != "groovy.lang.GroovyClassLoader$InnerLoader" {
+		t.Errorf("instance of 来源的类加载器错误: %+v", trace.Loads[2])
+	}
+	// JVM 内部定义的类不算重复加载
+	if dups := trace.Duplicates(); len(dups) != 0 {
+		t.Errorf("不应有重复加载: %+v", dups)
+	}
+	if JarName("jar:file:/app/app.jar!/BOOT-INF/classes!/") != "BOOT-INF/classes" || JarName("file:/app/classes/") != "/app/classes" {
+		t.Errorf("目录来源的名称错误")
+	}
+}
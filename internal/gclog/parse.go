@@ -0,0 +1,334 @@
+package gclog
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format GC日志的格式
+type Format string
+
+const (
+	FormatUnified Format = "unified" // JDK 9+ -Xlog:gc*
+	FormatLegacy  Format = "jdk8"    // JDK 8 -XX:+PrintGCDetails / -XX:+PrintGC
+)
+
+// 事件类型
+const (
+	KindYoung   = "young"
+	KindMixed   = "mixed"
+	KindFull    = "full"
+	KindRemark  = "remark"
+	KindCleanup = "cleanup"
+	KindOther   = "other"
+)
+
+// Event 一次GC停顿
+type Event struct {
+	Uptime   time.Duration // 距JVM启动的时间
+	ID       int           // GC(N) 编号，JDK 8 日志中为 -1
+	Kind     string
+	Cause    string // 如 Allocation Failure、G1 Evacuation Pause、Metadata GC Threshold
+	Before   int64  // GC前的堆占用
+	After    int64  // GC后的堆占用
+	Capacity int64  // 堆容量
+	Pause    time.Duration
+
+	MetaspaceBefore int64 // GC前后的 Metaspace 占用，未记录时为0
+	MetaspaceAfter  int64
+}
+
+// Log 解析后的GC日志
+type Log struct {
+	Format    Format
+	Collector string  // 日志中记录的收集器，如 G1、Parallel
+	Events    []Event // 最后一次JVM运行的GC停顿
+	Runs      int     // 日志中包含的JVM运行次数，时间回退时视为重新启动
+	Failures  int     // G1 的 To-space exhausted / Evacuation Failure 次数
+}
+
+var (
+	// [2025-09-23T19:46:55.123+0800][0.512s][info][gc] 的装饰部分
+	decoration = regexp.MustCompile(`^\[([^\]]*)\]`)
+	// GC(0) Pause Young (Normal) (G1 Evacuation Pause) 24M->4M(256M) 3.456ms
+	unifiedPause = regexp.MustCompile(`GC\((\d+)\) (Pause [A-Za-z ]+?)((?: \([^)]*\))*) (\d+[KMGB])->(\d+[KMGB])\((\d+[KMGB])\) ([\d.]+)ms`)
+	// GC(0) Garbage Collection (Warmup) 24M(2%)->12M(1%)，ZGC 和 Shenandoah 的周期
+	unifiedCycle = regexp.MustCompile(`GC\((\d+)\) Garbage Collection \(([^)]*)\) (\d+[KMGB])\(\d+%\)->(\d+[KMGB])\(\d+%\)`)
+	// GC(0) Metaspace: 20480K(20736K)->20480K(20736K) 或 GC(0) Metaspace: 9520K->9520K(1058816K)
+	unifiedMetaspace = regexp.MustCompile(`GC\((\d+)\) Metaspace: (\d+[KMGB])(?:\(\d+[KMGB]\))?->(\d+[KMGB])`)
+	// Using G1
+	unifiedCollector = regexp.MustCompile(`\] Using (\w[\w ]*)$`)
+	// 0.512: [GC (Allocation Failure) ... 或 2025-09-23T19:46:55.123+0800: 0.512: [Full GC (Ergonomics) ...
+	legacyStart = regexp.MustCompile(`(?:^|: )(\d+\.\d+): \[(Full GC|GC)( pause)?((?: \([^)]*\))*)`)
+	// 65536K->10736K(251392K)，前面是 "名称: " 时为某一代或 Metaspace
+	legacyTransition = regexp.MustCompile(`(\S*)\s?(\d+(?:\.\d+)?[KMGB])->(\d+(?:\.\d+)?[KMGB])\((\d+(?:\.\d+)?[KMGB])\)`)
+	// G1 的 Heap: 24.0M(256.0M)->4096.0K(256.0M)
+	legacyG1Heap = regexp.MustCompile(`Heap: (\d+(?:\.\d+)?[KMGB])\((\d+(?:\.\d+)?[KMGB])\)->(\d+(?:\.\d+)?[KMGB])\((\d+(?:\.\d+)?[KMGB])\)`)
+	legacySecs   = regexp.MustCompile(`, ([\d.]+) secs\]`)
+	// Metaspace: 20480K->20480K(1067008K)
+	legacyMetaspace = regexp.MustCompile(`Metaspace: (\d+[KMGB])->(\d+[KMGB])`)
+)
+
+// Parse 解析GC日志，自动识别 JDK 8 和统一日志格式。无法识别的行被忽略。
+func Parse(r io.Reader) (*Log, error) {
+	log := &Log{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		firstStamp time.Time
+		lastUptime time.Duration = -1
+		pendingG1  *Event        // JDK 8 G1 日志中等待 Heap: 行的事件
+		metaspace  = make(map[int][2]int64)
+		byID       = make(map[int]int) // GC(N) 到 Events 下标
+	)
+	// add 记录一次停顿，时间回退说明日志中开始了新的一次JVM运行，只保留最后一次
+	add := func(e Event) {
+		if lastUptime < 0 || e.Uptime+time.Second < lastUptime {
+			log.Runs++
+			log.Events = nil
+			byID = make(map[int]int)
+		}
+		lastUptime = e.Uptime
+		if e.ID >= 0 {
+			if m, ok := metaspace[e.ID]; ok && e.MetaspaceBefore == 0 {
+				e.MetaspaceBefore, e.MetaspaceAfter = m[0], m[1]
+			}
+			byID[e.ID] = len(log.Events)
+		}
+		log.Events = append(log.Events, e)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "To-space exhausted") || strings.Contains(line, "Evacuation Failure") || strings.Contains(line, "to-space overflow") {
+			log.Failures++
+		}
+
+		if strings.HasPrefix(line, "[") {
+			uptime, ok := unifiedUptime(line, &firstStamp)
+			if !ok {
+				continue
+			}
+			log.Format = FormatUnified
+			if m := unifiedCollector.FindStringSubmatch(line); m != nil && log.Collector == "" {
+				log.Collector = strings.TrimSpace(m[1])
+				continue
+			}
+			if m := unifiedMetaspace.FindStringSubmatch(line); m != nil {
+				id, _ := strconv.Atoi(m[1])
+				before, after := parseSize(m[2]), parseSize(m[3])
+				if i, ok := byID[id]; ok {
+					log.Events[i].MetaspaceBefore, log.Events[i].MetaspaceAfter = before, after
+				} else {
+					metaspace[id] = [2]int64{before, after}
+				}
+				continue
+			}
+			if m := unifiedPause.FindStringSubmatch(line); m != nil {
+				id, _ := strconv.Atoi(m[1])
+				ms, _ := strconv.ParseFloat(m[7], 64)
+				kind, cause := unifiedKind(m[2], m[3])
+				add(Event{
+					Uptime: uptime, ID: id, Kind: kind, Cause: cause,
+					Before: parseSize(m[4]), After: parseSize(m[5]), Capacity: parseSize(m[6]),
+					Pause: time.Duration(ms * float64(time.Millisecond)),
+				})
+				continue
+			}
+			if m := unifiedCycle.FindStringSubmatch(line); m != nil {
+				// 并发收集器的周期没有整体停顿，停顿记录在 gc,phases 中，这里只记录堆占用
+				id, _ := strconv.Atoi(m[1])
+				add(Event{Uptime: uptime, ID: id, Kind: KindOther, Cause: m[2], Before: parseSize(m[3]), After: parseSize(m[4])})
+			}
+			continue
+		}
+
+		if pendingG1 != nil {
+			if m := legacyG1Heap.FindStringSubmatch(line); m != nil {
+				pendingG1.Before, pendingG1.After, pendingG1.Capacity = parseSize(m[1]), parseSize(m[3]), parseSize(m[4])
+				add(*pendingG1)
+				pendingG1 = nil
+				continue
+			}
+		}
+		m := legacyStart.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		log.Format = FormatLegacy
+		seconds, _ := strconv.ParseFloat(m[1], 64)
+		e := Event{Uptime: time.Duration(seconds * float64(time.Second)), ID: -1}
+		e.Kind, e.Cause = legacyKind(m[2], m[4])
+		if s := legacySecs.FindAllStringSubmatch(line, -1); s != nil {
+			secs, _ := strconv.ParseFloat(s[len(s)-1][1], 64)
+			e.Pause = time.Duration(secs * float64(time.Second))
+		}
+		if ms := legacyMetaspace.FindStringSubmatch(line); ms != nil {
+			e.MetaspaceBefore, e.MetaspaceAfter = parseSize(ms[1]), parseSize(ms[2])
+		}
+		// 整个堆的变化是最后一个前面没有 "名称:" 的变化
+		found := false
+		for _, t := range legacyTransition.FindAllStringSubmatch(line, -1) {
+			if strings.HasSuffix(t[1], ":") {
+				continue
+			}
+			e.Before, e.After, e.Capacity = parseSize(t[2]), parseSize(t[3]), parseSize(t[4])
+			found = true
+		}
+		switch {
+		case found:
+			if log.Collector == "" {
+				log.Collector = legacyCollector(line)
+			}
+			add(e)
+		case m[3] != "":
+			// G1 的 "GC pause" 跨多行，堆的变化在后续的 Heap: 行中
+			pendingG1 = &e
+			log.Collector = "G1"
+		}
+	}
+	return log, scanner.Err()
+}
+
+// unifiedUptime 从统一日志的装饰中取出距JVM启动的时间。只有时间戳时以第一个时间戳为起点。
+func unifiedUptime(line string, first *time.Time) (time.Duration, bool) {
+	var stamp time.Time
+	uptime := time.Duration(-1)
+	gc := false
+	rest := line
+	for m := decoration.FindStringSubmatchIndex(rest); m != nil; m = decoration.FindStringSubmatchIndex(rest) {
+		value := strings.TrimSpace(rest[m[2]:m[3]])
+		rest = rest[m[1]:]
+		switch {
+		case strings.HasPrefix(value, "gc"):
+			gc = true
+		case strings.HasSuffix(value, "ms"):
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64); err == nil {
+				uptime = time.Duration(n * float64(time.Millisecond))
+			}
+		case strings.HasSuffix(value, "ns"):
+			if n, err := strconv.ParseInt(strings.TrimSuffix(value, "ns"), 10, 64); err == nil {
+				uptime = time.Duration(n)
+			}
+		case strings.HasSuffix(value, "s"):
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64); err == nil {
+				uptime = time.Duration(n * float64(time.Second))
+			}
+		default:
+			for _, layout := range []string{"2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05.000Z0700", time.RFC3339Nano} {
+				if t, err := time.Parse(layout, value); err == nil {
+					stamp = t
+					break
+				}
+			}
+		}
+	}
+	if !gc {
+		return 0, false
+	}
+	if uptime < 0 {
+		if stamp.IsZero() {
+			return 0, true
+		}
+		if first.IsZero() {
+			*first = stamp
+		}
+		uptime = stamp.Sub(*first)
+	}
+	return uptime, true
+}
+
+// unifiedKind 根据 "Pause Young" 和括号中的说明确定停顿类型和原因
+func unifiedKind(pause, details string) (string, string) {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSpace(details), ") (") {
+		parts = append(parts, strings.Trim(part, "()"))
+	}
+	cause := ""
+	if len(parts) > 0 {
+		cause = parts[len(parts)-1]
+	}
+	switch strings.TrimSpace(strings.TrimPrefix(pause, "Pause ")) {
+	case "Young":
+		for _, part := range parts {
+			if part == "Mixed" {
+				return KindMixed, cause
+			}
+		}
+		return KindYoung, cause
+	case "Full":
+		return KindFull, cause
+	case "Remark", "Final Mark", "Mark End":
+		return KindRemark, cause
+	case "Cleanup":
+		return KindCleanup, cause
+	default:
+		return KindOther, cause
+	}
+}
+
+// legacyKind 根据 JDK 8 的 "GC (原因)" 或 "GC pause (原因) (young)" 确定停顿类型和原因
+func legacyKind(name, details string) (string, string) {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSpace(details), ") (") {
+		if part = strings.Trim(part, "()"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	cause := ""
+	if len(parts) > 0 {
+		cause = parts[0]
+	}
+	switch {
+	case name == "Full GC":
+		return KindFull, cause
+	case strings.Contains(details, "mixed"):
+		return KindMixed, cause
+	case strings.Contains(cause, "Remark"):
+		return KindRemark, cause
+	case strings.Contains(cause, "Initial Mark") && !strings.Contains(details, "young"):
+		return KindOther, cause
+	default:
+		return KindYoung, cause
+	}
+}
+
+// legacyCollector 根据 JDK 8 日志中的分代名称推断收集器
+func legacyCollector(line string) string {
+	switch {
+	case strings.Contains(line, "PSYoungGen"):
+		return "Parallel"
+	case strings.Contains(line, "ParNew") || strings.Contains(line, "CMS"):
+		return "CMS"
+	case strings.Contains(line, "DefNew") || strings.Contains(line, "Tenured"):
+		return "Serial"
+	default:
+		return ""
+	}
+}
+
+// parseSize 解析日志中的大小，如 24M、4096.0K、0.0B
+func parseSize(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	multiplier := 1.0
+	switch s[len(s)-1] {
+	case 'K':
+		multiplier = 1 << 10
+	case 'M':
+		multiplier = 1 << 20
+	case 'G':
+		multiplier = 1 << 30
+	}
+	n, err := strconv.ParseFloat(strings.TrimRight(s, "KMGB"), 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n * multiplier)
+}
@@ -0,0 +1,117 @@
+package gclog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const unifiedLog = `[2025-09-23T19:46:55.100+0800][0.005s][info][gc] Using G1
+[2025-09-23T19:46:55.600+0800][0.512s][info][gc,start    ] GC(0) Pause Young (Normal) (G1 Evacuation Pause)
+[2025-09-23T19:46:55.600+0800][0.512s][info][gc,metaspace] GC(0) Metaspace: 20480K(20736K)->20480K(20736K) NonClass: 18000K(18176K)->18000K(18176K) Class: 2480K(2560K)->2480K(2560K)
+[2025-09-23T19:46:55.604+0800][0.516s][info][gc          ] GC(0) Pause Young (Normal) (G1 Evacuation Pause) 24M->4M(256M) 3.456ms
+[2025-09-23T19:46:56.100+0800][1.012s][info][gc          ] GC(1) Pause Young (Concurrent Start) (Metadata GC Threshold) 40M->8M(256M) 5.000ms
+[2025-09-23T19:46:56.100+0800][1.012s][info][gc,metaspace] GC(1) Metaspace: 40960K(41216K)->40960K(41216K) NonClass: 36000K(36176K)->36000K(36176K) Class: 4960K(5120K)->4960K(5120K)
+[2025-09-23T19:46:56.200+0800][1.112s][info][gc          ] GC(2) Concurrent Mark Cycle 45.678ms
+[2025-09-23T19:46:57.100+0800][2.012s][info][gc          ] GC(3) Pause Young (Mixed) (G1 Evacuation Pause) 100M->60M(256M) 8.000ms
+`
+
+const parallelLog = `2025-09-23T19:46:55.612+0800: 0.512: [GC (Allocation Failure) [PSYoungGen: 65536K->10720K(76288K)] 65536K->10736K(251392K), 0.0123456 secs] [Times: user=0.03 sys=0.01, real=0.01 secs]
+2025-09-23T19:46:56.334+0800: 1.234: [Full GC (Ergonomics) [PSYoungGen: 10720K->0K(76288K)] [ParOldGen: 16K->10412K(175104K)] 10736K->10412K(251392K), [Metaspace: 20480K->20480K(1067008K)], 0.0456789 secs] [Times: user=0.10 sys=0.00, real=0.05 secs]
+`
+
+const g1Log = `0.512: [GC pause (G1 Evacuation Pause) (young), 0.0123456 secs]
+   [Parallel Time: 10.1 ms, GC Workers: 4]
+   [Eden: 24.0M(24.0M)->0.0B(13.0M) Survivors: 0.0B->3072.0K Heap: 24.0M(256.0M)->4096.0K(256.0M)]
+ [Times: user=0.03 sys=0.01, real=0.01 secs]
+1.000: [Full GC (Allocation Failure)  250M->248M(256M), 0.1234567 secs]
+`
+
+func TestParseUnified(t *testing.T) {
+	log, err := Parse(strings.NewReader(unifiedLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Format != FormatUnified || log.Collector != "G1" || len(log.Events) != 3 {
+		t.Fatalf("解析错误: %+v", log)
+	}
+	e := log.Events[0]
+	if e.Kind != KindYoung || e.Cause != "G1 Evacuation Pause" || e.Before != 24<<20 || e.After != 4<<20 || e.Capacity != 256<<20 || e.Uptime != 516*time.Millisecond {
+		t.Errorf("停顿解析错误: %+v", e)
+	}
+	if e.Pause != 3456*time.Microsecond || e.MetaspaceAfter != 20480<<10 {
+		t.Errorf("停顿时间或 Metaspace 错误: %+v", e)
+	}
+	// Metaspace 行在停顿行之后
+	if log.Events[1].Cause != "Metadata GC Threshold" || log.Events[1].MetaspaceAfter != 40960<<10 {
+		t.Errorf("后出现的 Metaspace 未关联: %+v", log.Events[1])
+	}
+	if log.Events[2].Kind != KindMixed {
+		t.Errorf("Mixed 停顿类型错误: %+v", log.Events[2])
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	log, err := Parse(strings.NewReader(parallelLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Format != FormatLegacy || log.Collector != "Parallel" || len(log.Events) != 2 {
+		t.Fatalf("解析错误: %+v", log)
+	}
+	full := log.Events[1]
+	if full.Kind != KindFull || full.Cause != "Ergonomics" || full.Before != 10736<<10 || full.After != 10412<<10 || full.Capacity != 251392<<10 {
+		t.Errorf("Full GC 解析错误: %+v", full)
+	}
+	if full.MetaspaceAfter != 20480<<10 || full.Pause.Round(time.Microsecond) != 45679*time.Microsecond {
+		t.Errorf("Metaspace 或停顿时间错误: %+v", full)
+	}
+
+	log, err = Parse(strings.NewReader(g1Log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Collector != "G1" || len(log.Events) != 2 {
+		t.Fatalf("G1 解析错误: %+v", log)
+	}
+	if e := log.Events[0]; e.Before != 24<<20 || e.After != 4096<<10 || e.Pause.Round(time.Microsecond) != 12346*time.Microsecond {
+		t.Errorf("跨行的 G1 停顿解析错误: %+v", e)
+	}
+}
+
+func TestSummarizeStorm(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[0.100s][info][gc] Using Parallel\n")
+	id := 0
+	// 启动后堆逐步扩展，最后连续 Full GC
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&b, "[%d.000s][info][gc] GC(%d) Pause Young (Allocation Failure) %dM->%dM(%dM) 5.000ms\n", i+1, id, 60+i*20, 20+i*20, 128+i*40)
+		id++
+	}
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(&b, "[%d.500s][info][gc] GC(%d) Pause Full (Ergonomics) 500M->%dM(512M) 1500.000ms\n", 20+i, id, 480+i*5)
+		id++
+	}
+	// 日志追加了上一次运行的内容时只统计最后一次
+	log, err := Parse(strings.NewReader("[50.000s][info][gc] GC(9) Pause Young (Allocation Failure) 10M->5M(64M) 1.000ms\n" + b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Summarize(log)
+	if s.Runs != 2 || s.Events != 15 || s.Counts[KindFull] != 5 || len(s.LongPauses) != 5 {
+		t.Fatalf("统计错误: %+v", s)
+	}
+	if len(s.Storms) != 1 || s.Storms[0].Count != 5 || s.Storms[0].Occupancy < 0.9 {
+		t.Errorf("Full GC 风暴识别错误: %+v", s.Storms)
+	}
+	if s.GCTimeRatio < 0.3 || s.AllocationRate <= 0 {
+		t.Errorf("GC时间占比或分配速率错误: %f %f", s.GCTimeRatio, s.AllocationRate)
+	}
+	all := strings.Join(s.Findings, "\n")
+	for _, want := range []string{"Full GC 风暴", "存活对象几乎占满", "-Xms", "2 次JVM运行"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("结论中缺少 %q:\n%s", want, all)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package gclog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/jvm"
+)
+
+const (
+	// maxSamples 堆占用和 Metaspace 变化曲线的采样点数
+	maxSamples = 20
+	// stormGap 两次 Full GC 的间隔小于该值时视为连续
+	stormGap = 5 * time.Second
+	// stormMinCount 连续 Full GC 达到该次数时视为 Full GC 风暴
+	stormMinCount = 3
+	// longPause 超过该时长的停顿单独列出
+	longPause = time.Second
+)
+
+// Sample 某一时刻的内存占用
+type Sample struct {
+	Uptime   time.Duration
+	Used     int64 // GC后的占用
+	Capacity int64
+}
+
+// Storm 一段连续发生的 Full GC
+type Storm struct {
+	Start, End time.Duration
+	Count      int
+	Reclaimed  float64 // 平均每次回收的比例
+	Occupancy  float64 // 最后一次GC后的堆占用比例
+}
+
+// Summary GC日志的统计结果
+type Summary struct {
+	Format    Format
+	Collector string
+	Runs      int
+	Events    int
+	Elapsed   time.Duration // 第一次到最后一次GC的时间
+
+	PauseTotal time.Duration
+	PauseMax   time.Duration
+	PauseMaxAt time.Duration
+	// GCTimeRatio GC停顿占运行时间的比例
+	GCTimeRatio float64
+	Counts      map[string]int // 各类停顿的次数
+	Causes      map[string]int // 各种原因的次数
+	LongPauses  []Event        // 超过1秒的停顿
+
+	// Occupancy GC后的堆占用随时间的变化
+	Occupancy     []Sample
+	MaxCapacity   int64
+	FirstCapacity int64
+	// LiveSet Full GC 后的堆占用，近似为存活对象的大小
+	LiveSet        []Sample
+	Storms         []Storm
+	Metaspace      []Sample
+	AllocationRate float64 // 字节/秒
+	Failures       int
+
+	Findings []string
+}
+
+// Summarize 统计GC停顿，识别 Full GC 风暴、存活对象增长、Metaspace 增长等问题
+func Summarize(log *Log) Summary {
+	s := Summary{
+		Format:    log.Format,
+		Collector: log.Collector,
+		Runs:      log.Runs,
+		Events:    len(log.Events),
+		Failures:  log.Failures,
+		Counts:    make(map[string]int),
+		Causes:    make(map[string]int),
+	}
+	events := log.Events
+	if len(events) == 0 {
+		return s
+	}
+	s.Elapsed = events[len(events)-1].Uptime - events[0].Uptime
+	s.FirstCapacity = events[0].Capacity
+
+	var occupancy, metaspace []Sample
+	var allocated int64
+	for i, e := range events {
+		s.Counts[e.Kind]++
+		if e.Cause != "" {
+			s.Causes[e.Cause]++
+		}
+		s.PauseTotal += e.Pause
+		if e.Pause > s.PauseMax {
+			s.PauseMax, s.PauseMaxAt = e.Pause, e.Uptime
+		}
+		if e.Pause >= longPause {
+			s.LongPauses = append(s.LongPauses, e)
+		}
+		s.MaxCapacity = max(s.MaxCapacity, e.Capacity)
+		if e.Before > 0 || e.After > 0 {
+			occupancy = append(occupancy, Sample{Uptime: e.Uptime, Used: e.After, Capacity: e.Capacity})
+			if e.Kind == KindFull {
+				s.LiveSet = append(s.LiveSet, Sample{Uptime: e.Uptime, Used: e.After, Capacity: e.Capacity})
+			}
+		}
+		if e.MetaspaceAfter > 0 {
+			metaspace = append(metaspace, Sample{Uptime: e.Uptime, Used: e.MetaspaceAfter})
+		}
+		// 两次GC之间分配的内存 = 本次GC前的占用 - 上次GC后的占用
+		if i > 0 && e.Before > events[i-1].After {
+			allocated += e.Before - events[i-1].After
+		}
+	}
+	if s.Elapsed > 0 {
+		s.GCTimeRatio = float64(s.PauseTotal) / float64(s.Elapsed)
+		s.AllocationRate = float64(allocated) / s.Elapsed.Seconds()
+	}
+	s.Occupancy = downsample(occupancy)
+	s.Metaspace = downsample(metaspace)
+	s.Storms = storms(events)
+	if len(s.LiveSet) > maxSamples {
+		s.LiveSet = downsample(s.LiveSet)
+	}
+	s.Findings = findings(s, events)
+	return s
+}
+
+// storms 找出连续发生的 Full GC
+func storms(events []Event) []Storm {
+	var result []Storm
+	var run []Event
+	flush := func() {
+		if len(run) >= stormMinCount {
+			storm := Storm{Start: run[0].Uptime, End: run[len(run)-1].Uptime, Count: len(run)}
+			for _, e := range run {
+				if e.Before > 0 {
+					storm.Reclaimed += float64(e.Before-e.After) / float64(e.Before)
+				}
+			}
+			storm.Reclaimed /= float64(len(run))
+			if last := run[len(run)-1]; last.Capacity > 0 {
+				storm.Occupancy = float64(last.After) / float64(last.Capacity)
+			}
+			result = append(result, storm)
+		}
+		run = nil
+	}
+	for _, e := range events {
+		if e.Kind != KindFull {
+			continue
+		}
+		if len(run) > 0 && e.Uptime-run[len(run)-1].Uptime > stormGap {
+			flush()
+		}
+		run = append(run, e)
+	}
+	flush()
+	return result
+}
+
+// downsample 均匀选取最多 maxSamples 个采样点，保留首尾
+func downsample(samples []Sample) []Sample {
+	if len(samples) <= maxSamples {
+		return samples
+	}
+	result := make([]Sample, 0, maxSamples)
+	for i := 0; i < maxSamples; i++ {
+		result = append(result, samples[i*(len(samples)-1)/(maxSamples-1)])
+	}
+	return result
+}
+
+// findings 根据统计结果给出结论
+func findings(s Summary, events []Event) []string {
+	var result []string
+	percent := func(ratio float64) string { return fmt.Sprintf("%.0f%%", ratio*100) }
+
+	for _, storm := range s.Storms {
+		result = append(result, fmt.Sprintf("Full GC 风暴：%s 到 %s 之间连续 %d 次 Full GC，平均每次只回收 %s，最后堆占用 %s，即将出现 OutOfMemoryError: Java heap space 或 GC overhead limit exceeded",
+			formatUptime(storm.Start), formatUptime(storm.End), storm.Count, percent(storm.Reclaimed), percent(storm.Occupancy)))
+	}
+	if s.GCTimeRatio > 0.1 {
+		result = append(result, fmt.Sprintf("GC 停顿共 %s，占运行时间的 %s，应用大部分时间在做GC", s.PauseTotal.Round(time.Millisecond), percent(s.GCTimeRatio)))
+	}
+	if n := len(s.LiveSet); n > 0 {
+		last := s.LiveSet[n-1]
+		if last.Capacity > 0 && float64(last.Used)/float64(last.Capacity) > 0.9 {
+			result = append(result, fmt.Sprintf("最后一次 Full GC 后堆仍占用 %s / %s（%s），存活对象几乎占满了堆，需要增大 -Xmx 或排查内存泄漏",
+				jvm.FormatSize(last.Used), jvm.FormatSize(last.Capacity), percent(float64(last.Used)/float64(last.Capacity))))
+		}
+		if first := s.LiveSet[0]; n >= 3 && first.Used > 0 && float64(last.Used) > float64(first.Used)*1.5 {
+			result = append(result, fmt.Sprintf("Full GC 后的存活对象从 %s 增长到 %s，可能存在内存泄漏或不断增长的缓存",
+				jvm.FormatSize(first.Used), jvm.FormatSize(last.Used)))
+		}
+	}
+	if n := s.Causes["Metadata GC Threshold"]; n > 0 && len(s.Metaspace) > 0 {
+		result = append(result, fmt.Sprintf("%d 次GC由 Metadata GC Threshold 触发，Metaspace 从 %s 增长到 %s；启动期间类加载很多时可以设置 -XX:MetaspaceSize 减少这类GC，持续增长则可能是类加载器泄漏",
+			n, jvm.FormatSize(s.Metaspace[0].Used), jvm.FormatSize(s.Metaspace[len(s.Metaspace)-1].Used)))
+	}
+	if s.Failures > 0 {
+		result = append(result, fmt.Sprintf("出现 %d 次 To-space exhausted / Evacuation Failure，G1 没有足够的空闲区域复制存活对象，堆太小或有大量大对象", s.Failures))
+	}
+	if n := s.Causes["G1 Humongous Allocation"]; n > 0 {
+		result = append(result, fmt.Sprintf("%d 次GC由大对象（Humongous）分配触发，可以增大 -XX:G1HeapRegionSize 或避免分配超大数组", n))
+	}
+	if len(s.LongPauses) > 0 {
+		result = append(result, fmt.Sprintf("%d 次停顿超过 %s，最长 %s（%s）", len(s.LongPauses), longPause, s.PauseMax.Round(time.Millisecond), formatUptime(s.PauseMaxAt)))
+	}
+	// 启动期间堆从很小逐步扩展，说明 -Xms 太小
+	if s.FirstCapacity > 0 && s.MaxCapacity >= s.FirstCapacity*2 && len(events) >= 10 {
+		result = append(result, fmt.Sprintf("堆容量从 %s 逐步扩展到 %s，期间发生 %d 次GC；设置 -Xms%dm 可以减少启动期间的GC",
+			jvm.FormatSize(s.FirstCapacity), jvm.FormatSize(s.MaxCapacity), len(events), s.MaxCapacity>>20))
+	}
+	if s.Runs > 1 {
+		result = append(result, fmt.Sprintf("日志中包含 %d 次JVM运行，只统计了最后一次", s.Runs))
+	}
+	return result
+}
+
+// formatUptime 将距JVM启动的时间格式化为 "启动后 12.3s"
+func formatUptime(d time.Duration) string {
+	return fmt.Sprintf("启动后 %.1fs", d.Seconds())
+}
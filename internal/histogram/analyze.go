@@ -0,0 +1,290 @@
+package histogram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/user/java-startup-analyzer/internal/jvm"
+)
+
+// FindingKind 可疑模式的类型
+type FindingKind string
+
+const (
+	FindingLargeArrays      FindingKind = "large_arrays"      // 数组占用了大部分堆
+	FindingCache            FindingKind = "cache"             // Map 条目或缓存库的对象很多
+	FindingClassLoaders     FindingKind = "classloaders"      // 类加载器被重复创建
+	FindingDuplicateClasses FindingKind = "duplicate_classes" // 同名类被多个类加载器加载
+	FindingApplication      FindingKind = "application_class" // 占用较多的非JDK类
+	FindingGrowth           FindingKind = "growth"            // 两次直方图之间增长最多的类
+)
+
+const (
+	// arrayShare 单种数组占堆的比例超过该值时视为可疑
+	arrayShare = 0.3
+	// hugeArray 平均大小超过该值时视为超大数组
+	hugeArray = 1 << 20
+	// mapShare Map 条目占堆的比例超过该值时视为可疑
+	mapShare = 0.15
+	// mapEntries Map 条目超过该数量时视为可疑
+	mapEntries = 1000000
+	// cacheObjects 缓存库的对象超过该数量时视为可疑
+	cacheObjects = 100000
+	// classLoaders 同一种类加载器的实例超过该数量时视为重复创建
+	classLoaders = 50
+	// duplicateClasses 被多次加载的类超过该数量时视为可疑
+	duplicateClasses = 10
+	// applicationShare 非JDK类占堆的比例超过该值时列出
+	applicationShare = 0.01
+	// growthShare 增长量占堆的比例超过该值时列出
+	growthShare = 0.05
+	// maxFindingsPerKind 每类结论的上限
+	maxFindingsPerKind = 3
+)
+
+// Growth 一个类在两次直方图之间的变化
+type Growth struct {
+	Class       string
+	Instances   int64 // 实例数的增量
+	Bytes       int64 // 字节数的增量
+	BytesBefore int64
+	BytesAfter  int64
+}
+
+// Finding 可疑的分配模式
+type Finding struct {
+	Kind      FindingKind
+	Class     string
+	Instances int64
+	Bytes     int64
+	Message   string
+}
+
+// mapEntryClasses HashMap 等 Map 的条目类
+var mapEntryClasses = []string{
+	"java.util.HashMap$Node", "java.util.HashMap$TreeNode", "java.util.LinkedHashMap$Entry",
+	"java.util.concurrent.ConcurrentHashMap$Node", "java.util.concurrent.ConcurrentHashMap$TreeNode",
+	"java.util.TreeMap$Entry", "java.util.Hashtable$Entry", "java.util.WeakHashMap$Entry",
+}
+
+// cacheLibraries 常见缓存库的包名
+var cacheLibraries = []string{
+	"com.github.benmanes.caffeine.cache.", "com.google.common.cache.", "org.ehcache.", "net.sf.ehcache.",
+	"com.alicp.jetcache.", "org.springframework.cache.",
+}
+
+// jdkPackages JDK 自身的包
+var jdkPackages = []string{"java.", "javax.", "jdk.", "sun.", "com.sun."}
+
+// TopByBytes 返回占用字节最多的 n 个类
+func (h *Histogram) TopByBytes(n int) []Entry {
+	return top(h.Entries, n, func(a, b Entry) bool { return a.Bytes > b.Bytes })
+}
+
+// TopByInstances 返回实例最多的 n 个类
+func (h *Histogram) TopByInstances(n int) []Entry {
+	return top(h.Entries, n, func(a, b Entry) bool { return a.Instances > b.Instances })
+}
+
+// top 排序后返回前 n 个
+func top(entries []Entry, n int, less func(a, b Entry) bool) []Entry {
+	sorted := append([]Entry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted[:min(n, len(sorted))]
+}
+
+// Diff 比较两次直方图，按字节增量从大到小返回增长的类
+// 同名类被多个类加载器加载时合并计算
+func Diff(before, after *Histogram) []Growth {
+	sum := func(h *Histogram) map[string]Entry {
+		result := make(map[string]Entry)
+		for _, e := range h.Entries {
+			total := result[e.Class]
+			total.Instances += e.Instances
+			total.Bytes += e.Bytes
+			result[e.Class] = total
+		}
+		return result
+	}
+	old, cur := sum(before), sum(after)
+
+	var result []Growth
+	for class, e := range cur {
+		o := old[class]
+		if e.Bytes > o.Bytes {
+			result = append(result, Growth{
+				Class:       class,
+				Instances:   e.Instances - o.Instances,
+				Bytes:       e.Bytes - o.Bytes,
+				BytesBefore: o.Bytes,
+				BytesAfter:  e.Bytes,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Class < result[j].Class
+	})
+	return result
+}
+
+// Analyze 找出可能导致内存不足的分配模式：超大数组、作为缓存的 Map、重复创建的类加载器、
+// 被多次加载的类、占用较多的应用类，以及两次直方图之间增长最多的类
+func Analyze(h *Histogram, growth []Growth) []Finding {
+	var result []Finding
+	if h.TotalBytes == 0 {
+		return result
+	}
+	percent := func(bytes int64) string { return fmt.Sprintf("%.0f%%", float64(bytes)*100/float64(h.TotalBytes)) }
+
+	// 数组
+	for _, e := range h.TopByBytes(5) {
+		if !strings.HasPrefix(e.Class, "[") || float64(e.Bytes) < float64(h.TotalBytes)*arrayShare {
+			continue
+		}
+		name := DisplayName(e.Class)
+		msg := fmt.Sprintf("%s 占用 %s（堆的 %s），共 %d 个", name, jvm.FormatSize(e.Bytes), percent(e.Bytes), e.Instances)
+		if e.Instances > 0 && e.Bytes/e.Instances >= hugeArray {
+			msg += fmt.Sprintf("，平均每个 %s，存在超大数组：检查一次性读入内存的文件或报文、未限制大小的缓冲区和批量查询结果", jvm.FormatSize(e.Bytes/e.Instances))
+		} else {
+			msg += "；数组通常被其他对象引用，结合占用较多的应用类和 Map 条目找出持有者，或用 jmap -dump 生成堆转储分析引用链"
+		}
+		result = append(result, Finding{Kind: FindingLargeArrays, Class: name, Instances: e.Instances, Bytes: e.Bytes, Message: msg})
+	}
+
+	// Map 条目
+	var entries, entryBytes int64
+	for _, e := range h.Entries {
+		for _, class := range mapEntryClasses {
+			if e.Class == class {
+				entries += e.Instances
+				entryBytes += e.Bytes
+			}
+		}
+	}
+	if entries >= mapEntries || float64(entryBytes) >= float64(h.TotalBytes)*mapShare {
+		result = append(result, Finding{Kind: FindingCache, Class: "java.util.Map", Instances: entries, Bytes: entryBytes,
+			Message: fmt.Sprintf("Map 条目共 %d 个，占用 %s（堆的 %s，不含键和值），常见于没有上限或过期时间的缓存（static Map、本地缓存），以及启动时全量加载到内存的字典表",
+				entries, jvm.FormatSize(entryBytes), percent(entryBytes))})
+	}
+	// 缓存库
+	for _, prefix := range cacheLibraries {
+		var objects, bytes int64
+		for _, e := range h.Entries {
+			if strings.HasPrefix(e.Class, prefix) {
+				objects += e.Instances
+				bytes += e.Bytes
+			}
+		}
+		if objects >= cacheObjects {
+			library := strings.TrimSuffix(prefix, ".")
+			result = append(result, Finding{Kind: FindingCache, Class: library, Instances: objects, Bytes: bytes,
+				Message: fmt.Sprintf("%s 的对象共 %d 个，占用 %s，缓存条目很多，检查缓存的 maximumSize/过期时间配置", library, objects, jvm.FormatSize(bytes))})
+		}
+	}
+
+	// 类加载器
+	count := 0
+	for _, e := range h.TopByInstances(len(h.Entries)) {
+		if count >= maxFindingsPerKind || e.Instances < classLoaders {
+			break
+		}
+		if !strings.HasSuffix(e.Class, "ClassLoader") && !strings.HasSuffix(e.Class, "$InnerLoader") {
+			continue
+		}
+		msg := fmt.Sprintf("%s 有 %d 个实例，类加载器被重复创建，它们加载的类无法卸载，会导致 Metaspace 和堆持续增长", e.Class, e.Instances)
+		switch {
+		case strings.HasSuffix(e.Class, "DelegatingClassLoader"):
+			msg += "；这是反射调用生成访问器时创建的，大量出现说明反射调用的方法很多，可以设置 -Dsun.reflect.inflationThreshold 调整"
+		case strings.HasPrefix(e.Class, "groovy."):
+			msg += "；通常是每次都重新编译 Groovy 脚本，应缓存编译后的脚本类"
+		default:
+			msg += "；检查是否在每次调用时创建 URLClassLoader、脚本引擎或热部署模块"
+		}
+		result = append(result, Finding{Kind: FindingClassLoaders, Class: e.Class, Instances: e.Instances, Bytes: e.Bytes, Message: msg})
+		count++
+	}
+
+	// 同名类被多次加载
+	copies := make(map[string]int)
+	for _, e := range h.Entries {
+		if !strings.HasPrefix(e.Class, "[") && !strings.HasPrefix(e.Class, "<") {
+			copies[e.Class]++
+		}
+	}
+	var duplicated []string
+	for class, n := range copies {
+		if n > 1 {
+			duplicated = append(duplicated, class)
+		}
+	}
+	if len(duplicated) >= duplicateClasses {
+		sort.Slice(duplicated, func(i, j int) bool {
+			if copies[duplicated[i]] != copies[duplicated[j]] {
+				return copies[duplicated[i]] > copies[duplicated[j]]
+			}
+			return duplicated[i] < duplicated[j]
+		})
+		var examples []string
+		for _, class := range duplicated[:min(5, len(duplicated))] {
+			examples = append(examples, fmt.Sprintf("%s ×%d", class, copies[class]))
+		}
+		result = append(result, Finding{Kind: FindingDuplicateClasses, Class: duplicated[0], Instances: int64(copies[duplicated[0]]),
+			Message: fmt.Sprintf("%d 个类被多个类加载器重复加载（如 %s），说明同一组类被加载了多次，存在类加载器泄漏或重复部署",
+				len(duplicated), strings.Join(examples, "、"))})
+	}
+
+	// 占用较多的非JDK类
+	count = 0
+	for _, e := range h.TopByBytes(20) {
+		if count >= maxFindingsPerKind || float64(e.Bytes) < float64(h.TotalBytes)*applicationShare {
+			break
+		}
+		name := DisplayName(e.Class)
+		if isJDKClass(name) {
+			continue
+		}
+		result = append(result, Finding{Kind: FindingApplication, Class: name, Instances: e.Instances, Bytes: e.Bytes,
+			Message: fmt.Sprintf("%s 有 %d 个实例，占用 %s（堆的 %s，不含引用的数组和字符串），是占用最多的非JDK类之一，检查创建和持有它的代码",
+				name, e.Instances, jvm.FormatSize(e.Bytes), percent(e.Bytes))})
+		count++
+	}
+
+	// 增长最多的类
+	for _, g := range growth[:min(maxFindingsPerKind, len(growth))] {
+		if float64(g.Bytes) < float64(h.TotalBytes)*growthShare {
+			break
+		}
+		name := DisplayName(g.Class)
+		result = append(result, Finding{Kind: FindingGrowth, Class: name, Instances: g.Instances, Bytes: g.Bytes,
+			Message: fmt.Sprintf("%s 从 %s 增长到 %s（新增 %d 个实例），是两次直方图之间增长最多的类之一",
+				name, jvm.FormatSize(g.BytesBefore), jvm.FormatSize(g.BytesAfter), g.Instances)})
+	}
+	return result
+}
+
+// isJDKClass 判断是否为 JDK 的类、基本类型数组或 JVM 内部对象
+func isJDKClass(name string) bool {
+	name = strings.TrimRight(name, "[]")
+	if primitiveName[name] || strings.HasPrefix(name, "<") {
+		return true
+	}
+	for _, prefix := range jdkPackages {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// primitiveName 基本类型的名称
+var primitiveName = func() map[string]bool {
+	result := make(map[string]bool)
+	for _, name := range primitiveArrays {
+		result[name] = true
+	}
+	return result
+}()
@@ -0,0 +1,92 @@
+package histogram
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Entry 直方图中的一行
+type Entry struct {
+	Rank      int
+	Instances int64
+	Bytes     int64
+	Class     string // JVM 内部名，如 [B、java.util.HashMap$Node
+	Module    string // 如 java.base@17.0.8，JDK 8 为空
+}
+
+// Histogram jmap -histo 或 jcmd GC.class_histogram 的输出
+type Histogram struct {
+	Entries        []Entry
+	TotalInstances int64
+	TotalBytes     int64
+}
+
+var (
+	//    1:        123456       12345678  [B (java.base@17.0.8)
+	entryPattern = regexp.MustCompile(`^\s*(\d+):\s+(\d+)\s+(\d+)\s+(\S+)(?:\s+\((.*)\))?\s*$`)
+	// Total       1234567      123456789
+	totalPattern = regexp.MustCompile(`^\s*Total\s+(\d+)\s+(\d+)\s*$`)
+)
+
+// Parse 解析类直方图，文件中有多个直方图时返回最后一个
+func Parse(r io.Reader) (*Histogram, error) {
+	h := &Histogram{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := entryPattern.FindStringSubmatch(line); m != nil {
+			rank, _ := strconv.Atoi(m[1])
+			// 序号重新从 1 开始说明是新的直方图
+			if rank == 1 && len(h.Entries) > 0 {
+				h = &Histogram{}
+			}
+			instances, _ := strconv.ParseInt(m[2], 10, 64)
+			bytes, _ := strconv.ParseInt(m[3], 10, 64)
+			h.Entries = append(h.Entries, Entry{Rank: rank, Instances: instances, Bytes: bytes, Class: m[4], Module: m[5]})
+			continue
+		}
+		if m := totalPattern.FindStringSubmatch(line); m != nil {
+			h.TotalInstances, _ = strconv.ParseInt(m[1], 10, 64)
+			h.TotalBytes, _ = strconv.ParseInt(m[2], 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// 输出被截断时没有 Total 行
+	if h.TotalBytes == 0 {
+		for _, e := range h.Entries {
+			h.TotalInstances += e.Instances
+			h.TotalBytes += e.Bytes
+		}
+	}
+	return h, nil
+}
+
+// primitiveArrays 基本类型数组的描述符
+var primitiveArrays = map[byte]string{
+	'B': "byte", 'C': "char", 'D': "double", 'F': "float",
+	'I': "int", 'J': "long", 'S': "short", 'Z': "boolean",
+}
+
+// DisplayName 将 JVM 内部的数组类名转换为 Java 写法，如 [B 为 byte[]，[Ljava.lang.Object; 为 java.lang.Object[]
+func DisplayName(class string) string {
+	dims := 0
+	for dims < len(class) && class[dims] == '[' {
+		dims++
+	}
+	if dims == 0 || dims == len(class) {
+		return class
+	}
+	element := class[dims:]
+	if name, ok := primitiveArrays[element[0]]; ok && len(element) == 1 {
+		element = name
+	} else if strings.HasPrefix(element, "L") && strings.HasSuffix(element, ";") {
+		element = element[1 : len(element)-1]
+	}
+	return element + strings.Repeat("[]", dims)
+}
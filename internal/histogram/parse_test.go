@@ -0,0 +1,83 @@
+package histogram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const histo = `12345:
+ num     #instances         #bytes  class name (module)
+-------------------------------------------------------
+   1:            40      419430400  [B (java.base@17.0.8)
+   2:       2000000       64000000  java.util.HashMap$Node (java.base@17.0.8)
+   3:        500000       24000000  com.example.order.OrderSnapshot
+   4:        300000        7200000  java.lang.String (java.base@17.0.8)
+   5:           120          11520  groovy.lang.GroovyClassLoader$InnerLoader
+   6:          1000          64000  [Ljava.lang.Object; (java.base@17.0.8)
+Total       2800160      514705920
+`
+
+func TestParse(t *testing.T) {
+	h, err := Parse(strings.NewReader(histo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Entries) != 6 || h.TotalInstances != 2800160 || h.TotalBytes != 514705920 {
+		t.Fatalf("解析错误: %+v", h)
+	}
+	if e := h.Entries[1]; e.Class != "java.util.HashMap$Node" || e.Module != "java.base@17.0.8" || e.Instances != 2000000 {
+		t.Errorf("条目解析错误: %+v", e)
+	}
+	if e := h.Entries[2]; e.Class != "com.example.order.OrderSnapshot" || e.Module != "" {
+		t.Errorf("JDK 8 格式的条目解析错误: %+v", e)
+	}
+	if top := h.TopByInstances(1); top[0].Class != "java.util.HashMap$Node" {
+		t.Errorf("按实例数排序错误: %+v", top)
+	}
+	for class, want := range map[string]string{"[B": "byte[]", "[[I": "int[][]", "[Ljava.lang.Object;": "java.lang.Object[]", "java.lang.String": "java.lang.String"} {
+		if got := DisplayName(class); got != want {
+			t.Errorf("DisplayName(%q) = %q, want %q", class, got, want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	h, _ := Parse(strings.NewReader(histo))
+	kinds := make(map[FindingKind]string)
+	for _, f := range Analyze(h, nil) {
+		kinds[f.Kind] = f.Class
+	}
+	want := map[FindingKind]string{
+		FindingLargeArrays:  "byte[]",
+		FindingCache:        "java.util.Map",
+		FindingClassLoaders: "groovy.lang.GroovyClassLoader$InnerLoader",
+		FindingApplication:  "com.example.order.OrderSnapshot",
+	}
+	for kind, class := range want {
+		if kinds[kind] != class {
+			t.Errorf("缺少 %s 结论: %v", kind, kinds)
+		}
+	}
+
+	// 同名类被多个类加载器加载，以及两次直方图之间的增长
+	var b strings.Builder
+	b.WriteString("   1:        100000      104857600  com.example.order.OrderSnapshot\n")
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&b, "   %d:            1            100  com.example.Script%d\n", i*2+2, i)
+		fmt.Fprintf(&b, "   %d:            1            100  com.example.Script%d\n", i*2+3, i)
+	}
+	after, _ := Parse(strings.NewReader(b.String()))
+	before, _ := Parse(strings.NewReader("   1:        10000       10485760  com.example.order.OrderSnapshot\n"))
+	growth := Diff(before, after)
+	if len(growth) != 13 || growth[0].Class != "com.example.order.OrderSnapshot" || growth[0].Instances != 90000 {
+		t.Fatalf("比较错误: %+v", growth)
+	}
+	kinds = make(map[FindingKind]string)
+	for _, f := range Analyze(after, growth) {
+		kinds[f.Kind] = f.Class
+	}
+	if kinds[FindingGrowth] != "com.example.order.OrderSnapshot" || kinds[FindingDuplicateClasses] == "" {
+		t.Errorf("缺少增长或重复加载结论: %v", kinds)
+	}
+}
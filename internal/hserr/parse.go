@@ -0,0 +1,258 @@
+package hserr
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind 致命错误的类型
+type Kind string
+
+const (
+	KindCrash         Kind = "crash"          // 收到信号或异常，如 SIGSEGV、EXCEPTION_ACCESS_VIOLATION
+	KindNativeOOM     Kind = "native_oom"     // 本地内存分配失败
+	KindInternalError Kind = "internal_error" // JVM 内部错误，如 guarantee/assert 失败
+	KindUnknown       Kind = "unknown"
+)
+
+// 库的来源
+const (
+	OriginJDK         = "jdk"         // JDK 自带的库
+	OriginSystem      = "system"      // 操作系统的库
+	OriginApplication = "application" // 应用或第三方的本地库，如 netty、rocksdb 解压到临时目录的库
+)
+
+const (
+	maxFrames    = 30 // 每类栈帧的上限
+	maxHeapLines = 12 // Heap 部分的行数上限
+)
+
+// Library 进程加载的本地库
+type Library struct {
+	Path   string
+	Origin string
+}
+
+// Report hs_err_pid<N>.log 中的关键信息
+type Report struct {
+	Kind             Kind
+	Signal           string   // 如 SIGSEGV (0xb)
+	PC               string   // 出错的指令地址
+	PID              int      // 崩溃进程的进程号
+	Error            string   // Out of Memory Error 或 Internal Error 的位置
+	ErrorDetail      string   // guarantee/assert 失败的说明
+	NativeAllocation string   // 失败的本地内存分配，如 "Native memory allocation (mmap) failed to map 264241152 bytes for ..."
+	AllocationBytes  int64    // 失败的分配大小
+	PossibleReasons  []string // 文件给出的可能原因
+	OutsideJVM       bool     // 崩溃发生在JVM之外的本地代码中
+	ProblematicFrame string   // 如 "C  [libfoo.so+0x1b2c]  Java_com_example_Native_call+0x1c"
+	JREVersion       string
+	JavaVM           string
+	CommandLine      string
+	Time             string
+	Host             string
+	CurrentThread    string
+	Siginfo          string
+	NativeFrames     []string
+	JavaFrames       []string
+	VMArguments      []string // VM Arguments 部分：jvm_args、java_command、类路径等
+	Libraries        []Library
+	JavaHome         string   // 根据 libjvm 的位置推算
+	Mappings         int      // 内存映射的数量
+	Memory           string   // 系统内存，如 "Memory: 4k page, physical 16318412k(8000000k free), swap 0k(0k free)"
+	Heap             []string // 崩溃时的堆概况
+	Container        []string // 容器 (cgroup) 信息
+}
+
+var (
+	// #  SIGSEGV (0xb) at pc=0x00007f8b2c0a1b2c, pid=12345, tid=12346
+	signalPattern = regexp.MustCompile(`^#\s+(\w+) \((0x[0-9a-fA-F]+)\) at pc=(0x[0-9a-fA-F]+), pid=(\d+)`)
+	// #  Out of Memory Error (os_linux.cpp:2798), pid=1, tid=7
+	errorPattern = regexp.MustCompile(`^#\s+((?:Out of Memory|Internal) Error \([^)]*\)), pid=(\d+)`)
+	// # Native memory allocation (mmap) failed to map 264241152 bytes for committing reserved memory.
+	allocationPattern = regexp.MustCompile(`Native memory allocation \(\w+\) failed to \w+ (\d+) bytes`)
+	// 内存映射行中的路径，Linux 为绝对路径，Windows 为带盘符的路径
+	mappingPath = regexp.MustCompile(`(?:^|\s)(/\S.*|[A-Za-z]:\\.*)$`)
+)
+
+// systemLibraryDirs 操作系统库所在的目录
+var systemLibraryDirs = []string{"/lib/", "/lib64/", "/usr/lib/", "/usr/lib64/", "/usr/local/lib/", "/System/", `C:\Windows\`}
+
+// Parse 解析 hs_err 文件的内容。文件格式随JDK版本略有不同，无法识别的部分被忽略。
+func Parse(r io.Reader) (*Report, error) {
+	report := &Report{Kind: KindUnknown}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	// section 当前正在收集的多行部分
+	var section string
+	var mappings []string
+	reasons := false
+	nextFrame := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if section != "" {
+			if trimmed == "" {
+				section = ""
+				continue
+			}
+			switch section {
+			case "native":
+				if len(report.NativeFrames) < maxFrames && !strings.HasPrefix(trimmed, "...") {
+					report.NativeFrames = append(report.NativeFrames, trimmed)
+				}
+			case "java":
+				if len(report.JavaFrames) < maxFrames {
+					report.JavaFrames = append(report.JavaFrames, trimmed)
+				}
+			case "heap":
+				if len(report.Heap) < maxHeapLines {
+					report.Heap = append(report.Heap, trimmed)
+				}
+			case "libraries":
+				mappings = append(mappings, trimmed)
+			case "vmargs":
+				report.VMArguments = append(report.VMArguments, trimmed)
+			case "container":
+				report.Container = append(report.Container, trimmed)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			text := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			switch {
+			case nextFrame:
+				report.ProblematicFrame = text
+				nextFrame = false
+			case reasons && strings.HasPrefix(line, "#   "):
+				report.PossibleReasons = append(report.PossibleReasons, text)
+				continue
+			}
+			reasons = false
+			if m := signalPattern.FindStringSubmatch(line); m != nil {
+				report.Kind = KindCrash
+				report.Signal = m[1] + " (" + m[2] + ")"
+				report.PC = m[3]
+				report.PID, _ = strconv.Atoi(m[4])
+			} else if m := errorPattern.FindStringSubmatch(line); m != nil {
+				report.Error = m[1]
+				report.PID, _ = strconv.Atoi(m[2])
+				if strings.HasPrefix(m[1], "Out of Memory") {
+					report.Kind = KindNativeOOM
+				} else {
+					report.Kind = KindInternalError
+				}
+			} else if m := allocationPattern.FindStringSubmatch(line); m != nil {
+				report.NativeAllocation = text
+				report.AllocationBytes, _ = strconv.ParseInt(m[1], 10, 64)
+				report.Kind = KindNativeOOM
+			}
+			switch {
+			case text == "Problematic frame:":
+				nextFrame = true
+			case text == "Possible reasons:":
+				reasons = true
+			case strings.HasPrefix(text, "JRE version:"):
+				report.JREVersion = strings.TrimSpace(strings.TrimPrefix(text, "JRE version:"))
+			case strings.HasPrefix(text, "Java VM:"):
+				report.JavaVM = strings.TrimSpace(strings.TrimPrefix(text, "Java VM:"))
+			case strings.HasPrefix(text, "The crash happened outside the Java Virtual Machine"):
+				report.OutsideJVM = true
+			case report.Kind == KindInternalError && report.ErrorDetail == "" &&
+				(strings.HasPrefix(text, "guarantee(") || strings.HasPrefix(text, "assert(") || strings.HasPrefix(text, "Error:")):
+				report.ErrorDetail = text
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "Command Line:"):
+			report.CommandLine = strings.TrimSpace(strings.TrimPrefix(line, "Command Line:"))
+		case strings.HasPrefix(line, "Host:"):
+			report.Host = strings.TrimSpace(strings.TrimPrefix(line, "Host:"))
+		case strings.HasPrefix(line, "Time:"):
+			report.Time = strings.TrimSpace(strings.TrimPrefix(line, "Time:"))
+		case strings.HasPrefix(line, "Current thread ") && report.CurrentThread == "":
+			report.CurrentThread = trimmed
+		case strings.HasPrefix(line, "siginfo:") && report.Siginfo == "":
+			report.Siginfo = strings.TrimSpace(strings.TrimPrefix(line, "siginfo:"))
+		case strings.HasPrefix(line, "Memory:") && report.Memory == "":
+			report.Memory = trimmed
+		case strings.HasPrefix(line, "Native frames:") && report.NativeFrames == nil:
+			section = "native"
+		case strings.HasPrefix(line, "Java frames:") && report.JavaFrames == nil:
+			section = "java"
+		case trimmed == "Heap:" && report.Heap == nil:
+			section = "heap"
+		case trimmed == "Dynamic libraries:" && mappings == nil:
+			section = "libraries"
+		case trimmed == "VM Arguments:" && report.VMArguments == nil:
+			section = "vmargs"
+		case strings.HasPrefix(trimmed, "container (cgroup) information:") && report.Container == nil:
+			section = "container"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	report.Mappings = len(mappings)
+	report.JavaHome, report.Libraries = libraries(mappings)
+	return report, nil
+}
+
+// libraries 从内存映射中提取去重后的本地库，并根据 libjvm 的位置区分JDK、系统和应用的库
+func libraries(mappings []string) (string, []Library) {
+	var paths []string
+	seen := make(map[string]bool)
+	javaHome := ""
+	for _, mapping := range mappings {
+		m := mappingPath.FindStringSubmatch(mapping)
+		if m == nil {
+			continue
+		}
+		path := strings.TrimSpace(m[1])
+		base := strings.ToLower(filepath.Base(strings.ReplaceAll(path, `\`, "/")))
+		if !strings.Contains(base, ".so") && !strings.HasSuffix(base, ".dll") && !strings.HasSuffix(base, ".dylib") {
+			continue
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+		if strings.HasPrefix(base, "libjvm.") || base == "jvm.dll" {
+			// <java.home>/lib/server/libjvm.so，JDK 8 为 <java.home>/jre/lib/amd64/server/libjvm.so
+			slash := strings.ReplaceAll(path, `\`, "/")
+			if i := strings.LastIndex(slash, "/lib/"); i > 0 {
+				javaHome = path[:i]
+			} else if i := strings.LastIndex(slash, "/bin/"); i > 0 {
+				javaHome = path[:i]
+			}
+		}
+	}
+
+	libs := make([]Library, 0, len(paths))
+	for _, path := range paths {
+		origin := OriginApplication
+		if javaHome != "" && strings.HasPrefix(path, javaHome) {
+			origin = OriginJDK
+		} else {
+			for _, dir := range systemLibraryDirs {
+				if strings.HasPrefix(path, dir) {
+					origin = OriginSystem
+					break
+				}
+			}
+		}
+		libs = append(libs, Library{Path: path, Origin: origin})
+	}
+	return javaHome, libs
+}
@@ -0,0 +1,139 @@
+package hserr
+
+import (
+	"strings"
+	"testing"
+)
+
+const crashLog = `#
+# A fatal error has been detected by the Java Runtime Environment:
+#
+#  SIGSEGV (0xb) at pc=0x00007f8b2c0a1b2c, pid=12345, tid=12346
+#
+# JRE version: OpenJDK Runtime Environment (17.0.8+7) (build 17.0.8+7-Ubuntu-1)
+# Java VM: OpenJDK 64-Bit Server VM (17.0.8+7-Ubuntu-1, mixed mode, sharing, tiered, compressed oops, g1 gc, linux-amd64)
+# Problematic frame:
+# C  [librocksdbjni123.so+0x1b2c]  rocksdb::DB::Get+0x1c
+#
+# Core dump will be written. Default location: /tmp/core.12345
+#
+# The crash happened outside the Java Virtual Machine in native code.
+# See problematic frame for where to report the bug.
+#
+
+---------------  S U M M A R Y ------------
+
+Command Line: -Xmx512m -jar app.jar
+
+Host: Intel(R) Xeon(R) CPU, 4 cores, 15G, Ubuntu 22.04.3 LTS
+Time: Mon Sep 23 19:46:55 2025 UTC elapsed time: 12.345 seconds (0d 0h 0m 12s)
+
+---------------  T H R E A D  ---------------
+
+Current thread (0x00007f8b24012345):  JavaThread "main" [_thread_in_native, id=12346, stack(0x00007f8b2a000000,0x00007f8b2a100000)]
+
+Stack: [0x00007f8b2a000000,0x00007f8b2a100000],  sp=0x00007f8b2a0fe8b0,  free space=1018k
+Native frames: (J=compiled Java code, j=interpreted, Vv=VM code, C=native code)
+C  [librocksdbjni123.so+0x1b2c]  rocksdb::DB::Get+0x1c
+j  org.rocksdb.RocksDB.get(J[BII)[B+0
+j  com.example.Store.load()V+10
+v  ~StubRoutines::call_stub
+
+Java frames: (J=compiled Java code, j=interpreted, Vv=VM code)
+j  org.rocksdb.RocksDB.get(J[BII)[B+0
+j  com.example.Store.load()V+10
+
+siginfo: si_signo: 11 (SIGSEGV), si_code: 1 (SEGV_MAPERR), si_addr: 0x0000000000000000
+
+---------------  P R O C E S S  ---------------
+
+Heap:
+ garbage-first heap   total 262144K, used 20480K [0x00000000e0000000, 0x0000000100000000)
+  region size 1024K, 21 young (21504K), 0 survivors (0K)
+
+Dynamic libraries:
+55d4c8a00000-55d4c8a01000 r--p 00000000 08:01 1234                       /usr/lib/jvm/java-17-openjdk-amd64/bin/java
+7f8b2b000000-7f8b2c000000 r-xp 00000000 08:01 2345                       /usr/lib/jvm/java-17-openjdk-amd64/lib/server/libjvm.so
+7f8b2c0a0000-7f8b2c0a2000 r-xp 00000000 08:01 5678                       /tmp/librocksdbjni123.so
+7f8b2c0a2000-7f8b2c0a3000 r--p 00002000 08:01 5678                       /tmp/librocksdbjni123.so
+7f8b2d000000-7f8b2d100000 r-xp 00000000 08:01 6789                       /usr/lib/x86_64-linux-gnu/libc.so.6
+7f8b2e000000-7f8b2e100000 rw-p 00000000 00:00 0
+
+VM Arguments:
+jvm_args: -Xmx512m
+java_command: app.jar
+java_class_path (initial): app.jar
+Launcher Type: SUN_STANDARD
+
+---------------  S Y S T E M  ---------------
+
+Memory: 4k page, physical 16318412k(8000000k free), swap 0k(0k free)
+`
+
+const oomLog = `#
+# There is insufficient memory for the Java Runtime Environment to continue.
+# Native memory allocation (mmap) failed to map 264241152 bytes for committing reserved memory.
+# Possible reasons:
+#   The system is out of physical RAM or swap space
+#   The process is running with CompressedOops enabled, and the Java Heap may be blocking the growth of the native heap
+# Possible solutions:
+#   Reduce memory load on the system
+#
+#  Out of Memory Error (os_linux.cpp:2798), pid=1, tid=7
+#
+# JRE version:  (17.0.8+7) (build )
+#
+
+container (cgroup) information:
+container_type: cgroupv2
+memory_limit_in_bytes: 536870912
+memory_usage_in_bytes: 530000000
+
+`
+
+func TestParseCrash(t *testing.T) {
+	report, err := Parse(strings.NewReader(crashLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Kind != KindCrash || report.Signal != "SIGSEGV (0xb)" || report.PID != 12345 || !report.OutsideJVM {
+		t.Errorf("信号解析错误: %+v", report)
+	}
+	if report.ProblematicFrame != "C  [librocksdbjni123.so+0x1b2c]  rocksdb::DB::Get+0x1c" {
+		t.Errorf("问题帧错误: %q", report.ProblematicFrame)
+	}
+	if len(report.NativeFrames) != 4 || len(report.JavaFrames) != 2 || len(report.Heap) != 2 {
+		t.Errorf("多行部分解析错误: %d %d %d", len(report.NativeFrames), len(report.JavaFrames), len(report.Heap))
+	}
+	if report.CommandLine != "-Xmx512m -jar app.jar" || len(report.VMArguments) != 4 || !strings.HasPrefix(report.Memory, "Memory: 4k page") {
+		t.Errorf("概况解析错误: %+v", report)
+	}
+	if report.Mappings != 6 || report.JavaHome != "/usr/lib/jvm/java-17-openjdk-amd64" {
+		t.Errorf("内存映射解析错误: %d %s", report.Mappings, report.JavaHome)
+	}
+	origins := make(map[string]string)
+	for _, lib := range report.Libraries {
+		origins[lib.Path] = lib.Origin
+	}
+	if len(report.Libraries) != 3 || origins["/tmp/librocksdbjni123.so"] != OriginApplication ||
+		origins["/usr/lib/x86_64-linux-gnu/libc.so.6"] != OriginSystem ||
+		origins["/usr/lib/jvm/java-17-openjdk-amd64/lib/server/libjvm.so"] != OriginJDK {
+		t.Errorf("本地库解析错误: %+v", report.Libraries)
+	}
+}
+
+func TestParseNativeOOM(t *testing.T) {
+	report, err := Parse(strings.NewReader(oomLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Kind != KindNativeOOM || report.AllocationBytes != 264241152 || report.PID != 1 {
+		t.Errorf("本地内存错误解析错误: %+v", report)
+	}
+	if report.Error != "Out of Memory Error (os_linux.cpp:2798)" || len(report.PossibleReasons) != 2 {
+		t.Errorf("错误位置或可能原因解析错误: %q %v", report.Error, report.PossibleReasons)
+	}
+	if len(report.Container) != 3 || report.Container[1] != "memory_limit_in_bytes: 536870912" {
+		t.Errorf("容器信息解析错误: %v", report.Container)
+	}
+}
@@ -0,0 +1,222 @@
+package jvm
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// unlimited cgroup v1 用接近 int64 上限的值表示没有内存限制
+const unlimited = int64(1) << 60
+
+// Limits 当前进程所在cgroup的资源限制和主机资源，读取失败的项为0。
+// 分析器和被分析的应用运行在同一个容器中时，这也是应用的限制。
+type Limits struct {
+	CgroupVersion int     // 1 或 2，0 表示未找到cgroup
+	CgroupPath    string  // 进程所在的cgroup
+	MemoryLimit   int64   // 内存限制，0 表示不限制
+	MemoryUsage   int64   // 当前内存使用
+	MemoryPeak    int64   // 内存使用峰值
+	OOMKills      int     // cgroup记录的OOM kill次数
+	CPUQuota      float64 // CPU配额（核数），0 表示不限制
+	CPUs          int     // 进程可用的CPU数（CPU亲和性）
+	HostMemory    int64   // 主机物理内存
+	HostAvailable int64   // 主机可用内存
+}
+
+// ReadLimits 读取 root 下 /proc 和 /sys/fs/cgroup 中的资源限制，root 通常为 "/"
+func ReadLimits(root string) Limits {
+	return readLimits(root, filepath.Join(root, "proc", "self", "cgroup"))
+}
+
+// ReadProcessLimits 读取 root 下进程 pid 所在cgroup的资源限制，root 通常为 "/"。
+// 进程在另一个容器中时通过 /proc/<pid>/root 读取它看到的 /sys/fs/cgroup，无权访问时退回到 root 下的
+func ReadProcessLimits(root string, pid int) Limits {
+	proc := filepath.Join(root, "proc", strconv.Itoa(pid))
+	fsRoot := filepath.Join(proc, "root")
+	if _, err := os.Stat(filepath.Join(fsRoot, "sys", "fs", "cgroup")); err != nil {
+		fsRoot = root
+	}
+	return readLimits(fsRoot, filepath.Join(proc, "cgroup"))
+}
+
+// readLimits 按 cgroupFile 中的cgroup读取 root 下的资源限制
+func readLimits(root, cgroupFile string) Limits {
+	limits := Limits{CPUs: runtime.NumCPU()}
+	readMeminfo(root, &limits)
+
+	cgroupRoot := filepath.Join(root, "sys", "fs", "cgroup")
+	paths := readProcCgroup(cgroupFile)
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		limits.CgroupVersion = 2
+		limits.CgroupPath = paths[""]
+		readCgroupV2(cgroupRoot, limits.CgroupPath, &limits)
+	} else if _, ok := paths["memory"]; ok {
+		limits.CgroupVersion = 1
+		limits.CgroupPath = paths["memory"]
+		readCgroupV1(cgroupRoot, paths, &limits)
+	}
+
+	if limits.MemoryLimit >= unlimited || (limits.HostMemory > 0 && limits.MemoryLimit >= limits.HostMemory) {
+		limits.MemoryLimit = 0
+	}
+	return limits
+}
+
+// readProcCgroup 解析 /proc/<pid>/cgroup，返回控制器到cgroup路径的映射，cgroup v2 的键为空字符串
+func readProcCgroup(path string) map[string]string {
+	paths := make(map[string]string)
+	file, err := os.Open(path)
+	if err != nil {
+		return paths
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// cgroupDirs 返回cgroup从自身到根的目录。容器中 /proc/self/cgroup 的路径可能是宿主机上的路径，
+// 而容器内只挂载了自身的cgroup，此时目录不存在，退回到挂载点本身。
+func cgroupDirs(mount, path string) []string {
+	var dirs []string
+	for p := filepath.Clean("/" + path); ; p = filepath.Dir(p) {
+		dir := filepath.Join(mount, p)
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+		if p == "/" {
+			break
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, mount)
+	}
+	return dirs
+}
+
+// readCgroupV2 读取 cgroup v2 的限制，上级cgroup的限制同样生效，取最小值
+func readCgroupV2(mount, path string, limits *Limits) {
+	dirs := cgroupDirs(mount, path)
+	for _, dir := range dirs {
+		if limit, ok := readInt(filepath.Join(dir, "memory.max")); ok && (limits.MemoryLimit == 0 || limit < limits.MemoryLimit) {
+			limits.MemoryLimit = limit
+		}
+		if fields := readFields(filepath.Join(dir, "cpu.max")); len(fields) == 2 && fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 && (limits.CPUQuota == 0 || quota/period < limits.CPUQuota) {
+				limits.CPUQuota = quota / period
+			}
+		}
+	}
+	self := dirs[0]
+	limits.MemoryUsage, _ = readInt(filepath.Join(self, "memory.current"))
+	limits.MemoryPeak, _ = readInt(filepath.Join(self, "memory.peak"))
+	limits.OOMKills = readKeyed(filepath.Join(self, "memory.events"), "oom_kill")
+}
+
+// readCgroupV1 读取 cgroup v1 的限制，memory 和 cpu 控制器分别挂载
+func readCgroupV1(mount string, paths map[string]string, limits *Limits) {
+	memory := cgroupDirs(filepath.Join(mount, "memory"), paths["memory"])
+	for _, dir := range memory {
+		if limit, ok := readInt(filepath.Join(dir, "memory.limit_in_bytes")); ok && (limits.MemoryLimit == 0 || limit < limits.MemoryLimit) {
+			limits.MemoryLimit = limit
+		}
+	}
+	limits.MemoryUsage, _ = readInt(filepath.Join(memory[0], "memory.usage_in_bytes"))
+	limits.MemoryPeak, _ = readInt(filepath.Join(memory[0], "memory.max_usage_in_bytes"))
+	limits.OOMKills = readKeyed(filepath.Join(memory[0], "memory.oom_control"), "oom_kill")
+
+	// cpu 控制器通常与 cpuacct 一起挂载在 cpu,cpuacct 目录
+	cpuMount := filepath.Join(mount, "cpu")
+	if _, err := os.Stat(cpuMount); err != nil {
+		cpuMount = filepath.Join(mount, "cpu,cpuacct")
+	}
+	for _, dir := range cgroupDirs(cpuMount, paths["cpu"]) {
+		quota, ok1 := readInt(filepath.Join(dir, "cpu.cfs_quota_us"))
+		period, ok2 := readInt(filepath.Join(dir, "cpu.cfs_period_us"))
+		if ok1 && ok2 && quota > 0 && period > 0 {
+			if cpus := float64(quota) / float64(period); limits.CPUQuota == 0 || cpus < limits.CPUQuota {
+				limits.CPUQuota = cpus
+			}
+		}
+	}
+}
+
+// readMeminfo 读取主机的物理内存和可用内存
+func readMeminfo(root string, limits *Limits) {
+	file, err := os.Open(filepath.Join(root, "proc", "meminfo"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// MemTotal:       16318412 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			limits.HostMemory = kb << 10
+		case "MemAvailable:":
+			limits.HostAvailable = kb << 10
+		}
+	}
+}
+
+// readFields 读取单行文件并按空白拆分
+func readFields(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// readInt 读取只包含一个整数的文件，"max" 或读取失败时返回 false
+func readInt(path string) (int64, bool) {
+	fields := readFields(path)
+	if len(fields) != 1 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(fields[0], 10, 64)
+	return n, err == nil
+}
+
+// readKeyed 读取 "key value" 格式文件中的一项
+func readKeyed(path, key string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == key {
+			n, _ := strconv.Atoi(fields[1])
+			return n
+		}
+	}
+	return 0
+}
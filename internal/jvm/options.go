@@ -0,0 +1,238 @@
+package jvm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// optionVariables JVM 或启动脚本读取参数的环境变量，按生效顺序排列，后面的覆盖前面的。
+// JAVA_TOOL_OPTIONS 由 JVM 读取，JDK_JAVA_OPTIONS 由 JDK 9+ 的 java 启动器读取，JAVA_OPTS 只由启动脚本读取
+var optionVariables = []string{"JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS", "JAVA_OPTS"}
+
+// scriptVariable 只有启动脚本才会展开到 java 命令中的环境变量
+const scriptVariable = "JAVA_OPTS"
+
+// SourceCommand 直接写在启动命令中的参数来源
+const SourceCommand = "启动命令"
+
+// Flag 识别出的一个JVM参数
+type Flag struct {
+	Arg    string // 参数原文，如 -Xmx2g
+	Source string // 来源：启动命令或环境变量名
+}
+
+// Options 启动命令中与内存、CPU和GC相关的JVM参数，未设置的大小和百分比为0
+type Options struct {
+	MaxHeap              int64    // -Xmx / -XX:MaxHeapSize
+	InitialHeap          int64    // -Xms / -XX:InitialHeapSize
+	MaxMetaspace         int64    // -XX:MaxMetaspaceSize
+	ReservedCodeCache    int64    // -XX:ReservedCodeCacheSize
+	MaxDirectMemory      int64    // -XX:MaxDirectMemorySize
+	ThreadStack          int64    // -Xss / -XX:ThreadStackSize
+	MaxRAM               int64    // -XX:MaxRAM，覆盖JVM探测到的物理内存
+	MaxRAMPercentage     float64  // -XX:MaxRAMPercentage
+	InitialRAMPercentage float64  // -XX:InitialRAMPercentage
+	MinRAMPercentage     float64  // -XX:MinRAMPercentage
+	ActiveProcessorCount int      // -XX:ActiveProcessorCount
+	ContainerSupport     *bool    // -XX:+/-UseContainerSupport，nil 表示默认开启
+	AlwaysPreTouch       bool     // -XX:+AlwaysPreTouch，启动时提交全部初始堆
+	GC                   string   // 显式选择的垃圾收集器：G1、Parallel、Serial、ZGC、Shenandoah、CMS、Epsilon
+	Flags                []Flag   // 识别出的参数，按生效顺序排列
+	Ignored              []Flag   // 设置了但不会生效的参数，如直接运行 java 时 JAVA_OPTS 中的参数
+	Errors               []string // 无法解析的参数
+}
+
+// gcFlags 选择垃圾收集器的参数
+var gcFlags = map[string]string{
+	"-XX:+UseG1GC":            "G1",
+	"-XX:+UseParallelGC":      "Parallel",
+	"-XX:+UseParallelOldGC":   "Parallel",
+	"-XX:+UseSerialGC":        "Serial",
+	"-XX:+UseZGC":             "ZGC",
+	"-XX:+UseShenandoahGC":    "Shenandoah",
+	"-XX:+UseConcMarkSweepGC": "CMS",
+	"-XX:+UseEpsilonGC":       "Epsilon",
+}
+
+// ParseOptions 从启动命令和环境变量中提取JVM参数。
+// 环境变量按 JAVA_TOOL_OPTIONS、JDK_JAVA_OPTIONS、JAVA_OPTS 的顺序生效，命令前的变量赋值覆盖 env 中的同名变量，
+// 命令行中的参数最后生效。JAVA_OPTS 只在通过启动脚本运行时生效，直接运行 java 时记录到 Ignored。
+// 启动脚本包装的命令中看不到的参数无法识别。
+func ParseOptions(command string, env map[string]string) Options {
+	var opts Options
+	commands := shell.Parse(command)
+
+	// 命令前的环境变量赋值，如 JAVA_OPTS="-Xmx1g" ./start.sh
+	vars := make(map[string]string)
+	for name, value := range env {
+		vars[name] = value
+	}
+	for _, c := range commands {
+		for name, value := range c.Env {
+			vars[name] = value
+		}
+	}
+
+	direct := false
+	for _, c := range commands {
+		direct = direct || IsJava(c.Program)
+	}
+	for _, name := range optionVariables {
+		if name == scriptVariable && direct {
+			// java 启动器不读取 JAVA_OPTS，只记录其中的参数用于提示
+			var ignored Options
+			for _, arg := range strings.Fields(vars[name]) {
+				ignored.apply(arg, name)
+			}
+			opts.Ignored = ignored.Flags
+			continue
+		}
+		for _, arg := range strings.Fields(vars[name]) {
+			opts.apply(arg, name)
+		}
+	}
+	for _, c := range commands {
+		for _, arg := range c.Args {
+			opts.apply(arg, SourceCommand)
+		}
+	}
+	return opts
+}
+
+// IsJava 判断程序是否为 java 启动器
+func IsJava(program string) bool {
+	switch strings.ToLower(filepath.Base(program)) {
+	case "java", "java.exe", "javaw", "javaw.exe":
+		return true
+	}
+	return false
+}
+
+// apply 解析单个参数，不相关的参数被忽略
+func (o *Options) apply(arg, source string) {
+	size := func(target *int64, value string) {
+		n, err := ParseSize(value)
+		if err != nil {
+			o.Errors = append(o.Errors, fmt.Sprintf("%s: %v", arg, err))
+			return
+		}
+		*target = n
+	}
+	percentage := func(target *float64, value string) {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < 0 || n > 100 {
+			o.Errors = append(o.Errors, fmt.Sprintf("%s: 无效的百分比", arg))
+			return
+		}
+		*target = n
+	}
+
+	known := true
+	switch {
+	case strings.HasPrefix(arg, "-Xmx"):
+		size(&o.MaxHeap, arg[4:])
+	case strings.HasPrefix(arg, "-Xms"):
+		size(&o.InitialHeap, arg[4:])
+	case strings.HasPrefix(arg, "-Xss"):
+		size(&o.ThreadStack, arg[4:])
+	case gcFlags[arg] != "":
+		o.GC = gcFlags[arg]
+	case arg == "-XX:+UseContainerSupport" || arg == "-XX:-UseContainerSupport":
+		enabled := arg[4] == '+'
+		o.ContainerSupport = &enabled
+	case arg == "-XX:+AlwaysPreTouch":
+		o.AlwaysPreTouch = true
+	case arg == "-XX:-AlwaysPreTouch":
+		o.AlwaysPreTouch = false
+	case strings.HasPrefix(arg, "-XX:") && strings.Contains(arg, "="):
+		name, value, _ := strings.Cut(arg[4:], "=")
+		switch name {
+		case "MaxHeapSize":
+			size(&o.MaxHeap, value)
+		case "InitialHeapSize":
+			size(&o.InitialHeap, value)
+		case "MaxMetaspaceSize":
+			size(&o.MaxMetaspace, value)
+		case "ReservedCodeCacheSize":
+			size(&o.ReservedCodeCache, value)
+		case "MaxDirectMemorySize":
+			size(&o.MaxDirectMemory, value)
+		case "ThreadStackSize":
+			// 单位为KB
+			var kb int64
+			size(&kb, value)
+			o.ThreadStack = kb * 1024
+		case "MaxRAM":
+			size(&o.MaxRAM, value)
+		case "MaxRAMPercentage":
+			percentage(&o.MaxRAMPercentage, value)
+		case "InitialRAMPercentage":
+			percentage(&o.InitialRAMPercentage, value)
+		case "MinRAMPercentage":
+			percentage(&o.MinRAMPercentage, value)
+		case "ActiveProcessorCount":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				o.Errors = append(o.Errors, fmt.Sprintf("%s: 无效的处理器数", arg))
+				return
+			}
+			o.ActiveProcessorCount = n
+		default:
+			known = false
+		}
+	default:
+		known = false
+	}
+	if known {
+		o.Flags = append(o.Flags, Flag{Arg: arg, Source: source})
+	}
+}
+
+// Java 是否识别出了任何JVM参数
+func (o Options) Java() bool {
+	return len(o.Flags) > 0
+}
+
+// ParseSize 解析JVM的大小参数，如 512m、2G、1048576，无单位时为字节
+func ParseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("缺少大小")
+	}
+	multiplier := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		multiplier = 1 << 10
+	case 'm', 'M':
+		multiplier = 1 << 20
+	case 'g', 'G':
+		multiplier = 1 << 30
+	case 't', 'T':
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小 %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// FormatSize 将字节数格式化为易读的大小，如 512M、1.5G
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return strings.TrimSuffix(strconv.FormatFloat(float64(size)/(1<<30), 'f', 1, 64), ".0") + "G"
+	case size >= 1<<20:
+		return strconv.FormatInt(size>>20, 10) + "M"
+	case size >= 1<<10:
+		return strconv.FormatInt(size>>10, 10) + "K"
+	default:
+		return strconv.FormatInt(size, 10) + "B"
+	}
+}
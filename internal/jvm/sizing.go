@@ -0,0 +1,250 @@
+package jvm
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// HotSpot 的默认值（JDK 11 及以上）
+const (
+	defaultMaxRAMPercentage = 25.0
+	defaultMinRAMPercentage = 50.0
+	// defaultMaxHeapSize 内存较小时判断是否改用 MinRAMPercentage 的阈值
+	defaultMaxHeapSize = 124 << 20
+	// serverClassMemory 至少2个处理器和1792M内存时默认使用G1，否则使用Serial
+	serverClassMemory = 1792 << 20
+)
+
+// 非堆内存的估算值，与常见的容器内存计算器一致
+const (
+	estimatedMetaspace   = 128 << 20
+	defaultCodeCache     = 240 << 20
+	estimatedDirect      = 10 << 20
+	defaultThreadStack   = 1 << 20
+	estimatedThreadCount = 250
+)
+
+// Severity 检查结果的严重程度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 会导致启动失败或被 OOM kill
+	SeverityWarning Severity = "warning" // 存在风险
+	SeverityInfo    Severity = "info"    // 说明性信息
+)
+
+// Finding 一项检查结果
+type Finding struct {
+	Severity Severity
+	Message  string
+	Advice   string
+}
+
+// Component 进程内存占用的一个组成部分
+type Component struct {
+	Name  string
+	Size  int64
+	Basis string // 显式设置的参数或估算依据
+}
+
+// Report JVM参数与资源限制的对比结果
+type Report struct {
+	Options      Options
+	Limits       Limits
+	Memory       int64  // JVM看到的可用内存
+	MemorySource string // 可用内存的来源
+	Heap         int64  // 最大堆
+	HeapBasis    string
+	Footprint    []Component // 预计的内存占用
+	Total        int64       // 预计的总内存占用
+	Processors   int         // JVM看到的处理器数
+	GC           string      // 实际使用的垃圾收集器
+	GCBasis      string
+	Findings     []Finding
+}
+
+// Analyze 按HotSpot的规则推算堆大小、处理器数和垃圾收集器，估算进程的内存占用并与限制对比
+func Analyze(opts Options, limits Limits) Report {
+	r := Report{Options: opts, Limits: limits}
+	containerSupport := opts.ContainerSupport == nil || *opts.ContainerSupport
+
+	switch {
+	case opts.MaxRAM > 0:
+		r.Memory, r.MemorySource = opts.MaxRAM, "-XX:MaxRAM"
+	case containerSupport && limits.MemoryLimit > 0:
+		r.Memory, r.MemorySource = limits.MemoryLimit, fmt.Sprintf("cgroup v%d 内存限制", limits.CgroupVersion)
+	default:
+		r.Memory, r.MemorySource = limits.HostMemory, "主机物理内存"
+	}
+
+	r.Heap, r.HeapBasis = r.defaultHeap()
+	r.Processors = limits.CPUs
+	if containerSupport && limits.CPUQuota > 0 {
+		r.Processors = min(r.Processors, int(math.Ceil(limits.CPUQuota)))
+	}
+	if opts.ActiveProcessorCount > 0 {
+		r.Processors = opts.ActiveProcessorCount
+	}
+	r.GC, r.GCBasis = opts.GC, "显式指定"
+	if r.GC == "" {
+		if r.Processors >= 2 && r.Memory >= serverClassMemory {
+			r.GC, r.GCBasis = "G1", "默认"
+		} else {
+			r.GC, r.GCBasis = "Serial", "默认"
+		}
+	}
+
+	r.Footprint = r.footprint()
+	for _, c := range r.Footprint {
+		r.Total += c.Size
+	}
+	r.check(containerSupport)
+	return r
+}
+
+// defaultHeap 返回最大堆和推算依据
+func (r *Report) defaultHeap() (int64, string) {
+	opts := r.Options
+	if opts.MaxHeap > 0 {
+		return opts.MaxHeap, "-Xmx"
+	}
+	if r.Memory == 0 {
+		return 0, "未知：无法读取可用内存"
+	}
+	minPercentage := defaultMinRAMPercentage
+	if opts.MinRAMPercentage > 0 {
+		minPercentage = opts.MinRAMPercentage
+	}
+	if small := int64(float64(r.Memory) * minPercentage / 100); small < defaultMaxHeapSize {
+		return small, fmt.Sprintf("内存较小，按 MinRAMPercentage=%g 取 %s 的 %g%%", minPercentage, r.MemorySource, minPercentage)
+	}
+	percentage, basis := defaultMaxRAMPercentage, "默认 MaxRAMPercentage=25"
+	if opts.MaxRAMPercentage > 0 {
+		percentage, basis = opts.MaxRAMPercentage, fmt.Sprintf("-XX:MaxRAMPercentage=%g", opts.MaxRAMPercentage)
+	}
+	return max(int64(float64(r.Memory)*percentage/100), defaultMaxHeapSize), fmt.Sprintf("%s，取 %s 的 %g%%", basis, r.MemorySource, percentage)
+}
+
+// footprint 估算进程的内存占用，未显式设置的部分使用估算值
+func (r *Report) footprint() []Component {
+	opts := r.Options
+	component := func(name string, explicit int64, flag string, estimate int64, basis string) Component {
+		if explicit > 0 {
+			return Component{Name: name, Size: explicit, Basis: flag}
+		}
+		return Component{Name: name, Size: estimate, Basis: basis}
+	}
+	stack := opts.ThreadStack
+	stackBasis := fmt.Sprintf("-Xss%s × %d 个线程（估算）", FormatSize(stack), estimatedThreadCount)
+	if stack == 0 {
+		stack = defaultThreadStack
+		stackBasis = fmt.Sprintf("默认 1M × %d 个线程（估算）", estimatedThreadCount)
+	}
+	return []Component{
+		{Name: "堆", Size: r.Heap, Basis: r.HeapBasis},
+		component("Metaspace", opts.MaxMetaspace, "-XX:MaxMetaspaceSize", estimatedMetaspace, "未设置上限，按常见的 Spring Boot 应用估算"),
+		component("代码缓存", opts.ReservedCodeCache, "-XX:ReservedCodeCacheSize", defaultCodeCache, "默认 ReservedCodeCacheSize"),
+		{Name: "线程栈", Size: stack * estimatedThreadCount, Basis: stackBasis},
+		component("直接内存", opts.MaxDirectMemory, "-XX:MaxDirectMemorySize", estimatedDirect, "未设置上限（默认与最大堆相同），按常见用量估算"),
+	}
+}
+
+// check 对比参数和限制，生成检查结果
+func (r *Report) check(containerSupport bool) {
+	opts, limits := r.Options, r.Limits
+	add := func(severity Severity, advice, format string, args ...any) {
+		r.Findings = append(r.Findings, Finding{Severity: severity, Message: fmt.Sprintf(format, args...), Advice: advice})
+	}
+	nonHeap := r.Total - r.Heap
+	// suggestHeap 在给定内存中为非堆留出空间后的最大堆，按16M取整
+	suggestHeap := func(memory int64) string {
+		heap := (memory - nonHeap) &^ (16<<20 - 1)
+		if heap < 64<<20 {
+			return fmt.Sprintf("内存不足以容纳预计 %s 的非堆内存，需要提高内存限制到至少 %s", FormatSize(nonHeap), FormatSize(nonHeap+256<<20))
+		}
+		return fmt.Sprintf("将最大堆降到 -Xmx%dm 以内（为非堆内存预留 %s），或提高内存限制", heap>>20, FormatSize(nonHeap))
+	}
+
+	if limits.OOMKills > 0 {
+		add(SeverityError, "进程因超过内存限制被内核终止（OOMKilled），需要按下面的估算调整 -Xmx 或提高内存限制",
+			"cgroup 已记录 %d 次 OOM kill，内存限制 %s，峰值 %s", limits.OOMKills, FormatSize(limits.MemoryLimit), FormatSize(limits.MemoryPeak))
+	}
+	if opts.InitialHeap > 0 && opts.MaxHeap > 0 && opts.InitialHeap > opts.MaxHeap {
+		add(SeverityError, "将 -Xms 设为不大于 -Xmx",
+			"初始堆 -Xms%s 大于最大堆 -Xmx%s，JVM 会报 \"Initial heap size set to a larger value than the maximum heap size\" 并退出", FormatSize(opts.InitialHeap), FormatSize(opts.MaxHeap))
+	}
+
+	if limits.MemoryLimit > 0 {
+		switch {
+		case !containerSupport:
+			add(SeverityWarning, "去掉 -XX:-UseContainerSupport，或显式设置 -Xmx",
+				"关闭了容器支持，JVM 按主机内存 %s 而不是容器限制 %s 计算默认堆", FormatSize(limits.HostMemory), FormatSize(limits.MemoryLimit))
+		case opts.MaxHeap == 0 && opts.MaxRAMPercentage == 0 && opts.MaxRAM == 0:
+			add(SeverityInfo, "容器中通常使用 -XX:MaxRAMPercentage=75 让堆随内存限制伸缩",
+				"未设置最大堆，JVM 默认最大堆只有 %s（%s）", FormatSize(r.Heap), r.HeapBasis)
+		}
+		if opts.MaxRAMPercentage > 90 {
+			add(SeverityWarning, "MaxRAMPercentage 一般不超过75-80",
+				"-XX:MaxRAMPercentage=%g 几乎没有为非堆内存留出空间", opts.MaxRAMPercentage)
+		}
+		switch {
+		case r.Heap >= limits.MemoryLimit:
+			add(SeverityError, suggestHeap(limits.MemoryLimit),
+				"最大堆 %s 不小于容器内存限制 %s，堆增长后进程会被 OOM kill", FormatSize(r.Heap), FormatSize(limits.MemoryLimit))
+		case r.Total > limits.MemoryLimit:
+			add(SeverityWarning, suggestHeap(limits.MemoryLimit),
+				"预计内存占用 %s（堆 %s + 非堆 %s）超过容器内存限制 %s，负载上升后可能被 OOM kill", FormatSize(r.Total), FormatSize(r.Heap), FormatSize(nonHeap), FormatSize(limits.MemoryLimit))
+		}
+		if opts.InitialHeap >= limits.MemoryLimit {
+			add(SeverityError, "将 -Xms 设为内存限制的一半以内",
+				"初始堆 -Xms%s 不小于容器内存限制 %s", FormatSize(opts.InitialHeap), FormatSize(limits.MemoryLimit))
+		}
+	} else if limits.HostMemory > 0 {
+		if opts.MaxHeap > limits.HostMemory {
+			add(SeverityWarning, suggestHeap(limits.HostMemory),
+				"最大堆 -Xmx%s 大于主机物理内存 %s", FormatSize(opts.MaxHeap), FormatSize(limits.HostMemory))
+		}
+		if opts.InitialHeap > 0 && limits.HostAvailable > 0 && opts.InitialHeap > limits.HostAvailable {
+			severity := SeverityWarning
+			if opts.AlwaysPreTouch {
+				severity = SeverityError
+			}
+			add(severity, "减小 -Xms 或释放主机内存",
+				"初始堆 -Xms%s 大于主机当前可用内存 %s，启动时可能报 \"Cannot allocate memory\" 或 \"os::commit_memory failed\"", FormatSize(opts.InitialHeap), FormatSize(limits.HostAvailable))
+		}
+	}
+
+	if opts.MaxMetaspace > 0 && opts.MaxMetaspace < 128<<20 {
+		add(SeverityWarning, "Spring Boot 应用的 Metaspace 通常需要100M以上，提高或去掉 -XX:MaxMetaspaceSize",
+			"-XX:MaxMetaspaceSize=%s 较小，类加载较多时会出现 \"OutOfMemoryError: Metaspace\"", FormatSize(opts.MaxMetaspace))
+	}
+	if opts.ThreadStack > 0 && opts.ThreadStack < 256<<10 {
+		add(SeverityWarning, "-Xss 一般不小于256K",
+			"-Xss%s 较小，深层调用（如Spring的代理和递归解析）可能出现 StackOverflowError", FormatSize(opts.ThreadStack))
+	}
+
+	if limits.CPUQuota > 0 && opts.ActiveProcessorCount > int(math.Ceil(limits.CPUQuota)) {
+		add(SeverityWarning, "去掉 -XX:ActiveProcessorCount 或设为不超过CPU配额",
+			"-XX:ActiveProcessorCount=%d 超过CPU配额 %g 核，GC和线程池线程过多会被限流", opts.ActiveProcessorCount, limits.CPUQuota)
+	}
+	switch {
+	case opts.GC == "CMS":
+		add(SeverityInfo, "改用 -XX:+UseG1GC", "CMS 在 JDK 14 中已移除，新版本JDK会忽略该参数或拒绝启动")
+	case opts.GC == "" && r.GC == "Serial":
+		reason := fmt.Sprintf("只有 %d 个处理器", r.Processors)
+		if r.Processors >= 2 {
+			reason = fmt.Sprintf("内存 %s 少于1792M", FormatSize(r.Memory))
+		}
+		add(SeverityInfo, "需要更短停顿时显式指定 -XX:+UseG1GC，或提高CPU配额和内存限制",
+			"%s，JVM 将默认使用 SerialGC", reason)
+	}
+	if len(opts.Ignored) > 0 {
+		var args []string
+		for _, f := range opts.Ignored {
+			args = append(args, f.Arg)
+		}
+		add(SeverityWarning, "把参数写在 java 命令中，或改用 JDK_JAVA_OPTIONS（JDK 9+）或 JAVA_TOOL_OPTIONS",
+			"%s 只由启动脚本读取，直接运行 java 时其中的 %s 不会生效", scriptVariable, strings.Join(args, " "))
+	}
+}
@@ -0,0 +1,160 @@
+package jvm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts := ParseOptions(
+		`JDK_JAVA_OPTIONS="-Xmx512m -XX:+UseSerialGC" java -Xms256M -Xmx2g -XX:MaxMetaspaceSize=96m -XX:ThreadStackSize=512 -XX:-UseContainerSupport -jar app.jar --server.port=8080`,
+		map[string]string{"JAVA_TOOL_OPTIONS": "-XX:MaxRAMPercentage=75.0 -Xss1x"},
+	)
+	if opts.MaxHeap != 2<<30 || opts.InitialHeap != 256<<20 || opts.MaxMetaspace != 96<<20 || opts.ThreadStack != 512<<10 {
+		t.Errorf("大小解析错误: %+v", opts)
+	}
+	if opts.MaxRAMPercentage != 75 || opts.GC != "Serial" || opts.ContainerSupport == nil || *opts.ContainerSupport {
+		t.Errorf("参数解析错误: %+v", opts)
+	}
+	if len(opts.Errors) != 1 || !strings.HasPrefix(opts.Errors[0], "-Xss1x") {
+		t.Errorf("错误的参数应被记录: %v", opts.Errors)
+	}
+	// 命令行中的 -Xmx 覆盖 JDK_JAVA_OPTIONS 中的
+	if last := opts.Flags[len(opts.Flags)-1]; last.Source != SourceCommand {
+		t.Errorf("参数顺序错误: %+v", opts.Flags)
+	}
+	if opts.Flags[0].Source != "JAVA_TOOL_OPTIONS" {
+		t.Errorf("JAVA_TOOL_OPTIONS 应最先生效: %+v", opts.Flags)
+	}
+}
+
+func TestParseOptionsJavaOpts(t *testing.T) {
+	// 直接运行 java 时不读取 JAVA_OPTS
+	opts := ParseOptions(`JAVA_OPTS="-Xmx512m -XX:+UseSerialGC" java -jar app.jar`, nil)
+	if opts.MaxHeap != 0 || opts.GC != "" || len(opts.Ignored) != 2 || opts.Ignored[0].Arg != "-Xmx512m" {
+		t.Errorf("JAVA_OPTS 不应生效: %+v", opts)
+	}
+	report := Analyze(opts, Limits{HostMemory: 16 << 30, CPUs: 8})
+	found := false
+	for _, f := range report.Findings {
+		found = found || strings.Contains(f.Message, "JAVA_OPTS") && strings.Contains(f.Message, "-Xmx512m")
+	}
+	if !found {
+		t.Errorf("应提示 JAVA_OPTS 不生效: %+v", report.Findings)
+	}
+
+	// 启动脚本展开 JAVA_OPTS
+	for _, command := range []string{
+		`JAVA_OPTS="-Xmx512m -XX:+UseSerialGC" ./bin/start.sh`,
+		`export JAVA_OPTS="-Xmx512m -XX:+UseSerialGC" && /opt/tomcat/bin/catalina.sh run`,
+	} {
+		opts := ParseOptions(command, nil)
+		if opts.MaxHeap != 512<<20 || opts.GC != "Serial" || len(opts.Ignored) != 0 {
+			t.Errorf("%s: JAVA_OPTS 应生效: %+v", command, opts)
+		}
+	}
+	if opts := ParseOptions("./start.sh", map[string]string{"JAVA_OPTS": "-Xmx1g"}); opts.MaxHeap != 1<<30 {
+		t.Errorf("环境中的 JAVA_OPTS 应生效: %+v", opts)
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	v2 := testutil.WriteFiles(t, map[string]string{
+		"proc/meminfo":                                  "MemTotal:       16318412 kB\nMemAvailable:    8000000 kB\n",
+		"proc/self/cgroup":                              "0::/kubepods/pod1/app\n",
+		"sys/fs/cgroup/cgroup.controllers":              "cpu memory\n",
+		"sys/fs/cgroup/kubepods/pod1/memory.max":        "1073741824\n",
+		"sys/fs/cgroup/kubepods/pod1/cpu.max":           "150000 100000\n",
+		"sys/fs/cgroup/kubepods/pod1/app/memory.max":    "max\n",
+		"sys/fs/cgroup/kubepods/pod1/app/cpu.max":       "max 100000\n",
+		"sys/fs/cgroup/kubepods/pod1/app/memory.peak":   "1073741824\n",
+		"sys/fs/cgroup/kubepods/pod1/app/memory.events": "low 0\nhigh 0\nmax 12\noom 2\noom_kill 2\n",
+	})
+	limits := ReadLimits(v2)
+	if limits.CgroupVersion != 2 || limits.MemoryLimit != 1<<30 || limits.CPUQuota != 1.5 || limits.OOMKills != 2 || limits.MemoryPeak != 1<<30 {
+		t.Errorf("cgroup v2 限制错误: %+v", limits)
+	}
+	if limits.HostMemory != 16318412<<10 {
+		t.Errorf("主机内存错误: %d", limits.HostMemory)
+	}
+
+	// cgroup v1，容器内只挂载了自身的cgroup
+	v1 := testutil.WriteFiles(t, map[string]string{
+		"proc/self/cgroup":                            "12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n",
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":  "536870912\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "-1\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+	})
+	limits = ReadLimits(v1)
+	if limits.CgroupVersion != 1 || limits.MemoryLimit != 512<<20 || limits.CPUQuota != 0 {
+		t.Errorf("cgroup v1 限制错误: %+v", limits)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	limits := Limits{CgroupVersion: 2, MemoryLimit: 1 << 30, CPUQuota: 1, CPUs: 8, HostMemory: 16 << 30}
+
+	// -Xmx 等于容器限制
+	report := Analyze(ParseOptions("java -Xmx1g -jar app.jar", nil), limits)
+	if report.Heap != 1<<30 || report.GC != "Serial" || report.Processors != 1 {
+		t.Errorf("推算错误: heap=%d gc=%s cpus=%d", report.Heap, report.GC, report.Processors)
+	}
+	if len(report.Findings) == 0 || report.Findings[0].Severity != SeverityError || !strings.Contains(report.Findings[0].Advice, "-Xmx") {
+		t.Errorf("应报告堆超过限制: %+v", report.Findings)
+	}
+
+	// 未设置 -Xmx 时按 MaxRAMPercentage 推算
+	report = Analyze(ParseOptions("java -XX:MaxRAMPercentage=50 -jar app.jar", nil), limits)
+	if report.Heap != 512<<20 {
+		t.Errorf("默认堆错误: %d (%s)", report.Heap, report.HeapBasis)
+	}
+	if report.Total != 512<<20+128<<20+240<<20+250<<20+10<<20 {
+		t.Errorf("内存估算错误: %d", report.Total)
+	}
+	for _, f := range report.Findings {
+		if f.Severity == SeverityError {
+			t.Errorf("不应报告错误: %+v", f)
+		}
+	}
+
+	// 内存很小时按 MinRAMPercentage 推算
+	report = Analyze(Options{}, Limits{CgroupVersion: 2, MemoryLimit: 200 << 20, CPUs: 2})
+	if report.Heap != 100<<20 {
+		t.Errorf("小内存默认堆错误: %d (%s)", report.Heap, report.HeapBasis)
+	}
+}
+
+func TestReadProcessLimits(t *testing.T) {
+	// 另一个容器中的进程：/proc/<pid>/cgroup 是宿主机上的路径，容器内只挂载了自身的cgroup
+	root := testutil.WriteFiles(t, map[string]string{
+		"proc/meminfo":                                  "MemTotal:       16318412 kB\n",
+		"proc/self/cgroup":                              "0::/user.slice\n",
+		"sys/fs/cgroup/cgroup.controllers":              "cpu memory\n",
+		"sys/fs/cgroup/user.slice/memory.max":           "max\n",
+		"proc/42/cgroup":                                "0::/kubepods/pod1/app\n",
+		"proc/42/root/proc/meminfo":                     "MemTotal:       16318412 kB\n",
+		"proc/42/root/sys/fs/cgroup/cgroup.controllers": "cpu memory\n",
+		"proc/42/root/sys/fs/cgroup/memory.max":         "536870912\n",
+		"proc/42/root/sys/fs/cgroup/cpu.max":            "200000 100000\n",
+		"proc/42/root/sys/fs/cgroup/memory.events":      "oom_kill 1\n",
+	})
+	limits := ReadProcessLimits(root, 42)
+	if limits.CgroupVersion != 2 || limits.CgroupPath != "/kubepods/pod1/app" || limits.MemoryLimit != 512<<20 || limits.CPUQuota != 2 || limits.OOMKills != 1 {
+		t.Errorf("进程的cgroup限制错误: %+v", limits)
+	}
+	if self := ReadLimits(root); self.MemoryLimit != 0 || self.CgroupPath != "/user.slice" {
+		t.Errorf("自身的cgroup限制错误: %+v", self)
+	}
+
+	// 无法访问 /proc/<pid>/root 时读取 root 下的cgroup
+	root = testutil.WriteFiles(t, map[string]string{
+		"proc/42/cgroup":                   "0::/app\n",
+		"sys/fs/cgroup/cgroup.controllers": "cpu memory\n",
+		"sys/fs/cgroup/app/memory.max":     "1073741824\n",
+	})
+	if limits := ReadProcessLimits(root, 42); limits.MemoryLimit != 1<<30 {
+		t.Errorf("退回到 root 的cgroup限制错误: %+v", limits)
+	}
+}
@@ -0,0 +1,470 @@
+package logparse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	detectSampleLines = 200       // 用于识别日志格式的行数
+	maxEntryMessage   = 64 * 1024 // 单条记录的消息（含续行）最大长度
+)
+
+// 日志中常见的时间戳：完整日期时间（可带毫秒和时区），或 logback 默认的 HH:mm:ss.SSS
+const timestampExpr = `\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d{1,9})?(?:Z|[+-]\d{2}:?\d{2})?|\d{2}:\d{2}:\d{2}[.,]\d{3}`
+
+// 各日志框架使用的级别名称
+const levelExpr = `TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|SEVERE|FINEST|FINER|FINE|CONFIG|CRITICAL`
+
+var (
+	// Spring Boot 默认格式 (logback 和 log4j2 相同)：
+	// 2025-09-23 19:47:01.123 ERROR 12345 --- [           main] o.s.boot.SpringApplication : msg
+	// Spring Boot 3.2+ 在线程前还有应用名: --- [app] [main]
+	springBootPattern = regexp.MustCompile(`^(` + timestampExpr + `)\s+(` + levelExpr + `)\s+(?:\d+\s+)?---\s+(?:\[[^\]]*\]\s+)?\[\s*([^\]]*?)\s*\]\s+(\S+)\s*:\s?(.*)$`)
+	// logback 常用格式: %d [%thread] %-5level %logger{36} - %msg
+	logbackPattern = regexp.MustCompile(`^(` + timestampExpr + `)\s+\[([^\]]*)\]\s+(` + levelExpr + `)\s+(\S+)\s+-\s?(.*)$`)
+	// log4j2 常用格式: %d %-5level [%t] %c{1.} - %msg 或 %d %p %c{1.} [%t] %m
+	log4j2Pattern       = regexp.MustCompile(`^(` + timestampExpr + `)\s+(` + levelExpr + `)\s+\[([^\]]*)\]\s+(\S+)\s+-\s?(.*)$`)
+	log4j2AltPattern    = regexp.MustCompile(`^(` + timestampExpr + `)\s+(` + levelExpr + `)\s+(\S+)\s+\[([^\]]*)\]\s?(.*)$`)
+	log4j2StatusPattern = regexp.MustCompile(`^(` + timestampExpr + `)\s+(\S+)\s+(` + levelExpr + `)\s+(.*)$`)
+	// 启动脚本输出: [2025-09-23 19:46:50] configmap.sh: msg
+	shellPattern = regexp.MustCompile(`^\[(` + timestampExpr + `)\]\s+([\w.-]+):\s?(.*)$`)
+	// JVM 自身输出的警告
+	jvmWarningPattern = regexp.MustCompile(`^(?:Java HotSpot\(TM\)|OpenJDK) .*?VM warning:\s?(.*)$`)
+	// 只有级别前缀的输出，例如 java.util.logging 的第二行或 Sentinel 的 "INFO: ..."
+	levelPrefixPattern = regexp.MustCompile(`^(` + levelExpr + `):\s+(.*)$`)
+	// 只有时间戳前缀的输出，级别可选
+	timestampedPattern = regexp.MustCompile(`^(` + timestampExpr + `)\s+(?:(` + levelExpr + `)\s+)?(.*)$`)
+
+	timestampPattern = regexp.MustCompile(`^(?:` + timestampExpr + `)$`)
+	levelPattern     = regexp.MustCompile(`^(?:` + levelExpr + `)$`)
+)
+
+// Entry 归一化后的一条日志记录，续行（堆栈、多行消息）并入所属记录
+type Entry struct {
+	Line    int       `json:"line"`     // 记录首行行号 (1-based)
+	EndLine int       `json:"end_line"` // 记录最后一个续行的行号
+	Time    time.Time `json:"time"`     // 没有时间戳时为零值
+	Level   string    `json:"level,omitempty"`
+	Thread  string    `json:"thread,omitempty"`
+	Logger  string    `json:"logger,omitempty"`
+	Message string    `json:"message"`
+	Format  string    `json:"format,omitempty"` // 识别该行所用的格式，续行组成的记录为空
+}
+
+// format 一种日志格式及其单行解析函数
+type format struct {
+	name  string
+	parse func(line string, base time.Time) (Entry, bool)
+}
+
+// formats 所有支持的格式，顺序即无法确定文件格式时的尝试顺序
+var formats = []format{
+	{"json", parseJSONLine},
+	{"spring-boot", func(line string, base time.Time) (Entry, bool) {
+		m := springBootPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return newEntry(m[1], m[2], m[3], m[4], m[5], base), true
+	}},
+	{"logback", func(line string, base time.Time) (Entry, bool) {
+		m := logbackPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return newEntry(m[1], m[3], m[2], m[4], m[5], base), true
+	}},
+	{"log4j2", func(line string, base time.Time) (Entry, bool) {
+		if m := log4j2Pattern.FindStringSubmatch(line); m != nil {
+			return newEntry(m[1], m[2], m[3], m[4], m[5], base), true
+		}
+		if m := log4j2AltPattern.FindStringSubmatch(line); m != nil {
+			return newEntry(m[1], m[2], m[4], m[3], m[5], base), true
+		}
+		return Entry{}, false
+	}},
+	{"bracketed", parseBracketedLine},
+	{"shell", func(line string, base time.Time) (Entry, bool) {
+		m := shellPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return newEntry(m[1], "", "", m[2], m[3], base), true
+	}},
+	{"log4j2-status", func(line string, base time.Time) (Entry, bool) {
+		m := log4j2StatusPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return newEntry(m[1], m[3], m[2], "", m[4], base), true
+	}},
+	{"jvm", func(line string, base time.Time) (Entry, bool) {
+		m := jvmWarningPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return Entry{Level: "WARN", Logger: "jvm", Message: m[1]}, true
+	}},
+	{"level-prefix", func(line string, base time.Time) (Entry, bool) {
+		m := levelPrefixPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return Entry{Level: NormalizeLevel(m[1]), Message: m[2]}, true
+	}},
+	{"timestamped", func(line string, base time.Time) (Entry, bool) {
+		m := timestampedPattern.FindStringSubmatch(line)
+		if m == nil {
+			return Entry{}, false
+		}
+		return newEntry(m[1], m[2], "", "", m[3], base), true
+	}},
+}
+
+// newEntry 由各字段构建记录，时间戳无法解析时保持零值
+func newEntry(timestamp, level, thread, logger, message string, base time.Time) Entry {
+	t, _ := ParseTimestamp(timestamp, base)
+	return Entry{
+		Time:    t,
+		Level:   NormalizeLevel(level),
+		Thread:  strings.TrimSpace(thread),
+		Logger:  logger,
+		Message: message,
+	}
+}
+
+// parseJSONLine 解析 logstash-logback-encoder 等输出的 JSON 日志
+func parseJSONLine(line string, base time.Time) (Entry, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return Entry{}, false
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return Entry{}, false
+	}
+
+	entry := newEntry(
+		firstString(fields, "@timestamp", "timestamp", "time", "ts"),
+		firstString(fields, "level", "log.level", "severity", "loglevel"),
+		firstString(fields, "thread_name", "thread", "process.thread.name"),
+		firstString(fields, "logger_name", "logger", "log.logger", "loggerName"),
+		firstString(fields, "message", "msg", "log"),
+		base,
+	)
+	if trace := firstString(fields, "stack_trace", "exception", "error.stack_trace"); trace != "" {
+		entry.Message += "\n" + trace
+	}
+	return entry, true
+}
+
+// firstString 返回第一个存在的字符串字段
+func firstString(fields map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := fields[key].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// parseBracketedLine 解析字段放在方括号中的格式，按顺序识别时间戳、级别、线程和日志器
+// 例如: [hlog][1t][2025-09-23 19:46:53.778][INFO][main][c.h.o.s.Application][,,,,][,,] - msg
+func parseBracketedLine(line string, base time.Time) (Entry, bool) {
+	fields, rest, ok := splitBrackets(line)
+	if !ok {
+		return Entry{}, false
+	}
+
+	// 时间戳之后紧跟级别，再之后依次为线程和日志器
+	for i, field := range fields {
+		if !timestampPattern.MatchString(field) {
+			continue
+		}
+		if i+1 >= len(fields) || !levelPattern.MatchString(fields[i+1]) {
+			return Entry{}, false
+		}
+		var thread, logger string
+		if i+2 < len(fields) {
+			thread = fields[i+2]
+		}
+		if i+3 < len(fields) {
+			logger = fields[i+3]
+		}
+		return newEntry(field, fields[i+1], thread, logger, rest, base), true
+	}
+	return Entry{}, false
+}
+
+// splitBrackets 拆分行首连续的方括号字段，返回字段和去掉 " - " 分隔符后的消息
+// 线程名中可能嵌套方括号，按配对拆分；logback 缩写的日志器名（如 o.a.c.c.C.[.[.[/]）无法配对，
+// 这时以后面紧跟下一个字段或空格的右括号作为结束
+func splitBrackets(line string) ([]string, string, bool) {
+	var fields []string
+	rest := line
+	for strings.HasPrefix(rest, "[") {
+		end := matchingBracket(rest)
+		if end < 0 {
+			end = fieldEnd(rest)
+			if end < 0 {
+				return nil, "", false
+			}
+		}
+		fields = append(fields, strings.TrimSpace(rest[1:end]))
+		rest = strings.TrimLeft(rest[end+1:], " ")
+	}
+	if len(fields) == 0 {
+		return nil, "", false
+	}
+	rest = strings.TrimPrefix(rest, "- ")
+	return fields, strings.TrimPrefix(rest, "-"), true
+}
+
+// fieldEnd 返回第一个后面紧跟 '[' 或空格的右括号位置，都没有时返回第一个右括号
+func fieldEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == ']' && (i+1 == len(s) || s[i+1] == '[' || s[i+1] == ' ') {
+			return i
+		}
+	}
+	return strings.IndexByte(s, ']')
+}
+
+// matchingBracket 返回与开头的左括号配对的右括号位置，无法配对时返回 -1
+func matchingBracket(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ParseTimestamp 解析日志时间戳，没有时区的按本地时间处理
+// 只有时分秒的时间戳使用 base 的日期
+func ParseTimestamp(s string, base time.Time) (time.Time, error) {
+	s = strings.NewReplacer(",", ".", "/", "-", "T", " ").Replace(strings.TrimSpace(s))
+	// Go 在解析时会自动接受秒之后的小数部分
+	for _, layout := range []string{"2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05-0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("15:04:05", s, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	year, month, day := base.Date()
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local), nil
+}
+
+// NormalizeLevel 将各框架的级别名称统一为 TRACE/DEBUG/INFO/WARN/ERROR/FATAL
+func NormalizeLevel(level string) string {
+	switch level = strings.ToUpper(strings.TrimSpace(level)); level {
+	case "WARNING":
+		return "WARN"
+	case "SEVERE", "ERR":
+		return "ERROR"
+	case "CRITICAL":
+		return "FATAL"
+	case "FINE", "CONFIG":
+		return "DEBUG"
+	case "FINER", "FINEST":
+		return "TRACE"
+	default:
+		return level
+	}
+}
+
+// LevelRank 返回级别的严重程度，未知级别为 -1
+func LevelRank(level string) int {
+	switch NormalizeLevel(level) {
+	case "TRACE":
+		return 0
+	case "DEBUG":
+		return 1
+	case "INFO":
+		return 2
+	case "WARN":
+		return 3
+	case "ERROR":
+		return 4
+	case "FATAL":
+		return 5
+	default:
+		return -1
+	}
+}
+
+// DetectFormat 根据样本行识别日志格式，返回匹配行数最多的格式名；无法识别时返回空字符串
+func DetectFormat(lines []string) string {
+	counts := make(map[string]int)
+	for _, line := range lines {
+		for _, f := range formats {
+			if _, ok := f.parse(line, time.Time{}); ok {
+				counts[f.name]++
+				break
+			}
+		}
+	}
+
+	best := ""
+	for _, f := range formats {
+		if counts[f.name] > counts[best] {
+			best = f.name
+		}
+	}
+	return best
+}
+
+// EntryScanner 逐条读取日志记录，用法与 bufio.Scanner 相同
+// 格式按文件识别，但同一文件中混杂的其他格式（启动脚本输出、JVM 警告等）也能逐行识别
+type EntryScanner struct {
+	reader  *bufio.Reader
+	base    time.Time
+	format  string
+	formats []format // 识别出的格式优先
+	sample  []string // 识别格式时预读的行
+	lineNo  int
+	pending *Entry
+	entry   *Entry
+	err     error
+	eof     bool
+}
+
+// NewEntryScanner 创建日志记录读取器，base 为只有时分秒的时间戳所使用的日期（通常为文件修改时间）
+func NewEntryScanner(r io.Reader, base time.Time) *EntryScanner {
+	s := &EntryScanner{reader: bufio.NewReader(r), base: base}
+	for len(s.sample) < detectSampleLines {
+		line, ok := s.readRaw()
+		if !ok {
+			break
+		}
+		s.sample = append(s.sample, line)
+	}
+
+	s.format = DetectFormat(s.sample)
+	for _, f := range formats {
+		if f.name == s.format {
+			s.formats = append([]format{f}, s.formats...)
+		} else {
+			s.formats = append(s.formats, f)
+		}
+	}
+	return s
+}
+
+// Format 返回识别出的文件格式
+func (s *EntryScanner) Format() string {
+	return s.format
+}
+
+// Scan 读取下一条记录，没有更多记录或出错时返回 false
+func (s *EntryScanner) Scan() bool {
+	for {
+		line, ok := s.readLine()
+		if !ok {
+			s.entry, s.pending = s.pending, nil
+			return s.entry != nil
+		}
+
+		entry, isNew := s.parseLine(line)
+		if !isNew && s.pending != nil {
+			s.pending.appendLine(s.lineNo, line)
+			continue
+		}
+		if isNew {
+			entry.Line, entry.EndLine = s.lineNo, s.lineNo
+		} else {
+			// 文件开头的续行单独成为一条记录
+			entry = Entry{Line: s.lineNo, EndLine: s.lineNo, Message: line}
+		}
+
+		previous := s.pending
+		s.pending = &entry
+		if previous != nil {
+			s.entry = previous
+			return true
+		}
+	}
+}
+
+// Entry 返回最近一次 Scan 读取的记录
+func (s *EntryScanner) Entry() *Entry {
+	return s.entry
+}
+
+// Err 返回读取过程中遇到的错误
+func (s *EntryScanner) Err() error {
+	return s.err
+}
+
+// parseLine 依次尝试各种格式，返回 false 表示该行是上一条记录的续行
+func (s *EntryScanner) parseLine(line string) (Entry, bool) {
+	if strings.TrimSpace(line) == "" {
+		return Entry{}, false
+	}
+	for _, f := range s.formats {
+		if entry, ok := f.parse(line, s.base); ok {
+			entry.Format = f.name
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// appendLine 将续行并入记录，超过长度上限的部分只更新行号
+func (e *Entry) appendLine(lineNo int, line string) {
+	e.EndLine = lineNo
+	if len(e.Message)+len(line) < maxEntryMessage {
+		e.Message += "\n" + line
+	}
+}
+
+// readLine 先返回预读的样本行，再从文件中读取
+func (s *EntryScanner) readLine() (string, bool) {
+	if len(s.sample) > 0 {
+		line := s.sample[0]
+		s.sample = s.sample[1:]
+		s.lineNo++
+		return line, true
+	}
+	line, ok := s.readRaw()
+	if ok {
+		s.lineNo++
+	}
+	return line, ok
+}
+
+// readRaw 读取一行并去掉换行符，不使用 bufio.Scanner 以支持超长的单行日志
+func (s *EntryScanner) readRaw() (string, bool) {
+	if s.eof {
+		return "", false
+	}
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		s.eof = true
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+		}
+		if line == "" {
+			return "", false
+		}
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
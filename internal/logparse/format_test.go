@@ -0,0 +1,125 @@
+package logparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineFormats(t *testing.T) {
+	tests := []struct {
+		line   string
+		format string
+		level  string
+		thread string
+		logger string
+		msg    string
+	}{
+		{
+			line:   "2025-09-23 19:47:01.123 ERROR 12345 --- [           main] o.s.boot.SpringApplication               : Application run failed",
+			format: "spring-boot", level: "ERROR", thread: "main", logger: "o.s.boot.SpringApplication", msg: "Application run failed",
+		},
+		{
+			line:   "2024-01-15 14:22:10.456 INFO  --- [main] com.example.Application : Started Application in 0.145 seconds",
+			format: "spring-boot", level: "INFO", thread: "main", logger: "com.example.Application", msg: "Started Application in 0.145 seconds",
+		},
+		{
+			line:   "19:47:01.123 [main] WARN  c.e.DemoApplication - slow start",
+			format: "logback", level: "WARN", thread: "main", logger: "c.e.DemoApplication", msg: "slow start",
+		},
+		{
+			line:   "2025-09-23 19:47:01,230 ERROR [main] o.a.c.Foo - boom",
+			format: "log4j2", level: "ERROR", thread: "main", logger: "o.a.c.Foo", msg: "boom",
+		},
+		{
+			line:   `{"@timestamp":"2025-09-23T19:47:01.123+08:00","level":"WARN","thread_name":"main","logger_name":"c.e.App","message":"json msg"}`,
+			format: "json", level: "WARN", thread: "main", logger: "c.e.App", msg: "json msg",
+		},
+		{
+			line:   "[hlog][1t][2025-09-23 19:46:53.778][INFO][main][c.h.o.s.Application][,,,,][,,] - No active profile set",
+			format: "bracketed", level: "INFO", thread: "main", logger: "c.h.o.s.Application", msg: "No active profile set",
+		},
+		{
+			line:   "[2025-09-23 19:46:50] configmap.sh: App Config File Exist, Override.",
+			format: "shell", logger: "configmap.sh", msg: "App Config File Exist, Override.",
+		},
+		{
+			line:   "2025-09-23 19:47:01,230 main WARN No Root logger was configured",
+			format: "log4j2-status", level: "WARN", thread: "main", msg: "No Root logger was configured",
+		},
+		{
+			line:   "Java HotSpot(TM) 64-Bit Server VM warning: UseCMSCompactAtFullCollection is deprecated",
+			format: "jvm", level: "WARN", logger: "jvm", msg: "UseCMSCompactAtFullCollection is deprecated",
+		},
+	}
+
+	for _, tt := range tests {
+		s := NewEntryScanner(strings.NewReader(tt.line), time.Date(2025, 9, 23, 0, 0, 0, 0, time.Local))
+		if !s.Scan() {
+			t.Fatalf("未读取到记录: %s", tt.line)
+		}
+		e := s.Entry()
+		if e.Format != tt.format || e.Level != tt.level || e.Thread != tt.thread || e.Logger != tt.logger || e.Message != tt.msg {
+			t.Errorf("解析结果错误: %s\n得到 %+v", tt.line, *e)
+		}
+		if tt.format != "jvm" && e.Time.IsZero() {
+			t.Errorf("时间戳未解析: %s", tt.line)
+		}
+	}
+}
+
+func TestEntryScannerContinuation(t *testing.T) {
+	log := `[2025-09-23 19:46:50] configmap.sh: pulling config
+[hlog][1t][2025-09-23 19:46:53.778][INFO][main][c.h.o.s.Application][,,,,][,,] - starting
+[hlog][1t][2025-09-23 19:46:58.000][ERROR][main][o.s.b.SpringApplication][,,,,][,,] - Application run failed
+java.lang.IllegalStateException: boom
+	at com.example.A.run(A.java:1)
+[hlog][1t][2025-09-23 19:46:59.000][INFO][main][c.h.o.s.Application][,,,,][,,] - shutdown
+`
+	s := NewEntryScanner(strings.NewReader(log), time.Time{})
+	if s.Format() != "bracketed" {
+		t.Errorf("期望识别为bracketed格式，实际为%q", s.Format())
+	}
+
+	var entries []Entry
+	for s.Scan() {
+		entries = append(entries, *s.Entry())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("期望4条记录，实际为%d", len(entries))
+	}
+
+	failed := entries[2]
+	if failed.Level != "ERROR" || failed.Line != 3 || failed.EndLine != 5 {
+		t.Errorf("错误记录解析错误: %+v", failed)
+	}
+	if !strings.Contains(failed.Message, "at com.example.A.run") {
+		t.Errorf("堆栈应并入错误记录: %q", failed.Message)
+	}
+	want := time.Date(2025, 9, 23, 19, 46, 58, 0, time.Local)
+	if !failed.Time.Equal(want) {
+		t.Errorf("时间戳错误: %v", failed.Time)
+	}
+}
+
+func TestLevelRank(t *testing.T) {
+	if LevelRank("warning") != LevelRank("WARN") || LevelRank("SEVERE") != LevelRank("ERROR") {
+		t.Error("级别名称未统一")
+	}
+	if LevelRank("") != -1 || LevelRank("ERROR") <= LevelRank("WARN") {
+		t.Error("级别顺序错误")
+	}
+}
+
+func TestSplitBracketsNested(t *testing.T) {
+	fields, rest, ok := splitBrackets("[2025-09-23 19:47:17.622][WARN][pool-9:[Center:default]thread-1][o.a.c.c.C.[.[.[/]][,,] - msg")
+	if !ok || rest != "msg" {
+		t.Fatalf("拆分失败: %v %q", fields, rest)
+	}
+	if len(fields) != 5 || fields[2] != "pool-9:[Center:default]thread-1" || fields[3] != "o.a.c.c.C.[.[.[/]" {
+		t.Errorf("字段拆分错误: %q", fields)
+	}
+}
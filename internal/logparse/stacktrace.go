@@ -0,0 +1,402 @@
+package logparse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxMessageLines = 10   // 异常头之后、第一帧之前允许的多行消息续行数
+	maxFrames       = 1024 // 单个异常最多保留的帧数，StackOverflowError 等场景下其余帧直接丢弃
+	maxContextLen   = 500  // 堆栈前一行日志的最大保留长度
+)
+
+var (
+	// 异常头：全限定类名，可选的冒号和消息
+	headerPattern = regexp.MustCompile(`^([a-zA-Z_$][\w$]*(?:\.[\w$]+)+)(?::\s?(.*))?$`)
+	// 顶层异常的类名需要有明确的异常后缀，避免把普通日志误判为堆栈
+	throwableSuffix = regexp.MustCompile(`(?:Exception|Error|Throwable)$`)
+	// 未捕获异常的默认输出: Exception in thread "main" java.lang.IllegalStateException: ...
+	uncaughtPattern = regexp.MustCompile(`^Exception in thread "([^"]*)" (.*)$`)
+	// 帧：at 后面是方法，括号中是源文件位置，logback 还会附加 jar 信息
+	framePattern = regexp.MustCompile(`^at\s+(\S+?)\(([^)]*)\)\s*(?:~?\[([^\]]*)\])?`)
+	// 省略的帧: "... 42 more" 或 logback 的 "... 42 common frames omitted"
+	elidedPattern = regexp.MustCompile(`^\.\.\.\s+(\d+)\s+(?:more|common frames omitted)`)
+)
+
+// Frame 堆栈中的一帧
+type Frame struct {
+	Class    string `json:"class"`
+	Method   string `json:"method"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Native   bool   `json:"native,omitempty"`
+	Module   string `json:"module,omitempty"`   // Java 9+ 的模块或类加载器名，如 java.base
+	Location string `json:"location,omitempty"` // logback 附加的 jar 信息，如 spring-beans-5.3.20.jar:5.3.20
+}
+
+// String 以堆栈中的格式输出帧，不含模块和 jar 信息
+func (f Frame) String() string {
+	var source string
+	switch {
+	case f.Native:
+		source = "Native Method"
+	case f.File == "":
+		source = "Unknown Source"
+	case f.Line > 0:
+		source = f.File + ":" + strconv.Itoa(f.Line)
+	default:
+		source = f.File
+	}
+	return f.Class + "." + f.Method + "(" + source + ")"
+}
+
+// frameworkPrefixes JDK 和常见框架的包前缀，用于定位业务代码所在的帧
+var frameworkPrefixes = []string{
+	"java.", "javax.", "jakarta.", "jdk.", "sun.", "com.sun.", "kotlin.", "scala.",
+	"org.springframework.", "org.apache.", "org.hibernate.", "org.mybatis.", "org.eclipse.",
+	"com.zaxxer.", "com.alibaba.", "com.mysql.", "org.postgresql.", "io.netty.", "io.undertow.",
+	"reactor.", "feign.", "net.sf.cglib.", "org.aspectj.", "ch.qos.logback.", "org.slf4j.",
+}
+
+// IsFramework 判断帧是否属于 JDK 或常见框架，而不是业务代码
+func (f Frame) IsFramework() bool {
+	for _, prefix := range frameworkPrefixes {
+		if strings.HasPrefix(f.Class, prefix) {
+			return true
+		}
+	}
+	// Spring 生成的代理类
+	return strings.Contains(f.Class, "$$")
+}
+
+// Exception 异常及其帧、原因链和被抑制的异常
+type Exception struct {
+	Class      string       `json:"class"`
+	Message    string       `json:"message,omitempty"`
+	Line       int          `json:"line"` // 异常头所在行号 (1-based)
+	Frames     []Frame      `json:"frames,omitempty"`
+	Elided     int          `json:"elided,omitempty"` // "... N more" 省略的、与外层异常相同的帧数
+	Cause      *Exception   `json:"cause,omitempty"`
+	Suppressed []*Exception `json:"suppressed,omitempty"`
+}
+
+// Chain 返回从自身开始、沿 Caused by 展开的异常链
+func (e *Exception) Chain() []*Exception {
+	var chain []*Exception
+	for cur := e; cur != nil; cur = cur.Cause {
+		chain = append(chain, cur)
+	}
+	return chain
+}
+
+// RootCause 返回异常链中最深层的原因
+func (e *Exception) RootCause() *Exception {
+	root := e
+	for root.Cause != nil {
+		root = root.Cause
+	}
+	return root
+}
+
+// AppFrame 从最深层的原因开始向外查找第一个业务代码帧
+func (e *Exception) AppFrame() (Frame, bool) {
+	chain := e.Chain()
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, f := range chain[i].Frames {
+			if !f.IsFramework() {
+				return f, true
+			}
+		}
+	}
+	return Frame{}, false
+}
+
+// Header 返回 "类名: 消息" 形式的异常头
+func (e *Exception) Header() string {
+	if e.Message == "" {
+		return e.Class
+	}
+	return e.Class + ": " + e.Message
+}
+
+// StackTrace 日志中的一段完整堆栈
+type StackTrace struct {
+	Exception *Exception `json:"exception"`
+	StartLine int        `json:"start_line"`
+	EndLine   int        `json:"end_line"`
+	Thread    string     `json:"thread,omitempty"`  // 未捕获异常所在线程
+	Context   string     `json:"context,omitempty"` // 堆栈前一行日志，通常是打印异常的日志消息
+}
+
+// Signature 返回用于合并重复堆栈的特征：异常链中的类名和最深层原因的消息及首帧
+func (t *StackTrace) Signature() string {
+	var b strings.Builder
+	for _, e := range t.Exception.Chain() {
+		b.WriteString(e.Class)
+		b.WriteString("<-")
+	}
+	root := t.Exception.RootCause()
+	b.WriteString(root.Message)
+	if len(root.Frames) > 0 {
+		b.WriteString("@")
+		b.WriteString(root.Frames[0].String())
+	}
+	return b.String()
+}
+
+// Parser 逐行识别日志中的 Java 堆栈
+type Parser struct {
+	traces  []*StackTrace
+	current *StackTrace
+	chain   []*Exception // 按缩进层级记录正在填充的异常，下标即层级
+	last    *Exception   // 最近一个出现异常头的异常
+	pending int          // last 已经追加的消息续行数
+	prev    string       // 上一行非空日志
+}
+
+// NewParser 创建堆栈解析器
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Add 输入一行日志，lineNo 为 1-based 行号
+func (p *Parser) Add(lineNo int, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if p.current != nil {
+		if p.continueTrace(lineNo, line) {
+			return
+		}
+		p.finishTrace()
+	}
+
+	p.startTrace(lineNo, line)
+	if strings.TrimSpace(line) != "" && p.current == nil {
+		p.prev = line
+	}
+}
+
+// Finish 结束输入并返回识别出的所有堆栈
+func (p *Parser) Finish() []*StackTrace {
+	if p.current != nil {
+		p.finishTrace()
+	}
+	return p.traces
+}
+
+// startTrace 判断一行是否为顶层异常头
+func (p *Parser) startTrace(lineNo int, line string) {
+	var thread string
+	header := line
+	if m := uncaughtPattern.FindStringSubmatch(line); m != nil {
+		thread, header = m[1], m[2]
+	}
+
+	e := parseHeader(header, lineNo)
+	if e == nil || (thread == "" && !throwableSuffix.MatchString(e.Class)) {
+		return
+	}
+
+	context := p.prev
+	if len(context) > maxContextLen {
+		context = context[:maxContextLen]
+	}
+	p.current = &StackTrace{Exception: e, StartLine: lineNo, EndLine: lineNo, Thread: thread, Context: context}
+	p.chain = []*Exception{e}
+	p.last = e
+	p.pending = 0
+}
+
+// continueTrace 尝试把一行并入当前堆栈，返回 false 表示堆栈已经结束
+func (p *Parser) continueTrace(lineNo int, line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	level := indentLevel(line)
+
+	if frame, ok := parseFrame(trimmed); ok {
+		e := p.chain[p.levelIndex(level-1)]
+		if len(e.Frames) < maxFrames {
+			e.Frames = append(e.Frames, frame)
+		}
+		p.current.EndLine = lineNo
+		return true
+	}
+
+	if m := elidedPattern.FindStringSubmatch(trimmed); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		p.chain[p.levelIndex(level-1)].Elided = n
+		p.current.EndLine = lineNo
+		return true
+	}
+
+	// Caused by: 与所属异常的异常头缩进相同，被抑制异常的原因与 Suppressed: 行对齐
+	if rest, ok := strings.CutPrefix(trimmed, "Caused by:"); ok {
+		if e := parseHeader(strings.TrimSpace(rest), lineNo); e != nil {
+			i := p.levelIndex(level)
+			p.chain[i].Cause = e
+			p.chain = append(p.chain[:i], e)
+			p.begin(e, lineNo)
+			return true
+		}
+	}
+
+	// Suppressed: 比所属异常多缩进一级
+	if rest, ok := strings.CutPrefix(trimmed, "Suppressed:"); ok {
+		if e := parseHeader(strings.TrimSpace(rest), lineNo); e != nil {
+			i := p.levelIndex(level - 1)
+			p.chain[i].Suppressed = append(p.chain[i].Suppressed, e)
+			p.chain = append(p.chain[:i+1], e)
+			p.begin(e, lineNo)
+			return true
+		}
+	}
+
+	// 第一帧之前的非空行视为多行异常消息，例如 SQL 错误详情
+	if p.last != nil && len(p.last.Frames) == 0 && p.last.Elided == 0 &&
+		p.pending < maxMessageLines && trimmed != "" && !p.looksLikeHeader(line, lineNo) {
+		p.last.Message += "\n" + line
+		p.pending++
+		p.current.EndLine = lineNo
+		return true
+	}
+
+	return false
+}
+
+// begin 记录新出现的异常头
+func (p *Parser) begin(e *Exception, lineNo int) {
+	p.last = e
+	p.pending = 0
+	p.current.EndLine = lineNo
+}
+
+// looksLikeHeader 判断一行是否为新的顶层异常头，用于终止多行消息
+func (p *Parser) looksLikeHeader(line string, lineNo int) bool {
+	if uncaughtPattern.MatchString(line) {
+		return true
+	}
+	e := parseHeader(line, lineNo)
+	return e != nil && throwableSuffix.MatchString(e.Class)
+}
+
+// levelIndex 将缩进层级换算为 chain 中的下标
+func (p *Parser) levelIndex(level int) int {
+	if level < 0 {
+		return 0
+	}
+	if level >= len(p.chain) {
+		return len(p.chain) - 1
+	}
+	return level
+}
+
+// finishTrace 结束当前堆栈，只保留至少有一帧的堆栈
+func (p *Parser) finishTrace() {
+	t := p.current
+	p.current, p.chain, p.last = nil, nil, nil
+	// 没有帧的异常头只是一行普通日志
+	if hasFrames(t.Exception) {
+		p.traces = append(p.traces, t)
+	}
+}
+
+// hasFrames 判断异常链中是否出现过帧或省略标记
+func hasFrames(e *Exception) bool {
+	for _, cur := range e.Chain() {
+		if len(cur.Frames) > 0 || cur.Elided > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHeader 解析 "类名: 消息" 形式的异常头
+func parseHeader(header string, lineNo int) *Exception {
+	m := headerPattern.FindStringSubmatch(header)
+	if m == nil {
+		return nil
+	}
+	return &Exception{Class: m[1], Message: strings.TrimSpace(m[2]), Line: lineNo}
+}
+
+// parseFrame 解析 "at ..." 形式的帧
+func parseFrame(line string) (Frame, bool) {
+	m := framePattern.FindStringSubmatch(line)
+	if m == nil {
+		return Frame{}, false
+	}
+
+	var frame Frame
+	method := m[1]
+	// Java 9+ 的帧可能带有 "模块@版本/" 或 "类加载器//" 前缀
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		frame.Module = strings.Trim(method[:i], "/")
+		method = method[i+1:]
+	}
+	i := strings.LastIndex(method, ".")
+	if i <= 0 {
+		return Frame{}, false
+	}
+	frame.Class, frame.Method = method[:i], method[i+1:]
+
+	switch source := m[2]; source {
+	case "Native Method":
+		frame.Native = true
+	case "Unknown Source", "":
+	default:
+		file, lineStr, found := strings.Cut(source, ":")
+		frame.File = file
+		if found {
+			frame.Line, _ = strconv.Atoi(lineStr)
+		}
+	}
+	frame.Location = m[3]
+	return frame, true
+}
+
+// indentLevel 计算行首缩进层级，一个制表符或最多四个空格算一级
+func indentLevel(line string) int {
+	tabs, spaces := 0, 0
+	for _, c := range line {
+		switch c {
+		case '\t':
+			tabs++
+		case ' ':
+			spaces++
+		default:
+			return tabs + (spaces+3)/4
+		}
+	}
+	return tabs + (spaces+3)/4
+}
+
+// Parse 读取日志并返回其中所有的堆栈
+func Parse(r io.Reader) ([]*StackTrace, error) {
+	p := NewParser()
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		// 不使用 bufio.Scanner，超长的单行日志不会导致读取失败
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lineNo++
+			p.Add(lineNo, line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return p.Finish(), err
+		}
+	}
+	return p.Finish(), nil
+}
+
+// ParseString 解析字符串中的堆栈
+func ParseString(s string) []*StackTrace {
+	traces, _ := Parse(strings.NewReader(s))
+	return traces
+}
@@ -0,0 +1,161 @@
+package logparse
+
+import (
+	"testing"
+)
+
+const springFailureLog = `2025-09-23 19:47:01.123 ERROR 12345 --- [           main] o.s.boot.SpringApplication               : Application run failed
+
+org.springframework.beans.factory.BeanCreationException: Error creating bean with name 'dataSource': Invocation of init method failed
+	at org.springframework.beans.factory.support.AbstractAutowireCapableBeanFactory.initializeBean(AbstractAutowireCapableBeanFactory.java:1786) ~[spring-beans-5.3.20.jar:5.3.20]
+	at org.springframework.boot.SpringApplication.run(SpringApplication.java:1306) [spring-boot-2.7.0.jar:2.7.0]
+	at com.example.demo.DemoApplication.main(DemoApplication.java:10) [classes/:na]
+Caused by: com.zaxxer.hikari.pool.HikariPool$PoolInitializationException: Failed to initialize pool: Communications link failure
+	at com.zaxxer.hikari.pool.HikariPool.throwPoolInitializationException(HikariPool.java:596) ~[HikariCP-4.0.3.jar:na]
+	... 2 common frames omitted
+Caused by: java.net.ConnectException: Connection refused
+	at java.base/sun.nio.ch.Net.connect0(Native Method)
+	at java.base/sun.nio.ch.Net.connect(Net.java:579)
+	... 5 more
+	Suppressed: java.io.IOException: close failed
+		at com.example.demo.Pool.close(Pool.java:42)
+	Caused by: java.lang.IllegalStateException: already closed
+		at com.example.demo.Pool.check(Pool.java:50)
+		... 3 more
+2025-09-23 19:47:01.130  INFO 12345 --- [           main] o.s.b.a.l.ConditionEvaluationReportLoggingListener : done
+`
+
+func TestParseCauseChain(t *testing.T) {
+	traces := ParseString(springFailureLog)
+	if len(traces) != 1 {
+		t.Fatalf("期望识别出1段堆栈，实际为%d", len(traces))
+	}
+
+	trace := traces[0]
+	if trace.StartLine != 3 || trace.EndLine != 18 {
+		t.Errorf("堆栈行号范围错误: %d-%d", trace.StartLine, trace.EndLine)
+	}
+	if trace.Context == "" {
+		t.Error("期望记录堆栈前的日志行")
+	}
+
+	chain := trace.Exception.Chain()
+	if len(chain) != 3 {
+		t.Fatalf("期望异常链长度为3，实际为%d", len(chain))
+	}
+	if len(chain[0].Frames) != 3 || chain[0].Frames[0].Location != "spring-beans-5.3.20.jar:5.3.20" {
+		t.Errorf("顶层异常的帧解析错误: %+v", chain[0].Frames)
+	}
+	if chain[1].Elided != 2 {
+		t.Errorf("期望省略2帧，实际为%d", chain[1].Elided)
+	}
+
+	root := trace.Exception.RootCause()
+	if root.Class != "java.net.ConnectException" || root.Message != "Connection refused" || root.Line != 10 {
+		t.Errorf("最深层原因错误: %+v", root)
+	}
+	if len(root.Frames) != 2 || !root.Frames[0].Native || root.Frames[0].Module != "java.base" {
+		t.Errorf("最深层原因的帧解析错误: %+v", root.Frames)
+	}
+	if root.Frames[1].File != "Net.java" || root.Frames[1].Line != 579 {
+		t.Errorf("帧的源文件位置解析错误: %+v", root.Frames[1])
+	}
+	if root.Elided != 5 {
+		t.Errorf("期望省略5帧，实际为%d", root.Elided)
+	}
+
+	if len(root.Suppressed) != 1 {
+		t.Fatalf("期望1个被抑制的异常，实际为%d", len(root.Suppressed))
+	}
+	suppressed := root.Suppressed[0]
+	if suppressed.Class != "java.io.IOException" || len(suppressed.Frames) != 1 {
+		t.Errorf("被抑制的异常解析错误: %+v", suppressed)
+	}
+	if suppressed.Cause == nil || suppressed.Cause.Class != "java.lang.IllegalStateException" || suppressed.Cause.Elided != 3 {
+		t.Errorf("被抑制异常的原因解析错误: %+v", suppressed.Cause)
+	}
+}
+
+func TestParseNestedSuppressed(t *testing.T) {
+	// Throwable.printStackTrace 的格式：被抑制的异常比所属异常多缩进一级，
+	// 它的 Caused by 与 Suppressed: 行缩进相同
+	log := `java.lang.IllegalStateException: shutdown failed
+	at com.example.App.stop(App.java:10)
+	Suppressed: java.io.IOException: close pool
+		at com.example.Pool.close(Pool.java:42)
+	Caused by: java.sql.SQLException: connection reset
+		at com.example.Pool.release(Pool.java:60)
+		... 1 more
+		Suppressed: java.net.SocketException: broken pipe
+			at com.example.Conn.flush(Conn.java:7)
+		Caused by: java.io.EOFException
+			at com.example.Conn.read(Conn.java:9)
+	Suppressed: java.io.IOException: close cache
+		at com.example.Cache.close(Cache.java:5)
+Caused by: java.lang.InterruptedException: sleep interrupted
+	at java.base/java.lang.Thread.sleep(Native Method)
+	... 1 more
+`
+	traces := ParseString(log)
+	if len(traces) != 1 {
+		t.Fatalf("期望识别出1段堆栈，实际为%d", len(traces))
+	}
+	top := traces[0].Exception
+	if len(top.Frames) != 1 || top.Cause == nil || top.Cause.Class != "java.lang.InterruptedException" || top.Cause.Elided != 1 {
+		t.Fatalf("顶层异常解析错误: %+v", top)
+	}
+	if len(top.Suppressed) != 2 || top.Suppressed[1].Message != "close cache" || len(top.Suppressed[1].Frames) != 1 {
+		t.Fatalf("顶层异常的被抑制异常解析错误: %+v", top.Suppressed)
+	}
+
+	pool := top.Suppressed[0]
+	if len(pool.Frames) != 1 || pool.Cause == nil || pool.Cause.Class != "java.sql.SQLException" {
+		t.Fatalf("被抑制异常的原因应挂在被抑制异常下: %+v", pool)
+	}
+	reset := pool.Cause
+	if len(reset.Frames) != 1 || reset.Elided != 1 || len(reset.Suppressed) != 1 {
+		t.Fatalf("原因的帧和被抑制异常解析错误: %+v", reset)
+	}
+	pipe := reset.Suppressed[0]
+	if pipe.Class != "java.net.SocketException" || len(pipe.Frames) != 1 || pipe.Cause == nil || pipe.Cause.Class != "java.io.EOFException" || len(pipe.Cause.Frames) != 1 {
+		t.Errorf("嵌套的被抑制异常解析错误: %+v %+v", pipe, pipe.Cause)
+	}
+}
+
+func TestParseUncaughtAndMultilineMessage(t *testing.T) {
+	log := `Exception in thread "main" java.sql.SQLException: Access denied
+for user 'app'@'10.0.0.1'
+	at com.example.Main.main(Main.java:5)
+java.lang.RuntimeException: logged without stack trace
+next log line
+`
+	traces := ParseString(log)
+	if len(traces) != 1 {
+		t.Fatalf("期望识别出1段堆栈，实际为%d", len(traces))
+	}
+	e := traces[0].Exception
+	if traces[0].Thread != "main" {
+		t.Errorf("期望线程为main，实际为%q", traces[0].Thread)
+	}
+	if e.Message != "Access denied\nfor user 'app'@'10.0.0.1'" {
+		t.Errorf("多行消息解析错误: %q", e.Message)
+	}
+	if len(e.Frames) != 1 || e.Frames[0].Class != "com.example.Main" || e.Frames[0].Method != "main" {
+		t.Errorf("帧解析错误: %+v", e.Frames)
+	}
+}
+
+func TestSignatureGroupsRepeatedTraces(t *testing.T) {
+	log := `java.lang.IllegalStateException: boom
+	at com.example.A.run(A.java:1)
+java.lang.IllegalStateException: boom
+	at com.example.A.run(A.java:1)
+`
+	traces := ParseString(log)
+	if len(traces) != 2 {
+		t.Fatalf("期望识别出2段堆栈，实际为%d", len(traces))
+	}
+	if traces[0].Signature() != traces[1].Signature() {
+		t.Errorf("相同的堆栈应有相同的特征: %q != %q", traces[0].Signature(), traces[1].Signature())
+	}
+}
@@ -0,0 +1,270 @@
+package logparse
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 启动阶段名称
+const (
+	PhaseScripts        = "scripts"         // JVM 启动前的脚本（拉取配置、解压等）
+	PhaseJVM            = "jvm"             // JVM 启动到第一条应用日志
+	PhaseContextRefresh = "context_refresh" // Spring Boot 启动到上下文刷新完成
+	PhaseDataSource     = "datasource"      // 数据源连接池初始化
+	PhaseWebContext     = "web_context"     // Web 应用上下文初始化
+	PhaseWebServer      = "web_server"      // 嵌入式服务器启动
+	PhaseStarted        = "started"         // Started ... in N seconds
+	PhaseFailed         = "failed"          // Application run failed
+	PhaseFirstRequest   = "first_request"   // 第一个请求触发 DispatcherServlet 初始化
+)
+
+const (
+	maxGaps = 3               // 时间线中保留的最长静默区间数
+	minGap  = 1 * time.Second // 小于该时长的静默不记录
+)
+
+// phaseMarker 阶段的开始或结束标志
+type phaseMarker struct {
+	phase   string
+	end     bool
+	pattern *regexp.Regexp
+}
+
+var phaseMarkers = []phaseMarker{
+	{PhaseContextRefresh, false, regexp.MustCompile(`Starting \S+ (?:v\S+ )?(?:using Java|on \S+ with PID)|Refreshing \S*ApplicationContext|The following (?:\d+ )?profiles? (?:is|are) active|No active profile set`)},
+	{PhaseDataSource, false, regexp.MustCompile(`HikariPool-\d+ - Starting|\{dataSource-\d+\} init(?:ing)?\b|Init DruidDataSource`)},
+	{PhaseDataSource, true, regexp.MustCompile(`HikariPool-\d+ - Start completed|\{dataSource-\d+\} inited`)},
+	{PhaseWebServer, false, regexp.MustCompile(`(?:Tomcat|Jetty|Undertow|Netty) initialized with port`)},
+	{PhaseWebServer, true, regexp.MustCompile(`(?:Tomcat|Jetty|Undertow|Netty) started on port|Undertow started|Jetty started`)},
+	{PhaseFirstRequest, false, regexp.MustCompile(`Initializing Spring DispatcherServlet|Initializing Servlet '[^']+'`)},
+	{PhaseFirstRequest, true, regexp.MustCompile(`Completed initialization in \d+ ms`)},
+}
+
+var (
+	// Started Application in 24.427 seconds (JVM running for 26.208)，Spring Boot 3 为 process running for
+	startedPattern    = regexp.MustCompile(`Started \S+ in ([\d.]+) seconds(?: \((?:JVM|process) running for ([\d.]+)\))?`)
+	failedPattern     = regexp.MustCompile(`Application run failed|APPLICATION FAILED TO START`)
+	webContextPattern = regexp.MustCompile(`Root WebApplicationContext: initialization completed in (\d+) ms`)
+)
+
+// appFormats 应用日志框架输出的格式，第一条这样的记录标志着 JVM 已经启动完成
+var appFormats = map[string]bool{
+	"spring-boot": true, "logback": true, "log4j2": true, "bracketed": true, "json": true,
+}
+
+// Phase 启动过程中的一个阶段
+type Phase struct {
+	Name      string    `json:"name"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"` // 只有一个时间点的阶段为零值
+	StartLine int       `json:"start_line"`
+	EndLine   int       `json:"end_line,omitempty"`
+	Message   string    `json:"message"` // 开始标志所在记录的消息首行
+}
+
+// Duration 返回阶段耗时，只有一个时间点的阶段为 0
+func (p Phase) Duration() time.Duration {
+	if p.End.IsZero() {
+		return 0
+	}
+	return p.End.Sub(p.Start)
+}
+
+// Gap 两条相邻日志之间的静默区间
+type Gap struct {
+	Start      time.Time     `json:"start"`
+	End        time.Time     `json:"end"`
+	Duration   time.Duration `json:"duration"`
+	BeforeLine int           `json:"before_line"`
+	AfterLine  int           `json:"after_line"`
+	Before     string        `json:"before"` // 静默前最后一条日志
+	After      string        `json:"after"`  // 静默后第一条日志
+}
+
+// Timeline 从日志中提取的启动时间线
+type Timeline struct {
+	Phases          []Phase       `json:"phases"`                     // 按开始时间排序
+	Gaps            []Gap         `json:"gaps"`                       // 最长的静默区间，按时长降序
+	Start           time.Time     `json:"start"`                      // 第一条带时间戳的日志
+	End             time.Time     `json:"end"`                        // 最后一条带时间戳的日志
+	ReportedStartup time.Duration `json:"reported_startup,omitempty"` // Spring Boot 报告的启动耗时
+	JVMUptime       time.Duration `json:"jvm_uptime,omitempty"`       // 启动完成时 JVM 已运行的时长
+}
+
+// timelineBuilder 逐条记录构建时间线
+type timelineBuilder struct {
+	timeline *Timeline
+	phases   map[string]*Phase
+	appSeen  bool   // 是否已出现应用日志
+	prev     *Entry // 上一条带时间戳的记录
+}
+
+// BuildTimeline 读取日志并提取启动阶段，base 为只有时分秒的时间戳所使用的日期
+func BuildTimeline(r io.Reader, base time.Time) (*Timeline, error) {
+	b := &timelineBuilder{timeline: &Timeline{}, phases: make(map[string]*Phase)}
+	scanner := NewEntryScanner(r, base)
+	for scanner.Scan() {
+		b.add(scanner.Entry())
+	}
+	return b.finish(), scanner.Err()
+}
+
+// add 处理一条记录
+func (b *timelineBuilder) add(e *Entry) {
+	if e.Time.IsZero() {
+		return
+	}
+	t := b.timeline
+	if t.Start.IsZero() {
+		t.Start = e.Time
+	}
+	t.End = e.Time
+	b.addGap(e)
+
+	message := firstLine(e.Message)
+	if !b.appSeen {
+		if appFormats[e.Format] {
+			b.appSeen = true
+			// 开始时间在出现启动完成日志后由 JVM 运行时长推算
+			b.phases[PhaseJVM] = &Phase{Name: PhaseJVM, Start: e.Time, End: e.Time, StartLine: e.Line, EndLine: e.Line, Message: message}
+		} else if e.Format == "shell" || e.Format == "timestamped" {
+			// JVM 启动前的脚本输出，阶段结束时间随每条输出延后
+			if p := b.mark(PhaseScripts, false, e, message); p != nil {
+				p.End, p.EndLine = e.Time, e.Line
+			}
+			return
+		}
+	}
+
+	for _, m := range phaseMarkers {
+		if m.pattern.MatchString(message) {
+			b.mark(m.phase, m.end, e, message)
+		}
+	}
+
+	if m := webContextPattern.FindStringSubmatch(message); m != nil {
+		if _, ok := b.phases[PhaseWebContext]; !ok {
+			ms, _ := strconv.Atoi(m[1])
+			b.phases[PhaseWebContext] = &Phase{
+				Name:      PhaseWebContext,
+				Start:     e.Time.Add(-time.Duration(ms) * time.Millisecond),
+				End:       e.Time,
+				StartLine: e.Line,
+				EndLine:   e.Line,
+				Message:   message,
+			}
+		}
+	}
+
+	if m := startedPattern.FindStringSubmatch(message); m != nil && t.ReportedStartup == 0 {
+		t.ReportedStartup = parseSeconds(m[1])
+		t.JVMUptime = parseSeconds(m[2])
+		b.mark(PhaseStarted, false, e, message)
+		b.finishPhase(PhaseContextRefresh, e)
+		// 由 JVM 运行时长推算 JVM 的启动时间
+		if jvm := b.phases[PhaseJVM]; jvm != nil && t.JVMUptime > 0 {
+			jvm.Start = e.Time.Add(-t.JVMUptime)
+		}
+	} else if failedPattern.MatchString(message) {
+		b.mark(PhaseFailed, false, e, message)
+		b.finishPhase(PhaseContextRefresh, e)
+	}
+}
+
+// mark 记录阶段的开始或结束，每个阶段只记录第一次出现的开始和结束标志
+// 没有开始标志的结束标志按时间点记录
+func (b *timelineBuilder) mark(name string, end bool, e *Entry, message string) *Phase {
+	p, ok := b.phases[name]
+	if !ok {
+		p = &Phase{Name: name, Start: e.Time, StartLine: e.Line, Message: message}
+		b.phases[name] = p
+		if end {
+			p.EndLine = e.Line
+		}
+		return p
+	}
+	if end && p.EndLine == 0 {
+		p.End, p.EndLine = e.Time, e.Line
+	}
+	return p
+}
+
+// finishPhase 记录已开始阶段的结束
+func (b *timelineBuilder) finishPhase(name string, e *Entry) {
+	if p, ok := b.phases[name]; ok && p.EndLine == 0 {
+		p.End, p.EndLine = e.Time, e.Line
+	}
+}
+
+// addGap 记录与上一条带时间戳记录之间的静默区间
+func (b *timelineBuilder) addGap(e *Entry) {
+	prev := b.prev
+	b.prev = e
+	if prev == nil {
+		return
+	}
+	d := e.Time.Sub(prev.Time)
+	if d < minGap {
+		return
+	}
+
+	t := b.timeline
+	t.Gaps = append(t.Gaps, Gap{
+		Start:      prev.Time,
+		End:        e.Time,
+		Duration:   d,
+		BeforeLine: prev.Line,
+		AfterLine:  e.Line,
+		Before:     firstLine(prev.Message),
+		After:      firstLine(e.Message),
+	})
+	sort.SliceStable(t.Gaps, func(i, j int) bool { return t.Gaps[i].Duration > t.Gaps[j].Duration })
+	if len(t.Gaps) > maxGaps {
+		t.Gaps = t.Gaps[:maxGaps]
+	}
+}
+
+// finish 整理阶段顺序
+func (b *timelineBuilder) finish() *Timeline {
+	t := b.timeline
+	t.Phases = []Phase{}
+	for _, p := range b.phases {
+		// JVM 阶段只在能推算出 JVM 启动时间时才有意义
+		if p.Name == PhaseJVM && t.JVMUptime == 0 {
+			continue
+		}
+		if p.Start.Before(t.Start) {
+			t.Start = p.Start
+		}
+		t.Phases = append(t.Phases, *p)
+	}
+	sort.SliceStable(t.Phases, func(i, j int) bool {
+		if t.Phases[i].Start.Equal(t.Phases[j].Start) {
+			return t.Phases[i].StartLine < t.Phases[j].StartLine
+		}
+		return t.Phases[i].Start.Before(t.Phases[j].Start)
+	})
+	if t.Gaps == nil {
+		t.Gaps = []Gap{}
+	}
+	return t
+}
+
+// parseSeconds 解析以秒为单位的小数
+func parseSeconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
+
+// firstLine 返回消息的第一行
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
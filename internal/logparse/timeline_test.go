@@ -0,0 +1,56 @@
+package logparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	log := `[2025-09-23 19:46:50] configmap.sh: pulling config
+[2025-09-23 19:46:52] configmap.sh: done
+2025-09-23 19:46:55.000  INFO 1 --- [           main] com.example.Application : Starting Application using Java 11.0.16 on host with PID 1
+2025-09-23 19:46:56.000  INFO 1 --- [           main] o.s.b.w.e.tomcat.TomcatWebServer : Tomcat initialized with port(s): 8080 (http)
+2025-09-23 19:46:57.000  INFO 1 --- [           main] w.s.c.ServletWebServerApplicationContext : Root WebApplicationContext: initialization completed in 1500 ms
+2025-09-23 19:46:58.000  INFO 1 --- [           main] com.zaxxer.hikari.HikariDataSource : HikariPool-1 - Starting...
+2025-09-23 19:47:58.000  INFO 1 --- [           main] com.zaxxer.hikari.HikariDataSource : HikariPool-1 - Start completed.
+2025-09-23 19:47:59.000  INFO 1 --- [           main] o.s.b.w.e.tomcat.TomcatWebServer : Tomcat started on port(s): 8080 (http)
+2025-09-23 19:48:00.000  INFO 1 --- [           main] com.example.Application : Started Application in 65.0 seconds (JVM running for 67.0)
+`
+	tl, err := BuildTimeline(strings.NewReader(log), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phases := make(map[string]Phase)
+	var order []string
+	for _, p := range tl.Phases {
+		phases[p.Name] = p
+		order = append(order, p.Name)
+	}
+	want := []string{PhaseScripts, PhaseJVM, PhaseContextRefresh, PhaseWebContext, PhaseWebServer, PhaseDataSource, PhaseStarted}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Fatalf("阶段顺序错误: %v", order)
+	}
+
+	checks := map[string]time.Duration{
+		PhaseScripts:        2 * time.Second,
+		PhaseJVM:            2 * time.Second, // 由 JVM running for 67s 推算 JVM 在 19:46:53 启动
+		PhaseContextRefresh: 65 * time.Second,
+		PhaseDataSource:     60 * time.Second,
+		PhaseWebServer:      63 * time.Second,
+		PhaseWebContext:     1500 * time.Millisecond,
+	}
+	for name, d := range checks {
+		if got := phases[name].Duration(); got != d {
+			t.Errorf("%s 耗时错误: 期望 %s, 实际 %s", name, d, got)
+		}
+	}
+
+	if tl.ReportedStartup != 65*time.Second || tl.JVMUptime != 67*time.Second {
+		t.Errorf("启动耗时解析错误: %s %s", tl.ReportedStartup, tl.JVMUptime)
+	}
+	if len(tl.Gaps) == 0 || tl.Gaps[0].Duration != time.Minute || tl.Gaps[0].BeforeLine != 6 || tl.Gaps[0].AfterLine != 7 {
+		t.Errorf("最长静默区间错误: %+v", tl.Gaps)
+	}
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/tools"
+)
+
+// SchemaVersion 结构化报告的格式版本，字段含义变化时递增
+const SchemaVersion = "1"
+
+// 根因分类，报告中只会出现以下取值
+const (
+	CategoryNone               = "none"                // 没有发现问题
+	CategoryPortConflict       = "port_conflict"       // 端口被占用
+	CategoryOutOfMemory        = "out_of_memory"       // 堆、元空间或本地内存不足
+	CategoryClassNotFound      = "class_not_found"     // ClassNotFoundException / NoClassDefFoundError
+	CategoryDependencyConflict = "dependency_conflict" // NoSuchMethodError 等依赖版本冲突
+	CategoryConfiguration      = "configuration_error" // 配置缺失、绑定失败、占位符无法解析
+	CategoryDatabase           = "database_connection" // 数据源、连接池初始化失败
+	CategoryExternalService    = "external_service"    // 注册中心、配置中心、Redis 等外部依赖不可用
+	CategoryBeanCreation       = "bean_creation"       // 业务 Bean 初始化失败
+	CategoryHang               = "startup_hang"        // 启动卡死或超时
+	CategoryDeadlock           = "deadlock"            // 线程死锁
+	CategoryJVMCrash           = "jvm_crash"           // JVM 崩溃、被信号终止
+	CategoryPermission         = "permission"          // 文件或端口权限不足
+	CategoryUnknown            = "unknown"             // 无法归类
+)
+
+// Categories 所有合法的根因分类
+var Categories = []string{
+	CategoryNone, CategoryPortConflict, CategoryOutOfMemory, CategoryClassNotFound,
+	CategoryDependencyConflict, CategoryConfiguration, CategoryDatabase, CategoryExternalService,
+	CategoryBeanCreation, CategoryHang, CategoryDeadlock, CategoryJVMCrash, CategoryPermission,
+	CategoryUnknown,
+}
+
+// Report 结构化的分析报告
+type Report struct {
+	SchemaVersion      string               `json:"schema_version"`
+	GeneratedAt        time.Time            `json:"generated_at"`
+	LogPaths           []string             `json:"log_paths"`
+	Verdict            string               `json:"verdict"` // started / degraded / failed / unknown
+	RootCause          RootCause            `json:"root_cause"`
+	Evidence           []tools.SearchResult `json:"evidence"`
+	RecommendedActions []string             `json:"recommended_actions"`
+	Analysis           string               `json:"analysis"` // 代理输出的完整诊断文本
+}
+
+// RootCause 根因
+type RootCause struct {
+	Category string `json:"category"`
+	Summary  string `json:"summary"`
+}
+
+// IsCategory 判断是否为合法的根因分类
+func IsCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Write 按指定格式输出报告，支持 json 和 sarif
+func Write(w io.Writer, format string, r *Report) error {
+	switch format {
+	case "json":
+		return writeJSON(w, r)
+	case "sarif":
+		return writeJSON(w, toSARIF(r))
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// writeJSON 输出缩进的JSON
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/java-startup-analyzer/internal/tools"
+)
+
+var update = flag.Bool("update", false, "用当前输出更新 testdata 中的 golden 文件")
+
+// testReports 覆盖有根因和没有发现问题两种报告
+var testReports = map[string]*Report{
+	"failed": {
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Date(2025, 9, 23, 19, 47, 3, 0, time.FixedZone("CST", 8*3600)),
+		LogPaths:      []string{"/var/log/order service/app.log"},
+		Verdict:       "failed",
+		RootCause: RootCause{
+			Category: CategoryPortConflict,
+			Summary:  "端口 8080 已被另一个进程占用，内嵌 Tomcat 无法启动",
+		},
+		Evidence: []tools.SearchResult{
+			{
+				FilePath:   "/var/log/order service/app.log",
+				LineNumber: 42,
+				Content:    "Caused by: java.net.BindException: Address already in use",
+				Before:     []string{"org.springframework.context.ApplicationContextException: Failed to start bean 'webServerStartStop'"},
+			},
+			{FilePath: "logs/app.log", LineNumber: 57, Content: "Web server failed to start. Port 8080 was already in use."},
+		},
+		RecommendedActions: []string{"停止占用 8080 端口的进程", "或通过 server.port 修改应用端口"},
+		Analysis:           "## 根因\n端口冲突。\n\nVERDICT: FAILED",
+	},
+	"started": {
+		SchemaVersion:      SchemaVersion,
+		GeneratedAt:        time.Date(2025, 9, 23, 11, 47, 3, 0, time.UTC),
+		LogPaths:           []string{"/var/log/app.log"},
+		Verdict:            "started",
+		RootCause:          RootCause{Category: CategoryNone, Summary: "应用正常启动"},
+		Evidence:           []tools.SearchResult{},
+		RecommendedActions: []string{},
+		Analysis:           "应用已正常启动。\n\nVERDICT: STARTED",
+	},
+}
+
+func TestWriteGolden(t *testing.T) {
+	for name, r := range testReports {
+		for _, format := range []string{"json", "sarif"} {
+			t.Run(name+"."+format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, format, r); err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", name+"."+format)
+				if *update {
+					if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("输出与 %s 不一致，确认改动后使用 -update 更新:\n%s", golden, buf.String())
+				}
+			})
+		}
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", testReports["failed"]); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
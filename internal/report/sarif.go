@@ -0,0 +1,126 @@
+package report
+
+import (
+	"net/url"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 中报告需要用到的子集
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int          `json:"startLine"`
+	Snippet   sarifMessage `json:"snippet"`
+}
+
+// toSARIF 将报告转换为 SARIF，根因作为一条结果，证据作为其位置
+func toSARIF(r *Report) *sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:  "java-startup-analyzer",
+			Rules: []sarifRule{},
+		}},
+		Results: []sarifResult{},
+		Properties: map[string]any{
+			"schemaVersion":      r.SchemaVersion,
+			"verdict":            r.Verdict,
+			"recommendedActions": r.RecommendedActions,
+		},
+	}
+
+	if r.RootCause.Category != CategoryNone {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               r.RootCause.Category,
+			ShortDescription: sarifMessage{Text: r.RootCause.Category},
+		})
+
+		result := sarifResult{
+			RuleID:  r.RootCause.Category,
+			Level:   sarifLevel(r.Verdict),
+			Message: sarifMessage{Text: r.RootCause.Summary},
+		}
+		for _, e := range r.Evidence {
+			result.Locations = append(result.Locations, sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: fileURI(e.FilePath)},
+					Region: sarifRegion{
+						StartLine: e.LineNumber,
+						Snippet:   sarifMessage{Text: e.Content},
+					},
+				},
+			})
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// sarifLevel 启动失败为 error，其余为 warning
+func sarifLevel(verdict string) string {
+	if verdict == "failed" {
+		return "error"
+	}
+	return "warning"
+}
+
+// fileURI 将绝对路径转换为 file:// URI
+func fileURI(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
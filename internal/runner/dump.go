@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	threadDumpCount   = 3 // 卡死时采集的线程转储次数
+	threadDumpTimeout = 30 * time.Second
+)
+
+// threadDumpInterval 两次采集之间的间隔，便于对比线程是否有进展；测试中会缩短
+var threadDumpInterval = 2 * time.Second
+
+// captureThreadDumps 依次尝试 jcmd、jstack、kill -3 采集线程转储，返回包含转储的文件路径
+func captureThreadDumps(ctx context.Context, rootPID int, prefix, stdoutPath string) []string {
+	pid, isJava := findJavaPID(rootPID)
+
+	var paths []string
+	quitSent := false
+	for i := 1; i <= threadDumpCount; i++ {
+		if i > 1 {
+			select {
+			case <-ctx.Done():
+				return paths
+			case <-time.After(threadDumpInterval):
+			}
+		}
+
+		path := fmt.Sprintf("%s.threaddump-%d.txt", prefix, i)
+		if err := dumpThreads(ctx, pid, path); err == nil {
+			paths = append(paths, path)
+			continue
+		}
+		// kill -3 的输出写入进程自身的标准输出，由捕获文件保留
+		// 只向确认是 JVM 的进程发送，SIGQUIT 会直接结束 shell 等其他进程
+		if !isJava || sendQuit(pid) != nil {
+			break
+		}
+		if !quitSent {
+			quitSent = true
+			paths = append(paths, stdoutPath)
+		}
+	}
+
+	if quitSent {
+		// 等待 JVM 把转储写完
+		time.Sleep(pollInterval)
+	}
+	return paths
+}
+
+// dumpThreads 使用 jcmd 或 jstack 将线程转储写入文件
+func dumpThreads(ctx context.Context, pid int, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, threadDumpTimeout)
+	defer cancel()
+
+	pidStr := strconv.Itoa(pid)
+	attempts := [][]string{
+		{jdkTool("jcmd"), pidStr, "Thread.print", "-l"},
+		{jdkTool("jstack"), "-l", pidStr},
+	}
+
+	var lastErr error
+	for _, args := range attempts {
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !bytes.Contains(out, []byte("java.lang.Thread.State")) && !bytes.Contains(out, []byte("Full thread dump")) {
+			lastErr = fmt.Errorf("%s 输出中没有线程信息", filepath.Base(args[0]))
+			continue
+		}
+		return os.WriteFile(path, out, 0644)
+	}
+	return lastErr
+}
+
+// jdkTool 优先使用 JAVA_HOME 下的 JDK 工具
+func jdkTool(name string) string {
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		path := filepath.Join(javaHome, "bin", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+// findJavaPID 在启动命令的进程组中查找 java 进程，找不到时返回进程组组长
+func findJavaPID(rootPID int) (pid int, isJava bool) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return rootPID, false
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		comm, pgrp, ok := parseProcStat(string(data))
+		if ok && pgrp == rootPID && comm == "java" {
+			return pid, true
+		}
+	}
+	return rootPID, false
+}
+
+// parseProcStat 解析 /proc/<pid>/stat 中的进程名和进程组ID
+func parseProcStat(stat string) (comm string, pgrp int, ok bool) {
+	// 进程名可能包含空格和括号，以最后一个右括号为界
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", 0, false
+	}
+	comm = stat[open+1 : end]
+
+	// 右括号之后依次为 state ppid pgrp ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 3 {
+		return "", 0, false
+	}
+	pgrp, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return comm, pgrp, true
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCaptureThreadDumps(t *testing.T) {
+	const threadDump = `"main" #1 prio=5 os_prio=0 tid=0x1 nid=0x2 waiting on condition
+   java.lang.Thread.State: TIMED_WAITING (sleeping)`
+	const fail = "exit 1"
+
+	tests := []struct {
+		name   string
+		jcmd   string
+		jstack string
+		java   bool   // 目标进程是否为 JVM
+		calls  string // 每次采集依次调用的工具
+		dumps  int    // 采集的次数
+		dump   string // 采集到的转储内容，kill -3 时为标准输出
+	}{
+		{"jcmd", "echo '" + threadDump + "'", fail, false, "jcmd", threadDumpCount, threadDump},
+		{"jstack after failed jcmd", fail, "echo 'Full thread dump OpenJDK 64-Bit Server VM'", false, "jcmd jstack", threadDumpCount, "Full thread dump OpenJDK 64-Bit Server VM"},
+		{"jstack after jcmd without threads", "echo 'com.sun.tools.attach.AttachNotSupportedException'", "echo '" + threadDump + "'", false, "jcmd jstack", threadDumpCount, threadDump},
+		{"kill -3", fail, fail, true, "jcmd jstack", threadDumpCount, "Full thread dump"},
+		// SIGQUIT 只发送给 JVM，其他进程收到会直接结束，因此第一次失败后就放弃
+		{"no jvm", fail, fail, false, "jcmd jstack", 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			calls := filepath.Join(dir, "calls")
+			javaHome := filepath.Join(dir, "jdk")
+			writeScript(t, filepath.Join(javaHome, "bin", "jcmd"), "echo jcmd >> "+calls+"\n"+tt.jcmd)
+			writeScript(t, filepath.Join(javaHome, "bin", "jstack"), "echo jstack >> "+calls+"\n"+tt.jstack)
+			t.Setenv("JAVA_HOME", javaHome)
+			shortenDumpInterval(t)
+
+			program := "/bin/sleep"
+			if tt.java {
+				// 进程名为 java 的脚本，收到 SIGQUIT 时像 JVM 一样把转储打印到标准输出
+				program = filepath.Join(dir, "java")
+				writeScript(t, program, "trap 'echo \"Full thread dump\"' QUIT\necho started\nwhile :; do /bin/sleep 0.05; done")
+			}
+			stdoutPath := filepath.Join(dir, "stdout.log")
+			cmd := startGroup(t, stdoutPath, program, "30")
+			if tt.java {
+				// 等待脚本设置好 SIGQUIT 的处理
+				waitForOutput(t, stdoutPath)
+			}
+
+			prefix := filepath.Join(dir, capturePrefix+"test")
+			paths := captureThreadDumps(context.Background(), cmd.Process.Pid, prefix, stdoutPath)
+
+			wantCalls := strings.Repeat(strings.ReplaceAll(tt.calls, " ", "\n")+"\n", tt.dumps)
+			if got, _ := os.ReadFile(calls); string(got) != wantCalls {
+				t.Errorf("调用顺序错误:\n%s\n期望:\n%s", got, wantCalls)
+			}
+			switch {
+			case tt.dump == "":
+				if len(paths) != 0 {
+					t.Errorf("不应采集到转储: %v", paths)
+				}
+				if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+					t.Errorf("非 JVM 进程不应收到 SIGQUIT: %v", err)
+				}
+			case tt.java:
+				if len(paths) != 1 || paths[0] != stdoutPath {
+					t.Fatalf("kill -3 的转储应在标准输出捕获文件中: %v", paths)
+				}
+				if data, _ := os.ReadFile(stdoutPath); !strings.Contains(string(data), tt.dump) {
+					t.Errorf("标准输出中没有转储: %q", data)
+				}
+			default:
+				if len(paths) != threadDumpCount {
+					t.Fatalf("应采集 %d 次转储: %v", threadDumpCount, paths)
+				}
+				for _, path := range paths {
+					if data, _ := os.ReadFile(path); strings.TrimSpace(string(data)) != tt.dump {
+						t.Errorf("%s 内容错误: %q", path, data)
+					}
+				}
+			}
+		})
+	}
+}
+
+// startGroup 在独立的进程组中启动程序，测试结束时终止整个进程组
+func startGroup(t *testing.T, stdoutPath, program string, args ...string) *exec.Cmd {
+	t.Helper()
+	stdout, err := os.Create(stdoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	cmd := exec.Command(program, args...)
+	cmd.Stdout = stdout
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		killProcessGroup(cmd.Process)
+		cmd.Wait()
+	})
+	return cmd
+}
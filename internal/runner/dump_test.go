@@ -0,0 +1,27 @@
+package runner
+
+import "testing"
+
+func TestParseProcStat(t *testing.T) {
+	tests := []struct {
+		stat string
+		comm string
+		pgrp int
+		ok   bool
+	}{
+		{"4242 (java) S 4240 4240 4240 0 -1 4194304 12345 0", "java", 4240, true},
+		// 进程名中的空格和括号
+		{"77 (my app (v2)) R 1 77 77 0", "my app (v2)", 77, true},
+		{"12 () S 1 12 12", "", 12, true},
+		{"4242 (java S 4240 4240", "", 0, false},
+		{"4242 (java) S 4240", "", 0, false},
+		{"4242 (java) S 4240 abc 4240", "", 0, false},
+		{"", "", 0, false},
+	}
+	for _, tt := range tests {
+		comm, pgrp, ok := parseProcStat(tt.stat)
+		if comm != tt.comm || pgrp != tt.pgrp || ok != tt.ok {
+			t.Errorf("parseProcStat(%q) = %q, %d, %v, want %q, %d, %v", tt.stat, comm, pgrp, ok, tt.comm, tt.pgrp, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	shellName = "sh"
+	shellFlag = "-c"
+)
+
+// setProcessGroup 让子进程成为新进程组的组长，便于整体终止
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcessGroup 向整个进程组发送 SIGTERM
+func terminateProcessGroup(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+	return syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+// killProcessGroup 向整个进程组发送 SIGKILL
+func killProcessGroup(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// sendQuit 发送 SIGQUIT，让 JVM 将线程转储打印到标准输出
+func sendQuit(pid int) error {
+	return syscall.Kill(pid, syscall.SIGQUIT)
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+const (
+	shellName = "cmd"
+	shellFlag = "/C"
+)
+
+// setProcessGroup Windows 下没有进程组，保持默认行为
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup 直接结束子进程
+func terminateProcessGroup(p *os.Process) error {
+	if p == nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+// killProcessGroup 直接结束子进程
+func killProcessGroup(p *os.Process) error {
+	return terminateProcessGroup(p)
+}
+
+// sendQuit Windows 不支持 SIGQUIT
+func sendQuit(pid int) error {
+	return fmt.Errorf("当前平台不支持发送 SIGQUIT")
+}
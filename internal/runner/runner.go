@@ -0,0 +1,277 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// State 进程在 Run 返回时所处的状态
+type State string
+
+const (
+	StateExited State = "exited" // 进程已结束
+	StateReady  State = "ready"  // 检测到启动完成标志，进程继续运行
+	StateHung   State = "hung"   // 超时未完成启动，已采集线程转储，进程继续运行
+)
+
+// pollInterval 监视日志和输出捕获文件的间隔
+const pollInterval = 500 * time.Millisecond
+
+// capturePrefix 捕获文件和线程转储文件名的前缀
+const capturePrefix = "java-analyzer-run_"
+
+// Options 启动选项
+type Options struct {
+	Command        string        // 启动命令，通过系统shell执行
+	Dir            string        // 工作目录 (可选，默认为当前目录)
+	OutputDir      string        // stdout/stderr 捕获文件所在目录
+	Echo           io.Writer     // 同时回显进程输出 (可选)
+	LogPaths       []string      // 需要实时监视的应用日志路径或glob模式 (可选)，运行期间持续展开以发现新生成的日志
+	StartupTimeout time.Duration // 启动完成的最长等待时间，0 表示不限制
+	HangTimeout    time.Duration // 日志和输出无任何进展的最长时间，0 表示不检测
+}
+
+// Result 一次启动的运行结果
+type Result struct {
+	Command     string        `json:"command"`
+	PID         int           `json:"pid"`
+	State       State         `json:"state"`
+	StartTime   time.Time     `json:"start_time"`
+	EndTime     time.Time     `json:"end_time"`
+	Duration    time.Duration `json:"duration"`
+	Exited      bool          `json:"exited"`           // 进程是否已经结束
+	ExitCode    int           `json:"exit_code"`        // 退出码，被信号终止时为 -1
+	Signal      string        `json:"signal,omitempty"` // 终止进程的信号
+	StdoutPath  string        `json:"stdout_path"`
+	StderrPath  string        `json:"stderr_path"`
+	Milestones  []Milestone   `json:"milestones,omitempty"`   // 启动过程中观察到的关键标志
+	HangReason  string        `json:"hang_reason,omitempty"`  // 判定为卡死的原因
+	ThreadDumps []string      `json:"thread_dumps,omitempty"` // 卡死时采集的线程转储文件
+}
+
+// Run 在独立的进程组中启动命令，并实时监视日志直到以下任一情况发生：
+// 进程结束、检测到启动完成标志、或超过卡死判定时间。
+// 后两种情况下进程会继续运行；ctx 被取消时会终止整个进程组。
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if strings.TrimSpace(opts.Command) == "" {
+		return nil, fmt.Errorf("启动命令不能为空")
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	prefix := filepath.Join(outputDir, capturePrefix+timestamp)
+	result := &Result{
+		Command:    opts.Command,
+		StdoutPath: prefix + ".stdout.log",
+		StderrPath: prefix + ".stderr.log",
+	}
+
+	stdoutFile, err := os.Create(result.StdoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建stdout捕获文件失败: %w", err)
+	}
+	stderrFile, err := os.Create(result.StderrPath)
+	if err != nil {
+		stdoutFile.Close()
+		return nil, fmt.Errorf("创建stderr捕获文件失败: %w", err)
+	}
+
+	// 子进程直接写入捕获文件，分析器退出后仍在运行的进程不会因管道断开而受影响
+	cmd := exec.Command(shellName, shellFlag, opts.Command)
+	cmd.Dir = opts.Dir
+	cmd.Stdout = stdoutFile
+	cmd.Stderr = stderrFile
+	setProcessGroup(cmd)
+
+	// 监视器在进程启动前记录已有日志的大小，进程一启动就写入的内容不会被当作旧内容跳过
+	w := newWatcher(opts.Echo, result.StdoutPath, result.StderrPath, opts.LogPaths)
+	result.StartTime = time.Now()
+	if err := cmd.Start(); err != nil {
+		stdoutFile.Close()
+		stderrFile.Close()
+		return nil, fmt.Errorf("启动进程失败: %w", err)
+	}
+	result.PID = cmd.Process.Pid
+
+	var waitErr error
+	done := make(chan struct{})
+	go func() {
+		waitErr = cmd.Wait()
+		stdoutFile.Close()
+		stderrFile.Close()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastProgress := time.Now()
+
+	for {
+		select {
+		case <-done:
+			w.poll()
+			return result.finish(w, cmd.ProcessState, waitErr)
+
+		case <-ctx.Done():
+			stopProcessGroup(cmd.Process, done)
+			w.poll()
+			return result.finish(w, cmd.ProcessState, waitErr)
+
+		case <-ticker.C:
+			if w.poll() {
+				lastProgress = time.Now()
+			}
+			if w.ready() {
+				result.State = StateReady
+				result.snapshot(w)
+				return result, nil
+			}
+
+			var reason string
+			switch {
+			case opts.HangTimeout > 0 && time.Since(lastProgress) > opts.HangTimeout:
+				reason = fmt.Sprintf("日志和进程输出已超过 %s 没有任何进展", opts.HangTimeout)
+			case opts.StartupTimeout > 0 && time.Since(result.StartTime) > opts.StartupTimeout:
+				reason = fmt.Sprintf("超过 %s 仍未检测到启动完成标志", opts.StartupTimeout)
+			}
+			if reason != "" {
+				result.State = StateHung
+				result.HangReason = reason
+				result.ThreadDumps = captureThreadDumps(ctx, result.PID, prefix, result.StdoutPath)
+				w.poll()
+				result.snapshot(w)
+				return result, nil
+			}
+		}
+	}
+}
+
+// IsCaptureFile 判断文件是否为 Run 生成的输出捕获或线程转储文件，日志路径的glob模式不应匹配这些文件
+func IsCaptureFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), capturePrefix)
+}
+
+// stopProcessGroup 终止进程组，超时后强制结束
+func stopProcessGroup(p *os.Process, done <-chan struct{}) {
+	terminateProcessGroup(p)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		killProcessGroup(p)
+		<-done
+	}
+}
+
+// snapshot 记录当前时刻的运行时长和启动标志
+func (r *Result) snapshot(w *watcher) {
+	r.EndTime = time.Now()
+	r.Duration = r.EndTime.Sub(r.StartTime)
+	r.Milestones = w.milestones
+}
+
+// finish 进程结束后记录最终状态
+func (r *Result) finish(w *watcher, state *os.ProcessState, waitErr error) (*Result, error) {
+	r.State = StateExited
+	r.Exited = true
+	r.snapshot(w)
+	if err := r.recordExit(state, waitErr); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// recordExit 从进程状态中提取退出码或终止信号
+func (r *Result) recordExit(state *os.ProcessState, waitErr error) error {
+	if state == nil {
+		return fmt.Errorf("等待进程结束失败: %w", waitErr)
+	}
+
+	r.ExitCode = state.ExitCode()
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		r.ExitCode = -1
+		r.Signal = status.Signal().String()
+	}
+
+	// 非零退出码是正常的分析对象，只有 I/O 等异常需要上报
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		return fmt.Errorf("等待进程结束失败: %w", waitErr)
+	}
+	return nil
+}
+
+// Status 返回人类可读的进程状态
+func (r *Result) Status() string {
+	switch {
+	case r.State == StateReady:
+		return "启动完成，仍在运行"
+	case r.State == StateHung:
+		return "启动卡死，仍在运行"
+	case !r.Exited:
+		return "仍在运行"
+	case r.Signal != "":
+		return fmt.Sprintf("被信号终止 (%s)", r.Signal)
+	default:
+		return fmt.Sprintf("退出码 %d", r.ExitCode)
+	}
+}
+
+// crashed 进程是否可能因JVM致命错误而终止。JVM 写完 hs_err 文件后调用 abort()，
+// 经由 shell 启动时表现为退出码 134 (128+SIGABRT)
+func (r *Result) crashed() bool {
+	if !r.Exited {
+		return false
+	}
+	return r.Signal == syscall.SIGABRT.String() || r.Signal == syscall.SIGSEGV.String() || r.ExitCode == 134 || r.ExitCode == 139
+}
+
+// Describe 生成供分析代理使用的运行结果描述
+func (r *Result) Describe() string {
+	var b strings.Builder
+	b.WriteString("该应用由分析器通过启动命令直接运行，运行结果如下：\n")
+	b.WriteString(fmt.Sprintf("- 启动命令: %s\n", r.Command))
+	b.WriteString(fmt.Sprintf("- 进程PID: %d\n", r.PID))
+	b.WriteString(fmt.Sprintf("- 启动时间: %s\n", r.StartTime.Format("2006-01-02 15:04:05")))
+	b.WriteString(fmt.Sprintf("- 运行时长: %s\n", r.Duration.Round(time.Millisecond)))
+	b.WriteString(fmt.Sprintf("- 进程状态: %s\n", r.Status()))
+	b.WriteString(fmt.Sprintf("- 标准输出捕获文件: %s\n", r.StdoutPath))
+	b.WriteString(fmt.Sprintf("- 标准错误捕获文件: %s\n", r.StderrPath))
+	if len(r.Milestones) > 0 {
+		b.WriteString("- 观察到的启动标志:\n")
+		for _, m := range r.Milestones {
+			b.WriteString(fmt.Sprintf("  - [+%s] %s: %s\n", m.Elapsed.Round(time.Millisecond), m.Name, m.Line))
+		}
+	}
+	if r.State == StateHung {
+		b.WriteString(fmt.Sprintf("- 卡死判定: %s\n", r.HangReason))
+		if len(r.ThreadDumps) > 0 {
+			b.WriteString("- 线程转储文件:\n")
+			for _, path := range r.ThreadDumps {
+				b.WriteString(fmt.Sprintf("  - %s\n", path))
+			}
+		} else {
+			b.WriteString("- 线程转储: 采集失败（jcmd/jstack 不可用）\n")
+		}
+		b.WriteString("应用启动卡死且没有退出，请使用thread_dump工具按采集顺序分析线程转储文件，找出 main 线程阻塞的位置（数据源连接池、DNS解析、配置中心拉取、锁等待等）和死锁。\n")
+	}
+	if r.crashed() {
+		b.WriteString("- 进程异常终止，JVM 致命错误（SIGSEGV、本地内存不足等）会在工作目录写入 hs_err_pid<N>.log，请使用crash_report工具查看\n")
+	}
+	b.WriteString("请结合进程状态，同时检查标准输出和标准错误捕获文件（JVM参数错误、类加载失败等问题通常只出现在这里）。")
+	return b.String()
+}
@@ -0,0 +1,226 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunExit(t *testing.T) {
+	// Run 通过 sh -c 执行启动命令
+	tests := []struct {
+		command string
+		code    int
+		signal  string
+		status  string
+	}{
+		{"exit 3", 3, "", "退出码 3"},
+		{"kill -9 $$", -1, syscall.SIGKILL.String(), "被信号终止 (killed)"},
+		{"kill -ABRT $$", -1, syscall.SIGABRT.String(), "被信号终止 (aborted)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			result, err := Run(context.Background(), Options{Command: tt.command, OutputDir: t.TempDir()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.State != StateExited || !result.Exited || result.ExitCode != tt.code || result.Signal != tt.signal || result.Status() != tt.status {
+				t.Errorf("退出状态错误: state=%s exited=%v code=%d signal=%q status=%s", result.State, result.Exited, result.ExitCode, result.Signal, result.Status())
+			}
+			// 被 SIGABRT 终止可能是 JVM 致命错误
+			if crashed := tt.signal == syscall.SIGABRT.String(); result.crashed() != crashed {
+				t.Errorf("crashed() = %v", result.crashed())
+			}
+		})
+	}
+}
+
+func TestRunCancelStopsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// 等待后台子进程启动后再取消
+		for {
+			if data, _ := os.ReadFile(filepath.Join(dir, "child.pid")); len(data) > 0 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+
+	result, err := Run(ctx, Options{
+		Command:   "/bin/sleep 30 & echo $! > child.pid; /bin/sleep 30",
+		Dir:       dir,
+		OutputDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopAfterTest(t, result.PID)
+	if !result.Exited || result.Signal != syscall.SIGTERM.String() {
+		t.Fatalf("取消后应以 SIGTERM 终止: %+v", result)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "child.pid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 后台子进程属于同一进程组，应一并终止
+	for deadline := time.Now().Add(5 * time.Second); !processGone(child); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("后台子进程 %d 仍在运行", child)
+		}
+	}
+}
+
+func TestRunReady(t *testing.T) {
+	dir := t.TempDir()
+	result, err := Run(context.Background(), Options{
+		Command:        "echo 'Tomcat started on port 8080' >> app.log; echo 'Started App in 0.5 seconds'; exec /bin/sleep 30",
+		Dir:            dir,
+		OutputDir:      dir,
+		LogPaths:       []string{filepath.Join(dir, "*.log")},
+		StartupTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopAfterTest(t, result.PID)
+
+	if result.State != StateReady || result.Exited || len(result.Milestones) != 2 {
+		t.Fatalf("应检测到启动完成: %+v", result)
+	}
+	names := result.Milestones[0].Name + "," + result.Milestones[1].Name
+	if names != "web_server_started,application_started" && names != "application_started,web_server_started" {
+		t.Errorf("启动标志错误: %+v", result.Milestones)
+	}
+	// 启动完成后进程继续运行
+	if err := syscall.Kill(result.PID, 0); err != nil {
+		t.Errorf("进程不应被终止: %v", err)
+	}
+}
+
+func TestRunHung(t *testing.T) {
+	dir := t.TempDir()
+	javaHome := filepath.Join(dir, "jdk")
+	writeScript(t, filepath.Join(javaHome, "bin", "jcmd"), "echo '   java.lang.Thread.State: WAITING (parking)'")
+	writeScript(t, filepath.Join(javaHome, "bin", "jstack"), "exit 1")
+	t.Setenv("JAVA_HOME", javaHome)
+	shortenDumpInterval(t)
+
+	result, err := Run(context.Background(), Options{
+		Command:     "echo starting; exec /bin/sleep 30",
+		OutputDir:   dir,
+		HangTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopAfterTest(t, result.PID)
+
+	if result.State != StateHung || result.Exited || !strings.Contains(result.HangReason, "没有任何进展") {
+		t.Fatalf("应判定为卡死: %+v", result)
+	}
+	if len(result.ThreadDumps) != threadDumpCount {
+		t.Fatalf("应采集 %d 次线程转储: %v", threadDumpCount, result.ThreadDumps)
+	}
+	for _, path := range result.ThreadDumps {
+		if !IsCaptureFile(path) {
+			t.Errorf("线程转储文件名错误: %s", path)
+		}
+	}
+	if !strings.Contains(result.Describe(), "卡死判定") {
+		t.Errorf("描述中缺少卡死判定: %s", result.Describe())
+	}
+}
+
+func TestRunStartupTimeout(t *testing.T) {
+	dir := t.TempDir()
+	javaHome := filepath.Join(dir, "jdk")
+	writeScript(t, filepath.Join(javaHome, "bin", "jcmd"), "exit 1")
+	writeScript(t, filepath.Join(javaHome, "bin", "jstack"), "exit 1")
+	t.Setenv("JAVA_HOME", javaHome)
+
+	// 持续有输出，但一直没有启动完成标志
+	result, err := Run(context.Background(), Options{
+		Command:        "while :; do echo tick; /bin/sleep 0.1; done",
+		OutputDir:      dir,
+		StartupTimeout: 300 * time.Millisecond,
+		HangTimeout:    time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopAfterTest(t, result.PID)
+
+	if result.State != StateHung || !strings.Contains(result.HangReason, "仍未检测到启动完成标志") {
+		t.Fatalf("应判定为启动超时: %+v", result)
+	}
+	if len(result.ThreadDumps) != 0 || !strings.Contains(result.Describe(), "采集失败") {
+		t.Errorf("非 JVM 进程不应采集到线程转储: %v", result.ThreadDumps)
+	}
+}
+
+// processGone 判断进程是否已经结束，尚未被回收的僵尸进程也算结束
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return true
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	end := strings.LastIndexByte(string(stat), ')')
+	return end >= 0 && strings.HasPrefix(strings.TrimSpace(string(stat[end+1:])), "Z")
+}
+
+// stopAfterTest 测试结束时终止仍在运行的进程组
+func stopAfterTest(t *testing.T, pid int) {
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			killProcessGroup(p)
+		}
+	})
+}
+
+// writeScript 写入可执行的 shell 脚本
+func writeScript(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// shortenDumpInterval 缩短测试中两次采集线程转储的间隔
+func shortenDumpInterval(t *testing.T) {
+	interval := threadDumpInterval
+	threadDumpInterval = 10 * time.Millisecond
+	t.Cleanup(func() { threadDumpInterval = interval })
+}
+
+// waitForOutput 等待进程开始向文件输出
+func waitForOutput(t *testing.T, path string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			return
+		}
+	}
+	t.Fatalf("进程没有输出: %s", path)
+}
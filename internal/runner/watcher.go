@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Milestone 启动过程中观察到的关键标志
+type Milestone struct {
+	Name    string        `json:"name"`
+	Line    string        `json:"line"`
+	Elapsed time.Duration `json:"elapsed"` // 相对进程启动的时间
+}
+
+// 启动过程中的关键标志，按名称只记录第一次出现
+var milestonePatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"web_server_started", regexp.MustCompile(`(Tomcat|Jetty|Undertow|Netty) started on port`)},
+	{"application_started", regexp.MustCompile(`Started \S+ in [\d.]+ seconds`)},
+	{"application_failed", regexp.MustCompile(`APPLICATION FAILED TO START|Application run failed`)},
+}
+
+// readyMilestone 表示应用启动完成的标志
+const readyMilestone = "application_started"
+
+// maxReadPerPoll 单次轮询从单个文件读取的最大字节数
+const maxReadPerPoll = 8 << 20
+
+// watcher 轮询读取文件新增内容，检测启动标志
+type watcher struct {
+	echo       io.Writer
+	files      []*tailFile
+	patterns   []string        // 应用日志的路径或glob模式
+	known      map[string]bool // 已在监视的文件
+	start      time.Time
+	seen       map[string]bool
+	milestones []Milestone
+}
+
+// tailFile 记录单个文件的读取位置
+type tailFile struct {
+	path    string
+	offset  int64
+	partial []byte
+	echo    bool
+}
+
+// newWatcher 创建监视器，进程输出捕获文件的内容会被回显
+func newWatcher(echo io.Writer, stdoutPath, stderrPath string, logPatterns []string) *watcher {
+	w := &watcher{
+		echo:     echo,
+		patterns: logPatterns,
+		known:    map[string]bool{stdoutPath: true, stderrPath: true},
+		start:    time.Now(),
+		seen:     make(map[string]bool),
+		files: []*tailFile{
+			{path: stdoutPath, echo: true},
+			{path: stderrPath, echo: true},
+		},
+	}
+	w.discover(true)
+	return w
+}
+
+// discover 展开日志路径模式，开始监视新出现的日志文件
+// 启动时已存在的日志只关注本次启动新增的内容，之后出现的日志从头读取
+func (w *watcher) discover(initial bool) {
+	for _, pattern := range w.patterns {
+		paths := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			paths, _ = filepath.Glob(pattern)
+		}
+		for _, path := range paths {
+			if w.known[path] || path != pattern && IsCaptureFile(path) {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err == nil && info.IsDir() {
+				continue
+			}
+			if err != nil && path != pattern {
+				continue
+			}
+			w.known[path] = true
+			f := &tailFile{path: path}
+			if initial && err == nil {
+				f.offset = info.Size()
+			}
+			w.files = append(w.files, f)
+		}
+	}
+}
+
+// poll 读取所有文件的新增内容，返回是否有任何进展
+func (w *watcher) poll() bool {
+	w.discover(false)
+	progress := false
+	for _, f := range w.files {
+		data := f.readNew()
+		if len(data) == 0 {
+			continue
+		}
+		progress = true
+		if f.echo && w.echo != nil {
+			w.echo.Write(data)
+		}
+
+		data = append(f.partial, data...)
+		lines := bytes.Split(data, []byte{'\n'})
+		f.partial = append([]byte(nil), lines[len(lines)-1]...)
+		for _, line := range lines[:len(lines)-1] {
+			w.check(string(line))
+		}
+	}
+	return progress
+}
+
+// check 检查单行日志是否包含启动标志
+func (w *watcher) check(line string) {
+	for _, mp := range milestonePatterns {
+		if w.seen[mp.name] || !mp.pattern.MatchString(line) {
+			continue
+		}
+		w.seen[mp.name] = true
+		w.milestones = append(w.milestones, Milestone{
+			Name:    mp.name,
+			Line:    strings.TrimSpace(line),
+			Elapsed: time.Since(w.start),
+		})
+	}
+}
+
+// ready 是否已检测到启动完成
+func (w *watcher) ready() bool {
+	return w.seen[readyMilestone]
+}
+
+// readNew 读取文件自上次以来新增的内容，文件被截断或轮转时从头读取
+func (f *tailFile) readNew() []byte {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	if info.Size() < f.offset {
+		f.offset = 0
+		f.partial = nil
+	}
+	if info.Size() == f.offset {
+		return nil
+	}
+
+	size := info.Size() - f.offset
+	if size > maxReadPerPoll {
+		size = maxReadPerPoll
+	}
+	data := make([]byte, size)
+	n, err := file.ReadAt(data, f.offset)
+	if err != nil && err != io.EOF {
+		return nil
+	}
+	f.offset += int64(n)
+	return data[:n]
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatcherDiscoversLogs(t *testing.T) {
+	dir := t.TempDir()
+	stdout := filepath.Join(dir, capturePrefix+"x.stdout.log")
+	stderr := filepath.Join(dir, capturePrefix+"x.stderr.log")
+	old := filepath.Join(dir, "app-old.log")
+	for _, path := range []string{stdout, stderr} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(old, []byte("Started OldApp in 1.0 seconds\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := newWatcher(nil, stdout, stderr, []string{filepath.Join(dir, "*.log")})
+	if len(w.files) != 3 {
+		t.Fatalf("应监视捕获文件和已有日志: %d", len(w.files))
+	}
+	// 已有日志只读取新增内容
+	if w.poll() || w.ready() {
+		t.Fatalf("不应读取启动前的日志内容")
+	}
+
+	// 运行期间新生成的日志从头读取
+	if err := os.WriteFile(filepath.Join(dir, "app-new.log"), []byte("Started App in 3.2 seconds (process running for 3.9)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.poll() || !w.ready() || len(w.files) != 4 {
+		t.Fatalf("未发现新生成的日志: %d %+v", len(w.files), w.milestones)
+	}
+}
+
+func TestWatcherMilestones(t *testing.T) {
+	dir := t.TempDir()
+	stdout := filepath.Join(dir, capturePrefix+"x.stdout.log")
+	stderr := filepath.Join(dir, capturePrefix+"x.stderr.log")
+	logPath := filepath.Join(dir, "app.log")
+	for _, path := range []string{stdout, stderr} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var echo bytes.Buffer
+	// 尚不存在的日志路径也会被监视
+	w := newWatcher(&echo, stdout, stderr, []string{logPath})
+	if len(w.files) != 3 {
+		t.Fatalf("应监视尚未生成的日志: %d", len(w.files))
+	}
+
+	appendFile(t, logPath, "2025-09-23 19:46:55.100  INFO 1 --- [main] o.s.b.w.e.tomcat.TomcatWebServer : Tomcat started on port(s): 8080 (http)\n")
+	appendFile(t, stdout, "Started App in 3.")
+	if !w.poll() || w.ready() {
+		t.Fatalf("不完整的行不应被检查: %+v", w.milestones)
+	}
+	if len(w.milestones) != 1 || w.milestones[0].Name != "web_server_started" || !strings.Contains(w.milestones[0].Line, "8080") {
+		t.Fatalf("启动标志错误: %+v", w.milestones)
+	}
+
+	appendFile(t, stdout, "2 seconds (process running for 3.9)\nTomcat started on port 9090\n")
+	if !w.poll() || !w.ready() {
+		t.Fatalf("跨轮询的行未拼接: %+v", w.milestones)
+	}
+	// 同名标志只记录第一次出现
+	if len(w.milestones) != 2 || w.milestones[1].Line != "Started App in 3.2 seconds (process running for 3.9)" {
+		t.Errorf("启动标志错误: %+v", w.milestones)
+	}
+	// 只回显进程输出，不回显应用日志
+	if echo.String() != "Started App in 3.2 seconds (process running for 3.9)\nTomcat started on port 9090\n" {
+		t.Errorf("回显内容错误: %q", echo.String())
+	}
+	if w.poll() {
+		t.Errorf("没有新内容时不应有进展")
+	}
+}
+
+func TestWatcherTruncatedLog(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	appendFile(t, logPath, "starting\nAPPLICATION FAILED TO START\n")
+
+	w := newWatcher(nil, filepath.Join(dir, "stdout"), filepath.Join(dir, "stderr"), []string{logPath})
+	// 日志被截断或轮转后从头读取
+	if err := os.WriteFile(logPath, []byte("Started App in 1.5 seconds\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.poll() || !w.ready() || len(w.milestones) != 1 {
+		t.Fatalf("截断后未从头读取: %+v", w.milestones)
+	}
+}
+
+// appendFile 向文件追加内容，模拟进程写日志
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
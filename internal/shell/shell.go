@@ -0,0 +1,155 @@
+// Package shell 按 shell 的规则解析应用的启动命令和环境变量
+package shell
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// assignment 匹配命令前的环境变量赋值，如 SPRING_PROFILES_ACTIVE=prod java -jar app.jar
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// Command 启动命令中的一条简单命令
+type Command struct {
+	Env     map[string]string // 命令前的环境变量赋值，包括 export 的变量
+	Program string            // 执行的程序，只有赋值时为空
+	Args    []string          // 程序的参数
+}
+
+// Split 按 shell 的规则拆分命令，支持单引号、双引号和反斜杠转义，控制操作符 ; & && | || 单独成为一项
+func Split(cmd string) []string {
+	var args []string
+	var b strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(cmd); i++ {
+		ch := cmd[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else if ch == '\\' && quote == '"' && i+1 < len(cmd) {
+				i++
+				b.WriteByte(cmd[i])
+			} else {
+				b.WriteByte(ch)
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inArg = true
+		case ch == '\\' && i+1 < len(cmd):
+			i++
+			b.WriteByte(cmd[i])
+			inArg = true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		case ch == '&' && (i > 0 && (cmd[i-1] == '>' || cmd[i-1] == '<') || i+1 < len(cmd) && cmd[i+1] == '>'):
+			// 重定向 2>&1、&>file 中的 & 不是控制操作符
+			b.WriteByte(ch)
+			inArg = true
+		case ch == ';' || ch == '&' || ch == '|':
+			// 控制操作符不需要空格分隔，如 cd /app;java
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+			op := string(ch)
+			if ch != ';' && i+1 < len(cmd) && cmd[i+1] == ch {
+				op += op
+				i++
+			}
+			args = append(args, op)
+		default:
+			b.WriteByte(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args
+}
+
+// Parse 将启动命令按 &&、||、;、|、& 拆分成简单命令，跳过 env、export、exec、nohup 前缀，
+// sh -c / bash -c 执行的命令字符串会被展开。只处理启动命令中常见的写法，不支持子shell和重定向
+func Parse(cmd string) []Command {
+	var commands []Command
+	current := Command{Env: make(map[string]string)}
+	flush := func() {
+		if current.Program != "" || len(current.Env) > 0 {
+			commands = append(commands, current)
+		}
+		current = Command{Env: make(map[string]string)}
+	}
+	for _, arg := range Split(cmd) {
+		switch {
+		case arg == "&&" || arg == "||" || arg == ";" || arg == "|" || arg == "&":
+			flush()
+		case current.Program != "":
+			current.Args = append(current.Args, arg)
+		case arg == "env" || arg == "export" || arg == "exec" || arg == "nohup":
+		default:
+			if name, value, ok := Assignment(arg); ok {
+				current.Env[name] = value
+			} else {
+				current.Program = arg
+			}
+		}
+	}
+	flush()
+
+	// sh -c '...' 中的命令继承外层的变量赋值
+	var expanded []Command
+	for _, c := range commands {
+		if script, ok := shellScript(c); ok {
+			inner := Parse(script)
+			for i := range inner {
+				for name, value := range c.Env {
+					if _, ok := inner[i].Env[name]; !ok {
+						inner[i].Env[name] = value
+					}
+				}
+			}
+			expanded = append(expanded, inner...)
+			continue
+		}
+		expanded = append(expanded, c)
+	}
+	return expanded
+}
+
+// shellScript 返回 sh -c 执行的命令字符串
+func shellScript(c Command) (string, bool) {
+	switch filepath.Base(c.Program) {
+	case "sh", "bash", "dash", "zsh":
+		if len(c.Args) >= 2 && c.Args[0] == "-c" {
+			return c.Args[1], true
+		}
+	}
+	return "", false
+}
+
+// Assignment 解析环境变量赋值，如 JAVA_OPTS=-Xmx1g
+func Assignment(arg string) (name, value string, ok bool) {
+	if !assignment.MatchString(arg) {
+		return "", "", false
+	}
+	return strings.Cut(arg, "=")
+}
+
+// Environ 将 os.Environ 和 /proc/<pid>/environ 格式的 "NAME=value" 列表转换为映射
+func Environ(environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && name != "" {
+			env[name] = value
+		}
+	}
+	return env
+}
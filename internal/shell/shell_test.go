@@ -0,0 +1,64 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{`java -jar app.jar`, []string{"java", "-jar", "app.jar"}},
+		{`JAVA_OPTS="-Xmx1g -Xms1g" ./start.sh`, []string{"JAVA_OPTS=-Xmx1g -Xms1g", "./start.sh"}},
+		{`java '-Dmsg=it''s' "-Dq=\"x\"" a\ b`, []string{"java", "-Dmsg=its", `-Dq="x"`, "a b"}},
+		{"cd /app &&\tjava\n-jar app.jar", []string{"cd", "/app", "&&", "java", "-jar", "app.jar"}},
+		{`cd /app;java -Da=1&&x||y&`, []string{"cd", "/app", ";", "java", "-Da=1", "&&", "x", "||", "y", "&"}},
+		{`nohup java -jar app.jar >out.log 2>&1 &`, []string{"nohup", "java", "-jar", "app.jar", ">out.log", "2>&1", "&"}},
+		{`  `, nil},
+	}
+	for _, tt := range tests {
+		if got := Split(tt.cmd); strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("Split(%q) = %q，期望 %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	commands := Parse(`export APP_ENV=prod; cd /app && JAVA_OPTS="-Xmx1g" nohup ./bin/start.sh --debug & sh -c 'SPRING_PROFILES_ACTIVE=k8s exec java -jar app.jar'`)
+	var got []string
+	for _, c := range commands {
+		var env []string
+		for _, name := range []string{"APP_ENV", "JAVA_OPTS", "SPRING_PROFILES_ACTIVE"} {
+			if value, ok := c.Env[name]; ok {
+				env = append(env, name+"="+value)
+			}
+		}
+		got = append(got, strings.Join(env, ",")+" "+c.Program+" "+strings.Join(c.Args, " "))
+	}
+	want := []string{
+		"APP_ENV=prod  ",
+		" cd /app",
+		"JAVA_OPTS=-Xmx1g ./bin/start.sh --debug",
+		"SPRING_PROFILES_ACTIVE=k8s java -jar app.jar",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Parse 结果:\n%s\n期望:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAssignmentAndEnviron(t *testing.T) {
+	if name, value, ok := Assignment("JAVA_OPTS=-Xmx1g -Dx=y"); !ok || name != "JAVA_OPTS" || value != "-Xmx1g -Dx=y" {
+		t.Errorf("赋值解析错误: %s %s %v", name, value, ok)
+	}
+	for _, arg := range []string{"-Dx=y", "--server.port=80", "1A=b", "a.b=c"} {
+		if _, _, ok := Assignment(arg); ok {
+			t.Errorf("%s 不是环境变量赋值", arg)
+		}
+	}
+	env := Environ([]string{"PATH=/usr/bin", "EMPTY=", "OPTS=a=b", "=C:=C:\\", "garbage"})
+	if len(env) != 3 || env["EMPTY"] != "" || env["OPTS"] != "a=b" {
+		t.Errorf("环境变量解析错误: %v", env)
+	}
+}
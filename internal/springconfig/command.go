@@ -0,0 +1,52 @@
+package springconfig
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// command 从启动命令中提取的配置
+type command struct {
+	args      []Property        // --key=value 应用参数
+	sysProps  []Property        // -Dkey=value 系统属性
+	env       map[string]string // 命令前的环境变量赋值
+	locations []string          // spring.config.location 和 spring.config.additional-location
+	jar       string            // java -jar 启动的 jar
+}
+
+// parseCommand 解析启动命令中的环境变量赋值、系统属性和应用参数
+func parseCommand(cmd string) command {
+	c := command{env: make(map[string]string)}
+	for _, sc := range shell.Parse(cmd) {
+		for name, value := range sc.Env {
+			c.env[name] = value
+		}
+		for i, arg := range sc.Args {
+			switch {
+			case arg == "-jar" && i+1 < len(sc.Args) && c.jar == "":
+				c.jar = sc.Args[i+1]
+			case strings.HasPrefix(arg, "-D") && len(arg) > 2:
+				key, value, _ := strings.Cut(arg[2:], "=")
+				c.sysProps = append(c.sysProps, Property{Key: key, Value: value, Source: SourceSystemProp})
+			case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+				key, value, _ := strings.Cut(arg[2:], "=")
+				c.args = append(c.args, Property{Key: key, Value: value, Source: SourceCommandLine})
+			}
+		}
+	}
+
+	for _, p := range append(append([]Property{}, c.args...), c.sysProps...) {
+		switch canonical(p.Key) {
+		case canonical("spring.config.location"), canonical("spring.config.additional-location"):
+			for _, location := range strings.Split(p.Value, ",") {
+				location = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(location), "optional:"), "file:")
+				if location != "" && !strings.HasPrefix(location, "classpath:") {
+					c.locations = append(c.locations, filepath.FromSlash(location))
+				}
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,537 @@
+package springconfig
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 非文件配置源的名称
+const (
+	SourceCommandLine = "命令行参数"
+	SourceSystemProp  = "系统属性"
+	SourceEnvironment = "环境变量"
+)
+
+const maxPlaceholderPasses = 10 // 占位符的名称由其他占位符拼接时最多解析的轮数
+
+// configFilePattern 匹配 Spring Boot 会加载的配置文件名，分组为基础名和 profile
+var configFilePattern = regexp.MustCompile(`^(application|bootstrap)(?:-([\w.-]+))?\.(yml|yaml|properties)$`)
+
+// skippedDirs 查找配置文件时跳过的目录：版本库、IDE、构建输出和测试资源
+var skippedDirs = map[string]bool{
+	".git": true, ".svn": true, ".idea": true, ".gradle": true, ".mvn": true,
+	"node_modules": true, "target": true, "build": true, "out": true, "test": true,
+}
+
+// Options 加载配置的参数
+type Options struct {
+	Root     string            // 查找配置文件的目录，可以是项目根目录或部署的 config 目录
+	Profiles []string          // 激活的 profile，为空时从启动命令、环境变量和配置文件中确定
+	Command  string            // 启动命令，从中提取 --key=value、-Dkey=value、环境变量前缀和 spring.config.location
+	Env      map[string]string // 应用进程的环境变量，启动命令中的前缀赋值会覆盖其中的值
+	// Module 多模块项目中目标模块相对 Root 的目录，为空时根据启动命令中的 jar 名确定
+	Module string
+	// Allow 检查是否允许读取某个配置文件，为 nil 时允许全部
+	Allow func(path string) bool
+	// Open 打开配置文件，为 nil 时使用 os.Open；可在打开后再次检查文件，避免检查后被替换为符号链接
+	Open func(path string) (io.ReadCloser, error)
+}
+
+// configFile 一个配置文件及其优先级
+type configFile struct {
+	path      string
+	profile   string // 文件名中的 profile，空表示通用配置
+	bootstrap bool   // Spring Cloud 的 bootstrap 配置，优先级低于 application
+	external  bool   // 不在 src/main/resources 下，对应打包后 jar 外部的配置
+	location  int    // 同一类位置中的优先级：根目录 < config 目录 < config 子目录
+	yaml      bool
+	docs      []document
+}
+
+// source 一个配置源中的全部配置项，按书写顺序排列，后出现的覆盖先出现的
+type source struct {
+	name  string
+	props []Property
+}
+
+// Environment 按 Spring Boot 优先级合并后的配置
+type Environment struct {
+	Profiles     []string // 激活的 profile
+	ProfilesFrom string   // profile 的来源
+	Files        []string // 加载的配置文件，按优先级从低到高
+	Errors       []string // 无法解析的配置文件
+	Modules      []string // Root 下包含 src/main/resources 配置的模块，"." 为根目录
+	Module       string   // 加载的模块，有多个模块且无法确定时为空，此时合并了所有模块的配置
+
+	sources []*source // 按优先级从高到低
+}
+
+// Resolved 一个配置项的最终值
+type Resolved struct {
+	Key        string     // 最高优先级来源中书写的键
+	Value      string     // 解析占位符后的值
+	Property   Property   // 生效的原始配置项
+	Overridden []Property // 被覆盖的低优先级配置项，按优先级从高到低
+	Unresolved []string   // 无法解析的占位符
+}
+
+// Load 查找并加载配置文件，确定激活的 profile 并按优先级合并各配置源
+func Load(opts Options) (*Environment, error) {
+	command := parseCommand(opts.Command)
+	env := make(map[string]string)
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+	for k, v := range command.env {
+		env[k] = v
+	}
+	allow := opts.Allow
+	if allow == nil {
+		allow = func(string) bool { return true }
+	}
+	open := opts.Open
+	if open == nil {
+		open = func(path string) (io.ReadCloser, error) { return os.Open(path) }
+	}
+
+	files, err := findConfigFiles(opts.Root, allow)
+	if err != nil {
+		return nil, err
+	}
+	e := &Environment{}
+	files, e.Modules, e.Module = selectModule(files, opts.Root, opts.Module, command.jar)
+	// spring.config.location / additional-location 指定的外部配置
+	for _, location := range command.locations {
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(opts.Root, location)
+		}
+		extra, err := findConfigFiles(location, allow)
+		if err != nil {
+			continue
+		}
+		for _, f := range extra {
+			f.external = true
+			f.location = 10
+			files = append(files, f)
+		}
+	}
+
+	for _, f := range files {
+		docs, err := parseFile(open, f.path)
+		if err != nil {
+			e.Errors = append(e.Errors, err.Error())
+			continue
+		}
+		f.docs = docs
+	}
+
+	// 非文件配置源的优先级高于所有配置文件
+	e.sources = append(e.sources, &source{name: SourceCommandLine, props: command.args}, &source{name: SourceSystemProp, props: command.sysProps})
+	envSource := &source{name: SourceEnvironment}
+	for k, v := range env {
+		envSource.props = append(envSource.props, Property{Key: k, Value: v, Source: SourceEnvironment})
+	}
+	sort.Slice(envSource.props, func(i, j int) bool { return envSource.props[i].Key < envSource.props[j].Key })
+	e.sources = append(e.sources, envSource)
+
+	e.Profiles, e.ProfilesFrom = opts.Profiles, "参数指定"
+	if len(e.Profiles) == 0 {
+		e.Profiles, e.ProfilesFrom = e.detectProfiles(files)
+	}
+	e.addFileSources(files)
+	return e, nil
+}
+
+// findConfigFiles 查找目录下的 application/bootstrap 配置文件，root 也可以是单个文件
+func findConfigFiles(root string, allow func(string) bool) ([]*configFile, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if f := newConfigFile(root, filepath.Dir(root)); f != nil && allow(root) {
+			return []*configFile{f}, nil
+		}
+		return nil, nil
+	}
+
+	var files []*configFile
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if f := newConfigFile(path, root); f != nil && allow(path) {
+			files = append(files, f)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// selectModule 多模块项目中只保留目标模块的配置文件，以及不属于任何模块的配置文件
+// 返回找到的模块和选中的模块，无法确定目标模块时保留全部文件
+func selectModule(files []*configFile, root, want, jar string) ([]*configFile, []string, string) {
+	rels := make(map[*configFile]string)
+	set := make(map[string]bool)
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		rels[f] = rel
+		if i := strings.Index("/"+rel, "/src/main/resources/"); i >= 0 {
+			module := "."
+			if i > 0 {
+				module = rel[:i-1]
+			}
+			set[module] = true
+		}
+	}
+	var modules []string
+	for m := range set {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+
+	want = strings.Trim(filepath.ToSlash(want), "/")
+	if want == "" && len(modules) == 1 {
+		return files, modules, modules[0]
+	}
+	if want == "" {
+		want = moduleForJar(modules, jar)
+	}
+	if want == "" {
+		return files, modules, ""
+	}
+	// 文件属于路径前缀最长的模块，不在任何模块目录下的文件属于根目录
+	owner := func(rel string) string {
+		best := "."
+		for _, m := range modules {
+			if m != "." && strings.HasPrefix(rel, m+"/") && len(m) > len(best) {
+				best = m
+			}
+		}
+		return best
+	}
+	var selected []*configFile
+	for _, f := range files {
+		if o := owner(rels[f]); o == want || o == "." {
+			selected = append(selected, f)
+		}
+	}
+	return selected, modules, want
+}
+
+// moduleForJar 根据启动的 jar 名确定模块，如 order-service-1.0.jar 对应 order-service 目录
+func moduleForJar(modules []string, jar string) string {
+	if jar == "" {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(jar), filepath.Ext(jar))
+	best := ""
+	for _, m := range modules {
+		base := path.Base(m)
+		if m == "." || len(base) <= len(path.Base(best)) {
+			continue
+		}
+		if name == base || strings.HasPrefix(name, base+"-") {
+			best = m
+		}
+	}
+	return best
+}
+
+// newConfigFile 根据文件名和位置确定配置文件的优先级，不是配置文件时返回 nil
+func newConfigFile(path, root string) *configFile {
+	m := configFilePattern.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return nil
+	}
+	f := &configFile{path: path, profile: m[2], bootstrap: m[1] == "bootstrap", yaml: m[3] != "properties"}
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		rel = filepath.Dir(path)
+	}
+	dir := "/" + filepath.ToSlash(rel) + "/"
+	if i := strings.Index(dir, "/src/main/resources/"); i >= 0 {
+		// classpath: 根目录 < classpath:/config/
+		if strings.HasPrefix(dir[i+len("/src/main/resources/"):], "config/") {
+			f.location = 1
+		}
+		return f
+	}
+	// jar 外部: 当前目录 < ./config/ < ./config/*/
+	f.external = true
+	switch {
+	case strings.HasSuffix(dir, "/config/"):
+		f.location = 3
+	case strings.Contains(dir, "/config/"):
+		f.location = 4
+	default:
+		f.location = 2
+	}
+	return f
+}
+
+// detectProfiles 依次从命令行参数、系统属性、环境变量和通用配置文件中读取 spring.profiles.active
+func (e *Environment) detectProfiles(files []*configFile) ([]string, string) {
+	for _, src := range e.sources {
+		if p, ok := lookupIn(src, "spring.profiles.active"); ok && p.Value != "" {
+			return splitProfiles(p.Value), src.name
+		}
+	}
+	ordered := orderFiles(files, nil)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		f := ordered[i]
+		if f.profile != "" {
+			continue
+		}
+		for j := len(f.docs) - 1; j >= 0; j-- {
+			if f.docs[j].onProfile != "" {
+				continue
+			}
+			for k := len(f.docs[j].properties) - 1; k >= 0; k-- {
+				p := f.docs[j].properties[k]
+				if canonical(p.Key) == canonical("spring.profiles.active") && p.Value != "" {
+					return splitProfiles(p.Value), p.Location()
+				}
+			}
+		}
+	}
+	return []string{"default"}, "默认"
+}
+
+// addFileSources 将生效的配置文件按优先级从高到低追加到配置源
+func (e *Environment) addFileSources(files []*configFile) {
+	ordered := orderFiles(files, e.Profiles)
+	for i := len(ordered) - 1; i >= 0; i-- {
+		f := ordered[i]
+		src := &source{name: f.path}
+		for _, doc := range f.docs {
+			if doc.activeFor(e.Profiles) {
+				src.props = append(src.props, doc.properties...)
+			}
+		}
+		e.sources = append(e.sources, src)
+	}
+	for _, f := range ordered {
+		e.Files = append(e.Files, f.path)
+	}
+}
+
+// orderFiles 返回按优先级从低到高排列的配置文件
+// profiles 不为 nil 时只保留通用配置和激活 profile 的配置，后激活的 profile 优先级更高
+func orderFiles(files []*configFile, profiles []string) []*configFile {
+	rank := make(map[string]int)
+	for i, p := range profiles {
+		rank[p] = i + 1
+	}
+	var selected []*configFile
+	for _, f := range files {
+		if f.docs == nil {
+			continue
+		}
+		if profiles != nil && f.profile != "" && rank[f.profile] == 0 {
+			continue
+		}
+		selected = append(selected, f)
+	}
+	// Spring Boot 2.4+：bootstrap < application，jar 内 < jar 外，通用 < profile，同一位置 properties 优先于 yml
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		switch {
+		case a.bootstrap != b.bootstrap:
+			return a.bootstrap
+		case a.external != b.external:
+			return !a.external
+		case (a.profile != "") != (b.profile != ""):
+			return a.profile == ""
+		case rank[a.profile] != rank[b.profile]:
+			return rank[a.profile] < rank[b.profile]
+		case a.location != b.location:
+			return a.location < b.location
+		case a.yaml != b.yaml:
+			return a.yaml
+		}
+		return a.path < b.path
+	})
+	return selected
+}
+
+// canonical 返回宽松绑定下配置项的规范形式：忽略大小写和 '-'，环境变量的 '_' 等同于 '.'
+func canonical(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ReplaceAll(key, "_", ".")
+}
+
+// lookupIn 返回配置源中某个键最后出现的值
+func lookupIn(src *source, key string) (Property, bool) {
+	want := canonical(key)
+	for i := len(src.props) - 1; i >= 0; i-- {
+		if canonical(src.props[i].Key) == want {
+			return src.props[i], true
+		}
+	}
+	return Property{}, false
+}
+
+// Get 返回配置项的最终值及被它覆盖的值
+func (e *Environment) Get(key string) (*Resolved, bool) {
+	var found []Property
+	for _, src := range e.sources {
+		if p, ok := lookupIn(src, key); ok {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 0 {
+		return nil, false
+	}
+	r := &Resolved{Key: found[0].Key, Property: found[0], Overridden: found[1:]}
+	if found[0].Source == SourceEnvironment {
+		// 环境变量名不是配置项的写法，使用查询的键
+		r.Key = key
+	}
+	resolving := map[string]bool{canonical(key): true}
+	r.Value = strings.ReplaceAll(e.resolve(found[0].Value, resolving, &r.Unresolved), "$\x00{", "${")
+	return r, true
+}
+
+// placeholderPattern 匹配最内层的 ${name} 或 ${name:default}
+var placeholderPattern = regexp.MustCompile(`\$\{([^${}]*)\}`)
+
+// resolve 解析值中的占位符，无法解析且没有默认值的占位符保持原样并记录
+// resolving 为正在解析的配置项，再次引用它们时与 Spring 一样视为循环引用
+// 保留原样的占位符以 "$\x00{" 标记，防止被外层再次匹配，由调用方还原
+func (e *Environment) resolve(value string, resolving map[string]bool, unresolved *[]string) string {
+	keep := func(ref string) string { return "$\x00" + ref[1:] }
+	for pass := 0; pass < maxPlaceholderPasses && strings.Contains(value, "${"); pass++ {
+		replaced := placeholderPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			name, def, hasDefault := strings.Cut(ref[2:len(ref)-1], ":")
+			if strings.HasPrefix(name, "random.") {
+				return "<" + name + ">"
+			}
+			key := canonical(name)
+			if resolving[key] {
+				*unresolved = appendUnique(*unresolved, name+"（循环引用）")
+				return keep(ref)
+			}
+			if r, ok := e.lookupRaw(name); ok {
+				resolving[key] = true
+				defer delete(resolving, key)
+				return e.resolve(r, resolving, unresolved)
+			}
+			if hasDefault {
+				return def
+			}
+			*unresolved = appendUnique(*unresolved, name)
+			return keep(ref)
+		})
+		if replaced == value {
+			break
+		}
+		value = replaced
+	}
+	return value
+}
+
+// lookupRaw 返回占位符引用的原始值，环境变量也可以用原名引用
+func (e *Environment) lookupRaw(name string) (string, bool) {
+	for _, src := range e.sources {
+		if p, ok := lookupIn(src, name); ok {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Keys 返回以 prefix 开头的所有配置项的键（规范形式去重，保留最高优先级的写法），按字母排序
+// 环境变量只有在与配置文件中的键或其顶级前缀一致时才列出，避免列出无关的系统变量
+func (e *Environment) Keys(prefix string) []string {
+	want := canonical(prefix)
+	roots := make(map[string]bool)
+	for _, src := range e.sources {
+		if src.name == SourceEnvironment {
+			continue
+		}
+		for _, p := range src.props {
+			root, _, _ := strings.Cut(canonical(p.Key), ".")
+			roots[root] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, src := range e.sources {
+		for _, p := range src.props {
+			c := canonical(p.Key)
+			if seen[c] {
+				continue
+			}
+			if want != "" && c != want && !strings.HasPrefix(c, want+".") && !strings.HasPrefix(c, want+"[") {
+				continue
+			}
+			if src.name == SourceEnvironment {
+				root, _, _ := strings.Cut(c, ".")
+				if !roots[root] || !strings.Contains(c, ".") {
+					continue
+				}
+			}
+			seen[c] = true
+			keys = append(keys, p.Key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return canonical(keys[i]) < canonical(keys[j]) })
+	return keys
+}
+
+// Similar 返回与 key 相近的配置项：同一前缀下的键，或最后一段相同的键
+// 用于提示拼写错误，例如 spring.datasource.jdbc-url 与 spring.datasource.url
+func (e *Environment) Similar(key string) []string {
+	want := canonical(key)
+	parent, last := want, want
+	if i := strings.LastIndexByte(want, '.'); i >= 0 {
+		parent, last = want[:i], want[i+1:]
+	}
+	var similar []string
+	for _, k := range e.Keys("") {
+		c := canonical(k)
+		if c == want {
+			continue
+		}
+		kParent, kLast := c, c
+		if i := strings.LastIndexByte(c, '.'); i >= 0 {
+			kParent, kLast = c[:i], c[i+1:]
+		}
+		if kParent == parent || kLast == last || strings.HasPrefix(c, want) {
+			similar = append(similar, k)
+		}
+	}
+	return similar
+}
+
+// appendUnique 追加不重复的字符串
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,220 @@
+package springconfig
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"src/main/resources/application.yml": `spring:
+  profiles:
+    active: dev
+  datasource:
+    url: jdbc:mysql://${DB_HOST:localhost}:3306/app
+    username: app
+    driverClassName: com.mysql.cj.jdbc.Driver
+server:
+  port: 8080
+---
+spring:
+  config:
+    activate:
+      on-profile: prod
+server:
+  port: 80
+`,
+		"src/main/resources/application-dev.properties": "spring.datasource.username=dev\\\n  _user\nspring.datasource.password=${DB_PASSWORD}\n",
+		"src/test/resources/application.yml":            "server:\n  port: 1\n",
+		"config/application.properties":                 "spring.datasource.driver-class-name=org.h2.Driver\n",
+	})
+
+	env, err := Load(Options{
+		Root:    root,
+		Command: "DB_HOST=db.internal java -Dserver.port=9090 -jar app.jar --spring.datasource.url=jdbc:h2:mem:test",
+		Env:     map[string]string{"SPRING_DATASOURCE_USERNAME": "from_env", "HOME": "/root"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(env.Profiles, ",") != "dev" || !strings.HasSuffix(env.ProfilesFrom, "application.yml:3") {
+		t.Errorf("profile 错误: %v 来自 %s", env.Profiles, env.ProfilesFrom)
+	}
+	if len(env.Files) != 3 {
+		t.Errorf("应加载3个配置文件（不含测试资源）: %v", env.Files)
+	}
+
+	tests := []struct {
+		key, value, source string
+	}{
+		{"server.port", "9090", SourceSystemProp},
+		{"spring.datasource.url", "jdbc:h2:mem:test", SourceCommandLine},
+		{"spring.datasource.username", "from_env", SourceEnvironment},
+		{"spring.datasource.driver-class-name", "org.h2.Driver", "application.properties"},
+	}
+	for _, tt := range tests {
+		r, ok := env.Get(tt.key)
+		if !ok {
+			t.Errorf("缺少配置项 %s", tt.key)
+			continue
+		}
+		if r.Value != tt.value || !strings.HasSuffix(r.Property.Source, tt.source) {
+			t.Errorf("%s = %q 来自 %s，期望 %q 来自 %s", tt.key, r.Value, r.Property.Source, tt.value, tt.source)
+		}
+	}
+
+	// 宽松绑定：driverClassName 被 config/ 下的 driver-class-name 覆盖
+	r, _ := env.Get("spring.datasource.driverClassName")
+	if len(r.Overridden) != 1 || r.Overridden[0].Value != "com.mysql.cj.jdbc.Driver" {
+		t.Errorf("被覆盖的值错误: %+v", r.Overridden)
+	}
+
+	// 续行和无法解析的占位符
+	r, _ = env.Get("spring.datasource.password")
+	if r.Value != "${DB_PASSWORD}" || len(r.Unresolved) != 1 || r.Unresolved[0] != "DB_PASSWORD" {
+		t.Errorf("未解析的占位符错误: %+v", r)
+	}
+	if len(r.Overridden) != 0 || r.Property.Line != 3 {
+		t.Errorf("续行后的行号错误: %+v", r.Property)
+	}
+}
+
+func TestLoadProfileDocuments(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"application.yml": "server:\n  port: 8080\n---\nspring.config.activate.on-profile: prod\nserver:\n  port: 80\n",
+		"application-prod.yml": `app:
+  url: http://${server.host:example.com}:${server.port}/
+`,
+	})
+	env, err := Load(Options{Root: root, Profiles: []string{"prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := env.Get("server.port")
+	if r.Value != "80" || r.Property.Line != 6 {
+		t.Errorf("profile 文档未生效: %+v", r)
+	}
+	r, _ = env.Get("app.url")
+	if r.Value != "http://example.com:80/" {
+		t.Errorf("占位符解析错误: %q", r.Value)
+	}
+	if keys := env.Keys("server"); strings.Join(keys, ",") != "server.port" {
+		t.Errorf("前缀查询错误: %v", keys)
+	}
+}
+
+func TestResolveCircularPlaceholder(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"application.properties": "app.home=${app.home}/sub\na=${b}\nb=x-${a}\nc=${b}\n",
+	})
+	env, err := Load(Options{Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key, value, cycle string
+	}{
+		{"app.home", "${app.home}/sub", "app.home（循环引用）"},
+		{"a", "x-${a}", "a（循环引用）"},
+		{"c", "x-${b}", "b（循环引用）"},
+	}
+	for _, tt := range tests {
+		r, _ := env.Get(tt.key)
+		if r.Value != tt.value || len(r.Unresolved) != 1 || r.Unresolved[0] != tt.cycle {
+			t.Errorf("%s 的循环引用解析错误: %q %v", tt.key, r.Value, r.Unresolved)
+		}
+	}
+}
+
+func TestLoadModule(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"order-service/src/main/resources/application.yml": "server:\n  port: 8081\n",
+		"user-service/src/main/resources/application.yml":  "server:\n  port: 8082\nuser.only: true\n",
+		"config/application.properties":                    "shared=1\n",
+	})
+
+	env, err := Load(Options{Root: root, Command: "java -jar order-service/target/order-service-1.0.jar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(env.Modules, ",") != "order-service,user-service" || env.Module != "order-service" || len(env.Files) != 2 {
+		t.Fatalf("模块选择错误: %v %q %v", env.Modules, env.Module, env.Files)
+	}
+	if r, _ := env.Get("server.port"); r.Value != "8081" {
+		t.Errorf("应使用 order-service 的配置: %+v", r)
+	}
+	if _, ok := env.Get("user.only"); ok {
+		t.Errorf("不应加载其他模块的配置")
+	}
+	if _, ok := env.Get("shared"); !ok {
+		t.Errorf("应加载不属于任何模块的配置")
+	}
+
+	env, err = Load(Options{Root: root, Module: "user-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := env.Get("server.port"); env.Module != "user-service" || r.Value != "8082" {
+		t.Errorf("指定模块错误: %q %+v", env.Module, r)
+	}
+
+	// 无法确定模块时合并所有配置
+	env, err = Load(Options{Root: root, Command: "java -cp app.jar com.example.App"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Module != "" || len(env.Files) != 3 {
+		t.Errorf("无法确定模块时应合并: %q %v", env.Module, env.Files)
+	}
+}
+
+func TestProfilesFromLog(t *testing.T) {
+	log := `2025-09-23 19:46:55.000  INFO 1 --- [main] com.example.App : No active profile set, falling back to default profiles: default
+2025-09-23 19:50:55.000  INFO 1 --- [main] com.example.App : The following 2 profiles are active: "prod", "mysql"
+`
+	profiles, err := ProfilesFromLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(profiles, ",") != "prod,mysql" {
+		t.Errorf("profile 错误: %v", profiles)
+	}
+}
+
+func TestLoadOpen(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"src/main/resources/application.yml":            "server:\n  port: 8080\n",
+		"src/main/resources/application-dev.properties": "server.port=9090\n",
+	})
+	// 配置文件通过 Open 读取，打开失败的文件记为无法解析
+	var opened []string
+	env, err := Load(Options{
+		Root:     root,
+		Profiles: []string{"dev"},
+		Open: func(path string) (io.ReadCloser, error) {
+			opened = append(opened, filepath.Base(path))
+			if strings.HasSuffix(path, ".properties") {
+				return nil, errors.New("拒绝访问 " + path)
+			}
+			return os.Open(path)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opened) != 2 {
+		t.Errorf("应通过 Open 读取全部配置文件: %v", opened)
+	}
+	if len(env.Errors) != 1 || !strings.Contains(env.Errors[0], "拒绝访问") {
+		t.Errorf("错误记录不正确: %v", env.Errors)
+	}
+	if r, _ := env.Get("server.port"); r.Value != "8080" {
+		t.Errorf("不应读取被拒绝的文件: %+v", r)
+	}
+}
@@ -0,0 +1,287 @@
+package springconfig
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Property 一个配置项在某个配置源中的值
+type Property struct {
+	Key    string // 配置源中书写的键，如 spring.datasource.driverClassName
+	Value  string // 原始值，可能包含 ${...} 占位符
+	Source string // 配置源：文件路径、"命令行参数"、"系统属性"或"环境变量"
+	Line   int    // 文件中的行号，非文件来源为0
+}
+
+// Location 返回 "来源:行号" 形式的位置
+func (p Property) Location() string {
+	if p.Line > 0 {
+		return p.Source + ":" + strconv.Itoa(p.Line)
+	}
+	return p.Source
+}
+
+// document 配置文件中的一个文档，YAML 以 "---" 分隔，properties 以 "#---" 分隔
+type document struct {
+	onProfile  string // spring.config.activate.on-profile 或旧版 spring.profiles，空表示总是生效
+	properties []Property
+}
+
+// onProfileKeys 限定文档生效 profile 的配置项
+var onProfileKeys = []string{"spring.config.activate.on-profile", "spring.profiles"}
+
+// parseFile 打开并按扩展名解析配置文件
+func parseFile(open func(string) (io.ReadCloser, error), path string) ([]document, error) {
+	file, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".yml") || strings.HasSuffix(lower, ".yaml") {
+		return parseYAML(data, path)
+	}
+	return parseProperties(data, path)
+}
+
+// parseYAML 解析多文档 YAML，将嵌套结构展开为点分隔的键，列表元素展开为 key[i]
+func parseYAML(data []byte, source string) ([]document, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []document
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("解析 %s 失败: %w", source, err)
+		}
+		var props []Property
+		flattenYAML(&node, "", source, &props)
+		docs = append(docs, newDocument(props))
+	}
+	return docs, nil
+}
+
+// flattenYAML 递归展开 YAML 节点
+func flattenYAML(node *yaml.Node, prefix, source string, props *[]Property) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			flattenYAML(child, prefix, source, props)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenYAML(node.Content[i+1], key, source, props)
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			*props = append(*props, Property{Key: prefix, Source: source, Line: node.Line})
+		}
+		for i, child := range node.Content {
+			flattenYAML(child, prefix+"["+strconv.Itoa(i)+"]", source, props)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			flattenYAML(node.Alias, prefix, source, props)
+		}
+	case yaml.ScalarNode:
+		if prefix == "" {
+			return
+		}
+		value := node.Value
+		if node.Tag == "!!null" {
+			value = ""
+		}
+		*props = append(*props, Property{Key: prefix, Value: value, Source: source, Line: node.Line})
+	}
+}
+
+// parseProperties 解析 .properties 文件，支持续行、转义和 "#---" 多文档分隔
+func parseProperties(data []byte, source string) ([]document, error) {
+	var docs []document
+	var props []Property
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		start := lineNo
+		if line == "#---" || line == "!---" {
+			docs = append(docs, newDocument(props))
+			props = nil
+			continue
+		}
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// 以奇数个反斜杠结尾的行与下一行相连
+		for continues(line) && scanner.Scan() {
+			lineNo++
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+		key, value := splitProperty(line)
+		props = append(props, Property{Key: unescape(key), Value: unescape(value), Source: source, Line: start})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", source, err)
+	}
+	return append(docs, newDocument(props)), nil
+}
+
+// continues 判断 properties 的一行是否以续行符结尾
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty 按第一个未转义的 '='、':' 或空白拆分键和值
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return strings.TrimRight(line[:i], " \t\f"), strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+// unescape 处理 properties 中的转义字符
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// newDocument 从文档的配置项中取出生效条件
+func newDocument(props []Property) document {
+	doc := document{properties: props}
+	for _, p := range props {
+		for _, key := range onProfileKeys {
+			if canonical(p.Key) == canonical(key) {
+				doc.onProfile = p.Value
+			}
+		}
+	}
+	return doc
+}
+
+// activeFor 判断文档在给定的 profile 下是否生效
+// 支持逗号分隔的列表、"|"、"&" 和 "!" 取反，例如 "dev | test"、"!prod"
+func (d document) activeFor(profiles []string) bool {
+	if d.onProfile == "" {
+		return true
+	}
+	active := make(map[string]bool)
+	for _, p := range profiles {
+		active[p] = true
+	}
+	for _, alternative := range strings.FieldsFunc(d.onProfile, func(r rune) bool { return r == ',' || r == '|' }) {
+		matched := true
+		for _, term := range strings.Split(alternative, "&") {
+			term = strings.Trim(strings.TrimSpace(term), "()")
+			negate := strings.HasPrefix(term, "!")
+			term = strings.TrimSpace(strings.TrimPrefix(term, "!"))
+			if term != "" && active[term] == negate {
+				matched = false
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// profilesPattern 匹配 Spring Boot 启动时输出的激活 profile
+var profilesPattern = regexp.MustCompile(`The following (?:\d+ )?profiles? (?:is|are) active: (.+)$|No active profile set, falling back to (?:\d+ )?default profiles?: (.+)$`)
+
+// ProfilesFromLog 返回日志中最后一次启动时激活的 profile，没有找到时返回 nil
+func ProfilesFromLog(r io.Reader) ([]string, error) {
+	var profiles []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := profilesPattern.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+		if m == nil {
+			continue
+		}
+		list := m[1]
+		if list == "" {
+			list = m[2]
+		}
+		profiles = splitProfiles(list)
+	}
+	return profiles, scanner.Err()
+}
+
+// splitProfiles 拆分逗号分隔且可能带引号的 profile 列表
+func splitProfiles(list string) []string {
+	var profiles []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.Trim(strings.TrimSpace(p), `"'`)
+		if p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
@@ -0,0 +1,350 @@
+package threaddump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HangKind 启动线程阻塞的原因
+type HangKind string
+
+const (
+	HangDeadlock     HangKind = "deadlock"      // 参与了死锁
+	HangDNS          HangKind = "dns"           // DNS 解析
+	HangConfigCenter HangKind = "config_center" // 从 Nacos、Apollo 等配置中心拉取配置
+	HangDataSource   HangKind = "datasource"    // 数据库连接池获取或建立连接
+	HangInitLock     HangKind = "init_lock"     // @PostConstruct/afterPropertiesSet 中等待锁
+	HangLock         HangKind = "lock"          // 等待其他线程持有的锁
+	HangNetwork      HangKind = "network"       // 网络连接或读取
+	HangWaiting      HangKind = "waiting"       // 等待其他线程完成，如 Future.get、CountDownLatch.await
+	HangRunning      HangKind = "running"       // 正在执行
+)
+
+// Group 状态和调用栈都相同的一组线程
+type Group struct {
+	State   string
+	Frames  []string
+	Threads []string
+}
+
+// DeadlockThread 死锁环中的一个线程
+type DeadlockThread struct {
+	Name    string
+	State   string
+	Frame   string // 栈顶帧
+	Waiting Lock   // 等待的锁
+	HeldBy  string // 持有该锁的线程
+}
+
+// Deadlock 一个锁等待环
+type Deadlock struct {
+	Threads []DeadlockThread
+}
+
+// Hang 启动线程阻塞的位置和原因
+type Hang struct {
+	Kind   HangKind
+	Thread string
+	State  string
+	Frame  string // 说明原因的栈帧
+	Caller string // 最近的应用代码栈帧
+	Owner  string // 持有所等待的锁的线程
+	// Stuck 多次转储中该线程的栈顶帧都相同，没有进展
+	Stuck       bool
+	Description string
+}
+
+// Analysis 线程转储的分析结果，基于最后一次转储
+type Analysis struct {
+	Dumps             int
+	Time              string
+	Threads           int // Java 线程数
+	VMThreads         int // GC、编译等 JVM 内部线程数
+	States            map[string]int
+	Groups            []Group
+	Deadlocks         []Deadlock
+	ReportedDeadlocks int
+	Hangs             []Hang
+	Main              *Thread
+}
+
+// startupThreads 执行启动流程的线程，devtools 使用 restartedMain，外置 Tomcat 使用 localhost-startStop-N
+var startupThreads = []string{"main", "restartedMain", "localhost-startStop-"}
+
+// hangRule 按栈帧识别阻塞原因，按顺序匹配
+type hangRule struct {
+	kind   HangKind
+	frames []string // 栈帧的前缀或包含的方法
+	desc   string
+}
+
+var hangRules = []hangRule{
+	{HangDNS, []string{
+		"java.net.Inet4AddressImpl.lookupAllHostAddr", "java.net.Inet6AddressImpl.lookupAllHostAddr",
+		"java.net.Inet4AddressImpl.getHostByAddr", "java.net.Inet6AddressImpl.getHostByAddr",
+		"java.net.InetAddress.getLocalHost",
+	}, "阻塞在DNS解析，主机名（包括本机主机名）无法解析时每次都会等待DNS超时；检查 /etc/hosts 和 /etc/resolv.conf"},
+	{HangConfigCenter, []string{
+		"com.alibaba.nacos.", "com.alibaba.cloud.nacos.", "com.ctrip.framework.apollo.",
+		"org.springframework.cloud.config.client.", "org.springframework.cloud.consul.", "com.ecwid.consul.",
+	}, "阻塞在从配置中心拉取配置，检查配置中心地址、网络连通性、命名空间和超时设置"},
+	{HangDataSource, []string{
+		"com.zaxxer.hikari.", "com.alibaba.druid.pool.", "org.apache.commons.dbcp", "org.apache.tomcat.jdbc.pool.",
+		"com.mchange.v2.c3p0.", "oracle.ucp.", "com.mysql.", "org.postgresql.", "oracle.jdbc.",
+		"com.microsoft.sqlserver.", "org.mariadb.",
+	}, "阻塞在数据库连接池获取或建立连接，检查数据库地址、网络、账号和连接池超时（如 connectionTimeout、initializationFailTimeout）"},
+}
+
+// initFrames 执行 Bean 初始化方法的栈帧
+var initFrames = []string{
+	"org.springframework.beans.factory.annotation.InitDestroyAnnotationBeanPostProcessor",
+	"org.springframework.beans.factory.support.AbstractAutowireCapableBeanFactory.invokeInitMethods",
+}
+
+// networkFrames 网络连接或读取的栈帧
+var networkFrames = []string{
+	"java.net.SocketInputStream.socketRead0", "java.net.PlainSocketImpl.socketConnect", "sun.nio.ch.Net.poll",
+	"sun.nio.ch.Net.connect0", "sun.nio.ch.NioSocketImpl.park", "sun.nio.ch.NioSocketImpl.timedRead",
+}
+
+// frameworkPackages 不属于应用代码的包
+var frameworkPackages = []string{
+	"java.", "javax.", "jakarta.", "jdk.", "sun.", "com.sun.", "kotlin.", "scala.",
+	"org.springframework.", "org.apache.", "org.hibernate.", "org.mybatis.", "org.slf4j.", "ch.qos.logback.",
+	"com.zaxxer.", "com.alibaba.", "com.ctrip.", "com.ecwid.", "com.mysql.", "org.postgresql.", "oracle.",
+	"com.microsoft.", "org.mariadb.", "com.mchange.", "io.netty.", "io.undertow.", "org.eclipse.",
+	"com.fasterxml.", "com.google.", "reactor.", "io.micrometer.", "io.grpc.", "feign.", "net.bytebuddy.",
+}
+
+// Analyze 分析最后一次转储：按状态和调用栈分组，检测死锁，找出启动线程阻塞的原因；
+// 有多次转储时比较启动线程是否有进展
+func Analyze(dumps []*Dump) Analysis {
+	a := Analysis{Dumps: len(dumps), States: make(map[string]int)}
+	if len(dumps) == 0 {
+		return a
+	}
+	dump := dumps[len(dumps)-1]
+	a.Time = dump.Time
+	a.ReportedDeadlocks = dump.ReportedDeadlocks
+
+	byName := make(map[string]*Thread)
+	groups := make(map[string]*Group)
+	for _, t := range dump.Threads {
+		if t.State == "" {
+			a.VMThreads++
+			continue
+		}
+		a.Threads++
+		a.States[t.State]++
+		byName[t.Name] = t
+		key := t.State + "\n" + strings.Join(t.Frames, "\n")
+		if groups[key] == nil {
+			groups[key] = &Group{State: t.State, Frames: t.Frames}
+		}
+		groups[key].Threads = append(groups[key].Threads, t.Name)
+	}
+	for _, g := range groups {
+		a.Groups = append(a.Groups, *g)
+	}
+	sort.Slice(a.Groups, func(i, j int) bool {
+		if len(a.Groups[i].Threads) != len(a.Groups[j].Threads) {
+			return len(a.Groups[i].Threads) > len(a.Groups[j].Threads)
+		}
+		return a.Groups[i].Threads[0] < a.Groups[j].Threads[0]
+	})
+
+	owners := lockOwners(dump.Threads)
+	a.Deadlocks = deadlocks(dump.Threads, owners)
+	inDeadlock := make(map[string]bool)
+	for _, d := range a.Deadlocks {
+		for _, t := range d.Threads {
+			inDeadlock[t.Name] = true
+		}
+	}
+
+	for _, t := range dump.Threads {
+		if t.State == "" || !isStartupThread(t.Name) {
+			continue
+		}
+		if t.Name == "main" {
+			a.Main = t
+		}
+		hang := detectHang(t, owners, byName, inDeadlock[t.Name])
+		hang.Stuck = stuck(dumps, t)
+		a.Hangs = append(a.Hangs, hang)
+	}
+	return a
+}
+
+// lockOwners 返回锁地址到持有线程的映射
+func lockOwners(threads []*Thread) map[string]*Thread {
+	owners := make(map[string]*Thread)
+	for _, t := range threads {
+		for _, lock := range t.Held() {
+			owners[lock.Address] = t
+		}
+	}
+	return owners
+}
+
+// deadlocks 找出锁等待环，每个线程最多等待一个锁，沿等待关系走到重复的线程即为环
+func deadlocks(threads []*Thread, owners map[string]*Thread) []Deadlock {
+	next := func(t *Thread) *Thread {
+		if lock := t.WaitingFor(); lock != nil {
+			if owner := owners[lock.Address]; owner != nil && owner != t {
+				return owner
+			}
+		}
+		return nil
+	}
+
+	var result []Deadlock
+	done := make(map[*Thread]bool)
+	for _, start := range threads {
+		if done[start] {
+			continue
+		}
+		index := make(map[*Thread]int)
+		var path []*Thread
+		t := start
+		for t != nil && !done[t] {
+			if i, ok := index[t]; ok {
+				result = append(result, deadlock(path[i:], next))
+				break
+			}
+			index[t] = len(path)
+			path = append(path, t)
+			t = next(t)
+		}
+		for _, p := range path {
+			done[p] = true
+		}
+	}
+	return result
+}
+
+// deadlock 将锁等待环转换为结果
+func deadlock(cycle []*Thread, next func(*Thread) *Thread) Deadlock {
+	var d Deadlock
+	for _, t := range cycle {
+		dt := DeadlockThread{Name: t.Name, State: t.State, Waiting: *t.WaitingFor(), HeldBy: next(t).Name}
+		if len(t.Frames) > 0 {
+			dt.Frame = t.Frames[0]
+		}
+		d.Threads = append(d.Threads, dt)
+	}
+	return d
+}
+
+// isStartupThread 判断是否为执行启动流程的线程
+func isStartupThread(name string) bool {
+	for _, prefix := range startupThreads {
+		if name == prefix || strings.HasSuffix(prefix, "-") && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectHang 根据调用栈判断启动线程阻塞的原因
+func detectHang(t *Thread, owners map[string]*Thread, byName map[string]*Thread, deadlocked bool) Hang {
+	hang := Hang{Thread: t.Name, State: t.State, Caller: applicationFrame(t.Frames)}
+	if len(t.Frames) > 0 {
+		hang.Frame = t.Frames[0]
+	}
+	waiting := t.WaitingFor()
+	if waiting != nil {
+		if owner := owners[waiting.Address]; owner != nil && owner != t {
+			hang.Owner = owner.Name
+		}
+	}
+
+	if deadlocked {
+		hang.Kind = HangDeadlock
+		hang.Description = fmt.Sprintf("%s 线程与 %s 等线程互相等待对方持有的锁，形成死锁，启动永远无法完成", t.Name, hang.Owner)
+		return hang
+	}
+	for _, rule := range hangRules {
+		if i := findFrame(t.Frames, rule.frames); i >= 0 {
+			hang.Kind, hang.Frame = rule.kind, t.Frames[i]
+			hang.Description = t.Name + " 线程" + rule.desc
+			return hang
+		}
+	}
+	if waiting != nil {
+		hang.Kind = HangLock
+		if i := findFrame(t.Frames, initFrames); i >= 0 {
+			hang.Kind = HangInitLock
+		}
+		hang.Description = fmt.Sprintf("%s 线程在等待锁 <%s>（%s）", t.Name, waiting.Address, waiting.Class)
+		if hang.Owner != "" {
+			hang.Description += "，该锁由 " + hang.Owner + " 线程持有"
+			if owner := byName[hang.Owner]; owner != nil && len(owner.Frames) > 0 {
+				hang.Description += fmt.Sprintf("（%s，%s）", owner.State, owner.Frames[0])
+			}
+		}
+		if hang.Kind == HangInitLock {
+			hang.Description += "；阻塞发生在 Bean 的 @PostConstruct/afterPropertiesSet 初始化方法中，检查初始化方法中的同步代码和它等待的后台线程"
+		}
+		return hang
+	}
+	if i := findFrame(t.Frames, networkFrames); i >= 0 {
+		hang.Kind, hang.Frame = HangNetwork, t.Frames[i]
+		hang.Description = t.Name + " 线程在等待网络连接或读取，检查被调用方的地址和超时设置"
+		return hang
+	}
+	if t.State == "WAITING" || t.State == "TIMED_WAITING" {
+		hang.Kind = HangWaiting
+		hang.Description = t.Name + " 线程在等待其他线程完成或休眠，查看调用栈中等待的对象，并找出应当完成它的线程"
+		if findFrame(t.Frames, initFrames) >= 0 {
+			hang.Description += "；等待发生在 Bean 的 @PostConstruct/afterPropertiesSet 初始化方法中，被等待的线程如果需要获取 Bean 会被单例锁阻塞"
+		}
+		return hang
+	}
+	hang.Kind = HangRunning
+	hang.Description = t.Name + " 线程正在执行"
+	return hang
+}
+
+// findFrame 返回第一个匹配的栈帧下标，没有时返回 -1
+func findFrame(frames []string, patterns []string) int {
+	for i, frame := range frames {
+		for _, pattern := range patterns {
+			if strings.HasPrefix(frame, pattern) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// applicationFrame 返回最靠近栈顶的应用代码栈帧
+func applicationFrame(frames []string) string {
+	for _, frame := range frames {
+		if findFrame([]string{frame}, frameworkPackages) < 0 {
+			return frame
+		}
+	}
+	return ""
+}
+
+// stuck 判断线程在每次转储中的栈顶帧是否都相同
+func stuck(dumps []*Dump, t *Thread) bool {
+	if len(dumps) < 2 || len(t.Frames) == 0 {
+		return false
+	}
+	for _, dump := range dumps[:len(dumps)-1] {
+		var found *Thread
+		for _, other := range dump.Threads {
+			if other.Name == t.Name {
+				found = other
+				break
+			}
+		}
+		if found == nil || len(found.Frames) == 0 || found.Frames[0] != t.Frames[0] {
+			return false
+		}
+	}
+	return true
+}
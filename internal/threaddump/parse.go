@@ -0,0 +1,210 @@
+package threaddump
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 栈帧中锁信息的动作
+const (
+	ActionLocked      = "locked"
+	ActionWaitingLock = "waiting to lock"              // 等待进入 synchronized
+	ActionRelock      = "waiting to re-lock in wait()" // wait() 返回后等待重新获得监视器
+	ActionWaitingOn   = "waiting on"                   // Object.wait()，已释放监视器
+	ActionParking     = "parking to wait for"          // LockSupport.park，如 ReentrantLock、Condition
+)
+
+// Lock 线程持有或等待的锁
+type Lock struct {
+	Action  string
+	Address string // 如 0x000000076ab62208
+	Class   string // 如 java.lang.Object
+	Frame   int    // 所在栈帧的下标，Locked ownable synchronizers 为 -1
+}
+
+// Thread 线程转储中的一个线程
+type Thread struct {
+	Name   string
+	Daemon bool
+	// Status 线程头中的状态说明，如 "waiting on condition"、"runnable"
+	Status string
+	// State java.lang.Thread.State，如 BLOCKED；JVM 内部线程为空
+	State       string
+	StateDetail string // 如 "on object monitor"
+	Frames      []string
+	Locks       []Lock
+	// Synchronizers Locked ownable synchronizers，如 ReentrantLock
+	Synchronizers []Lock
+}
+
+// Dump 一次线程转储
+type Dump struct {
+	Time    string // 转储前的时间戳行
+	VM      string // 如 "OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode, sharing)"
+	Threads []*Thread
+	// ReportedDeadlocks JVM 自己检测到的死锁数量，"Found N deadlock(s)."
+	ReportedDeadlocks int
+}
+
+var (
+	// 2025-09-23 19:46:55
+	timePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
+	// Full thread dump OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode, sharing):
+	dumpPattern = regexp.MustCompile(`^Full thread dump (.*):$`)
+	// "main" #1 prio=5 os_prio=0 cpu=1234.56ms elapsed=12.34s tid=0x00007f8b24012345 nid=0x3039 waiting on condition  [0x00007f8b2a0fe000]
+	nidPattern = regexp.MustCompile(`\bnid=\S+\s*(.*?)\s*(?:\[0x[0-9a-fA-F]+\])?$`)
+	//    java.lang.Thread.State: BLOCKED (on object monitor)
+	statePattern = regexp.MustCompile(`^\s+java\.lang\.Thread\.State: (\w+)(?: \((.*)\))?`)
+	// 	- waiting to lock <0x000000076ab62208> (a java.lang.Object)
+	lockPattern = regexp.MustCompile(`^\s+- (locked|waiting to lock|waiting to re-lock in wait\(\)|waiting on|parking to wait for)\s+<(0x[0-9a-fA-F]+)> \(a ([^)]+)\)`)
+	// 	- <0x000000076ab62300> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+	synchronizerPattern = regexp.MustCompile(`^\s+- <(0x[0-9a-fA-F]+)> \(a ([^)]+)\)`)
+	// Found 1 deadlock.
+	deadlockPattern = regexp.MustCompile(`^Found (\d+) deadlocks?\.`)
+)
+
+// Parse 解析 jstack、jcmd Thread.print 的输出，或 kill -3 后写入标准输出的日志，
+// 按出现顺序返回其中的所有线程转储
+func Parse(r io.Reader) ([]*Dump, error) {
+	var dumps []*Dump
+	var dump *Dump
+	var thread *Thread
+	var lastTime string
+	inSynchronizers := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if timePattern.MatchString(trimmed) {
+			lastTime = trimmed
+			continue
+		}
+		if m := dumpPattern.FindStringSubmatch(trimmed); m != nil {
+			dump = &Dump{Time: lastTime, VM: m[1]}
+			dumps = append(dumps, dump)
+			thread, lastTime = nil, ""
+			continue
+		}
+		if isThreadHeader(line) {
+			// 没有 "Full thread dump" 行时（如只复制了部分转储）视为一次转储
+			if dump == nil {
+				dump = &Dump{Time: lastTime}
+				dumps = append(dumps, dump)
+			}
+			thread = parseHeader(line)
+			dump.Threads = append(dump.Threads, thread)
+			inSynchronizers = false
+			continue
+		}
+		if dump != nil {
+			if m := deadlockPattern.FindStringSubmatch(trimmed); m != nil {
+				dump.ReportedDeadlocks, _ = strconv.Atoi(m[1])
+				continue
+			}
+		}
+		if thread == nil || trimmed == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "at "):
+			thread.Frames = append(thread.Frames, strings.TrimPrefix(trimmed, "at "))
+		case trimmed == "Locked ownable synchronizers:":
+			inSynchronizers = true
+		case trimmed == "- None":
+		default:
+			if m := statePattern.FindStringSubmatch(line); m != nil {
+				thread.State, thread.StateDetail = m[1], m[2]
+			} else if m := lockPattern.FindStringSubmatch(line); m != nil {
+				thread.Locks = append(thread.Locks, Lock{Action: m[1], Address: m[2], Class: m[3], Frame: len(thread.Frames) - 1})
+			} else if m := synchronizerPattern.FindStringSubmatch(line); m != nil && inSynchronizers {
+				thread.Synchronizers = append(thread.Synchronizers, Lock{Action: ActionLocked, Address: m[1], Class: m[2], Frame: -1})
+			} else if !strings.HasPrefix(trimmed, "- ") {
+				// 转储结束（JNI global refs、Heap 或应用日志），之后的行不属于该线程
+				thread = nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dumps, nil
+}
+
+// isThreadHeader 判断是否为线程头，排除死锁说明中的 "Thread-1": 行
+func isThreadHeader(line string) bool {
+	if !strings.HasPrefix(line, `"`) || strings.HasSuffix(line, `":`) {
+		return false
+	}
+	return strings.Contains(line, " prio=") || strings.Contains(line, " tid=") || strings.Contains(line, " nid=")
+}
+
+// parseHeader 解析线程头
+func parseHeader(line string) *Thread {
+	end := strings.LastIndexByte(line, '"')
+	thread := &Thread{Name: line[1:end]}
+	rest := line[end+1:]
+	thread.Daemon = strings.Contains(rest, " daemon ")
+	if m := nidPattern.FindStringSubmatch(rest); m != nil {
+		thread.Status = m[1]
+	}
+	return thread
+}
+
+// ownableSynchronizers 可被线程独占持有的同步器类名前缀，持有者会出现在 Locked ownable synchronizers 中
+var ownableSynchronizers = []string{
+	"java.util.concurrent.locks.ReentrantLock$",
+	"java.util.concurrent.locks.ReentrantReadWriteLock$",
+	"java.util.concurrent.ThreadPoolExecutor$Worker",
+}
+
+// WaitingFor 返回线程正在等待获得的锁，没有时返回 nil
+// Object.wait() 等待的是通知而不是锁，不算在内；park 只有在 ReentrantLock 等可独占持有的同步器上才是等待锁，
+// 在 FutureTask、CountDownLatch、Condition 等对象上是等待其他线程完成
+func (t *Thread) WaitingFor() *Lock {
+	for i, lock := range t.Locks {
+		switch lock.Action {
+		case ActionWaitingLock, ActionRelock:
+			return &t.Locks[i]
+		case ActionParking:
+			if isOwnable(lock.Class) {
+				return &t.Locks[i]
+			}
+		}
+	}
+	return nil
+}
+
+// isOwnable 判断同步器是否可被线程独占持有
+func isOwnable(class string) bool {
+	for _, prefix := range ownableSynchronizers {
+		if strings.HasPrefix(class, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Held 返回线程持有的锁，包括 ReentrantLock 等 ownable synchronizer
+func (t *Thread) Held() []Lock {
+	// Object.wait() 会释放监视器，JDK 8 仍会在同一对象上打印 locked
+	released := make(map[string]bool)
+	for _, lock := range t.Locks {
+		if lock.Action == ActionWaitingOn {
+			released[lock.Address] = true
+		}
+	}
+	var held []Lock
+	for _, lock := range t.Locks {
+		if lock.Action == ActionLocked && !released[lock.Address] {
+			held = append(held, lock)
+		}
+	}
+	return append(held, t.Synchronizers...)
+}
@@ -0,0 +1,183 @@
+package threaddump
+
+import (
+	"strings"
+	"testing"
+)
+
+const deadlockDump = `12345:
+2025-09-23 19:46:55
+Full thread dump OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode, sharing):
+
+"main" #1 prio=5 os_prio=0 cpu=1234.56ms elapsed=12.34s tid=0x00007f8b24012345 nid=0x3039 waiting for monitor entry  [0x00007f8b2a0fe000]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.CacheLoader.load(CacheLoader.java:42)
+	- waiting to lock <0x000000076ab62208> (a java.lang.Object)
+	- locked <0x000000076ab62300> (a java.util.HashMap)
+	at com.example.CacheLoader.init(CacheLoader.java:30)
+	at org.springframework.beans.factory.annotation.InitDestroyAnnotationBeanPostProcessor$LifecycleElement.invoke(InitDestroyAnnotationBeanPostProcessor.java:389)
+
+   Locked ownable synchronizers:
+	- None
+
+"refresher" #20 daemon prio=5 os_prio=0 cpu=1.00ms elapsed=12.00s tid=0x00007f8b24099999 nid=0x3040 waiting on condition  [0x00007f8b1a0fe000]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.8/Native Method)
+	- parking to wait for  <0x000000076ab62400> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+	at java.util.concurrent.locks.ReentrantLock.lock(java.base@17.0.8/ReentrantLock.java:322)
+	at com.example.Refresher.run(Refresher.java:18)
+	- locked <0x000000076ab62208> (a java.lang.Object)
+
+   Locked ownable synchronizers:
+	- None
+
+"worker" #21 prio=5 os_prio=0 cpu=1.00ms elapsed=12.00s tid=0x00007f8b24088888 nid=0x3041 waiting for monitor entry  [0x00007f8b1b0fe000]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Worker.run(Worker.java:25)
+	- waiting to lock <0x000000076ab62300> (a java.util.HashMap)
+
+   Locked ownable synchronizers:
+	- <0x000000076ab62400> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+
+"Reference Handler" #2 daemon prio=10 os_prio=0 tid=0x00007f8b24011111 nid=0x303a in Object.wait() [0x00007f8b1c0fe000]
+   java.lang.Thread.State: WAITING (on object monitor)
+	at java.lang.Object.wait(Native Method)
+	- waiting on <0x000000076ab10000> (a java.lang.ref.Reference$Lock)
+	at java.lang.ref.Reference.tryHandlePending(Reference.java:191)
+	- locked <0x000000076ab10000> (a java.lang.ref.Reference$Lock)
+
+"VM Thread" os_prio=0 cpu=10.00ms elapsed=12.34s tid=0x00007f8b24022222 nid=0x303b runnable
+
+JNI global refs: 15, weak refs: 0
+
+
+Found one Java-level deadlock:
+=============================
+"main":
+  waiting to lock monitor 0x00007f8b10003f00 (object 0x000000076ab62208, a java.lang.Object),
+  which is held by "refresher"
+
+Java stack information for the threads listed above:
+===================================================
+"main":
+	at com.example.CacheLoader.load(CacheLoader.java:42)
+
+Found 1 deadlock.
+`
+
+func TestParseDeadlock(t *testing.T) {
+	dumps, err := Parse(strings.NewReader(deadlockDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || len(dumps[0].Threads) != 5 || dumps[0].Time != "2025-09-23 19:46:55" || dumps[0].ReportedDeadlocks != 1 {
+		t.Fatalf("解析错误: %+v", dumps)
+	}
+	main := dumps[0].Threads[0]
+	if main.Name != "main" || main.State != "BLOCKED" || main.Status != "waiting for monitor entry" || len(main.Frames) != 3 {
+		t.Fatalf("main 线程解析错误: %+v", main)
+	}
+	if w := main.WaitingFor(); w == nil || w.Address != "0x000000076ab62208" || w.Frame != 0 {
+		t.Errorf("等待的锁错误: %+v", w)
+	}
+	// Object.wait() 已释放监视器
+	if held := dumps[0].Threads[3].Held(); len(held) != 0 {
+		t.Errorf("wait() 中的线程不应持有锁: %+v", held)
+	}
+
+	a := Analyze(dumps)
+	if a.Threads != 4 || a.VMThreads != 1 || a.States["BLOCKED"] != 2 {
+		t.Errorf("统计错误: %+v", a)
+	}
+	// main -> refresher -> worker -> main
+	if len(a.Deadlocks) != 1 || len(a.Deadlocks[0].Threads) != 3 {
+		t.Fatalf("死锁检测错误: %+v", a.Deadlocks)
+	}
+	for _, dt := range a.Deadlocks[0].Threads {
+		if dt.Name == "refresher" && (dt.HeldBy != "worker" || dt.Waiting.Action != ActionParking) {
+			t.Errorf("ReentrantLock 的持有者错误: %+v", dt)
+		}
+	}
+	if len(a.Hangs) != 1 || a.Hangs[0].Kind != HangDeadlock || a.Hangs[0].Owner != "refresher" || a.Hangs[0].Caller != "com.example.CacheLoader.load(CacheLoader.java:42)" {
+		t.Errorf("启动阻塞识别错误: %+v", a.Hangs)
+	}
+}
+
+func TestAnalyzeStartupHang(t *testing.T) {
+	const dns = `"main" #1 prio=5 os_prio=0 tid=0x00007f0001 nid=0x1 runnable [0x00007f0002]
+   java.lang.Thread.State: RUNNABLE
+	at java.net.Inet6AddressImpl.lookupAllHostAddr(Native Method)
+	at java.net.InetAddress$PlatformNameService.lookupAllHostAddr(InetAddress.java:929)
+	at java.net.InetAddress.getLocalHost(InetAddress.java:1500)
+	at com.example.config.NodeId.<init>(NodeId.java:12)
+	at org.springframework.beans.BeanUtils.instantiateClass(BeanUtils.java:172)
+`
+	const hikari = `"main" #1 prio=5 os_prio=0 tid=0x00007f0001 nid=0x1 runnable [0x00007f0002]
+   java.lang.Thread.State: RUNNABLE
+	at sun.nio.ch.Net.poll(Native Method)
+	at sun.nio.ch.NioSocketImpl.park(NioSocketImpl.java:181)
+	at com.mysql.cj.protocol.StandardSocketFactory.connect(StandardSocketFactory.java:153)
+	at com.zaxxer.hikari.pool.HikariPool.checkFailFast(HikariPool.java:555)
+	at com.zaxxer.hikari.HikariDataSource.getConnection(HikariDataSource.java:112)
+`
+	// 两次 kill -3 写入同一个日志，中间夹着应用日志
+	log := "Full thread dump OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode):\n" + dns +
+		"2025-09-23 19:47:00.000  INFO 1 --- [main] c.e.App : still starting\n" +
+		"Full thread dump OpenJDK 64-Bit Server VM (17.0.8+7 mixed mode):\n" + dns
+	dumps, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Analyze(dumps)
+	if a.Dumps != 2 || len(a.Hangs) != 1 || len(a.Main.Frames) != 5 {
+		t.Fatalf("解析错误: %+v", a)
+	}
+	h := a.Hangs[0]
+	if h.Kind != HangDNS || !h.Stuck || h.Frame != "java.net.Inet6AddressImpl.lookupAllHostAddr(Native Method)" || h.Caller != "com.example.config.NodeId.<init>(NodeId.java:12)" {
+		t.Errorf("DNS 阻塞识别错误: %+v", h)
+	}
+
+	dumps, _ = Parse(strings.NewReader(dns + "\n" + hikari))
+	if len(dumps) != 1 {
+		t.Fatalf("没有转储头时应视为一次转储: %d", len(dumps))
+	}
+	a = Analyze([]*Dump{dumps[0], {Threads: dumps[0].Threads[1:]}})
+	if h := a.Hangs[0]; h.Kind != HangDataSource || h.Stuck || !strings.HasPrefix(h.Frame, "com.mysql.") {
+		t.Errorf("数据源阻塞识别错误: %+v", h)
+	}
+
+	// park 在 FutureTask、CountDownLatch 上是等待其他线程完成，不是等待锁
+	parked := func(class, frame string) string {
+		return `"main" #1 prio=5 os_prio=0 tid=0x00007f0001 nid=0x1 waiting on condition [0x00007f0002]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.8/Native Method)
+	- parking to wait for  <0x000000076ab70000> (a ` + class + `)
+	at java.util.concurrent.locks.LockSupport.park(java.base@17.0.8/LockSupport.java:211)
+	at ` + frame + `
+	at com.example.Warmup.afterPropertiesSet(Warmup.java:20)
+
+"loader" #30 prio=5 os_prio=0 tid=0x00007f0003 nid=0x2 runnable [0x00007f0004]
+   java.lang.Thread.State: RUNNABLE
+	at com.example.Warmup.load(Warmup.java:40)
+
+   Locked ownable synchronizers:
+	- <0x000000076ab70000> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+`
+	}
+	tests := []struct {
+		class, frame string
+		kind         HangKind
+		owner        string
+	}{
+		{"java.util.concurrent.FutureTask", "java.util.concurrent.FutureTask.get(java.base@17.0.8/FutureTask.java:190)", HangWaiting, ""},
+		{"java.util.concurrent.CountDownLatch$Sync", "java.util.concurrent.CountDownLatch.await(java.base@17.0.8/CountDownLatch.java:230)", HangWaiting, ""},
+		{"java.util.concurrent.locks.ReentrantLock$NonfairSync", "java.util.concurrent.locks.ReentrantLock.lock(java.base@17.0.8/ReentrantLock.java:322)", HangLock, "loader"},
+	}
+	for _, tt := range tests {
+		dumps, _ := Parse(strings.NewReader(parked(tt.class, tt.frame)))
+		a := Analyze(dumps)
+		if len(a.Hangs) != 1 || a.Hangs[0].Kind != tt.kind || a.Hangs[0].Owner != tt.owner {
+			t.Errorf("park 在 %s 上的识别错误: %+v", tt.class, a.Hangs)
+		}
+	}
+}
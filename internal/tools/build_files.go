@@ -0,0 +1,493 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// buildDependency is one dependency found in a build file or a saved
+// dependency tree.
+type buildDependency struct {
+	GroupID    string
+	ArtifactID string
+	Version    string // resolved version, empty if unknown
+	Scope      string
+	// Origin describes where the dependency was found, e.g. the declaring
+	// pom.xml or the path through a dependency tree.
+	Origin string
+	// ManagedBy names the BOM or parent outside the repository that manages
+	// the version when it could not be resolved.
+	ManagedBy string
+	// Requested is true for versions that were requested but lost conflict
+	// resolution, e.g. Maven's "omitted for conflict with".
+	Requested bool
+	// Resolved is true for versions a dependency tree or lockfile shows on
+	// the classpath, as opposed to versions only declared in a pom.xml.
+	Resolved bool
+}
+
+// key returns groupId:artifactId.
+func (d buildDependency) key() string {
+	return d.GroupID + ":" + d.ArtifactID
+}
+
+// pomFile is the subset of a Maven POM needed to resolve dependency versions.
+type pomFile struct {
+	Parent struct {
+		GroupID      string  `xml:"groupId"`
+		ArtifactID   string  `xml:"artifactId"`
+		Version      string  `xml:"version"`
+		RelativePath *string `xml:"relativePath"`
+	} `xml:"parent"`
+	GroupID              string          `xml:"groupId"`
+	ArtifactID           string          `xml:"artifactId"`
+	Version              string          `xml:"version"`
+	Properties           pomProperties   `xml:"properties"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+}
+
+// pomDependency is a <dependency> element.
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+	Type       string `xml:"type"`
+}
+
+// pomProperties collects the arbitrary child elements of <properties>.
+type pomProperties map[string]string
+
+func (p *pomProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = make(pomProperties)
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// mavenProject is a parsed pom.xml with its resolved parent.
+type mavenProject struct {
+	path   string
+	pom    *pomFile
+	parent *mavenProject
+	// external lists the parent and imported BOMs outside the repository.
+	external string
+
+	props    map[string]string
+	managed  map[string]managedDependency
+	resolved bool
+}
+
+// managedDependency is a dependencyManagement entry. Like Maven, entries of
+// the project and its parents are interpolated with the properties of the
+// project that uses them, entries of an imported BOM with the BOM's own.
+type managedDependency struct {
+	dep pomDependency
+	bom *mavenProject
+}
+
+// groupID returns the project groupId, inherited from the parent if omitted.
+func (p *mavenProject) groupID() string {
+	if p.pom.GroupID != "" {
+		return p.pom.GroupID
+	}
+	return p.pom.Parent.GroupID
+}
+
+// version returns the project version, inherited from the parent if omitted.
+func (p *mavenProject) version() string {
+	if p.pom.Version != "" {
+		return p.pom.Version
+	}
+	return p.pom.Parent.Version
+}
+
+// parseMavenProjects parses the given pom.xml files and links each project to
+// its parent when the parent is part of the same set.
+func parseMavenProjects(paths []string) ([]*mavenProject, []error) {
+	var projects []*mavenProject
+	var errs []error
+	byPath := make(map[string]*mavenProject)
+	byCoordinate := make(map[string]*mavenProject)
+	for _, path := range paths {
+		pom, err := readPom(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		project := &mavenProject{path: path, pom: pom}
+		projects = append(projects, project)
+		byPath[path] = project
+		byCoordinate[project.groupID()+":"+pom.ArtifactID] = project
+	}
+
+	for _, project := range projects {
+		parent := project.pom.Parent
+		if parent.ArtifactID == "" {
+			continue
+		}
+		relative := "../pom.xml"
+		if parent.RelativePath != nil {
+			relative = strings.TrimSpace(*parent.RelativePath)
+		}
+		var candidate *mavenProject
+		if relative != "" {
+			path := filepath.Join(filepath.Dir(project.path), filepath.FromSlash(relative))
+			if !strings.HasSuffix(path, ".xml") {
+				path = filepath.Join(path, "pom.xml")
+			}
+			candidate = byPath[filepath.Clean(path)]
+		}
+		if candidate == nil || candidate.pom.ArtifactID != parent.ArtifactID {
+			candidate = byCoordinate[parent.GroupID+":"+parent.ArtifactID]
+		}
+		if candidate != nil && candidate != project {
+			project.parent = candidate
+		} else {
+			project.external = parent.GroupID + ":" + parent.ArtifactID + ":" + parent.Version
+		}
+	}
+	for _, project := range projects {
+		project.resolve(byCoordinate, 0)
+	}
+	return projects, errs
+}
+
+// readPom parses a pom.xml file.
+func readPom(path string) (*pomFile, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pom pomFile
+	if err := xml.NewDecoder(file).Decode(&pom); err != nil {
+		return nil, &os.PathError{Op: "parse", Path: path, Err: err}
+	}
+	return &pom, nil
+}
+
+// maxParentDepth guards against parent cycles.
+const maxParentDepth = 20
+
+// resolve computes the effective properties and managed versions of the
+// project from its parents and imported BOMs found in the repository.
+func (p *mavenProject) resolve(byCoordinate map[string]*mavenProject, depth int) {
+	if p.resolved || depth > maxParentDepth {
+		return
+	}
+	p.resolved = true
+	p.props = make(map[string]string)
+	p.managed = make(map[string]managedDependency)
+	if p.parent != nil {
+		p.parent.resolve(byCoordinate, depth+1)
+		for k, v := range p.parent.props {
+			p.props[k] = v
+		}
+		for k, v := range p.parent.managed {
+			p.managed[k] = v
+		}
+	}
+	for k, v := range p.pom.Properties {
+		p.props[k] = v
+	}
+	p.props["project.groupId"] = p.groupID()
+	p.props["project.artifactId"] = p.pom.ArtifactID
+	p.props["project.version"] = p.version()
+	p.props["project.parent.version"] = p.pom.Parent.Version
+	p.props["pom.version"] = p.version()
+	p.props["version"] = p.version()
+
+	for _, dep := range p.pom.DependencyManagement {
+		resolved := p.dependency(dep)
+		if dep.Scope == "import" {
+			if bom := byCoordinate[resolved.key()]; bom != nil && bom != p {
+				bom.resolve(byCoordinate, depth+1)
+				for k, v := range bom.managed {
+					if _, ok := p.managed[k]; !ok {
+						if v.bom == nil {
+							v.bom = bom
+						}
+						p.managed[k] = v
+					}
+				}
+			} else {
+				p.external = strings.TrimPrefix(p.external+", "+resolved.key()+":"+resolved.Version, ", ")
+			}
+			continue
+		}
+		p.managed[resolved.key()] = managedDependency{dep: dep}
+	}
+}
+
+// placeholderRef matches a ${property} reference.
+var placeholderRef = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// interpolate replaces ${property} references with the effective properties.
+// Unknown references are left in place.
+func (p *mavenProject) interpolate(value string) string {
+	value = strings.TrimSpace(value)
+	for i := 0; i < 10 && strings.Contains(value, "${"); i++ {
+		replaced := placeholderRef.ReplaceAllStringFunc(value, func(ref string) string {
+			if v, ok := p.props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+		if replaced == value {
+			break
+		}
+		value = replaced
+	}
+	return value
+}
+
+// dependency resolves a <dependency> element of the project.
+func (p *mavenProject) dependency(dep pomDependency) buildDependency {
+	return buildDependency{
+		GroupID:    p.interpolate(dep.GroupID),
+		ArtifactID: p.interpolate(dep.ArtifactID),
+		Version:    p.interpolate(dep.Version),
+		Scope:      dep.Scope,
+	}
+}
+
+// dependencies returns the declared dependencies of the project with their
+// versions resolved from dependencyManagement when omitted.
+func (p *mavenProject) dependencies(origin string) []buildDependency {
+	var deps []buildDependency
+	for _, d := range p.pom.Dependencies {
+		dep := p.dependency(d)
+		dep.Origin = origin
+		if dep.Version == "" {
+			if m, ok := p.managed[dep.key()]; ok {
+				owner := p
+				if m.bom != nil {
+					owner = m.bom
+				}
+				managed := owner.dependency(m.dep)
+				dep.Version = managed.Version
+				if dep.Scope == "" {
+					dep.Scope = managed.Scope
+				}
+			}
+		}
+		if dep.Version == "" || strings.Contains(dep.Version, "${") {
+			dep.Version = ""
+			dep.ManagedBy = p.externalManager()
+		}
+		if dep.Scope == "" {
+			dep.Scope = "compile"
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// externalManager returns the parents and BOMs outside the repository that
+// may manage versions of the project.
+func (p *mavenProject) externalManager() string {
+	var names []string
+	for project, depth := p, 0; project != nil && depth <= maxParentDepth; project, depth = project.parent, depth+1 {
+		if project.external != "" {
+			names = append(names, project.external)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// gradleLockLine matches "group:artifact:version=configurations".
+var gradleLockLine = regexp.MustCompile(`^([\w.-]+):([\w.-]+):([^=\s]+)=(.*)$`)
+
+// parseGradleLockfile reads the dependencies locked by a Gradle lockfile.
+func parseGradleLockfile(path, origin string) ([]buildDependency, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var deps []buildDependency
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		m := gradleLockLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		deps = append(deps, buildDependency{
+			GroupID:    m[1],
+			ArtifactID: m[2],
+			Version:    m[3],
+			Scope:      m[4],
+			Origin:     origin,
+			Resolved:   true,
+		})
+	}
+	return deps, scanner.Err()
+}
+
+var (
+	// mavenTreeLine matches a dependency of `mvn dependency:tree` output
+	// after the "[INFO] " prefix, e.g. "|  \- org.yaml:snakeyaml:jar:1.33:compile".
+	mavenTreeLine = regexp.MustCompile(`^((?:[| ] {2})*)[+\\]- (\(?)([\w.-]+(?::[\w.-]+){3,5})(.*)$`)
+	// mavenTreeRoot matches the project line at the top of a tree.
+	mavenTreeRoot = regexp.MustCompile(`^[\w.-]+(?::[\w.-]+){3,4}$`)
+	// gradleTreeLine matches a dependency of `gradle dependencies` output,
+	// e.g. "|    +--- com.fasterxml.jackson.core:jackson-databind:2.13.0 -> 2.15.2 (*)".
+	gradleTreeLine = regexp.MustCompile(`^((?:[| ] {4})*)[+\\]--- ([\w.-]+):([\w.-]+)(?::(\{[^}]*\}|\S+))?(?: -> (\S+))?(.*)$`)
+	// gradleConfiguration matches the header of a configuration's tree,
+	// e.g. "runtimeClasspath - Runtime classpath of source set 'main'."
+	gradleConfiguration = regexp.MustCompile(`^[a-z]\w* - \S`)
+	// omittedConflict matches Maven's verbose conflict note.
+	omittedConflict = regexp.MustCompile(`omitted for conflict with ([\w.-]+)`)
+	// treeSniff recognizes a saved dependency tree.
+	treeSniff = regexp.MustCompile(`(?m)^(?:\[INFO\] )?[| ]*(?:[+\\]- [\w.-]+:[\w.-]+:|[+\\]--- [\w.-]+:[\w.-]+)`)
+)
+
+// isDependencyTree reports whether the file looks like saved
+// `mvn dependency:tree` or `gradle dependencies` output.
+func isDependencyTree(path string) bool {
+	file, err := openFile(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	head := make([]byte, 64<<10)
+	n, _ := io.ReadFull(file, head)
+	return treeSniff.Match(head[:n])
+}
+
+// parseDependencyTree reads saved `mvn dependency:tree` or `gradle
+// dependencies` output. Each dependency records its path from the root in
+// Origin; versions that lost conflict resolution are returned as Requested.
+func parseDependencyTree(path, origin string) ([]buildDependency, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var deps []buildDependency
+	// The Maven tree of each module starts with the project itself, while
+	// each Gradle configuration starts directly with its dependencies
+	var root string
+	var stack []string // coordinates from the first level to the current depth
+	push := func(depth int, coordinate string) {
+		depth = min(depth, len(stack))
+		stack = append(stack[:depth], coordinate)
+	}
+	via := func(depth int) string {
+		path := stack[:min(depth, len(stack))]
+		if root != "" {
+			path = append([]string{root}, path...)
+		}
+		if len(path) == 0 {
+			return origin
+		}
+		return origin + ": " + strings.Join(path, " > ")
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "[INFO]"), " ")
+
+		if m := mavenTreeLine.FindStringSubmatch(line); m != nil {
+			depth := len(m[1]) / 3
+			dep, ok := mavenCoordinate(m[3])
+			if !ok {
+				continue
+			}
+			dep.Origin = via(depth)
+			dep.Resolved = m[2] == ""
+			push(depth, dep.key()+":"+dep.Version)
+			if m[2] == "(" {
+				// Omitted dependencies are only interesting when they lost a conflict
+				conflict := omittedConflict.FindStringSubmatch(m[4])
+				if conflict == nil {
+					continue
+				}
+				dep.Requested = true
+				dep.Origin += " (omitted for conflict with " + conflict[1] + ")"
+			}
+			deps = append(deps, dep)
+			continue
+		}
+		if m := gradleTreeLine.FindStringSubmatch(line); m != nil {
+			if strings.Contains(m[6], "(c)") {
+				// Dependency constraints only pin versions, they are not dependencies
+				continue
+			}
+			depth := len(m[1]) / 5
+			requested, selected := m[4], m[5]
+			if fields := strings.Fields(strings.Trim(requested, "{}")); len(fields) > 0 {
+				// Rich versions such as {strictly 1.33} name the version last
+				requested = fields[len(fields)-1]
+			}
+			dep := buildDependency{GroupID: m[2], ArtifactID: m[3], Version: requested, Origin: via(depth), Resolved: true}
+			if selected != "" {
+				dep.Version = selected
+				if requested != "" && requested != selected {
+					// Gradle upgraded the requested version during conflict resolution
+					deps = append(deps, buildDependency{
+						GroupID: m[2], ArtifactID: m[3], Version: requested, Requested: true,
+						Origin: dep.Origin + " (upgraded to " + selected + ")",
+					})
+				}
+			}
+			push(depth, dep.key()+":"+dep.Version)
+			deps = append(deps, dep)
+			continue
+		}
+		if mavenTreeRoot.MatchString(line) {
+			// A new module starts a new tree
+			if dep, ok := mavenCoordinate(line); ok {
+				root, stack = dep.key()+":"+dep.Version, nil
+			}
+			continue
+		}
+		if line == "" || gradleConfiguration.MatchString(line) {
+			// A new configuration starts a new tree
+			root, stack = "", nil
+		}
+	}
+	return deps, scanner.Err()
+}
+
+// mavenCoordinate parses groupId:artifactId:type[:classifier]:version[:scope].
+func mavenCoordinate(coordinate string) (buildDependency, bool) {
+	parts := strings.Split(coordinate, ":")
+	dep := buildDependency{}
+	switch len(parts) {
+	case 4:
+		dep = buildDependency{GroupID: parts[0], ArtifactID: parts[1], Version: parts[3]}
+	case 5:
+		dep = buildDependency{GroupID: parts[0], ArtifactID: parts[1], Version: parts[3], Scope: parts[4]}
+	case 6:
+		dep = buildDependency{GroupID: parts[0], ArtifactID: parts[1], Version: parts[4], Scope: parts[5]}
+	default:
+		return dep, false
+	}
+	return dep, true
+}
@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestParseMavenProjects(t *testing.T) {
+	poms := map[string]string{
+		"pom.xml": `<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>2.7.18</version>
+  </parent>
+  <groupId>com.example</groupId>
+  <artifactId>shop</artifactId>
+  <version>1.0.0</version>
+  <packaging>pom</packaging>
+  <properties>
+    <jackson.version>2.13.5</jackson.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>${jackson.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>shop-bom</artifactId>
+        <version>${project.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"bom/pom.xml": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>shop-bom</artifactId>
+  <version>1.0.0</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.yaml</groupId>
+        <artifactId>snakeyaml</artifactId>
+        <version>1.33</version>
+        <scope>runtime</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"order/pom.xml": `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>shop</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>order</artifactId>
+  <properties>
+    <jackson.version>2.15.2</jackson.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.yaml</groupId>
+      <artifactId>snakeyaml</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.springframework.boot</groupId>
+      <artifactId>spring-boot-starter-web</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>common</artifactId>
+      <version>${project.version}</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`,
+		"broken/pom.xml": `<project><artifactId>broken`,
+	}
+	root := testutil.WriteFiles(t, poms)
+	var paths []string
+	for name := range poms {
+		paths = append(paths, filepath.Join(root, filepath.FromSlash(name)))
+	}
+
+	projects, errs := parseMavenProjects(paths)
+	if len(errs) != 1 || len(projects) != 3 {
+		t.Fatalf("expected 3 projects and 1 error, got %d %v", len(projects), errs)
+	}
+	var order *mavenProject
+	for _, p := range projects {
+		if p.pom.ArtifactID == "order" {
+			order = p
+		}
+	}
+	if order == nil || order.parent == nil || order.groupID() != "com.example" || order.version() != "1.0.0" {
+		t.Fatalf("order project not linked to its parent: %+v", order)
+	}
+
+	tests := []struct {
+		key, version, scope, managedBy string
+	}{
+		// The inherited managed version uses the child's property
+		{"com.fasterxml.jackson.core:jackson-databind", "2.15.2", "compile", ""},
+		{"org.yaml:snakeyaml", "1.33", "runtime", ""},
+		{"org.springframework.boot:spring-boot-starter-web", "", "compile", "org.springframework.boot:spring-boot-starter-parent:2.7.18"},
+		{"com.example:common", "1.0.0", "test", ""},
+	}
+	deps := order.dependencies("order/pom.xml")
+	if len(deps) != len(tests) {
+		t.Fatalf("expected %d dependencies, got %+v", len(tests), deps)
+	}
+	for i, tt := range tests {
+		d := deps[i]
+		if d.key() != tt.key || d.Version != tt.version || d.Scope != tt.scope || d.ManagedBy != tt.managedBy || d.Origin != "order/pom.xml" {
+			t.Errorf("dependency %d = %+v, want %+v", i, d, tt)
+		}
+	}
+}
+
+func TestParseDependencyTree(t *testing.T) {
+	tests := []struct {
+		name string
+		tree string
+		want []string // "group:artifact:version origin", requested versions prefixed with "!"
+	}{
+		{
+			name: "maven",
+			tree: `[INFO] --- maven-dependency-plugin:3.6.0:tree (default-cli) @ order ---
+[INFO] com.example:order:jar:1.0.0
+[INFO] +- org.springframework.boot:spring-boot-starter-web:jar:2.7.18:compile
+[INFO] |  +- org.springframework.boot:spring-boot-starter-json:jar:2.7.18:compile
+[INFO] |  |  \- (com.fasterxml.jackson.core:jackson-databind:jar:2.13.5:compile - omitted for conflict with 2.15.2)
+[INFO] |  \- (org.springframework:spring-web:jar:5.3.31:compile - omitted for duplicate)
+[INFO] +- com.fasterxml.jackson.core:jackson-databind:jar:2.15.2:compile
+[INFO] \- org.bouncycastle:bcprov-jdk15on:jar:jdk15:1.70:runtime
+[INFO]
+[INFO] --- maven-dependency-plugin:3.6.0:tree (default-cli) @ user ---
+[INFO] com.example:user:jar:1.0.0
+[INFO] \- org.yaml:snakeyaml:jar:1.33:compile
+`,
+			want: []string{
+				"org.springframework.boot:spring-boot-starter-web:2.7.18 tree.txt: com.example:order:1.0.0",
+				"org.springframework.boot:spring-boot-starter-json:2.7.18 tree.txt: com.example:order:1.0.0 > org.springframework.boot:spring-boot-starter-web:2.7.18",
+				"!com.fasterxml.jackson.core:jackson-databind:2.13.5 tree.txt: com.example:order:1.0.0 > org.springframework.boot:spring-boot-starter-web:2.7.18 > org.springframework.boot:spring-boot-starter-json:2.7.18 (omitted for conflict with 2.15.2)",
+				"com.fasterxml.jackson.core:jackson-databind:2.15.2 tree.txt: com.example:order:1.0.0",
+				"org.bouncycastle:bcprov-jdk15on:1.70 tree.txt: com.example:order:1.0.0",
+				"org.yaml:snakeyaml:1.33 tree.txt: com.example:user:1.0.0",
+			},
+		},
+		{
+			name: "gradle",
+			tree: `
+> Task :dependencies
+
+------------------------------------------------------------
+Root project 'order'
+------------------------------------------------------------
+
+compileClasspath - Compile classpath for source set 'main'.
++--- org.springframework.boot:spring-boot-starter-web -> 2.7.18
+|    +--- org.springframework.boot:spring-boot-starter-json:2.7.18
+|    |    \--- com.fasterxml.jackson.core:jackson-databind:2.13.5 -> 2.15.2
+|    \--- org.springframework:spring-web:5.3.31
++--- com.fasterxml.jackson.core:jackson-databind:2.15.2 (*)
++--- org.springframework:spring-core:5.3.31 (c)
+\--- org.yaml:snakeyaml:{strictly 1.33} -> 1.33
+
+runtimeClasspath - Runtime classpath of source set 'main'.
+\--- com.h2database:h2:2.1.214
+`,
+			want: []string{
+				"org.springframework.boot:spring-boot-starter-web:2.7.18 tree.txt",
+				"org.springframework.boot:spring-boot-starter-json:2.7.18 tree.txt: org.springframework.boot:spring-boot-starter-web:2.7.18",
+				"!com.fasterxml.jackson.core:jackson-databind:2.13.5 tree.txt: org.springframework.boot:spring-boot-starter-web:2.7.18 > org.springframework.boot:spring-boot-starter-json:2.7.18 (upgraded to 2.15.2)",
+				"com.fasterxml.jackson.core:jackson-databind:2.15.2 tree.txt: org.springframework.boot:spring-boot-starter-web:2.7.18 > org.springframework.boot:spring-boot-starter-json:2.7.18",
+				"org.springframework:spring-web:5.3.31 tree.txt: org.springframework.boot:spring-boot-starter-web:2.7.18",
+				"com.fasterxml.jackson.core:jackson-databind:2.15.2 tree.txt",
+				"org.yaml:snakeyaml:1.33 tree.txt",
+				"com.h2database:h2:2.1.214 tree.txt",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tree.txt")
+			if err := os.WriteFile(path, []byte(tt.tree), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if !isDependencyTree(path) {
+				t.Fatal("not recognized as a dependency tree")
+			}
+			deps, err := parseDependencyTree(path, "tree.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range deps {
+				line := fmt.Sprintf("%s:%s %s", d.key(), d.Version, d.Origin)
+				if d.Requested {
+					line = "!" + line
+				} else if !d.Resolved {
+					line = "?" + line
+				}
+				got = append(got, line)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDuplicateArtifacts(t *testing.T) {
+	artifacts := []DependencyArtifact{
+		{Artifact: "dom4j:dom4j"},
+		{Artifact: "org.dom4j:dom4j"},
+		{Artifact: "com.example:core"},
+		{Artifact: "org.other:core"},
+		{Artifact: "commons-logging:commons-logging"},
+		{Artifact: "org.springframework:spring-jcl"},
+	}
+	var got []string
+	for _, d := range duplicateArtifacts(artifacts) {
+		got = append(got, strings.Join(d.Artifacts, ",")+": "+d.Reason)
+	}
+	want := []string{
+		"commons-logging:commons-logging,org.springframework:spring-jcl: all provide package org.apache.commons.logging",
+		"dom4j:dom4j,org.dom4j:dom4j: dom4j:dom4j was relocated to org.dom4j:dom4j",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
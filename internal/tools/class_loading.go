@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/classload"
+)
+
+const (
+	// maxClassMatches caps the load records listed for a query.
+	maxClassMatches = 100
+	// maxDuplicateClasses caps the duplicated classes listed.
+	maxDuplicateClasses = 50
+	// maxClassSources caps the sources listed.
+	maxClassSources = 30
+)
+
+// ClassLoadingInput represents the input parameters for the class_loading tool
+type ClassLoadingInput struct {
+	AbsolutePath string `json:"absolute_path" description:"Absolute path to the output of -verbose:class (JDK 8) or -Xlog:class+load (JDK 9+), e.g. the stdout capture file. Use -Xlog:class+load=debug to also record the classloader."`
+	Class        string `json:"class,omitempty" description:"Optional: Class to look up, e.g. org.slf4j.LoggerFactory, or a package prefix ending with . or *, e.g. org.slf4j."`
+}
+
+// ClassLoad represents one load of a class
+type ClassLoad struct {
+	Class  string `json:"class" description:"Class name"`
+	Jar    string `json:"jar" description:"Jar file name or directory the class came from"`
+	Source string `json:"source" description:"Full source as logged by the JVM"`
+	Loader string `json:"loader,omitempty" description:"Classloader, only known with -Xlog:class+load=debug"`
+	Uptime string `json:"uptime,omitempty" description:"Time since JVM start"`
+}
+
+// DuplicateClass represents a class loaded from more than one source or by more than one classloader
+type DuplicateClass struct {
+	Class string      `json:"class" description:"Class name"`
+	Loads []ClassLoad `json:"loads" description:"Each distinct source and classloader"`
+}
+
+// ClassSource represents the number of classes loaded from a jar or directory
+type ClassSource struct {
+	Jar     string `json:"jar" description:"Jar file name or directory"`
+	Source  string `json:"source" description:"Full source"`
+	Classes int    `json:"classes" description:"Classes loaded from it"`
+}
+
+// ClassLoadingOutput represents the output of the class_loading tool
+type ClassLoadingOutput struct {
+	Path          string           `json:"path" description:"The analyzed trace"`
+	Format        string           `json:"format" description:"unified (-Xlog:class+load) or jdk8 (-verbose:class)"`
+	Loads         int              `json:"loads" description:"Class loads in the trace"`
+	JDKClasses    int              `json:"jdk_classes" description:"Classes loaded from the JDK"`
+	HasLoaders    bool             `json:"has_loaders" description:"Whether the trace records classloaders"`
+	Matches       []ClassLoad      `json:"matches,omitempty" description:"Where the requested class was loaded from"`
+	Duplicates    []DuplicateClass `json:"duplicates" description:"Classes loaded from more than one source or by more than one classloader"`
+	SplitPackages []SplitPackage   `json:"split_packages" description:"Packages whose classes came from more than one jar, a sign of two versions of the same library"`
+	Sources       []ClassSource    `json:"sources" description:"Jars and directories by number of classes loaded, JDK excluded"`
+	Note          string           `json:"note,omitempty" description:"Hints about the result"`
+}
+
+// ClassLoadingTool is a tool that analyzes class loading traces.
+var ClassLoadingTool tool.InvokableTool
+
+func init() {
+	var err error
+	ClassLoadingTool, err = utils.InferTool(
+		"class_loading",
+		"Parses a class loading trace from -verbose:class (JDK 8) or -Xlog:class+load (JDK 9+) and answers which jar a class was actually loaded from and by which classloader. Lists classes loaded from more than one source or by more than one classloader, and packages split across jars. Use it for NoSuchMethodError, NoSuchFieldError, ClassCastException, LinkageError or AbstractMethodError when the application was run with class loading tracing.",
+		classLoading,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create class_loading tool: %v", err))
+	}
+}
+
+// classLoading parses the trace and looks up the requested class.
+func classLoading(ctx context.Context, input ClassLoadingInput) (ClassLoadingOutput, error) {
+	if input.AbsolutePath == "" {
+		return ClassLoadingOutput{}, errors.New("absolute_path is required")
+	}
+	if !filepath.IsAbs(input.AbsolutePath) {
+		return ClassLoadingOutput{}, fmt.Errorf("path must be absolute: %s", input.AbsolutePath)
+	}
+	file, _, err := openLogFile(ctx, input.AbsolutePath)
+	if err != nil {
+		return ClassLoadingOutput{}, err
+	}
+	defer file.Close()
+	trace, err := classload.Parse(file)
+	if err != nil {
+		return ClassLoadingOutput{}, fmt.Errorf("failed to read class loading trace: %w", err)
+	}
+	if len(trace.Loads) == 0 {
+		return ClassLoadingOutput{}, fmt.Errorf("no class loading records found in %s; run the application with -verbose:class (JDK 8) or -Xlog:class+load=debug (JDK 9+)", input.AbsolutePath)
+	}
+
+	output := ClassLoadingOutput{
+		Path:          input.AbsolutePath,
+		Format:        string(trace.Format),
+		Loads:         len(trace.Loads),
+		JDKClasses:    trace.JDKClasses(),
+		HasLoaders:    trace.HasLoaders(),
+		Duplicates:    []DuplicateClass{},
+		SplitPackages: []SplitPackage{},
+		Sources:       []ClassSource{},
+	}
+	if input.Class != "" {
+		matches := trace.Find(input.Class)
+		for _, load := range matches[:min(len(matches), maxClassMatches)] {
+			output.Matches = append(output.Matches, classLoad(load))
+		}
+		if len(matches) == 0 {
+			output.Note = fmt.Sprintf("%s was not loaded; the failing code may not have run yet, or the class is missing from the class path", input.Class)
+		}
+	}
+	duplicates := trace.Duplicates()
+	for _, dup := range duplicates[:min(len(duplicates), maxDuplicateClasses)] {
+		d := DuplicateClass{Class: dup.Class}
+		for _, load := range dup.Loads {
+			d.Loads = append(d.Loads, classLoad(load))
+		}
+		output.Duplicates = append(output.Duplicates, d)
+	}
+	split := trace.SplitPackages()
+	for _, pkg := range split[:min(len(split), maxSplitPackages)] {
+		output.SplitPackages = append(output.SplitPackages, SplitPackage{Package: pkg.Package, Jars: pkg.Jars})
+	}
+	sources := trace.Sources()
+	for _, source := range sources[:min(len(sources), maxClassSources)] {
+		output.Sources = append(output.Sources, ClassSource{Jar: source.Jar, Source: source.Source, Classes: source.Classes})
+	}
+	if !output.HasLoaders && output.Note == "" {
+		output.Note = "The trace does not record classloaders; on JDK 9+ rerun with -Xlog:class+load=debug to see them"
+	}
+	return output, nil
+}
+
+// classLoad converts a load record to the tool output.
+func classLoad(load classload.Load) ClassLoad {
+	result := ClassLoad{Class: load.Class, Jar: classload.JarName(load.Source), Source: load.Source, Loader: load.Loader}
+	if load.Uptime > 0 {
+		result.Uptime = formatSeconds(load.Uptime)
+	}
+	return result
+}
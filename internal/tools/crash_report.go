@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/hserr"
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+const (
+	// defaultCrashPattern is the name of the JVM fatal error log.
+	defaultCrashPattern = "hs_err_pid*.log"
+	// maxCrashFiles caps the crash reports listed.
+	maxCrashFiles = 20
+	// maxVMArgumentLength caps each VM argument line, class paths can be huge.
+	maxVMArgumentLength = 500
+)
+
+// CrashReportInput represents the input parameters for the crash_report tool
+type CrashReportInput struct {
+	Path string `json:"path,omitempty" description:"Optional: Absolute path to an hs_err_pid<N>.log file to parse, or a directory to search. Default: search the log directories, the start command's working directory and -XX:ErrorFile location, the current directory and the temp directory, and parse the newest report."`
+}
+
+// CrashFile represents a found fatal error log
+type CrashFile struct {
+	Path     string `json:"path" description:"Absolute path of the file"`
+	Modified string `json:"modified" description:"Modification time"`
+	Size     int64  `json:"size" description:"File size in bytes"`
+}
+
+// CrashLibrary represents a native library loaded by the crashed JVM
+type CrashLibrary struct {
+	Path   string `json:"path" description:"Library path"`
+	Origin string `json:"origin" description:"application (bundled or extracted by the application, e.g. netty, rocksdb) or system"`
+}
+
+// CrashSummary represents the parsed contents of a fatal error log
+type CrashSummary struct {
+	Path             string         `json:"path" description:"The parsed file"`
+	Kind             string         `json:"kind" description:"crash (signal such as SIGSEGV), native_oom (native memory allocation failed) or internal_error (JVM assertion)"`
+	Signal           string         `json:"signal,omitempty" description:"Signal or exception, e.g. SIGSEGV (0xb)"`
+	PID              int            `json:"pid,omitempty" description:"PID of the crashed JVM"`
+	Time             string         `json:"time,omitempty" description:"When the JVM died and how long it had run"`
+	Error            string         `json:"error,omitempty" description:"Out of Memory Error or Internal Error location in the JVM sources"`
+	ErrorDetail      string         `json:"error_detail,omitempty" description:"Failed guarantee or assertion message"`
+	NativeAllocation string         `json:"native_allocation,omitempty" description:"The native memory allocation that failed"`
+	AllocationBytes  int64          `json:"allocation_bytes,omitempty" description:"Size of the failed allocation"`
+	PossibleReasons  []string       `json:"possible_reasons,omitempty" description:"Reasons the JVM suggests for a native OOM"`
+	ProblematicFrame string         `json:"problematic_frame,omitempty" description:"The frame that crashed: C = native library, V = JVM, J/j = Java code"`
+	OutsideJVM       bool           `json:"outside_jvm,omitempty" description:"The crash happened in native code outside the JVM, i.e. in a JNI library"`
+	JREVersion       string         `json:"jre_version,omitempty" description:"Java runtime version"`
+	JavaVM           string         `json:"java_vm,omitempty" description:"VM build, mode and GC"`
+	CommandLine      string         `json:"command_line,omitempty" description:"JVM command line"`
+	CurrentThread    string         `json:"current_thread,omitempty" description:"Thread that crashed and its state"`
+	Siginfo          string         `json:"siginfo,omitempty" description:"Signal details, si_addr 0 means a null pointer"`
+	NativeFrames     []string       `json:"native_frames,omitempty" description:"Native stack of the crashed thread"`
+	JavaFrames       []string       `json:"java_frames,omitempty" description:"Java stack of the crashed thread"`
+	VMArguments      []string       `json:"vm_arguments,omitempty" description:"jvm_args, java_command and class path"`
+	Heap             []string       `json:"heap,omitempty" description:"Heap usage at the time of death"`
+	Memory           string         `json:"memory,omitempty" description:"Physical memory and swap of the host"`
+	Container        []string       `json:"container,omitempty" description:"Container (cgroup) limits and usage seen by the JVM"`
+	MemoryMappings   int            `json:"memory_mappings" description:"Number of memory mappings of the process"`
+	JavaHome         string         `json:"java_home,omitempty" description:"JDK of the crashed JVM"`
+	JDKLibraries     int            `json:"jdk_libraries" description:"Number of JDK native libraries loaded"`
+	NativeLibraries  []CrashLibrary `json:"native_libraries" description:"Non-JDK native libraries loaded"`
+}
+
+// CrashReportOutput represents the output of the crash_report tool
+type CrashReportOutput struct {
+	Searched     []string      `json:"searched,omitempty" description:"Directories searched"`
+	Inaccessible []string      `json:"inaccessible,omitempty" description:"Directories that may contain crash reports but are outside the allowed directories"`
+	Files        []CrashFile   `json:"files" description:"Fatal error logs found, newest first"`
+	Report       *CrashSummary `json:"report,omitempty" description:"The newest or requested fatal error log, parsed"`
+}
+
+// CrashReportTool is a tool that finds and parses JVM fatal error logs.
+var CrashReportTool tool.InvokableTool
+
+func init() {
+	var err error
+	CrashReportTool, err = utils.InferTool(
+		"crash_report",
+		"Finds and parses JVM fatal error logs (hs_err_pid<N>.log), written when the JVM itself dies from SIGSEGV, a native out of memory or an internal error. These files are written to the working directory (or -XX:ErrorFile, or the temp directory), not to the application log. Returns the signal, problematic frame, native and Java stacks of the crashed thread, the failed native allocation with the possible reasons, VM arguments, heap and container memory, and the loaded non-JDK native libraries. Use it when the process exited without a Java exception, with exit code 134 or 139, or the output mentions 'A fatal error has been detected by the Java Runtime Environment' or 'There is insufficient memory for the Java Runtime Environment to continue'.",
+		crashReport,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create crash_report tool: %v", err))
+	}
+}
+
+// crashReport lists the fatal error logs and parses the newest one.
+func crashReport(ctx context.Context, input CrashReportInput) (CrashReportOutput, error) {
+	box := currentSandbox()
+	output := CrashReportOutput{Files: []CrashFile{}}
+
+	var target string
+	patterns := []string{defaultCrashPattern}
+	if input.Path != "" {
+		if !filepath.IsAbs(input.Path) {
+			return output, fmt.Errorf("path must be absolute: %s", input.Path)
+		}
+		if err := box.Check(input.Path); err != nil {
+			return output, err
+		}
+		info, err := os.Stat(input.Path)
+		if err != nil {
+			return output, fmt.Errorf("failed to access path: %w", err)
+		}
+		if !info.IsDir() {
+			target = input.Path
+		} else {
+			output.Searched = []string{input.Path}
+		}
+	} else {
+		dirs, errorFile := crashSearchDirs(box)
+		if errorFile != "" {
+			patterns = append(patterns, errorFile)
+		}
+		for _, dir := range dirs {
+			if box.Check(dir) != nil {
+				output.Inaccessible = append(output.Inaccessible, dir)
+				continue
+			}
+			output.Searched = append(output.Searched, dir)
+		}
+	}
+
+	if target == "" {
+		files, err := findCrashFiles(ctx, output.Searched, patterns)
+		if err != nil {
+			return output, err
+		}
+		for _, file := range files {
+			if len(output.Files) >= maxCrashFiles {
+				break
+			}
+			if box.Check(file.Path) == nil {
+				output.Files = append(output.Files, file)
+			}
+		}
+		if len(output.Files) == 0 {
+			return output, nil
+		}
+		target = output.Files[0].Path
+	} else if info, err := os.Stat(target); err == nil {
+		output.Files = append(output.Files, CrashFile{Path: target, Modified: info.ModTime().Format(time.DateTime), Size: info.Size()})
+	}
+
+	file, err := box.Open(target)
+	if err != nil {
+		return output, err
+	}
+	defer file.Close()
+	report, err := hserr.Parse(file)
+	if err != nil {
+		return output, fmt.Errorf("failed to parse %s: %w", target, err)
+	}
+	output.Report = crashSummary(target, report)
+	return output, nil
+}
+
+// crashSearchDirs returns the directories where the JVM may have written its
+// fatal error log, and the -XX:ErrorFile name pattern if one is configured.
+func crashSearchDirs(box *Sandbox) ([]string, string) {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if dir == "" {
+			return
+		}
+		if abs, err := filepath.Abs(dir); err == nil && !seen[abs] {
+			seen[abs] = true
+			dirs = append(dirs, abs)
+		}
+	}
+
+	// -XX:ErrorFile=/var/log/app/hs_err_%p.log and cd <dir> && java ... in the start command
+	errorFile := ""
+	args := shell.Split(currentStartCommand())
+	for i, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-XX:ErrorFile="):
+			path := strings.TrimPrefix(arg, "-XX:ErrorFile=")
+			errorFile = strings.ReplaceAll(filepath.Base(path), "%p", "*")
+			if filepath.IsAbs(path) {
+				add(filepath.Dir(path))
+			}
+		case arg == "cd" && i+1 < len(args):
+			add(args[i+1])
+		}
+	}
+	if box != nil {
+		for _, root := range box.Roots() {
+			add(root)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		add(cwd)
+	}
+	add(os.TempDir())
+	return dirs, errorFile
+}
+
+// findCrashFiles lists the files matching the patterns in the directories,
+// newest first.
+func findCrashFiles(ctx context.Context, dirs, patterns []string) ([]CrashFile, error) {
+	type found struct {
+		file    CrashFile
+		modTime time.Time
+	}
+	var files []found
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+			}
+			for _, path := range matches {
+				info, err := os.Stat(path)
+				if err != nil || info.IsDir() || seen[path] {
+					continue
+				}
+				seen[path] = true
+				files = append(files, found{
+					file:    CrashFile{Path: path, Modified: info.ModTime().Format(time.DateTime), Size: info.Size()},
+					modTime: info.ModTime(),
+				})
+			}
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+
+	result := make([]CrashFile, 0, len(files))
+	for _, f := range files {
+		result = append(result, f.file)
+	}
+	return result, nil
+}
+
+// crashSummary converts a parsed report to the tool output.
+func crashSummary(path string, report *hserr.Report) *CrashSummary {
+	summary := &CrashSummary{
+		Path:             path,
+		Kind:             string(report.Kind),
+		Signal:           report.Signal,
+		PID:              report.PID,
+		Time:             report.Time,
+		Error:            report.Error,
+		ErrorDetail:      report.ErrorDetail,
+		NativeAllocation: report.NativeAllocation,
+		AllocationBytes:  report.AllocationBytes,
+		PossibleReasons:  report.PossibleReasons,
+		ProblematicFrame: report.ProblematicFrame,
+		OutsideJVM:       report.OutsideJVM,
+		JREVersion:       report.JREVersion,
+		JavaVM:           report.JavaVM,
+		CommandLine:      truncateRunes(report.CommandLine, maxVMArgumentLength),
+		CurrentThread:    report.CurrentThread,
+		Siginfo:          report.Siginfo,
+		NativeFrames:     report.NativeFrames,
+		JavaFrames:       report.JavaFrames,
+		Heap:             report.Heap,
+		Memory:           report.Memory,
+		Container:        report.Container,
+		MemoryMappings:   report.Mappings,
+		JavaHome:         report.JavaHome,
+		NativeLibraries:  []CrashLibrary{},
+	}
+	for _, arg := range report.VMArguments {
+		summary.VMArguments = append(summary.VMArguments, truncateRunes(arg, maxVMArgumentLength))
+	}
+	for _, lib := range report.Libraries {
+		if lib.Origin == hserr.OriginJDK {
+			summary.JDKLibraries++
+			continue
+		}
+		summary.NativeLibraries = append(summary.NativeLibraries, CrashLibrary{Path: lib.Path, Origin: lib.Origin})
+	}
+	return summary
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const hsErrLog = `#
+# A fatal error has been detected by the Java Runtime Environment:
+#
+#  SIGSEGV (0xb) at pc=0x00007f8b2c0a1b2c, pid=12345, tid=12346
+#
+# Problematic frame:
+# C  [librocksdbjni123.so+0x1b2c]  rocksdb::DB::Get+0x1c
+#
+`
+
+func TestCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hs_err_pid12345.log")
+	writeLog(t, path, hsErrLog)
+	outside := filepath.Join(t.TempDir(), "hs_err_pid1.log")
+	writeLog(t, outside, hsErrLog)
+	link := filepath.Join(dir, "hs_err_pid1.log")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	setTestSandbox(t, dir)
+
+	output, err := crashReport(context.Background(), CrashReportInput{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Report == nil || output.Report.PID != 12345 || output.Report.Signal != "SIGSEGV (0xb)" {
+		t.Errorf("unexpected report: %+v", output.Report)
+	}
+
+	// A link out of the allowed directories is not parsed
+	var accessErr *AccessError
+	if _, err := crashReport(context.Background(), CrashReportInput{Path: link}); !errors.As(err, &accessErr) {
+		t.Errorf("expected an access error, got %v", err)
+	}
+}
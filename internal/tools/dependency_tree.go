@@ -0,0 +1,635 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	defaultMaxArtifacts = 100
+	// maxOrigins caps the places listed for one version of an artifact.
+	maxOrigins = 5
+)
+
+// skippedBuildDirs are not searched for build files: VCS metadata, IDE and
+// build caches, and build output that contains copies of the POMs.
+var skippedBuildDirs = map[string]bool{
+	".git": true, ".svn": true, ".idea": true, ".gradle": true, ".mvn": true,
+	"node_modules": true, "target": true, "build": true, "out": true,
+}
+
+// alignedGroups are groups whose artifacts are released together and must
+// have the same version; mixing them is a classic cause of NoSuchMethodError.
+var alignedGroups = []string{
+	"org.springframework",
+	"org.springframework.boot",
+	"org.springframework.security",
+	"org.springframework.data",
+	"com.fasterxml.jackson",
+	"io.netty",
+	"org.hibernate",
+	"org.hibernate.orm",
+	"org.eclipse.jetty",
+	"org.apache.tomcat.embed",
+	"io.micrometer",
+	"org.slf4j",
+	"ch.qos.logback",
+	"org.apache.logging.log4j",
+	"io.grpc",
+	"org.jetbrains.kotlin",
+	"io.projectreactor.netty",
+}
+
+// knownPackages maps artifacts whose packages do not follow their groupId.
+var knownPackages = map[string][]string{
+	"commons-logging:commons-logging":                    {"org.apache.commons.logging"},
+	"org.slf4j:jcl-over-slf4j":                           {"org.apache.commons.logging"},
+	"org.springframework:spring-jcl":                     {"org.apache.commons.logging"},
+	"log4j:log4j":                                        {"org.apache.log4j"},
+	"org.slf4j:log4j-over-slf4j":                         {"org.apache.log4j"},
+	"org.apache.logging.log4j:log4j-1.2-api":             {"org.apache.log4j"},
+	"com.google.guava:guava":                             {"com.google.common"},
+	"com.google.code.findbugs:jsr305":                    {"javax.annotation"},
+	"javax.annotation:javax.annotation-api":              {"javax.annotation"},
+	"jakarta.annotation:jakarta.annotation-api":          {"jakarta.annotation", "javax.annotation"},
+	"javax.servlet:javax.servlet-api":                    {"javax.servlet"},
+	"javax.servlet:servlet-api":                          {"javax.servlet"},
+	"jakarta.servlet:jakarta.servlet-api":                {"jakarta.servlet", "javax.servlet"},
+	"javax.validation:validation-api":                    {"javax.validation"},
+	"jakarta.validation:jakarta.validation-api":          {"jakarta.validation", "javax.validation"},
+	"javax.xml.bind:jaxb-api":                            {"javax.xml.bind"},
+	"jakarta.xml.bind:jakarta.xml.bind-api":              {"jakarta.xml.bind", "javax.xml.bind"},
+	"javax.persistence:javax.persistence-api":            {"javax.persistence"},
+	"jakarta.persistence:jakarta.persistence-api":        {"jakarta.persistence", "javax.persistence"},
+	"mysql:mysql-connector-java":                         {"com.mysql"},
+	"com.mysql:mysql-connector-j":                        {"com.mysql"},
+	"org.yaml:snakeyaml":                                 {"org.yaml.snakeyaml"},
+	"asm:asm":                                            {"org.objectweb.asm"},
+	"org.ow2.asm:asm":                                    {"org.objectweb.asm"},
+	"cglib:cglib":                                        {"net.sf.cglib"},
+	"org.apache.tomcat.embed:tomcat-embed-core":          {"org.apache.catalina", "org.apache.coyote", "javax.servlet", "jakarta.servlet"},
+	"com.alibaba:druid":                                  {"com.alibaba.druid"},
+	"org.mybatis:mybatis":                                {"org.apache.ibatis"},
+	"org.springframework.boot:spring-boot-autoconfigure": {"org.springframework.boot.autoconfigure"},
+	"com.google.collections:google-collections":          {"com.google.common"},
+}
+
+// knownRelocations maps artifacts that moved to new coordinates without
+// changing their packages to the coordinates they moved to. Having both on
+// the classpath means two copies of the same classes.
+var knownRelocations = map[string]string{
+	"bouncycastle:bcprov-jdk15":         "org.bouncycastle:bcprov-jdk15",
+	"c3p0:c3p0":                         "com.mchange:c3p0",
+	"commons-io:commons-io":             "org.apache.commons:commons-io",
+	"dom4j:dom4j":                       "org.dom4j:dom4j",
+	"javax.mail:mail":                   "com.sun.mail:javax.mail",
+	"javax.servlet:servlet-api":         "javax.servlet:javax.servlet-api",
+	"org.hibernate:hibernate-core":      "org.hibernate.orm:hibernate-core",
+	"org.hibernate:hibernate-validator": "org.hibernate.validator:hibernate-validator",
+	"quartz:quartz":                     "org.quartz-scheduler:quartz",
+}
+
+// DependencyTreeInput represents the input parameters for the dependency_tree tool
+type DependencyTreeInput struct {
+	Path     string `json:"path,omitempty" description:"Optional: Absolute path to the project directory. Default: the configured git repository."`
+	Package  string `json:"package,omitempty" description:"Optional: Java package or fully qualified class from an error such as NoSuchMethodError or NoClassDefFoundError (e.g. 'org.springframework.core.ResolvableType'). Reports the artifacts that provide it."`
+	Artifact string `json:"artifact,omitempty" description:"Optional: Only list artifacts whose 'groupId:artifactId' contains this text, e.g. 'jackson'."`
+	TreeFile string `json:"tree_file,omitempty" description:"Optional: Absolute path to saved 'mvn dependency:tree' or 'gradle dependencies' output outside the project directory."`
+}
+
+// DependencyVersion represents one version of an artifact and where it comes from
+type DependencyVersion struct {
+	Version   string   `json:"version" description:"Version, empty if managed outside the repository"`
+	ManagedBy string   `json:"managed_by,omitempty" description:"Parent or BOM outside the repository that manages the version"`
+	Resolved  bool     `json:"resolved" description:"Whether a dependency tree or lockfile shows this version on the classpath"`
+	Omitted   bool     `json:"omitted,omitempty" description:"Whether this version was only requested and lost conflict resolution"`
+	Origins   []string `json:"origins" description:"Build files and dependency paths that bring in this version"`
+	More      int      `json:"more,omitempty" description:"Number of further origins not listed"`
+}
+
+// DependencyArtifact represents an artifact with all its versions
+type DependencyArtifact struct {
+	Artifact string              `json:"artifact" description:"groupId:artifactId"`
+	Versions []DependencyVersion `json:"versions" description:"Versions found for the artifact"`
+}
+
+// VersionConflict represents an artifact requested in several versions
+type VersionConflict struct {
+	Artifact string   `json:"artifact" description:"groupId:artifactId"`
+	Versions []string `json:"versions" description:"Distinct versions requested"`
+	Resolved []string `json:"resolved,omitempty" description:"Versions that ended up on the classpath according to dependency trees or lockfiles"`
+}
+
+// GroupSkew represents artifacts of a group released together but used in different versions
+type GroupSkew struct {
+	Group    string              `json:"group" description:"The group, e.g. org.springframework"`
+	Versions map[string][]string `json:"versions" description:"Artifacts by version"`
+}
+
+// PackageProvider represents an artifact that provides the requested package
+type PackageProvider struct {
+	Artifact string `json:"artifact" description:"groupId:artifactId"`
+	Version  string `json:"version" description:"Version of the artifact"`
+	Match    string `json:"match" description:"How the package was matched: 'jar' (found in the jar of the local Maven/Gradle cache), 'artifact' (known package or groupId plus artifactId), 'group' (groupId only, weakest)"`
+	Jar      string `json:"jar,omitempty" description:"The jar that was inspected"`
+}
+
+// DuplicateArtifacts represents different artifacts that contain the same classes
+type DuplicateArtifacts struct {
+	Artifacts []string `json:"artifacts" description:"The artifacts"`
+	Reason    string   `json:"reason" description:"Why they are considered duplicates"`
+}
+
+// DependencyTreeOutput represents the output of the dependency_tree tool
+type DependencyTreeOutput struct {
+	Root           string               `json:"root" description:"The project directory"`
+	Sources        []string             `json:"sources" description:"Build files, lockfiles and saved dependency trees that were parsed"`
+	Package        string               `json:"package,omitempty" description:"The requested package"`
+	Providers      []PackageProvider    `json:"providers,omitempty" description:"Artifacts that provide the requested package"`
+	Conflicts      []VersionConflict    `json:"conflicts" description:"Artifacts requested in more than one version"`
+	GroupSkew      []GroupSkew          `json:"group_skew,omitempty" description:"Groups whose artifacts should share a version but do not"`
+	Duplicates     []DuplicateArtifacts `json:"duplicates,omitempty" description:"Different artifacts that contain the same classes"`
+	Artifacts      []DependencyArtifact `json:"artifacts" description:"Artifacts matching the filter (all artifacts without a filter)"`
+	TotalArtifacts int                  `json:"total_artifacts" description:"Number of distinct artifacts found"`
+	Truncated      bool                 `json:"truncated" description:"Whether artifacts were omitted from the list"`
+	Errors         []string             `json:"errors,omitempty" description:"Build files that could not be parsed"`
+}
+
+// DependencyTreeTool is a tool that inspects the declared and resolved
+// dependencies of a Maven or Gradle project.
+var DependencyTreeTool tool.InvokableTool
+
+func init() {
+	var err error
+	DependencyTreeTool, err = utils.InferTool(
+		"dependency_tree",
+		"Inspects the dependencies of a Maven or Gradle project: pom.xml files (resolving parents, properties and dependencyManagement within the project), Gradle lockfiles and saved 'mvn dependency:tree' or 'gradle dependencies' output. Reports artifacts requested in several versions, groups such as Spring or Jackson whose artifacts have mismatched versions, artifacts that duplicate each other's classes, and which artifacts provide a given package. Use it for NoSuchMethodError, NoSuchFieldError, NoClassDefFoundError, ClassNotFoundException and AbstractMethodError.",
+		dependencyTree,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create dependency_tree tool: %v", err))
+	}
+}
+
+// dependencyTree parses the build files of the project and reports version problems.
+func dependencyTree(ctx context.Context, input DependencyTreeInput) (DependencyTreeOutput, error) {
+	root := input.Path
+	if root == "" {
+		root = currentGitRepo()
+	}
+	if root == "" {
+		return DependencyTreeOutput{}, errors.New("path is required when no git repository is configured")
+	}
+	if !filepath.IsAbs(root) {
+		return DependencyTreeOutput{}, fmt.Errorf("path must be absolute: %s", root)
+	}
+	if err := currentSandbox().Check(root); err != nil {
+		return DependencyTreeOutput{}, err
+	}
+	if input.TreeFile != "" {
+		if !filepath.IsAbs(input.TreeFile) {
+			return DependencyTreeOutput{}, fmt.Errorf("tree_file must be absolute: %s", input.TreeFile)
+		}
+		if err := currentSandbox().Check(input.TreeFile); err != nil {
+			return DependencyTreeOutput{}, err
+		}
+	}
+
+	poms, lockfiles, trees, err := findBuildFiles(ctx, root)
+	if err != nil {
+		return DependencyTreeOutput{}, err
+	}
+	if input.TreeFile != "" {
+		trees = append(trees, input.TreeFile)
+	}
+	output := DependencyTreeOutput{Root: root, Sources: []string{}, Conflicts: []VersionConflict{}, Artifacts: []DependencyArtifact{}}
+	if len(poms)+len(lockfiles)+len(trees) == 0 {
+		return output, fmt.Errorf("no pom.xml, Gradle lockfile or saved dependency tree found in %s", root)
+	}
+
+	var deps []buildDependency
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+	projects, errs := parseMavenProjects(poms)
+	for _, err := range errs {
+		output.Errors = append(output.Errors, err.Error())
+	}
+	for _, project := range projects {
+		output.Sources = append(output.Sources, relative(project.path))
+		deps = append(deps, project.dependencies(relative(project.path))...)
+	}
+	for _, path := range lockfiles {
+		locked, err := parseGradleLockfile(path, relative(path))
+		if err != nil {
+			output.Errors = append(output.Errors, err.Error())
+			continue
+		}
+		output.Sources = append(output.Sources, relative(path))
+		deps = append(deps, locked...)
+	}
+	for _, path := range trees {
+		tree, err := parseDependencyTree(path, relative(path))
+		if err != nil {
+			output.Errors = append(output.Errors, err.Error())
+			continue
+		}
+		output.Sources = append(output.Sources, relative(path))
+		deps = append(deps, tree...)
+	}
+
+	artifacts := aggregateDependencies(deps)
+	output.TotalArtifacts = len(artifacts)
+	output.Conflicts = versionConflicts(artifacts)
+	output.GroupSkew = groupSkew(artifacts)
+	output.Duplicates = duplicateArtifacts(artifacts)
+
+	filter := strings.ToLower(input.Artifact)
+	var listed map[string]bool
+	if input.Package != "" {
+		output.Package = packageName(input.Package)
+		output.Providers = packageProviders(artifacts, output.Package)
+		listed = make(map[string]bool)
+		for _, provider := range output.Providers {
+			listed[provider.Artifact] = true
+		}
+		if len(output.Providers) > 1 {
+			var names []string
+			for name := range listed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if len(names) > 1 {
+				output.Duplicates = append(output.Duplicates, DuplicateArtifacts{
+					Artifacts: names,
+					Reason:    "all provide package " + output.Package,
+				})
+			}
+		}
+	}
+	for _, artifact := range artifacts {
+		if filter != "" && !strings.Contains(strings.ToLower(artifact.Artifact), filter) {
+			continue
+		}
+		if listed != nil && filter == "" && !listed[artifact.Artifact] {
+			continue
+		}
+		if len(output.Artifacts) >= defaultMaxArtifacts {
+			output.Truncated = true
+			break
+		}
+		output.Artifacts = append(output.Artifacts, artifact)
+	}
+	return output, nil
+}
+
+// findBuildFiles walks the project for pom.xml files, Gradle lockfiles and
+// saved dependency trees.
+func findBuildFiles(ctx context.Context, root string) (poms, lockfiles, trees []string, err error) {
+	box := currentSandbox()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable subdirectories are skipped, the root must be readable
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skippedBuildDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if box.Check(path) != nil {
+			return nil
+		}
+
+		name := strings.ToLower(d.Name())
+		switch {
+		case name == "pom.xml":
+			poms = append(poms, path)
+		case strings.HasSuffix(name, ".lockfile"):
+			lockfiles = append(lockfiles, path)
+		case (strings.Contains(name, "tree") || strings.Contains(name, "dependenc")) &&
+			(strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".out") || !strings.Contains(name, ".")):
+			if isDependencyTree(path) {
+				trees = append(trees, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, nil, ctxErr
+		}
+		return nil, nil, nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return poms, lockfiles, trees, nil
+}
+
+// aggregateDependencies groups dependencies by artifact and version.
+func aggregateDependencies(deps []buildDependency) []DependencyArtifact {
+	type versionKey struct{ artifact, version string }
+	versions := make(map[versionKey]*DependencyVersion)
+	seen := make(map[versionKey]map[string]bool)
+	var order []versionKey
+	for _, dep := range deps {
+		key := versionKey{dep.key(), dep.Version}
+		v, ok := versions[key]
+		if !ok {
+			v = &DependencyVersion{Version: dep.Version, Omitted: true, Origins: []string{}}
+			versions[key] = v
+			seen[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		v.Resolved = v.Resolved || dep.Resolved
+		v.Omitted = v.Omitted && dep.Requested
+		origin := dep.Origin
+		if dep.Scope != "" && !strings.Contains(origin, ": ") {
+			origin += " [" + dep.Scope + "]"
+		}
+		if v.ManagedBy == "" {
+			v.ManagedBy = dep.ManagedBy
+		}
+		if seen[key][origin] {
+			continue
+		}
+		seen[key][origin] = true
+		if len(v.Origins) < maxOrigins {
+			v.Origins = append(v.Origins, origin)
+		} else {
+			v.More++
+		}
+	}
+
+	byArtifact := make(map[string]*DependencyArtifact)
+	var artifacts []*DependencyArtifact
+	for _, key := range order {
+		artifact, ok := byArtifact[key.artifact]
+		if !ok {
+			artifact = &DependencyArtifact{Artifact: key.artifact}
+			byArtifact[key.artifact] = artifact
+			artifacts = append(artifacts, artifact)
+		}
+		artifact.Versions = append(artifact.Versions, *versions[key])
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Artifact < artifacts[j].Artifact })
+
+	result := make([]DependencyArtifact, len(artifacts))
+	for i, artifact := range artifacts {
+		result[i] = *artifact
+	}
+	return result
+}
+
+// versionConflicts returns the artifacts found with more than one known version.
+func versionConflicts(artifacts []DependencyArtifact) []VersionConflict {
+	conflicts := []VersionConflict{}
+	for _, artifact := range artifacts {
+		var versions, resolved []string
+		for _, v := range artifact.Versions {
+			if v.Version == "" {
+				continue
+			}
+			versions = append(versions, v.Version)
+			if v.Resolved {
+				resolved = append(resolved, v.Version)
+			}
+		}
+		if len(versions) > 1 {
+			conflicts = append(conflicts, VersionConflict{Artifact: artifact.Artifact, Versions: versions, Resolved: resolved})
+		}
+	}
+	return conflicts
+}
+
+// alignedGroup returns the aligned group the groupId belongs to, or "".
+func alignedGroup(groupID string) string {
+	best := ""
+	for _, group := range alignedGroups {
+		if (groupID == group || strings.HasPrefix(groupID, group+".")) && len(group) > len(best) {
+			best = group
+		}
+	}
+	// Jackson modules and datatypes follow the version of jackson-core
+	if strings.HasPrefix(best, "com.fasterxml.jackson") {
+		return "com.fasterxml.jackson"
+	}
+	return best
+}
+
+// groupSkew returns the aligned groups whose artifacts use different versions.
+// Only versions on the classpath are compared when a tree or lockfile is known.
+func groupSkew(artifacts []DependencyArtifact) []GroupSkew {
+	groups := make(map[string]map[string][]string)
+	for _, artifact := range artifacts {
+		groupID, artifactID, _ := strings.Cut(artifact.Artifact, ":")
+		group := alignedGroup(groupID)
+		if group == "" || strings.HasSuffix(artifactID, "-bom") || strings.HasSuffix(artifactID, "-dependencies") || strings.HasSuffix(artifactID, "-parent") {
+			continue
+		}
+		for _, version := range classpathVersions(artifact) {
+			if groups[group] == nil {
+				groups[group] = make(map[string][]string)
+			}
+			groups[group][version] = append(groups[group][version], artifactID)
+		}
+	}
+
+	var skew []GroupSkew
+	for group, versions := range groups {
+		if len(versions) > 1 {
+			for _, ids := range versions {
+				sort.Strings(ids)
+			}
+			skew = append(skew, GroupSkew{Group: group, Versions: versions})
+		}
+	}
+	sort.Slice(skew, func(i, j int) bool { return skew[i].Group < skew[j].Group })
+	return skew
+}
+
+// classpathVersions returns the resolved versions of an artifact, or its
+// declared versions when no tree or lockfile mentions it.
+func classpathVersions(artifact DependencyArtifact) []string {
+	var resolved, declared []string
+	for _, v := range artifact.Versions {
+		if v.Version == "" {
+			continue
+		}
+		if v.Resolved {
+			resolved = append(resolved, v.Version)
+		} else if !v.Omitted {
+			declared = append(declared, v.Version)
+		}
+	}
+	if len(resolved) > 0 {
+		return resolved
+	}
+	return declared
+}
+
+// duplicateArtifacts finds artifacts that are known to contain the same
+// classes: known relocations present under both coordinates and known
+// packages shared by several artifacts.
+func duplicateArtifacts(artifacts []DependencyArtifact) []DuplicateArtifacts {
+	present := make(map[string]bool)
+	byPackage := make(map[string][]string)
+	for _, artifact := range artifacts {
+		present[artifact.Artifact] = true
+		for _, pkg := range knownPackages[artifact.Artifact] {
+			byPackage[pkg] = append(byPackage[pkg], artifact.Artifact)
+		}
+	}
+
+	var duplicates []DuplicateArtifacts
+	for from, to := range knownRelocations {
+		if present[from] && present[to] {
+			duplicates = append(duplicates, DuplicateArtifacts{Artifacts: []string{from, to}, Reason: from + " was relocated to " + to})
+		}
+	}
+	for pkg, names := range byPackage {
+		if len(names) > 1 {
+			duplicates = append(duplicates, DuplicateArtifacts{Artifacts: names, Reason: "all provide package " + pkg})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Reason < duplicates[j].Reason })
+	return duplicates
+}
+
+// packageName returns the package of a fully qualified class name, or the
+// name itself if it already is a package.
+func packageName(name string) string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, "/", "."))
+	name = strings.TrimSuffix(name, ".class")
+	if i := strings.IndexByte(name, '$'); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && i+1 < len(name) {
+		if last := name[i+1]; last >= 'A' && last <= 'Z' {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// packageProviders returns the artifacts that provide the package, checking
+// the jars in the local Maven and Gradle caches when they are accessible and
+// falling back to matching the package against the coordinates.
+func packageProviders(artifacts []DependencyArtifact, pkg string) []PackageProvider {
+	var jar, strong, weak []PackageProvider
+	for _, artifact := range artifacts {
+		groupID, artifactID, _ := strings.Cut(artifact.Artifact, ":")
+		for _, version := range classpathVersions(artifact) {
+			provider := PackageProvider{Artifact: artifact.Artifact, Version: version}
+			if path := localJar(groupID, artifactID, version); path != "" {
+				if jarHasPackage(path, pkg) {
+					provider.Match = "jar"
+					provider.Jar = path
+					jar = append(jar, provider)
+				}
+				continue
+			}
+			switch packageMatch(artifact.Artifact, pkg) {
+			case "artifact":
+				provider.Match = "artifact"
+				strong = append(strong, provider)
+			case "group":
+				provider.Match = "group"
+				weak = append(weak, provider)
+			}
+		}
+	}
+	providers := append(jar, strong...)
+	if len(providers) == 0 {
+		providers = weak
+	}
+	return providers
+}
+
+// packageMatch guesses whether an artifact provides a package from its
+// coordinates: "artifact" if the package is known for the artifact or
+// follows the groupId and the artifactId, "group" if it only follows the
+// groupId, "" otherwise.
+func packageMatch(artifact, pkg string) string {
+	for _, known := range knownPackages[artifact] {
+		if pkg == known || strings.HasPrefix(pkg, known+".") {
+			return "artifact"
+		}
+	}
+	groupID, artifactID, _ := strings.Cut(artifact, ":")
+	if pkg != groupID && !strings.HasPrefix(pkg, groupID+".") {
+		if strings.HasPrefix(groupID, pkg+".") {
+			return "group"
+		}
+		return ""
+	}
+	rest := strings.Split(strings.TrimPrefix(strings.TrimPrefix(pkg, groupID), "."), ".")
+	if rest[0] == "" {
+		return "group"
+	}
+	tokens := strings.FieldsFunc(artifactID, func(r rune) bool { return r == '-' || r == '.' || r == '_' })
+	for _, token := range tokens {
+		if token == rest[0] {
+			return "artifact"
+		}
+	}
+	return "group"
+}
+
+// localJar returns the jar of an artifact in the local Maven repository or
+// Gradle cache, if it exists and the sandbox allows reading it.
+func localJar(groupID, artifactID, version string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := artifactID + "-" + version + ".jar"
+	candidates := []string{filepath.Join(home, ".m2", "repository", filepath.FromSlash(strings.ReplaceAll(groupID, ".", "/")), artifactID, version, name)}
+	gradleHome := os.Getenv("GRADLE_USER_HOME")
+	if gradleHome == "" {
+		gradleHome = filepath.Join(home, ".gradle")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(gradleHome, "caches", "modules-2", "files-2.1", groupID, artifactID, version, "*", name)); len(matches) > 0 {
+		candidates = append(candidates, matches...)
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil && currentSandbox().Check(path) == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// jarHasPackage reports whether the jar contains classes of the package.
+func jarHasPackage(path, pkg string) bool {
+	reader, file, err := openZip(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	dir := strings.ReplaceAll(pkg, ".", "/") + "/"
+	for _, f := range reader.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		if rest, ok := strings.CutPrefix(name, dir); ok && strings.HasSuffix(rest, ".class") && !strings.Contains(rest, "/") {
+			return true
+		}
+	}
+	return false
+}
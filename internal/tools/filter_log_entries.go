@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/logparse"
+)
+
+const (
+	defaultMaxEntries   = 50
+	maxEntryMessageRune = 1000
+	entryTimeLayout     = "2006-01-02 15:04:05.000"
+)
+
+// FilterLogEntriesInput represents the input parameters for the filter_log_entries tool
+type FilterLogEntriesInput struct {
+	AbsolutePath string `json:"absolute_path" description:"The absolute path to the log file."`
+	MinLevel     string `json:"min_level,omitempty" description:"Optional: Minimum level to return: TRACE, DEBUG, INFO, WARN, ERROR or FATAL. Entries without a level (e.g. shell script output) are excluded when set."`
+	Since        string `json:"since,omitempty" description:"Optional: Only entries at or after this time, e.g. '2025-09-23 19:46:55', '19:46', '2025-09-23' or RFC3339. A time without a date is on the date of the log file. Entries without a timestamp are excluded when set."`
+	Until        string `json:"until,omitempty" description:"Optional: Only entries up to the end of this time, same format as 'since'. The whole unit given is included: '19:46:55' includes 19:46:55.999, '19:46' the whole minute and '2025-09-23' the whole day."`
+	Logger       string `json:"logger,omitempty" description:"Optional: Case-insensitive substring the logger name must contain (e.g. 'SpringApplication', 'hikari')."`
+	Thread       string `json:"thread,omitempty" description:"Optional: Case-insensitive substring the thread name must contain (e.g. 'main')."`
+	Pattern      string `json:"pattern,omitempty" description:"Optional: Regular expression the message (including continuation lines such as stack traces) must match."`
+	MaxEntries   *int   `json:"max_entries,omitempty" description:"Optional: Maximum number of entries to return. Default: 50."`
+	Tail         *bool  `json:"tail,omitempty" description:"Optional: If true, return the last max_entries matching entries instead of the first. Useful to see the most recent errors."`
+}
+
+// LogEntry represents a normalized log entry
+type LogEntry struct {
+	Line    int    `json:"line" description:"First line of the entry"`
+	EndLine int    `json:"end_line" description:"Last line of the entry including continuation lines"`
+	Time    string `json:"time,omitempty" description:"Timestamp of the entry"`
+	Level   string `json:"level,omitempty" description:"Normalized level"`
+	Thread  string `json:"thread,omitempty" description:"Thread name"`
+	Logger  string `json:"logger,omitempty" description:"Logger name, or script name for shell output"`
+	Message string `json:"message" description:"Message including continuation lines, truncated if very long"`
+}
+
+// FilterLogEntriesOutput represents the output of the filter_log_entries tool
+type FilterLogEntriesOutput struct {
+	FilePath       string         `json:"file_path" description:"The parsed log file"`
+	Format         string         `json:"format" description:"Detected log format of the file"`
+	Entries        []LogEntry     `json:"entries" description:"Matching entries in file order"`
+	TotalEntries   int            `json:"total_entries" description:"Number of entries in the file"`
+	MatchedEntries int            `json:"matched_entries" description:"Number of entries matching the filters"`
+	Truncated      bool           `json:"truncated" description:"Whether more entries matched than were returned"`
+	LevelCounts    map[string]int `json:"level_counts" description:"Number of entries per level in the whole file"`
+	FirstTime      string         `json:"first_time,omitempty" description:"Timestamp of the first entry with a timestamp"`
+	LastTime       string         `json:"last_time,omitempty" description:"Timestamp of the last entry with a timestamp"`
+}
+
+// FilterLogEntriesTool is a tool that parses a log file into entries and filters them.
+var FilterLogEntriesTool tool.InvokableTool
+
+func init() {
+	var err error
+	FilterLogEntriesTool, err = utils.InferTool(
+		"filter_log_entries",
+		"Detects the log format of a file (Spring Boot, logback, log4j2, JSON/logstash, bracketed, shell script prefixes) and parses it into entries with timestamp, level, thread, logger and message. Stack traces and other continuation lines are kept with their entry. Filters entries by minimum level, time range, logger, thread and message regex, and reports per-level counts and the time span of the file.",
+		filterLogEntries,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create filter_log_entries tool: %v", err))
+	}
+}
+
+// filterLogEntries parses a log file and returns the entries matching the filters.
+func filterLogEntries(ctx context.Context, input FilterLogEntriesInput) (FilterLogEntriesOutput, error) {
+	if !filepath.IsAbs(input.AbsolutePath) {
+		return FilterLogEntriesOutput{}, fmt.Errorf("path must be absolute: %s", input.AbsolutePath)
+	}
+
+	minRank := -1
+	if input.MinLevel != "" {
+		minRank = logparse.LevelRank(input.MinLevel)
+		if minRank < 0 {
+			return FilterLogEntriesOutput{}, fmt.Errorf("invalid min_level: %s", input.MinLevel)
+		}
+	}
+	var regex *regexp.Regexp
+	var err error
+	if input.Pattern != "" {
+		if regex, err = regexp.Compile(input.Pattern); err != nil {
+			return FilterLogEntriesOutput{}, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+	maxEntries := defaultMaxEntries
+	if input.MaxEntries != nil && *input.MaxEntries > 0 {
+		maxEntries = *input.MaxEntries
+	}
+	tail := input.Tail != nil && *input.Tail
+
+	file, src, err := openLogFile(ctx, input.AbsolutePath)
+	if err != nil {
+		return FilterLogEntriesOutput{}, err
+	}
+	defer file.Close()
+
+	// Time-only timestamps (e.g. HH:mm:ss.SSS) take their date from the file,
+	// and so do time-only bounds so that they compare with the entries
+	base := src.Info.ModTime()
+	var since, until time.Time
+	if input.Since != "" {
+		if since, err = parseTimeBound(input.Since, base, false); err != nil {
+			return FilterLogEntriesOutput{}, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if input.Until != "" {
+		if until, err = parseTimeBound(input.Until, base, true); err != nil {
+			return FilterLogEntriesOutput{}, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+
+	scanner := logparse.NewEntryScanner(file, base)
+	output := FilterLogEntriesOutput{
+		FilePath:    input.AbsolutePath,
+		Format:      scanner.Format(),
+		Entries:     []LogEntry{},
+		LevelCounts: make(map[string]int),
+	}
+
+	var first, last time.Time
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return FilterLogEntriesOutput{}, err
+		}
+		entry := scanner.Entry()
+		output.TotalEntries++
+		if entry.Level != "" {
+			output.LevelCounts[entry.Level]++
+		}
+		if !entry.Time.IsZero() {
+			if first.IsZero() {
+				first = entry.Time
+			}
+			last = entry.Time
+		}
+
+		if !matchEntry(entry, minRank, since, until, input.Logger, input.Thread, regex) {
+			continue
+		}
+		output.MatchedEntries++
+		if len(output.Entries) < maxEntries {
+			output.Entries = append(output.Entries, toLogEntry(entry))
+		} else if tail {
+			output.Entries = append(output.Entries[1:], toLogEntry(entry))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return FilterLogEntriesOutput{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	output.Truncated = output.MatchedEntries > len(output.Entries)
+	if !first.IsZero() {
+		output.FirstTime = first.Format(entryTimeLayout)
+		output.LastTime = last.Format(entryTimeLayout)
+	}
+	return output, nil
+}
+
+// fractionDigits matches the fraction of the seconds of a timestamp.
+var fractionDigits = regexp.MustCompile(`:\d{2}[.,](\d+)`)
+
+// parseTimeBound parses a since or until value. Times without a date use the
+// date of base. For the end of a range the whole unit given is included, so
+// "19:46:55" covers up to 19:46:55.999999999 and "2025-09-23" the whole day.
+func parseTimeBound(value string, base time.Time, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	var t time.Time
+	var next func(time.Time) time.Time
+	if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		t, next = day, func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	} else if minute, err := time.ParseInLocation("2006-01-02 15:04", strings.Replace(value, "T", " ", 1), time.Local); err == nil {
+		t, next = minute, func(t time.Time) time.Time { return t.Add(time.Minute) }
+	} else if minute, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		year, month, day := base.Date()
+		t = time.Date(year, month, day, minute.Hour(), minute.Minute(), 0, 0, time.Local)
+		next = func(t time.Time) time.Time { return t.Add(time.Minute) }
+	} else {
+		if t, err = logparse.ParseTimestamp(value, base); err != nil {
+			return time.Time{}, err
+		}
+		unit := time.Second
+		if m := fractionDigits.FindStringSubmatch(value); m != nil {
+			for range min(len(m[1]), 9) {
+				unit /= 10
+			}
+		}
+		next = func(t time.Time) time.Time { return t.Add(unit) }
+	}
+	if end {
+		return next(t).Add(-time.Nanosecond), nil
+	}
+	return t, nil
+}
+
+// matchEntry reports whether an entry passes all filters.
+func matchEntry(entry *logparse.Entry, minRank int, since, until time.Time, logger, thread string, regex *regexp.Regexp) bool {
+	if minRank >= 0 && logparse.LevelRank(entry.Level) < minRank {
+		return false
+	}
+	if !since.IsZero() && (entry.Time.IsZero() || entry.Time.Before(since)) {
+		return false
+	}
+	if !until.IsZero() && (entry.Time.IsZero() || entry.Time.After(until)) {
+		return false
+	}
+	if logger != "" && !strings.Contains(strings.ToLower(entry.Logger), strings.ToLower(logger)) {
+		return false
+	}
+	if thread != "" && !strings.Contains(strings.ToLower(entry.Thread), strings.ToLower(thread)) {
+		return false
+	}
+	if regex != nil && !regex.MatchString(entry.Message) {
+		return false
+	}
+	return true
+}
+
+// toLogEntry converts a parsed entry into its tool representation.
+func toLogEntry(entry *logparse.Entry) LogEntry {
+	e := LogEntry{
+		Line:    entry.Line,
+		EndLine: entry.EndLine,
+		Level:   entry.Level,
+		Thread:  entry.Thread,
+		Logger:  entry.Logger,
+		Message: truncateRunes(entry.Message, maxEntryMessageRune),
+	}
+	if !entry.Time.IsZero() {
+		e.Time = entry.Time.Format(entryTimeLayout)
+	}
+	return e
+}
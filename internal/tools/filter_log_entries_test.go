@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	base := time.Date(2025, 9, 23, 8, 0, 0, 0, time.Local)
+	local := func(month time.Month, day, hour, minute, sec, nsec int) time.Time {
+		return time.Date(2025, month, day, hour, minute, sec, nsec, time.Local)
+	}
+	tests := []struct {
+		value string
+		end   bool
+		want  time.Time
+	}{
+		{"19:46:55", false, local(9, 23, 19, 46, 55, 0)},
+		{"19:46:55", true, local(9, 23, 19, 46, 55, 999999999)},
+		{"19:46:55.1", true, local(9, 23, 19, 46, 55, 199999999)},
+		{"19:46:55,120", true, local(9, 23, 19, 46, 55, 120999999)},
+		{"19:46", false, local(9, 23, 19, 46, 0, 0)},
+		{"19:46", true, local(9, 23, 19, 46, 59, 999999999)},
+		{"2025-09-20 10:00:00", true, local(9, 20, 10, 0, 0, 999999999)},
+		{"2025-09-20 10:00", true, local(9, 20, 10, 0, 59, 999999999)},
+		{"2025-09-20T10:00", false, local(9, 20, 10, 0, 0, 0)},
+		{"2025-09-20", false, local(9, 20, 0, 0, 0, 0)},
+		{"2025-09-20", true, local(9, 20, 23, 59, 59, 999999999)},
+		{"2025-09-20T10:00:00Z", true, time.Date(2025, 9, 20, 10, 0, 0, 999999999, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.value, base, tt.end)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q, end=%v) = %v, %v, want %v", tt.value, tt.end, got, err, tt.want)
+		}
+	}
+	if _, err := parseTimeBound("yesterday", base, false); err == nil {
+		t.Error("invalid time should fail")
+	}
+}
+
+func TestFilterLogEntriesTimeOnly(t *testing.T) {
+	// logback's default pattern has no date, the entries are on the date of the file
+	path := filepath.Join(t.TempDir(), "app.log")
+	log := `19:46:54.900 [main] INFO  com.example.App - Starting App
+19:46:55.000 [main] INFO  com.example.App - No active profile set
+19:46:55.999 [main] WARN  com.example.Cache - Cache disabled
+19:46:56.000 [main] ERROR com.example.App - Application run failed
+`
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 15, 20, 0, 0, 0, time.Local)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := filterLogEntries(context.Background(), FilterLogEntriesInput{AbsolutePath: path, Since: "19:46:55", Until: "19:46:55"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, e := range output.Entries {
+		messages = append(messages, e.Message)
+	}
+	want := "No active profile set,Cache disabled"
+	if strings.Join(messages, ",") != want {
+		t.Errorf("got %q, want %q", strings.Join(messages, ","), want)
+	}
+	if output.FirstTime != "2024-01-15 19:46:54.900" {
+		t.Errorf("entries should be on the date of the file: %s", output.FirstTime)
+	}
+
+	output, err = filterLogEntries(context.Background(), FilterLogEntriesInput{AbsolutePath: path, Since: "2024-01-15", Until: "2024-01-15"})
+	if err != nil || output.MatchedEntries != 4 {
+		t.Errorf("the whole day should match: %d %v", output.MatchedEntries, err)
+	}
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/gclog"
+	"github.com/user/java-startup-analyzer/internal/jvm"
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// maxLongPauses caps the long pauses listed.
+const maxLongPauses = 20
+
+// GCSummaryInput represents the input parameters for the gc_summary tool
+type GCSummaryInput struct {
+	AbsolutePath string `json:"absolute_path,omitempty" description:"Optional: Absolute path to the GC log. Default: the file from -Xloggc or -Xlog:gc*:file=... in the start command."`
+}
+
+// GCSample represents memory usage after a GC
+type GCSample struct {
+	Uptime   string `json:"uptime" description:"Time since JVM start"`
+	Used     string `json:"used" description:"Usage after GC"`
+	Capacity string `json:"capacity,omitempty" description:"Heap capacity"`
+}
+
+// GCStorm represents consecutive full GCs
+type GCStorm struct {
+	Start     string `json:"start" description:"Time since JVM start of the first full GC"`
+	End       string `json:"end" description:"Time since JVM start of the last full GC"`
+	Count     int    `json:"count" description:"Number of full GCs"`
+	Reclaimed string `json:"reclaimed" description:"Average share of the heap each full GC freed"`
+	Occupancy string `json:"occupancy" description:"Heap occupancy after the last full GC"`
+}
+
+// GCPause represents a long GC pause
+type GCPause struct {
+	Uptime string `json:"uptime" description:"Time since JVM start"`
+	Kind   string `json:"kind" description:"young, mixed, full, remark, cleanup or other"`
+	Cause  string `json:"cause,omitempty" description:"GC cause"`
+	Pause  string `json:"pause" description:"Pause duration"`
+	Heap   string `json:"heap,omitempty" description:"Heap before->after(capacity)"`
+}
+
+// GCSummaryOutput represents the output of the gc_summary tool
+type GCSummaryOutput struct {
+	Path           string         `json:"path" description:"The analyzed GC log"`
+	Format         string         `json:"format" description:"unified (JDK 9+ -Xlog:gc*) or jdk8 (-XX:+PrintGCDetails)"`
+	Collector      string         `json:"collector,omitempty" description:"Garbage collector"`
+	Runs           int            `json:"runs" description:"JVM runs in the log, only the last one is analyzed"`
+	Events         int            `json:"events" description:"GC pauses analyzed"`
+	Elapsed        string         `json:"elapsed" description:"Time from the first to the last GC"`
+	Counts         map[string]int `json:"counts" description:"Pauses by kind"`
+	Causes         map[string]int `json:"causes" description:"Pauses by cause"`
+	PauseTotal     string         `json:"pause_total" description:"Total pause time"`
+	PauseMax       string         `json:"pause_max" description:"Longest pause"`
+	GCTimePercent  float64        `json:"gc_time_percent" description:"Share of the elapsed time spent in GC pauses"`
+	AllocationRate string         `json:"allocation_rate" description:"Average allocation rate per second"`
+	MaxCapacity    string         `json:"max_capacity" description:"Largest heap capacity seen"`
+	HeapAfterGC    []GCSample     `json:"heap_after_gc" description:"Heap occupancy after GC over time"`
+	LiveSet        []GCSample     `json:"live_set,omitempty" description:"Heap occupancy after full GCs, approximately the live data"`
+	Metaspace      []GCSample     `json:"metaspace,omitempty" description:"Metaspace usage after GC over time"`
+	Storms         []GCStorm      `json:"full_gc_storms,omitempty" description:"Runs of back-to-back full GCs"`
+	LongPauses     []GCPause      `json:"long_pauses,omitempty" description:"Pauses over one second, longest first"`
+	Findings       []string       `json:"findings" description:"Problems found"`
+}
+
+// GCSummaryTool is a tool that summarizes a GC log.
+var GCSummaryTool tool.InvokableTool
+
+func init() {
+	var err error
+	GCSummaryTool, err = utils.InferTool(
+		"gc_summary",
+		"Parses a GC log, JDK 8 -XX:+PrintGCDetails or JDK 9+ unified -Xlog:gc*, and summarizes it: heap occupancy after GC over time, live data after full GCs, pause counts and totals by kind and cause, GC time share, full GC storms, metaspace growth, allocation rate and heap expansion during startup. Use it when the log shows OutOfMemoryError, GC overhead limit exceeded, long pauses or slow startup, and a GC log exists.",
+		gcSummary,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create gc_summary tool: %v", err))
+	}
+}
+
+// gcSummary parses the GC log and summarizes it.
+func gcSummary(ctx context.Context, input GCSummaryInput) (GCSummaryOutput, error) {
+	path := input.AbsolutePath
+	if path == "" {
+		path = gcLogFromCommand(currentStartCommand())
+		if path == "" {
+			return GCSummaryOutput{}, errors.New("absolute_path is required: the start command has no -Xloggc or -Xlog:gc file")
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return GCSummaryOutput{}, fmt.Errorf("path must be absolute: %s", path)
+	}
+
+	file, _, err := openLogFile(ctx, path)
+	if err != nil {
+		return GCSummaryOutput{}, err
+	}
+	defer file.Close()
+	log, err := gclog.Parse(file)
+	if err != nil {
+		return GCSummaryOutput{}, fmt.Errorf("failed to read GC log: %w", err)
+	}
+	if len(log.Events) == 0 {
+		return GCSummaryOutput{}, fmt.Errorf("no GC pauses found in %s; the file is not a GC log or uses -Xlog decorations without the gc tag", path)
+	}
+	summary := gclog.Summarize(log)
+
+	output := GCSummaryOutput{
+		Path:           path,
+		Format:         string(summary.Format),
+		Collector:      summary.Collector,
+		Runs:           summary.Runs,
+		Events:         summary.Events,
+		Elapsed:        formatSeconds(summary.Elapsed),
+		Counts:         summary.Counts,
+		Causes:         summary.Causes,
+		PauseTotal:     summary.PauseTotal.Round(time.Millisecond).String(),
+		PauseMax:       fmt.Sprintf("%s at %s", summary.PauseMax.Round(time.Millisecond), formatSeconds(summary.PauseMaxAt)),
+		GCTimePercent:  float64(int(summary.GCTimeRatio*1000)) / 10,
+		AllocationRate: jvm.FormatSize(int64(summary.AllocationRate)) + "/s",
+		MaxCapacity:    jvm.FormatSize(summary.MaxCapacity),
+		HeapAfterGC:    gcSamples(summary.Occupancy),
+		LiveSet:        gcSamples(summary.LiveSet),
+		Metaspace:      gcSamples(summary.Metaspace),
+		Findings:       summary.Findings,
+	}
+	if output.Findings == nil {
+		output.Findings = []string{}
+	}
+	for _, storm := range summary.Storms {
+		output.Storms = append(output.Storms, GCStorm{
+			Start:     formatSeconds(storm.Start),
+			End:       formatSeconds(storm.End),
+			Count:     storm.Count,
+			Reclaimed: fmt.Sprintf("%.0f%%", storm.Reclaimed*100),
+			Occupancy: fmt.Sprintf("%.0f%%", storm.Occupancy*100),
+		})
+	}
+	pauses := append([]gclog.Event{}, summary.LongPauses...)
+	sort.SliceStable(pauses, func(i, j int) bool { return pauses[i].Pause > pauses[j].Pause })
+	for _, e := range pauses[:min(len(pauses), maxLongPauses)] {
+		pause := GCPause{Uptime: formatSeconds(e.Uptime), Kind: e.Kind, Cause: e.Cause, Pause: e.Pause.Round(time.Millisecond).String()}
+		if e.Capacity > 0 {
+			pause.Heap = fmt.Sprintf("%s->%s(%s)", jvm.FormatSize(e.Before), jvm.FormatSize(e.After), jvm.FormatSize(e.Capacity))
+		}
+		output.LongPauses = append(output.LongPauses, pause)
+	}
+	return output, nil
+}
+
+// gcLogFromCommand returns the GC log configured in the start command, the
+// newest file when the name contains %p or %t.
+func gcLogFromCommand(command string) string {
+	var path, dir string
+	args := shell.Split(command)
+	for i, arg := range args {
+		switch {
+		case arg == "cd" && i+1 < len(args):
+			dir = args[i+1]
+		case strings.HasPrefix(arg, "-Xloggc:"):
+			path = strings.TrimPrefix(arg, "-Xloggc:")
+		case strings.HasPrefix(arg, "-Xlog:"):
+			// -Xlog:gc*:file=/var/log/gc.log:time,uptime:filecount=5
+			parts := strings.Split(strings.TrimPrefix(arg, "-Xlog:"), ":")
+			if len(parts) < 2 || !strings.Contains(parts[0], "gc") {
+				continue
+			}
+			output := strings.Trim(strings.TrimPrefix(parts[1], "file="), `"`)
+			if output != "" && output != "stdout" && output != "stderr" {
+				path = output
+			}
+		}
+	}
+	if path == "" {
+		return ""
+	}
+	// relative to cd <dir> && java ... in the start command
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if !strings.Contains(path, "%") {
+		return path
+	}
+	pattern := strings.NewReplacer("%p", "*", "%t", "*").Replace(path)
+	matches, _ := filepath.Glob(pattern)
+	var newest string
+	var newestTime time.Time
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.ModTime().After(newestTime) {
+			newest, newestTime = match, info.ModTime()
+		}
+	}
+	return newest
+}
+
+// gcSamples converts samples to the tool output.
+func gcSamples(samples []gclog.Sample) []GCSample {
+	var result []GCSample
+	for _, s := range samples {
+		sample := GCSample{Uptime: formatSeconds(s.Uptime), Used: jvm.FormatSize(s.Used)}
+		if s.Capacity > 0 {
+			sample.Capacity = jvm.FormatSize(s.Capacity)
+		}
+		result = append(result, sample)
+	}
+	return result
+}
+
+// formatSeconds formats a time since JVM start, e.g. 12.3s.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
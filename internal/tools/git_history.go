@@ -0,0 +1,592 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	defaultMaxCommits = 20
+	maxCommits        = 100
+	// maxDiffRunes caps the diff returned per commit.
+	maxDiffRunes = 4000
+	// maxBlameLines caps the number of blamed lines.
+	maxBlameLines = 30
+	// maxBlameFiles caps the number of files scanned for a pattern.
+	maxBlameFiles = 200
+	gitTimeout    = 30 * time.Second
+	// hashLength is the length of abbreviated hashes in log and blame output.
+	hashLength = 12
+)
+
+// defaultGitPathspecs are the files that usually explain a startup failure
+// after a change: Spring configuration and build files.
+var defaultGitPathspecs = []string{
+	":(glob)**/application*.yml",
+	":(glob)**/application*.yaml",
+	":(glob)**/application*.properties",
+	":(glob)**/bootstrap*.yml",
+	":(glob)**/bootstrap*.yaml",
+	":(glob)**/bootstrap*.properties",
+	":(glob)**/logback*.xml",
+	":(glob)**/log4j2*.xml",
+	":(glob)**/pom.xml",
+	":(glob)**/build.gradle",
+	":(glob)**/build.gradle.kts",
+	":(glob)**/settings.gradle",
+	":(glob)**/settings.gradle.kts",
+	":(glob)**/gradle.properties",
+	":(glob)**/gradle.lockfile",
+	":(glob)**/gradle/libs.versions.toml",
+}
+
+// GitHistoryInput represents the input parameters for the git_history tool
+type GitHistoryInput struct {
+	Classes      []string `json:"classes,omitempty" description:"Optional: Fully qualified class names from stack traces (e.g. 'com.example.OrderService' or 'com.example.Foo$Bar'). Their source files are added to the history and blame scope."`
+	Paths        []string `json:"paths,omitempty" description:"Optional: Repository-relative paths or globs (e.g. 'src/main/resources/*.yml', '**/pom.xml'). Default: Spring configuration files, logback/log4j2 configs, pom.xml and Gradle build files."`
+	Since        string   `json:"since,omitempty" description:"Optional: Only commits after this date, e.g. '2025-09-20', '2 weeks ago'."`
+	Pattern      string   `json:"pattern,omitempty" description:"Optional: Regex such as 'spring.datasource.url'. Only commits whose diff adds or removes a matching line are listed, and the matching lines of the current files are blamed."`
+	BlameFile    string   `json:"blame_file,omitempty" description:"Optional: Repository-relative file to blame, together with blame_lines."`
+	BlameLines   string   `json:"blame_lines,omitempty" description:"Optional: Line range of blame_file, e.g. '42' or '40,60'."`
+	MaxCommits   *int     `json:"max_commits,omitempty" description:"Optional: Maximum number of commits to return, newest first. Default: 20, max: 100."`
+	IncludeDiffs *bool    `json:"include_diffs,omitempty" description:"Optional: Include the diff of each commit, restricted to the scope files. Default: false."`
+}
+
+// GitFileChange represents a file changed by a commit
+type GitFileChange struct {
+	Path    string `json:"path" description:"Repository-relative path; renames are shown as 'old => new'"`
+	Added   int    `json:"added" description:"Lines added, -1 for binary files"`
+	Deleted int    `json:"deleted" description:"Lines deleted, -1 for binary files"`
+}
+
+// GitCommit represents a commit touching the scope files
+type GitCommit struct {
+	Hash    string          `json:"hash" description:"Abbreviated commit hash"`
+	Author  string          `json:"author" description:"Commit author"`
+	Date    string          `json:"date" description:"Author date"`
+	Subject string          `json:"subject" description:"First line of the commit message"`
+	Files   []GitFileChange `json:"files" description:"Scope files changed by the commit"`
+	Diff    string          `json:"diff,omitempty" description:"Diff of the scope files, truncated"`
+}
+
+// GitBlameLine represents the last commit that changed a line
+type GitBlameLine struct {
+	File    string `json:"file" description:"Repository-relative path"`
+	Line    int    `json:"line" description:"1-based line number in the current file"`
+	Content string `json:"content" description:"Current content of the line"`
+	Hash    string `json:"hash" description:"Abbreviated hash of the commit that last changed the line, empty if uncommitted"`
+	Author  string `json:"author,omitempty" description:"Author of that commit"`
+	Date    string `json:"date,omitempty" description:"Author date of that commit"`
+	Subject string `json:"subject,omitempty" description:"Subject of that commit"`
+}
+
+// GitHistoryOutput represents the output of the git_history tool
+type GitHistoryOutput struct {
+	Repo           string            `json:"repo" description:"The repository"`
+	Head           string            `json:"head" description:"Current branch and commit"`
+	Scope          []string          `json:"scope" description:"Pathspecs the history was restricted to"`
+	ClassFiles     map[string]string `json:"class_files,omitempty" description:"Source file found for each requested class"`
+	MissingClasses []string          `json:"missing_classes,omitempty" description:"Requested classes without a source file in the repository, e.g. library classes"`
+	Commits        []GitCommit       `json:"commits" description:"Commits touching the scope, newest first"`
+	Truncated      bool              `json:"truncated" description:"Whether more commits matched than were returned"`
+	Blame          []GitBlameLine    `json:"blame,omitempty" description:"Last change of the blamed lines"`
+	Uncommitted    []string          `json:"uncommitted,omitempty" description:"Scope files with uncommitted changes in the working tree"`
+}
+
+// GitHistoryTool is a tool that shows recent changes to configuration, build
+// files and classes of the application repository.
+var GitHistoryTool tool.InvokableTool
+
+func init() {
+	var err error
+	GitHistoryTool, err = utils.InferTool(
+		"git_history",
+		"Shows recent commits of the application's git repository that touched Spring configuration files, pom.xml/Gradle build files and the source files of the given classes, with the changed files and optionally their diffs. With 'pattern' it only lists commits that added or removed a matching line and blames the matching lines of the current files, e.g. to find the commit that last changed spring.datasource.url. 'blame_file' and 'blame_lines' blame a specific range.",
+		gitHistory,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create git_history tool: %v", err))
+	}
+}
+
+// gitHistory lists the commits touching the scope files and blames the requested lines.
+func gitHistory(ctx context.Context, input GitHistoryInput) (GitHistoryOutput, error) {
+	repo := currentGitRepo()
+	if repo == "" {
+		return GitHistoryOutput{}, errors.New("no git repository is configured")
+	}
+	if err := currentSandbox().Check(repo); err != nil {
+		return GitHistoryOutput{}, err
+	}
+
+	var pattern *regexp.Regexp
+	if input.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(input.Pattern); err != nil {
+			return GitHistoryOutput{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	limit := defaultMaxCommits
+	if input.MaxCommits != nil && *input.MaxCommits > 0 {
+		limit = min(*input.MaxCommits, maxCommits)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	output := GitHistoryOutput{Repo: repo, Commits: []GitCommit{}}
+	if head, err := runGit(ctx, repo, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		output.Head = strings.TrimSpace(head)
+	}
+	if hash, err := runGit(ctx, repo, "rev-parse", "--short", "HEAD"); err == nil {
+		output.Head = strings.TrimSpace(output.Head + " " + strings.TrimSpace(hash))
+	} else {
+		return output, fmt.Errorf("git repository %s has no commits: %w", repo, err)
+	}
+
+	// The scope is the given paths, or the default config and build files,
+	// plus the source files of the classes named in stack traces
+	scope := defaultGitPathspecs
+	if len(input.Paths) > 0 {
+		scope = nil
+		for _, path := range input.Paths {
+			pathspec, err := gitPathspec(repo, path)
+			if err != nil {
+				return output, err
+			}
+			scope = append(scope, pathspec)
+		}
+	}
+	if len(input.Classes) > 0 {
+		files, missing, err := classSourceFiles(ctx, repo, input.Classes)
+		if err != nil {
+			return output, err
+		}
+		output.ClassFiles = files
+		output.MissingClasses = missing
+		for _, file := range files {
+			scope = append(scope, ":(literal)"+file)
+		}
+	}
+	output.Scope = scope
+
+	commits, truncated, err := gitLog(ctx, repo, scope, input.Since, input.Pattern, limit)
+	if err != nil {
+		return output, err
+	}
+	output.Commits = commits
+	output.Truncated = truncated
+
+	if input.IncludeDiffs != nil && *input.IncludeDiffs {
+		for i := range output.Commits {
+			// Only the changed files the sandbox allows are diffed, so a
+			// glob cannot pull in the contents of a denied file
+			files := allowedChanges(repo, output.Commits[i].Files)
+			if len(files) == 0 {
+				continue
+			}
+			diff, err := runGit(ctx, repo, append([]string{"show", "--format=", "--no-color", "--unified=3", output.Commits[i].Hash, "--"}, files...)...)
+			if err != nil {
+				return output, err
+			}
+			output.Commits[i].Diff = truncateRunes(diff, maxDiffRunes)
+		}
+	}
+
+	if status, err := runGit(ctx, repo, append([]string{"status", "--porcelain", "--"}, scope...)...); err == nil {
+		for _, line := range strings.Split(status, "\n") {
+			if len(line) > 3 {
+				output.Uncommitted = append(output.Uncommitted, line[3:])
+			}
+		}
+	}
+
+	// Blame the requested range, then the lines matching the pattern
+	if input.BlameFile != "" {
+		start, end, err := parseBlameLines(input.BlameLines)
+		if err != nil {
+			return output, err
+		}
+		if err := currentSandbox().Check(filepath.Join(repo, filepath.FromSlash(input.BlameFile))); err != nil {
+			return output, err
+		}
+		lines, err := gitBlame(ctx, repo, input.BlameFile, [][2]int{{start, end}})
+		if err != nil {
+			return output, err
+		}
+		output.Blame = append(output.Blame, lines...)
+	}
+	if pattern != nil {
+		lines, err := blameMatches(ctx, repo, scope, pattern, maxBlameLines-len(output.Blame))
+		if err != nil {
+			return output, err
+		}
+		output.Blame = append(output.Blame, lines...)
+	}
+	if len(output.Blame) > maxBlameLines {
+		output.Blame = output.Blame[:maxBlameLines]
+	}
+	return output, nil
+}
+
+// runGit runs a git command in the repository and returns its stdout.
+func runGit(ctx context.Context, repo string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo, "-c", "core.quotepath=off"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// gitPathspec turns a path or glob given by the agent into a pathspec
+// relative to the repository root. Pathspec magic is rejected, since it could
+// widen the scope beyond the repository or exclude nothing at all; a literal
+// path must be allowed by the sandbox.
+func gitPathspec(repo, path string) (string, error) {
+	path = filepath.ToSlash(path)
+	if strings.HasPrefix(path, ":") {
+		return "", fmt.Errorf("pathspec magic is not supported: %s", path)
+	}
+	path = strings.TrimPrefix(path, "./")
+	if !strings.ContainsAny(path, "*?[") {
+		if err := currentSandbox().Check(filepath.Join(repo, filepath.FromSlash(path))); err != nil {
+			return "", err
+		}
+	}
+	if !strings.Contains(path, "/") {
+		// Like the search include filter, a bare name or glob matches at any depth
+		path = "**/" + path
+	}
+	return ":(glob)" + path, nil
+}
+
+// allowedChanges returns literal pathspecs for the changed files of a commit
+// that the sandbox allows, both sides of a rename included.
+func allowedChanges(repo string, changes []GitFileChange) []string {
+	var pathspecs []string
+	box := currentSandbox()
+	for _, change := range changes {
+		for _, file := range changePaths(change.Path) {
+			if box.Check(filepath.Join(repo, filepath.FromSlash(file))) == nil {
+				pathspecs = append(pathspecs, ":(literal)"+file)
+			}
+		}
+	}
+	return pathspecs
+}
+
+// changePaths expands a numstat path into the changed files: a rename is
+// printed as "old => new" or "dir/{old => new}/file".
+func changePaths(path string) []string {
+	if !strings.Contains(path, " => ") {
+		return []string{path}
+	}
+	start, end := strings.IndexByte(path, '{'), strings.LastIndexByte(path, '}')
+	if start < 0 || end < start {
+		from, to, _ := strings.Cut(path, " => ")
+		return []string{from, to}
+	}
+	from, to, _ := strings.Cut(path[start+1:end], " => ")
+	prefix, suffix := path[:start], path[end+1:]
+	// An empty side leaves a double slash, e.g. "src/{ => main}/App.java"
+	clean := func(p string) string { return strings.ReplaceAll(p, "//", "/") }
+	return []string{clean(prefix + from + suffix), clean(prefix + to + suffix)}
+}
+
+// classSourceFiles finds the Java, Kotlin or Groovy source file of each
+// class. Nested classes are mapped to their top-level class.
+func classSourceFiles(ctx context.Context, repo string, classes []string) (map[string]string, []string, error) {
+	wanted := make(map[string][]string) // relative source path without extension -> classes
+	var pathspecs []string
+	for _, class := range classes {
+		class = strings.TrimSpace(class)
+		if i := strings.IndexByte(class, '$'); i >= 0 {
+			class = class[:i]
+		}
+		if class == "" {
+			continue
+		}
+		base := strings.ReplaceAll(class, ".", "/")
+		if _, ok := wanted[base]; !ok {
+			for _, ext := range []string{".java", ".kt", ".groovy"} {
+				pathspecs = append(pathspecs, ":(glob)**/"+base+ext)
+			}
+		}
+		wanted[base] = append(wanted[base], class)
+	}
+	if len(pathspecs) == 0 {
+		return nil, nil, nil
+	}
+
+	out, err := runGit(ctx, repo, append([]string{"ls-files", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := make(map[string]string)
+	for _, file := range strings.Split(strings.TrimSpace(out), "\n") {
+		base := strings.TrimSuffix(file, filepath.Ext(file))
+		for suffix, names := range wanted {
+			if base != suffix && !strings.HasSuffix(base, "/"+suffix) {
+				continue
+			}
+			for _, name := range names {
+				// Prefer main sources over tests when both exist
+				if existing, ok := files[name]; !ok || strings.Contains(existing, "/test/") {
+					files[name] = file
+				}
+			}
+		}
+	}
+
+	var missing []string
+	for _, names := range wanted {
+		for _, name := range names {
+			if _, ok := files[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return files, missing, nil
+}
+
+// gitLog lists up to limit commits touching the scope with their changed files.
+func gitLog(ctx context.Context, repo string, scope []string, since, pattern string, limit int) ([]GitCommit, bool, error) {
+	// Fields are separated by \x1f and commits by \x1e so that subjects can
+	// contain any other character
+	args := []string{"log", "--no-color", "--date=iso-strict", "--numstat", "--abbrev=" + strconv.Itoa(hashLength),
+		"--format=%x1e%h%x1f%an%x1f%ad%x1f%s", "--max-count=" + strconv.Itoa(limit+1)}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if pattern != "" {
+		args = append(args, "-G"+pattern)
+	}
+	args = append(args, "--")
+	args = append(args, scope...)
+
+	out, err := runGit(ctx, repo, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	commits, truncated := parseGitLog(out, limit)
+	return commits, truncated, nil
+}
+
+// parseGitLog parses the records written by gitLog and keeps up to limit.
+func parseGitLog(out string, limit int) ([]GitCommit, bool) {
+	commits := []GitCommit{}
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		commit := GitCommit{Hash: fields[0], Author: fields[1], Date: fields[2], Subject: fields[3], Files: []GitFileChange{}}
+		for _, line := range lines[1:] {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			change := GitFileChange{Path: parts[2], Added: -1, Deleted: -1}
+			if n, err := strconv.Atoi(parts[0]); err == nil {
+				change.Added = n
+			}
+			if n, err := strconv.Atoi(parts[1]); err == nil {
+				change.Deleted = n
+			}
+			commit.Files = append(commit.Files, change)
+		}
+		commits = append(commits, commit)
+	}
+	if len(commits) > limit {
+		return commits[:limit], true
+	}
+	return commits, false
+}
+
+// parseBlameLines parses "42" or "40,60" into a 1-based inclusive range.
+func parseBlameLines(spec string) (int, int, error) {
+	if spec == "" {
+		return 0, 0, errors.New("blame_lines is required with blame_file")
+	}
+	first, last, found := strings.Cut(spec, ",")
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid blame_lines: %s", spec)
+	}
+	end := start
+	if found {
+		if end, err = strconv.Atoi(strings.TrimSpace(last)); err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid blame_lines: %s", spec)
+		}
+	}
+	if end-start >= maxBlameLines {
+		end = start + maxBlameLines - 1
+	}
+	return start, end, nil
+}
+
+// blameMatches blames the lines of the scope files that match the pattern.
+func blameMatches(ctx context.Context, repo string, scope []string, pattern *regexp.Regexp, limit int) ([]GitBlameLine, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	out, err := runGit(ctx, repo, append([]string{"ls-files", "--"}, scope...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var blamed []GitBlameLine
+	files := strings.Split(strings.TrimSpace(out), "\n")
+	for i, file := range files {
+		if file == "" || i >= maxBlameFiles || len(blamed) >= limit {
+			break
+		}
+		lines, err := matchingLines(filepath.Join(repo, filepath.FromSlash(file)), pattern, limit-len(blamed))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		ranges := make([][2]int, len(lines))
+		for j, line := range lines {
+			ranges[j] = [2]int{line, line}
+		}
+		result, err := gitBlame(ctx, repo, file, ranges)
+		if err != nil {
+			// Files that exist only in the working tree cannot be blamed
+			continue
+		}
+		blamed = append(blamed, result...)
+	}
+	return blamed, nil
+}
+
+// matchingLines returns the 1-based numbers of up to limit lines of a file
+// in the working tree that match the pattern.
+func matchingLines(path string, pattern *regexp.Regexp, limit int) ([]int, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan() && len(lines) < limit; n++ {
+		if pattern.MatchString(scanner.Text()) {
+			lines = append(lines, n)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// gitBlame blames line ranges of a file and returns one entry per line.
+func gitBlame(ctx context.Context, repo, file string, ranges [][2]int) ([]GitBlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	for _, r := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r[0], r[1]))
+	}
+	args = append(args, "--", filepath.ToSlash(file))
+	out, err := runGit(ctx, repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlamePorcelain(file, out), nil
+}
+
+// blameCommit holds the commit headers of porcelain blame output, which are
+// only printed the first time a commit appears.
+type blameCommit struct {
+	author  string
+	time    int64
+	tz      string
+	summary string
+}
+
+// parseBlamePorcelain parses the output of git blame --porcelain.
+func parseBlamePorcelain(file, out string) []GitBlameLine {
+	commits := make(map[string]*blameCommit)
+	var result []GitBlameLine
+	var hash string
+	var line int
+	for _, text := range strings.Split(out, "\n") {
+		if strings.HasPrefix(text, "\t") {
+			entry := GitBlameLine{File: filepath.ToSlash(file), Line: line, Content: truncateRunes(text[1:], 500)}
+			if c := commits[hash]; c != nil && strings.Trim(hash, "0") != "" {
+				entry.Hash = hash[:hashLength]
+				entry.Author = c.author
+				entry.Subject = c.summary
+				if c.time > 0 {
+					entry.Date = formatBlameTime(c.time, c.tz)
+				}
+			}
+			result = append(result, entry)
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		if len(key) == 40 || len(key) == 64 {
+			// "<hash> <orig line> <final line> [<lines in group>]"
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				hash = key
+				line, _ = strconv.Atoi(fields[1])
+				if commits[hash] == nil {
+					commits[hash] = &blameCommit{}
+				}
+			}
+			continue
+		}
+		c := commits[hash]
+		if c == nil {
+			continue
+		}
+		switch key {
+		case "author":
+			c.author = value
+		case "author-time":
+			c.time, _ = strconv.ParseInt(value, 10, 64)
+		case "author-tz":
+			c.tz = value
+		case "summary":
+			c.summary = value
+		}
+	}
+	return result
+}
+
+// formatBlameTime formats a blame timestamp in the author's time zone.
+func formatBlameTime(unix int64, tz string) string {
+	t := time.Unix(unix, 0)
+	if len(tz) == 5 {
+		if offset, err := strconv.Atoi(tz[1:]); err == nil {
+			seconds := (offset/100*60 + offset%100) * 60
+			if tz[0] == '-' {
+				seconds = -seconds
+			}
+			t = t.In(time.FixedZone(tz, seconds))
+		}
+	}
+	// Same layout as git's --date=iso-strict used for the log
+	return t.Format("2006-01-02T15:04:05-07:00")
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/histogram"
+	"github.com/user/java-startup-analyzer/internal/jvm"
+)
+
+const (
+	// defaultHistogramTop is the number of classes listed by default.
+	defaultHistogramTop = 20
+	// maxHistogramTop caps the number of classes listed.
+	maxHistogramTop = 100
+)
+
+// HeapHistogramInput represents the input parameters for the heap_histogram tool
+type HeapHistogramInput struct {
+	AbsolutePath string `json:"absolute_path" description:"Absolute path to the output of jmap -histo or jcmd <pid> GC.class_histogram. If the file has several histograms, the last one is used."`
+	BaselinePath string `json:"baseline_path,omitempty" description:"Optional: Absolute path to an earlier histogram of the same process, to show which classes grew."`
+	Top          int    `json:"top,omitempty" description:"Optional: Number of classes to list by bytes and by instances (default 20, max 100)."`
+}
+
+// HistogramClass represents a class in the histogram
+type HistogramClass struct {
+	Class     string  `json:"class" description:"Class name, arrays as byte[] etc."`
+	Module    string  `json:"module,omitempty" description:"Module the class belongs to"`
+	Instances int64   `json:"instances" description:"Number of instances"`
+	Bytes     int64   `json:"bytes" description:"Shallow size of all instances in bytes"`
+	Size      string  `json:"size" description:"Shallow size, human readable"`
+	Percent   float64 `json:"percent" description:"Share of the heap in percent"`
+}
+
+// HistogramGrowth represents the growth of a class between two histograms
+type HistogramGrowth struct {
+	Class     string `json:"class" description:"Class name"`
+	Instances int64  `json:"instances" description:"Change in instances"`
+	Bytes     int64  `json:"bytes" description:"Change in bytes"`
+	Before    string `json:"before" description:"Size in the baseline histogram"`
+	After     string `json:"after" description:"Size in the histogram"`
+}
+
+// HistogramFinding represents a suspicious allocation pattern
+type HistogramFinding struct {
+	Kind    string `json:"kind" description:"large_arrays, cache, classloaders, duplicate_classes, application_class or growth"`
+	Class   string `json:"class" description:"The class, library or package concerned"`
+	Message string `json:"message" description:"What was found and what to check"`
+}
+
+// HeapHistogramOutput represents the output of the heap_histogram tool
+type HeapHistogramOutput struct {
+	Path           string             `json:"path" description:"The analyzed histogram"`
+	Classes        int                `json:"classes" description:"Number of classes in the histogram"`
+	TotalInstances int64              `json:"total_instances" description:"Total number of instances"`
+	TotalSize      string             `json:"total_size" description:"Total shallow size of the heap objects"`
+	TopByBytes     []HistogramClass   `json:"top_by_bytes" description:"Classes using the most memory"`
+	TopByInstances []HistogramClass   `json:"top_by_instances" description:"Classes with the most instances"`
+	Growth         []HistogramGrowth  `json:"growth,omitempty" description:"Classes that grew the most since the baseline histogram"`
+	Findings       []HistogramFinding `json:"findings" description:"Suspicious patterns pointing to the likely allocation culprit"`
+}
+
+// HeapHistogramTool is a tool that analyzes class histograms.
+var HeapHistogramTool tool.InvokableTool
+
+func init() {
+	var err error
+	HeapHistogramTool, err = utils.InferTool(
+		"heap_histogram",
+		"Parses a class histogram from jmap -histo or jcmd GC.class_histogram and lists the top classes by bytes and by instance count. With a baseline histogram it shows which classes grew. It flags suspicious patterns such as huge byte[] arrays, maps used as unbounded caches, repeatedly created classloaders and classes loaded several times, so the likely allocation culprit can be named. Use it for OutOfMemoryError when a histogram was captured.",
+		heapHistogram,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create heap_histogram tool: %v", err))
+	}
+}
+
+// heapHistogram parses the histogram and looks for suspicious patterns.
+func heapHistogram(ctx context.Context, input HeapHistogramInput) (HeapHistogramOutput, error) {
+	if input.AbsolutePath == "" {
+		return HeapHistogramOutput{}, errors.New("absolute_path is required")
+	}
+	top := input.Top
+	if top <= 0 {
+		top = defaultHistogramTop
+	}
+	top = min(top, maxHistogramTop)
+
+	h, err := readHistogram(ctx, input.AbsolutePath)
+	if err != nil {
+		return HeapHistogramOutput{}, err
+	}
+	var growth []histogram.Growth
+	if input.BaselinePath != "" {
+		baseline, err := readHistogram(ctx, input.BaselinePath)
+		if err != nil {
+			return HeapHistogramOutput{}, err
+		}
+		growth = histogram.Diff(baseline, h)
+	}
+
+	output := HeapHistogramOutput{
+		Path:           input.AbsolutePath,
+		Classes:        len(h.Entries),
+		TotalInstances: h.TotalInstances,
+		TotalSize:      jvm.FormatSize(h.TotalBytes),
+		TopByBytes:     histogramClasses(h, h.TopByBytes(top)),
+		TopByInstances: histogramClasses(h, h.TopByInstances(top)),
+		Findings:       []HistogramFinding{},
+	}
+	for _, g := range growth[:min(len(growth), top)] {
+		output.Growth = append(output.Growth, HistogramGrowth{
+			Class:     histogram.DisplayName(g.Class),
+			Instances: g.Instances,
+			Bytes:     g.Bytes,
+			Before:    jvm.FormatSize(g.BytesBefore),
+			After:     jvm.FormatSize(g.BytesAfter),
+		})
+	}
+	for _, f := range histogram.Analyze(h, growth) {
+		output.Findings = append(output.Findings, HistogramFinding{Kind: string(f.Kind), Class: f.Class, Message: f.Message})
+	}
+	return output, nil
+}
+
+// readHistogram opens and parses a class histogram.
+func readHistogram(ctx context.Context, path string) (*histogram.Histogram, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("path must be absolute: %s", path)
+	}
+	file, _, err := openLogFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	h, err := histogram.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(h.Entries) == 0 {
+		return nil, fmt.Errorf("no class histogram found in %s", path)
+	}
+	return h, nil
+}
+
+// histogramClasses converts histogram entries to the tool output.
+func histogramClasses(h *histogram.Histogram, entries []histogram.Entry) []HistogramClass {
+	result := make([]HistogramClass, 0, len(entries))
+	for _, e := range entries {
+		class := HistogramClass{
+			Class:     histogram.DisplayName(e.Class),
+			Module:    e.Module,
+			Instances: e.Instances,
+			Bytes:     e.Bytes,
+			Size:      jvm.FormatSize(e.Bytes),
+		}
+		if h.TotalBytes > 0 {
+			class.Percent = float64(e.Bytes*1000/h.TotalBytes) / 10
+		}
+		result = append(result, class)
+	}
+	return result
+}
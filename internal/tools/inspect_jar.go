@@ -0,0 +1,554 @@
+package tools
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+const (
+	// maxNestedJarSize is the largest nested jar read into memory.
+	maxNestedJarSize = 256 << 20
+	// maxJars caps the number of jars inspected in a directory.
+	maxJars          = 2000
+	maxJarSummaries  = 200
+	maxDuplicateSets = 30
+	maxSplitPackages = 50
+	// maxDuplicateExamples caps the example classes listed per duplicate set.
+	maxDuplicateExamples = 5
+)
+
+// manifestKeys are the MANIFEST.MF attributes reported by inspect_jar.
+var manifestKeys = []string{
+	"Main-Class", "Start-Class", "Spring-Boot-Version", "Spring-Boot-Classes", "Spring-Boot-Lib",
+	"Implementation-Title", "Implementation-Version", "Build-Jdk-Spec", "Build-Jdk", "Created-By",
+	"Multi-Release", "Automatic-Module-Name", "Class-Path", "Add-Opens", "Add-Exports",
+}
+
+// jarVersionPattern extracts the version from a jar file name.
+var jarVersionPattern = regexp.MustCompile(`-(\d[\w.+-]*)\.jar$`)
+
+// InspectJarInput represents the input parameters for the inspect_jar tool
+type InspectJarInput struct {
+	Path      string `json:"path" description:"Absolute path to a jar or war (Spring Boot fat jars are opened including BOOT-INF/lib and WEB-INF/lib), or to a directory of jars such as lib/."`
+	ClassName string `json:"class_name,omitempty" description:"Optional: Fully qualified class (e.g. 'org.springframework.core.ResolvableType') or package to locate in the jars."`
+}
+
+// ClassLocation represents a jar containing the requested class
+type ClassLocation struct {
+	Jar          string `json:"jar" description:"The jar, nested jars as 'app.jar!/BOOT-INF/lib/x.jar'"`
+	Entry        string `json:"entry" description:"The class file entry"`
+	MajorVersion int    `json:"major_version" description:"Class file major version"`
+	Java         int    `json:"java" description:"Java release required by the class file"`
+	CRC          string `json:"crc" description:"CRC-32 of the class file; equal values mean identical copies"`
+}
+
+// PackageLocation represents a jar containing classes of the requested package
+type PackageLocation struct {
+	Jar     string `json:"jar" description:"The jar"`
+	Classes int    `json:"classes" description:"Number of classes of the package in the jar"`
+}
+
+// JarSummary represents one inspected jar
+type JarSummary struct {
+	Jar             string `json:"jar" description:"The jar"`
+	Version         string `json:"version,omitempty" description:"Implementation-Version from its manifest, or the version in the file name"`
+	Classes         int    `json:"classes" description:"Number of classes"`
+	MaxMajorVersion int    `json:"max_major_version,omitempty" description:"Highest class file major version"`
+	Java            int    `json:"java,omitempty" description:"Java release required by its newest class files"`
+}
+
+// DuplicateClassSet represents classes found in every jar of a set
+type DuplicateClassSet struct {
+	Jars      []string `json:"jars" description:"Jars that all contain the classes"`
+	Classes   int      `json:"classes" description:"Number of duplicated classes"`
+	Identical int      `json:"identical" description:"How many of them are byte-identical copies; differing copies cause NoSuchMethodError and similar errors depending on classpath order"`
+	Examples  []string `json:"examples" description:"Some of the duplicated classes, differing copies first"`
+}
+
+// SplitPackage represents a package whose classes are spread over several jars
+type SplitPackage struct {
+	Package string         `json:"package" description:"The package"`
+	Jars    map[string]int `json:"jars" description:"Number of classes of the package per jar"`
+}
+
+// InspectJarOutput represents the output of the inspect_jar tool
+type InspectJarOutput struct {
+	Path             string              `json:"path" description:"The inspected path"`
+	Kind             string              `json:"kind" description:"'spring-boot' fat jar, 'war', 'jar' or 'directory'"`
+	Manifest         map[string]string   `json:"manifest,omitempty" description:"Main attributes of MANIFEST.MF of the jar"`
+	ClassLocations   []ClassLocation     `json:"class_locations,omitempty" description:"Jars containing the requested class"`
+	PackageLocations []PackageLocation   `json:"package_locations,omitempty" description:"Jars containing the requested package"`
+	ClassVersions    map[string]int      `json:"class_versions" description:"Number of class files per major version, e.g. '61 (Java 17)'"`
+	Duplicates       []DuplicateClassSet `json:"duplicates" description:"Classes contained in more than one jar, grouped by the set of jars"`
+	SplitPackages    []SplitPackage      `json:"split_packages,omitempty" description:"Packages spread over several jars, excluding packages only split by duplicated classes"`
+	Jars             []JarSummary        `json:"jars" description:"The inspected jars"`
+	TotalJars        int                 `json:"total_jars" description:"Number of inspected jars"`
+	Truncated        bool                `json:"truncated" description:"Whether jars, duplicate sets or split packages were omitted"`
+	Errors           []string            `json:"errors,omitempty" description:"Jars that could not be read"`
+}
+
+// InspectJarTool is a tool that inspects jars for classpath conflicts.
+var InspectJarTool tool.InvokableTool
+
+func init() {
+	var err error
+	InspectJarTool, err = utils.InferTool(
+		"inspect_jar",
+		"Opens a jar, a Spring Boot fat jar or war (including its BOOT-INF/lib and WEB-INF/lib jars) or a directory of jars, and reports which jars contain a given class or package, classes duplicated across jars (with whether the copies are identical), split packages, the MANIFEST.MF attributes and the class file major versions. Use it to prove classpath conflicts behind NoSuchMethodError, NoClassDefFoundError, ClassCastException or LinkageError, and to explain UnsupportedClassVersionError.",
+		inspectJar,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create inspect_jar tool: %v", err))
+	}
+}
+
+// classEntry is a class file found in a jar.
+type classEntry struct {
+	entry string
+	major int
+	crc   uint32
+}
+
+// jarContent is the class index of one jar.
+type jarContent struct {
+	name     string
+	manifest map[string]string
+	classes  map[string]classEntry
+}
+
+// inspectJar indexes the classes of the jars and reports conflicts.
+func inspectJar(ctx context.Context, input InspectJarInput) (InspectJarOutput, error) {
+	if !filepath.IsAbs(input.Path) {
+		return InspectJarOutput{}, fmt.Errorf("path must be absolute: %s", input.Path)
+	}
+	if err := currentSandbox().Check(input.Path); err != nil {
+		return InspectJarOutput{}, err
+	}
+	info, err := os.Stat(input.Path)
+	if err != nil {
+		return InspectJarOutput{}, fmt.Errorf("failed to access path: %w", err)
+	}
+
+	output := InspectJarOutput{Path: input.Path, ClassVersions: make(map[string]int), Duplicates: []DuplicateClassSet{}, Jars: []JarSummary{}}
+	var jars []*jarContent
+	if info.IsDir() {
+		output.Kind = "directory"
+		paths, err := findJars(ctx, input.Path)
+		if err != nil {
+			return output, err
+		}
+		if len(paths) > maxJars {
+			paths = paths[:maxJars]
+			output.Truncated = true
+		}
+		for _, path := range paths {
+			if err := ctx.Err(); err != nil {
+				return output, err
+			}
+			name, _ := filepath.Rel(input.Path, path)
+			contents, err := openJar(path, filepath.ToSlash(name))
+			if err != nil {
+				output.Errors = append(output.Errors, err.Error())
+				continue
+			}
+			jars = append(jars, contents...)
+		}
+	} else {
+		contents, err := openJar(input.Path, filepath.Base(input.Path))
+		if err != nil {
+			return output, err
+		}
+		jars = contents
+		output.Manifest = jars[0].manifest
+		switch {
+		case output.Manifest["Spring-Boot-Version"] != "" || output.Manifest["Start-Class"] != "":
+			output.Kind = "spring-boot"
+		case strings.HasSuffix(strings.ToLower(input.Path), ".war"):
+			output.Kind = "war"
+		default:
+			output.Kind = "jar"
+		}
+	}
+
+	// Index classes by name across all jars
+	owners := make(map[string][]int)
+	for i, jar := range jars {
+		summary := JarSummary{Jar: jar.name, Classes: len(jar.classes), Version: jar.manifest["Implementation-Version"]}
+		if summary.Version == "" {
+			if m := jarVersionPattern.FindStringSubmatch(jar.name); m != nil {
+				summary.Version = m[1]
+			}
+		}
+		for class, entry := range jar.classes {
+			owners[class] = append(owners[class], i)
+			if entry.major > 0 {
+				output.ClassVersions[classVersionLabel(entry.major)]++
+				summary.MaxMajorVersion = max(summary.MaxMajorVersion, entry.major)
+			}
+		}
+		if summary.MaxMajorVersion > 0 {
+			summary.Java = javaRelease(summary.MaxMajorVersion)
+		}
+		output.Jars = append(output.Jars, summary)
+	}
+	output.TotalJars = len(jars)
+	// Newest bytecode first, it is what UnsupportedClassVersionError complains about
+	sort.SliceStable(output.Jars, func(i, j int) bool { return output.Jars[i].MaxMajorVersion > output.Jars[j].MaxMajorVersion })
+	if len(output.Jars) > maxJarSummaries {
+		output.Jars = output.Jars[:maxJarSummaries]
+		output.Truncated = true
+	}
+
+	if input.ClassName != "" {
+		name := strings.TrimSuffix(strings.ReplaceAll(strings.TrimSpace(input.ClassName), "/", "."), ".class")
+		for _, i := range owners[name] {
+			entry := jars[i].classes[name]
+			output.ClassLocations = append(output.ClassLocations, ClassLocation{
+				Jar:          jars[i].name,
+				Entry:        entry.entry,
+				MajorVersion: entry.major,
+				Java:         javaRelease(entry.major),
+				CRC:          fmt.Sprintf("%08x", entry.crc),
+			})
+		}
+		if len(output.ClassLocations) == 0 {
+			output.PackageLocations = packageLocations(jars, name)
+		}
+	}
+
+	var truncated bool
+	output.Duplicates, truncated = duplicateClasses(jars, owners)
+	output.Truncated = output.Truncated || truncated
+	output.SplitPackages, truncated = splitPackages(jars, owners)
+	output.Truncated = output.Truncated || truncated
+	return output, nil
+}
+
+// findJars returns the jars and wars below a directory.
+func findJars(ctx context.Context, root string) ([]string, error) {
+	box := currentSandbox()
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(d.Name())
+		if (strings.HasSuffix(name, ".jar") || strings.HasSuffix(name, ".war")) && box.Check(path) == nil {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return paths, nil
+}
+
+// openJar indexes a jar file and the jars nested in it.
+func openJar(path, name string) ([]*jarContent, error) {
+	reader, file, err := openZip(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+	return indexJar(reader, name, true), nil
+}
+
+// indexJar indexes the classes of a jar. Classes under BOOT-INF/classes and
+// WEB-INF/classes form a jar of their own, and nested jars are indexed
+// separately when nested is true.
+func indexJar(reader *zip.Reader, name string, nested bool) []*jarContent {
+	root := &jarContent{name: name, manifest: map[string]string{}, classes: make(map[string]classEntry)}
+	jars := []*jarContent{root}
+	var appClasses *jarContent
+	for _, f := range reader.File {
+		entry := f.Name
+		switch {
+		case entry == "META-INF/MANIFEST.MF":
+			root.manifest = readManifest(f)
+		case strings.HasSuffix(entry, ".jar") && nested:
+			inner, err := openNestedJar(f, name+archiveSeparator+entry)
+			if err != nil {
+				// A corrupt or oversized nested jar does not hide the others
+				continue
+			}
+			jars = append(jars, inner...)
+		case strings.HasSuffix(entry, ".class"):
+			target, class := root, entry
+			for _, prefix := range []string{"BOOT-INF/classes/", "WEB-INF/classes/"} {
+				if rest, ok := strings.CutPrefix(entry, prefix); ok {
+					if appClasses == nil {
+						appClasses = &jarContent{name: name + archiveSeparator + strings.TrimSuffix(prefix, "/"), manifest: map[string]string{}, classes: make(map[string]classEntry)}
+					}
+					target, class = appClasses, rest
+				}
+			}
+			// Multi-release variants and module descriptors are not separate classes
+			if strings.HasPrefix(class, "META-INF/") || strings.HasSuffix(class, "module-info.class") {
+				continue
+			}
+			class = strings.ReplaceAll(strings.TrimSuffix(class, ".class"), "/", ".")
+			target.classes[class] = classEntry{entry: entry, major: classMajorVersion(f), crc: f.CRC32}
+		}
+	}
+	if appClasses != nil {
+		jars = append(jars, appClasses)
+	}
+	return jars
+}
+
+// openNestedJar reads a jar stored inside another jar and indexes it.
+func openNestedJar(f *zip.File, name string) ([]*jarContent, error) {
+	if f.UncompressedSize64 > maxNestedJarSize {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return indexJar(reader, name, false), nil
+}
+
+// classMajorVersion reads the major version from a class file header, or
+// returns 0 if the entry is not a valid class file.
+func classMajorVersion(f *zip.File) int {
+	rc, err := f.Open()
+	if err != nil {
+		return 0
+	}
+	defer rc.Close()
+	var header [8]byte
+	if _, err := io.ReadFull(rc, header[:]); err != nil {
+		return 0
+	}
+	if binary.BigEndian.Uint32(header[:4]) != 0xCAFEBABE {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(header[6:8]))
+}
+
+// javaRelease returns the Java release of a class file major version.
+func javaRelease(major int) int {
+	if major < 49 {
+		// Java 1.4 and older
+		return 1
+	}
+	return major - 44
+}
+
+// classVersionLabel formats a class file major version with its Java release.
+func classVersionLabel(major int) string {
+	return strconv.Itoa(major) + " (Java " + strconv.Itoa(javaRelease(major)) + ")"
+}
+
+// readManifest returns the reported main attributes of a MANIFEST.MF.
+func readManifest(f *zip.File) map[string]string {
+	attributes := make(map[string]string)
+	rc, err := f.Open()
+	if err != nil {
+		return attributes
+	}
+	defer rc.Close()
+
+	var key string
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			// The main section ends at the first blank line
+			break
+		}
+		if strings.HasPrefix(line, " ") {
+			// Continuation of the previous value, lines are wrapped at 72 bytes
+			if key != "" {
+				attributes[key] += line[1:]
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = name
+		attributes[key] = strings.TrimSpace(value)
+	}
+
+	reported := make(map[string]string)
+	for _, name := range manifestKeys {
+		if value, ok := attributes[name]; ok {
+			reported[name] = value
+		}
+	}
+	return reported
+}
+
+// packageOf returns the package of a class, "" for the default package.
+func packageOf(class string) string {
+	if i := strings.LastIndexByte(class, '.'); i >= 0 {
+		return class[:i]
+	}
+	return ""
+}
+
+// packageLocations returns the jars containing classes of a package.
+func packageLocations(jars []*jarContent, pkg string) []PackageLocation {
+	var locations []PackageLocation
+	for _, jar := range jars {
+		count := 0
+		for class := range jar.classes {
+			if packageOf(class) == pkg {
+				count++
+			}
+		}
+		if count > 0 {
+			locations = append(locations, PackageLocation{Jar: jar.name, Classes: count})
+		}
+	}
+	return locations
+}
+
+// duplicateClasses groups the classes found in more than one jar by the set
+// of jars that contain them, largest sets first.
+func duplicateClasses(jars []*jarContent, owners map[string][]int) ([]DuplicateClassSet, bool) {
+	type duplicateSet struct {
+		jars      []int
+		classes   []string
+		identical int
+	}
+	sets := make(map[string]*duplicateSet)
+	for class, indexes := range owners {
+		if len(indexes) < 2 {
+			continue
+		}
+		key := fmt.Sprint(indexes)
+		set, ok := sets[key]
+		if !ok {
+			set = &duplicateSet{jars: indexes}
+			sets[key] = set
+		}
+		set.classes = append(set.classes, class)
+		if !differs(jars, indexes, class) {
+			set.identical++
+		}
+	}
+
+	result := []DuplicateClassSet{}
+	for _, set := range sets {
+		// Differing copies are the dangerous ones, list them first
+		sort.Slice(set.classes, func(i, j int) bool {
+			di, dj := differs(jars, set.jars, set.classes[i]), differs(jars, set.jars, set.classes[j])
+			if di != dj {
+				return di
+			}
+			return set.classes[i] < set.classes[j]
+		})
+		names := make([]string, len(set.jars))
+		for i, index := range set.jars {
+			names[i] = jars[index].name
+		}
+		result = append(result, DuplicateClassSet{
+			Jars:      names,
+			Classes:   len(set.classes),
+			Identical: set.identical,
+			Examples:  set.classes[:min(len(set.classes), maxDuplicateExamples)],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		// Sets with differing copies first, then by size
+		di, dj := result[i].Classes > result[i].Identical, result[j].Classes > result[j].Identical
+		if di != dj {
+			return di
+		}
+		if result[i].Classes != result[j].Classes {
+			return result[i].Classes > result[j].Classes
+		}
+		return strings.Join(result[i].Jars, ",") < strings.Join(result[j].Jars, ",")
+	})
+	if len(result) > maxDuplicateSets {
+		return result[:maxDuplicateSets], true
+	}
+	return result, false
+}
+
+// differs reports whether the copies of a class in the jars are not identical.
+func differs(jars []*jarContent, indexes []int, class string) bool {
+	for _, i := range indexes[1:] {
+		if jars[i].classes[class].crc != jars[indexes[0]].classes[class].crc {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPackages returns packages whose distinct classes are spread over
+// several jars. Packages that only appear in several jars because of
+// duplicated classes are reported as duplicates instead.
+func splitPackages(jars []*jarContent, owners map[string][]int) ([]SplitPackage, bool) {
+	packages := make(map[string]map[int]int)
+	for class, indexes := range owners {
+		if len(indexes) > 1 {
+			continue
+		}
+		pkg := packageOf(class)
+		if pkg == "" {
+			continue
+		}
+		if packages[pkg] == nil {
+			packages[pkg] = make(map[int]int)
+		}
+		packages[pkg][indexes[0]]++
+	}
+
+	var result []SplitPackage
+	for pkg, counts := range packages {
+		if len(counts) < 2 {
+			continue
+		}
+		split := SplitPackage{Package: pkg, Jars: make(map[string]int)}
+		for i, count := range counts {
+			split.Jars[jars[i].name] = count
+		}
+		result = append(result, split)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Package < result[j].Package })
+	if len(result) > maxSplitPackages {
+		return result[:maxSplitPackages], true
+	}
+	return result, false
+}
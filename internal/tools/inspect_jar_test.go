@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// classFile returns a class file of the given major version; body tells copies apart.
+func classFile(major int, body string) string {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, 0xCAFEBABE)
+	binary.BigEndian.PutUint16(header[6:], uint16(major))
+	return string(header) + body
+}
+
+// writeFatJar writes a Spring Boot fat jar with conflicting nested jars.
+func writeFatJar(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.jar")
+	writeZip(t, path, map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\n" +
+			"Main-Class: org.springframework.boot.loader.JarLauncher\r\n" +
+			"Start-Class: com.example.App\r\n" +
+			"Spring-Boot-Version: 2.7.18\r\n" +
+			"Implementation-Title: java-startup-analyzer-example-application-with-a-ve\r\n" +
+			" ry-long-title\r\n" +
+			"Built-By: ci\r\n" +
+			"\r\n" +
+			"Name: com/example/\r\n" +
+			"Implementation-Version: 9.9.9\r\n",
+		"org/springframework/boot/loader/JarLauncher.class": classFile(52, "launcher"),
+		"BOOT-INF/classes/com/example/App.class":            classFile(61, "app"),
+		"BOOT-INF/classes/bad.class":                        "not a class file",
+		"BOOT-INF/lib/slf4j-api-1.7.36.jar": string(zipBytes(t, map[string]string{
+			"org/slf4j/LoggerFactory.class": classFile(52, "1.7.36"),
+			"org/slf4j/Logger.class":        classFile(52, "logger"),
+		})),
+		"BOOT-INF/lib/slf4j-api-1.7.25.jar": string(zipBytes(t, map[string]string{
+			"org/slf4j/LoggerFactory.class": classFile(50, "1.7.25"),
+			"org/slf4j/Logger.class":        classFile(52, "logger"),
+		})),
+		"BOOT-INF/lib/logback-classic-1.2.12.jar": string(zipBytes(t, map[string]string{
+			"META-INF/MANIFEST.MF":                    "Manifest-Version: 1.0\nImplementation-Version: 1.2.12-patched\n",
+			"org/slf4j/impl/StaticLoggerBinder.class": classFile(52, "binder"),
+			"META-INF/versions/9/module-info.class":   classFile(53, "module"),
+			"lib/shaded.jar":                          string(zipBytes(t, map[string]string{"x/Shaded.class": classFile(52, "")})),
+		})),
+		"BOOT-INF/lib/slf4j-log4j12-1.7.36.jar": string(zipBytes(t, map[string]string{
+			"org/slf4j/impl/StaticMDCBinder.class": classFile(52, "mdc"),
+		})),
+		"BOOT-INF/lib/broken.jar": "not a zip",
+	})
+	return path
+}
+
+func TestIndexJar(t *testing.T) {
+	jars, err := openJar(writeFatJar(t), "app.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	var names []string
+	for _, jar := range jars {
+		names = append(names, jar.name)
+		for class, entry := range jar.classes {
+			got[jar.name] = append(got[jar.name], class+"@"+classVersionLabel(entry.major))
+		}
+		sort.Strings(got[jar.name])
+	}
+
+	// The broken jar is skipped, application classes come last
+	wantNames := []string{
+		"app.jar",
+		"app.jar!/BOOT-INF/lib/logback-classic-1.2.12.jar",
+		"app.jar!/BOOT-INF/lib/slf4j-api-1.7.25.jar",
+		"app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar",
+		"app.jar!/BOOT-INF/lib/slf4j-log4j12-1.7.36.jar",
+		"app.jar!/BOOT-INF/classes",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("jars = %v, want %v", names, wantNames)
+	}
+	want := map[string][]string{
+		"app.jar": {"org.springframework.boot.loader.JarLauncher@52 (Java 8)"},
+		// Jars nested two levels deep and module descriptors are not indexed
+		"app.jar!/BOOT-INF/lib/logback-classic-1.2.12.jar": {"org.slf4j.impl.StaticLoggerBinder@52 (Java 8)"},
+		"app.jar!/BOOT-INF/lib/slf4j-api-1.7.25.jar":       {"org.slf4j.Logger@52 (Java 8)", "org.slf4j.LoggerFactory@50 (Java 6)"},
+		"app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar":       {"org.slf4j.Logger@52 (Java 8)", "org.slf4j.LoggerFactory@52 (Java 8)"},
+		"app.jar!/BOOT-INF/lib/slf4j-log4j12-1.7.36.jar":   {"org.slf4j.impl.StaticMDCBinder@52 (Java 8)"},
+		// An invalid class file has no version
+		"app.jar!/BOOT-INF/classes": {"bad@0 (Java 1)", "com.example.App@61 (Java 17)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("classes = %v, want %v", got, want)
+	}
+	if entry := jars[len(jars)-1].classes["com.example.App"]; entry.entry != "BOOT-INF/classes/com/example/App.class" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	jars, err := openJar(writeFatJar(t), "app.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wrapped lines are joined, per-entry sections and unreported keys are ignored
+	want := map[string]string{
+		"Main-Class":           "org.springframework.boot.loader.JarLauncher",
+		"Start-Class":          "com.example.App",
+		"Spring-Boot-Version":  "2.7.18",
+		"Implementation-Title": "java-startup-analyzer-example-application-with-a-very-long-title",
+	}
+	if !reflect.DeepEqual(jars[0].manifest, want) {
+		t.Errorf("manifest = %v, want %v", jars[0].manifest, want)
+	}
+	if v := jars[1].manifest["Implementation-Version"]; v != "1.2.12-patched" {
+		t.Errorf("nested manifest version = %q", v)
+	}
+}
+
+func TestClassMajorVersion(t *testing.T) {
+	data := zipBytes(t, map[string]string{
+		"A.class":     classFile(65, "body"),
+		"B.class":     "\xca\xfe\xba",
+		"C.class":     "PK\x03\x04not a class",
+		"empty.class": "",
+	})
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"A.class": 65, "B.class": 0, "C.class": 0, "empty.class": 0}
+	for _, f := range reader.File {
+		if got := classMajorVersion(f); got != want[f.Name] {
+			t.Errorf("classMajorVersion(%s) = %d, want %d", f.Name, got, want[f.Name])
+		}
+	}
+	if javaRelease(65) != 21 || javaRelease(48) != 1 {
+		t.Errorf("unexpected java releases: %d %d", javaRelease(65), javaRelease(48))
+	}
+}
+
+func TestDuplicateClasses(t *testing.T) {
+	jar := func(name string, classes map[string]uint32) *jarContent {
+		content := &jarContent{name: name, classes: make(map[string]classEntry)}
+		for class, crc := range classes {
+			content.classes[class] = classEntry{crc: crc}
+		}
+		return content
+	}
+	jars := []*jarContent{
+		jar("a.jar", map[string]uint32{"p.A": 1, "p.B": 2, "p.C": 3, "q.D": 4}),
+		jar("b.jar", map[string]uint32{"p.A": 1, "p.B": 2, "p.C": 3}),
+		jar("c.jar", map[string]uint32{"q.D": 5, "q.E": 6}),
+	}
+	owners := make(map[string][]int)
+	for i, j := range jars {
+		for class := range j.classes {
+			owners[class] = append(owners[class], i)
+		}
+	}
+
+	got, truncated := duplicateClasses(jars, owners)
+	// The smaller set with a differing copy comes before the larger identical one
+	want := []DuplicateClassSet{
+		{Jars: []string{"a.jar", "c.jar"}, Classes: 1, Identical: 0, Examples: []string{"q.D"}},
+		{Jars: []string{"a.jar", "b.jar"}, Classes: 3, Identical: 3, Examples: []string{"p.A", "p.B", "p.C"}},
+	}
+	if truncated || !reflect.DeepEqual(got, want) {
+		t.Errorf("duplicateClasses = %+v, %v, want %+v", got, truncated, want)
+	}
+
+	// q.D is duplicated and q.E only in c.jar, so q is not a split package
+	if split, _ := splitPackages(jars, owners); len(split) != 0 {
+		t.Errorf("packages split only by duplicates should not be reported: %+v", split)
+	}
+}
+
+func TestInspectJar(t *testing.T) {
+	path := writeFatJar(t)
+	setTestSandbox(t, filepath.Dir(path))
+
+	output, err := inspectJar(context.Background(), InspectJarInput{Path: path, ClassName: "org/slf4j/LoggerFactory.class"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Kind != "spring-boot" || output.TotalJars != 6 || output.Truncated {
+		t.Errorf("unexpected summary: kind=%s jars=%d truncated=%v", output.Kind, output.TotalJars, output.Truncated)
+	}
+	if len(output.ClassLocations) != 2 || output.ClassLocations[0].CRC == output.ClassLocations[1].CRC {
+		t.Errorf("expected two differing copies: %+v", output.ClassLocations)
+	}
+
+	wantDuplicates := []DuplicateClassSet{{
+		Jars:      []string{"app.jar!/BOOT-INF/lib/slf4j-api-1.7.25.jar", "app.jar!/BOOT-INF/lib/slf4j-api-1.7.36.jar"},
+		Classes:   2,
+		Identical: 1,
+		Examples:  []string{"org.slf4j.LoggerFactory", "org.slf4j.Logger"},
+	}}
+	if !reflect.DeepEqual(output.Duplicates, wantDuplicates) {
+		t.Errorf("duplicates = %+v, want %+v", output.Duplicates, wantDuplicates)
+	}
+	wantSplit := []SplitPackage{{Package: "org.slf4j.impl", Jars: map[string]int{
+		"app.jar!/BOOT-INF/lib/logback-classic-1.2.12.jar": 1,
+		"app.jar!/BOOT-INF/lib/slf4j-log4j12-1.7.36.jar":   1,
+	}}}
+	if !reflect.DeepEqual(output.SplitPackages, wantSplit) {
+		t.Errorf("split packages = %+v, want %+v", output.SplitPackages, wantSplit)
+	}
+
+	// Newest bytecode first; versions come from the manifest or the file name
+	first := output.Jars[0]
+	if first.Jar != "app.jar!/BOOT-INF/classes" || first.MaxMajorVersion != 61 || first.Java != 17 {
+		t.Errorf("unexpected first jar: %+v", first)
+	}
+	versions := make(map[string]string)
+	for _, jar := range output.Jars {
+		versions[filepath.Base(jar.Jar)] = jar.Version
+	}
+	if versions["logback-classic-1.2.12.jar"] != "1.2.12-patched" || versions["slf4j-api-1.7.25.jar"] != "1.7.25" {
+		t.Errorf("unexpected versions: %v", versions)
+	}
+	wantVersions := map[string]int{"50 (Java 6)": 1, "52 (Java 8)": 6, "61 (Java 17)": 1}
+	if !reflect.DeepEqual(output.ClassVersions, wantVersions) {
+		t.Errorf("class versions = %v, want %v", output.ClassVersions, wantVersions)
+	}
+
+	output, err = inspectJar(context.Background(), InspectJarInput{Path: path, ClassName: "org.slf4j.impl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.ClassLocations) != 0 || len(output.PackageLocations) != 2 {
+		t.Errorf("expected the package in two jars: %+v", output.PackageLocations)
+	}
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/jvm"
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// JVMMemoryInput represents the input parameters for the jvm_memory tool
+type JVMMemoryInput struct {
+	Command string `json:"command,omitempty" description:"Optional: Java start command to check. Default: the configured start command."`
+	PID     int    `json:"pid,omitempty" description:"Optional: PID of a running JVM on this machine (Linux); its actual command line, environment and cgroup limits are checked instead of the start command."`
+}
+
+// JVMMemoryComponent represents one part of the estimated process memory
+type JVMMemoryComponent struct {
+	Name  string `json:"name" description:"Memory area"`
+	Size  string `json:"size" description:"Size, e.g. 512M"`
+	Basis string `json:"basis" description:"The flag that sets it or how it was estimated"`
+}
+
+// JVMMemoryFinding represents a sizing problem
+type JVMMemoryFinding struct {
+	Severity string `json:"severity" description:"error (fails startup or gets OOM killed), warning or info"`
+	Message  string `json:"message" description:"The problem with concrete numbers"`
+	Advice   string `json:"advice" description:"Suggested fix"`
+}
+
+// JVMMemoryOutput represents the output of the jvm_memory tool
+type JVMMemoryOutput struct {
+	Command       string               `json:"command" description:"The checked command"`
+	Flags         []string             `json:"flags" description:"Recognized JVM flags in effective order, with where they come from"`
+	FlagErrors    []string             `json:"flag_errors,omitempty" description:"Flags with invalid values"`
+	CgroupVersion int                  `json:"cgroup_version,omitempty" description:"cgroup version, absent when not in a cgroup"`
+	MemoryLimit   string               `json:"memory_limit" description:"cgroup memory limit, 'unlimited' when not set"`
+	MemoryPeak    string               `json:"memory_peak,omitempty" description:"Peak memory usage of the cgroup"`
+	OOMKills      int                  `json:"oom_kills,omitempty" description:"OOM kills recorded by the cgroup"`
+	CPUQuota      string               `json:"cpu_quota" description:"cgroup CPU quota in cores, 'unlimited' when not set"`
+	HostMemory    string               `json:"host_memory" description:"Physical memory of the host"`
+	HostAvailable string               `json:"host_available" description:"Currently available memory of the host"`
+	JVMMemory     string               `json:"jvm_memory" description:"Memory the JVM sizes its heap against, and where it comes from"`
+	MaxHeap       string               `json:"max_heap" description:"Effective maximum heap and how it is derived"`
+	Processors    int                  `json:"processors" description:"Processors the JVM sees"`
+	GC            string               `json:"gc" description:"Garbage collector the JVM uses"`
+	Footprint     []JVMMemoryComponent `json:"footprint" description:"Estimated process memory by area"`
+	Total         string               `json:"estimated_total" description:"Estimated total process memory"`
+	Findings      []JVMMemoryFinding   `json:"findings" description:"Sizing problems, errors first"`
+}
+
+// JVMMemoryTool is a tool that checks JVM memory options against container limits.
+var JVMMemoryTool tool.InvokableTool
+
+func init() {
+	var err error
+	JVMMemoryTool, err = utils.InferTool(
+		"jvm_memory",
+		"Checks the JVM memory and CPU options of the start command (-Xmx, -Xms, -XX:MaxRAMPercentage, -XX:MaxMetaspaceSize, -Xss, GC choice, also from JAVA_TOOL_OPTIONS, JDK_JAVA_OPTIONS and, for wrapper scripts, JAVA_OPTS) against the cgroup v1/v2 memory and CPU limits and the host memory. Derives the effective max heap, processor count and GC the way HotSpot does, estimates the total process memory (heap, metaspace, code cache, thread stacks, direct memory) and reports recorded OOM kills. Use it for OOMKilled, exit code 137, 'Cannot allocate memory', 'os::commit_memory failed' and OutOfMemoryError to get concrete sizing numbers.",
+		jvmMemory,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create jvm_memory tool: %v", err))
+	}
+}
+
+// jvmMemory compares the JVM options with the resource limits.
+func jvmMemory(ctx context.Context, input JVMMemoryInput) (JVMMemoryOutput, error) {
+	command := input.Command
+	env := currentAppEnvironment()
+	if input.PID > 0 {
+		var err error
+		command, env, err = processCommand(input.PID)
+		if err != nil {
+			return JVMMemoryOutput{}, err
+		}
+	}
+	if command == "" {
+		command = currentStartCommand()
+	}
+	if command == "" {
+		return JVMMemoryOutput{}, errors.New("command is required when no start command is configured")
+	}
+
+	opts := jvm.ParseOptions(command, env)
+	limits := jvm.ReadLimits("/")
+	if input.PID > 0 {
+		limits = jvm.ReadProcessLimits("/", input.PID)
+	}
+	report := jvm.Analyze(opts, limits)
+
+	output := JVMMemoryOutput{
+		Command:       command,
+		Flags:         []string{},
+		FlagErrors:    opts.Errors,
+		CgroupVersion: limits.CgroupVersion,
+		MemoryLimit:   "unlimited",
+		OOMKills:      limits.OOMKills,
+		CPUQuota:      "unlimited",
+		HostMemory:    jvm.FormatSize(limits.HostMemory),
+		HostAvailable: jvm.FormatSize(limits.HostAvailable),
+		JVMMemory:     fmt.Sprintf("%s (%s)", jvm.FormatSize(report.Memory), report.MemorySource),
+		MaxHeap:       fmt.Sprintf("%s (%s)", jvm.FormatSize(report.Heap), report.HeapBasis),
+		Processors:    report.Processors,
+		GC:            fmt.Sprintf("%s (%s)", report.GC, report.GCBasis),
+		Total:         jvm.FormatSize(report.Total),
+		Findings:      []JVMMemoryFinding{},
+	}
+	if limits.MemoryLimit > 0 {
+		output.MemoryLimit = jvm.FormatSize(limits.MemoryLimit)
+	}
+	if limits.MemoryPeak > 0 {
+		output.MemoryPeak = jvm.FormatSize(limits.MemoryPeak)
+	}
+	if limits.CPUQuota > 0 {
+		output.CPUQuota = strconv.FormatFloat(limits.CPUQuota, 'f', -1, 64)
+	}
+	for _, flag := range opts.Flags {
+		output.Flags = append(output.Flags, fmt.Sprintf("%s (%s)", flag.Arg, flag.Source))
+	}
+	for _, c := range report.Footprint {
+		output.Footprint = append(output.Footprint, JVMMemoryComponent{Name: c.Name, Size: jvm.FormatSize(c.Size), Basis: c.Basis})
+	}
+	for _, severity := range []jvm.Severity{jvm.SeverityError, jvm.SeverityWarning, jvm.SeverityInfo} {
+		for _, f := range report.Findings {
+			if f.Severity == severity {
+				output.Findings = append(output.Findings, JVMMemoryFinding{Severity: string(f.Severity), Message: f.Message, Advice: f.Advice})
+			}
+		}
+	}
+	return output, nil
+}
+
+// processCommand reads the command line and environment of a running process
+// from /proc, quoting the arguments so they split back the same way.
+func processCommand(pid int) (string, map[string]string, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read command line of process %d: %w", pid, err)
+	}
+	var args []string
+	for _, arg := range strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00") {
+		args = append(args, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	// The environment of another user's process is not readable, fall back to ours
+	env := shell.Environ(os.Environ())
+	if environ, err := os.ReadFile(filepath.Join(dir, "environ")); err == nil {
+		env = shell.Environ(strings.Split(string(environ), "\x00"))
+	}
+	return strings.Join(args, " "), env, nil
+}
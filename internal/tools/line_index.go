@@ -0,0 +1,400 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// indexStride is the number of lines between two recorded offsets.
+	indexStride = 1000
+	// indexChunkSize is the buffer size used when scanning a file for newlines.
+	indexChunkSize = 1 << 20
+	// reverseChunkSize is the block size used when reading backwards from EOF.
+	reverseChunkSize = 64 << 10
+	// syncIndexSize is the largest file whose index is built before answering
+	// a reverse read; larger files are indexed in the background.
+	syncIndexSize = 16 << 20
+	// indexHeadSize is the number of leading bytes hashed to recognize that a
+	// grown file is the same file with lines appended.
+	indexHeadSize = 4 << 10
+)
+
+// lineIndex is a sparse line-offset index of a file. Offsets[i] is the byte
+// offset of line i*indexStride (0-based). It is only valid for the file
+// size and modification time it was built for; when the file grows with the
+// same leading bytes, it is extended from its last offset.
+type lineIndex struct {
+	Path    string
+	Size    int64
+	ModTime int64
+	Lines   int
+	Offsets []int64
+	Head    [sha256.Size]byte // hash of the first indexHeadSize bytes
+}
+
+// matches reports whether the index was built for the current file state.
+func (idx *lineIndex) matches(path string, info os.FileInfo) bool {
+	return idx.Path == path && idx.Size == info.Size() && idx.ModTime == info.ModTime().UnixNano()
+}
+
+var (
+	indexMu       sync.Mutex
+	indexCache    = make(map[string]*lineIndex)
+	indexBuilding = make(map[string]chan struct{})
+)
+
+// cachedLineIndex returns the index for the file if one is already built,
+// either in memory or as a sidecar file in the cache directory.
+func cachedLineIndex(path string, info os.FileInfo) *lineIndex {
+	if idx := previousLineIndex(path); idx != nil && idx.matches(path, info) {
+		return idx
+	}
+	return nil
+}
+
+// previousLineIndex returns the last index built for the file, which may
+// be for an earlier state of the file, or nil.
+func previousLineIndex(path string) *lineIndex {
+	indexMu.Lock()
+	idx, ok := indexCache[path]
+	indexMu.Unlock()
+	if ok {
+		return idx
+	}
+
+	idx, err := loadSidecar(path)
+	if err != nil || idx.Path != path {
+		return nil
+	}
+	indexMu.Lock()
+	indexCache[path] = idx
+	indexMu.Unlock()
+	return idx
+}
+
+// getLineIndex returns the index for the file, building it in one pass if
+// needed. Concurrent callers for the same file share a single build.
+func getLineIndex(path string, info os.FileInfo) (*lineIndex, error) {
+	for {
+		if idx := cachedLineIndex(path, info); idx != nil {
+			return idx, nil
+		}
+
+		indexMu.Lock()
+		if done, ok := indexBuilding[path]; ok {
+			indexMu.Unlock()
+			<-done
+			continue
+		}
+		done := make(chan struct{})
+		indexBuilding[path] = done
+		indexMu.Unlock()
+
+		idx, err := buildLineIndex(path, info, previousLineIndex(path))
+		indexMu.Lock()
+		if err == nil {
+			indexCache[path] = idx
+		}
+		delete(indexBuilding, path)
+		indexMu.Unlock()
+		close(done)
+
+		if err != nil {
+			return nil, err
+		}
+		// The sidecar is only an optimization; log directories are often read-only.
+		_ = saveSidecar(idx)
+		return idx, nil
+	}
+}
+
+// buildLineIndexAsync starts building the index in the background unless a
+// build for the file is already running.
+func buildLineIndexAsync(path string, info os.FileInfo) {
+	indexMu.Lock()
+	_, building := indexBuilding[path]
+	indexMu.Unlock()
+	if !building {
+		go getLineIndex(path, info)
+	}
+}
+
+// buildLineIndex scans the first info.Size() bytes of the file and records
+// the offset of every indexStride-th line. If prev was built for a shorter
+// state of the same file, only the lines after its last offset are scanned.
+func buildLineIndex(path string, info os.FileInfo, prev *lineIndex) (*lineIndex, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	idx := &lineIndex{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Offsets: []int64{0},
+	}
+	if idx.Head, err = headHash(file, idx.Size); err != nil {
+		return nil, err
+	}
+	if idx.Size == 0 {
+		return idx, nil
+	}
+
+	var pos int64
+	if prev != nil && prev.Path == path && prev.Size < idx.Size && len(prev.Offsets) > 0 {
+		// The leading bytes tell an appended log from a rewritten one
+		head, err := headHash(file, prev.Size)
+		if err != nil {
+			return nil, err
+		}
+		if head == prev.Head {
+			checkpoint := len(prev.Offsets) - 1
+			idx.Offsets = append([]int64{}, prev.Offsets...)
+			idx.Lines = checkpoint * indexStride
+			pos = prev.Offsets[checkpoint]
+		}
+	}
+
+	// Only index the bytes that existed at stat time so the index matches
+	// the recorded size even if the log is still being written.
+	reader := io.NewSectionReader(file, pos, idx.Size-pos)
+	buf := make([]byte, indexChunkSize)
+	var last byte
+	for {
+		n, err := reader.Read(buf)
+		chunk := buf[:n]
+		for i := 0; ; {
+			j := bytes.IndexByte(chunk[i:], '\n')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+			idx.Lines++
+			if idx.Lines%indexStride == 0 {
+				idx.Offsets = append(idx.Offsets, pos+int64(i))
+			}
+		}
+		if n > 0 {
+			last = chunk[n-1]
+			pos += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A final line without a trailing newline still counts as a line
+	if last != '\n' {
+		idx.Lines++
+	} else if idx.Lines%indexStride == 0 {
+		// The last recorded offset points at EOF, not at a line
+		idx.Offsets = idx.Offsets[:len(idx.Offsets)-1]
+	}
+	return idx, nil
+}
+
+// headHash hashes the first indexHeadSize bytes of the first size bytes of
+// the file.
+func headHash(file io.ReaderAt, size int64) ([sha256.Size]byte, error) {
+	head := make([]byte, min(size, indexHeadSize))
+	if _, err := file.ReadAt(head, 0); err != nil && err != io.EOF {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(head), nil
+}
+
+// readLines returns lines [start, end) of the file using the index.
+func (idx *lineIndex) readLines(file *os.File, start, end int) ([]string, error) {
+	if start >= end {
+		return []string{}, nil
+	}
+	checkpoint := start / indexStride
+	if checkpoint >= len(idx.Offsets) {
+		checkpoint = len(idx.Offsets) - 1
+	}
+	reader := bufio.NewReaderSize(io.NewSectionReader(file, idx.Offsets[checkpoint], idx.Size-idx.Offsets[checkpoint]), reverseChunkSize)
+
+	lines := make([]string, 0, end-start)
+	for n := checkpoint * indexStride; n < end; n++ {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if n >= start {
+			lines = append(lines, trimLineEnding(line))
+		}
+	}
+	return lines, nil
+}
+
+// readLastLines reads backwards from the end of the first size bytes of the
+// file and returns up to n lines in file order, after skipping the last skip
+// lines. atStart reports whether the returned lines begin at the first line
+// of the file; total is the number of lines in the file when the whole file
+// was scanned. Skipped lines are only counted, so a large skip does not hold
+// them in memory.
+func readLastLines(file io.ReaderAt, size int64, skip, n int) (lines []string, atStart bool, total int, err error) {
+	if size == 0 || n <= 0 {
+		return []string{}, size == 0, 0, nil
+	}
+
+	// The newline ending the last line does not start a new line
+	end := size
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil && err != io.EOF {
+		return nil, false, 0, err
+	}
+	if last[0] == '\n' {
+		end--
+	}
+
+	// Scanning back from end, the skip-th newline ends the wanted lines and
+	// the (skip+n)-th one precedes them. Chunks are kept back to front.
+	var chunks [][]byte
+	cut, begin := end, int64(-1)
+	newlines := 0
+	pos := end
+	for pos > 0 && begin < 0 {
+		chunkSize := int64(reverseChunkSize)
+		if pos < chunkSize {
+			chunkSize = pos
+		}
+		pos -= chunkSize
+		chunk := make([]byte, chunkSize)
+		if _, err := file.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, false, 0, err
+		}
+		rest := chunk
+		for begin < 0 {
+			i := bytes.LastIndexByte(rest, '\n')
+			if i < 0 {
+				break
+			}
+			newlines++
+			if newlines == skip {
+				cut = pos + int64(i)
+			}
+			if newlines == skip+n {
+				begin = pos + int64(i) + 1
+			}
+			rest = rest[:i]
+		}
+		if begin >= 0 && pos == 0 {
+			// The rest of the file is in this chunk, count its lines too
+			total = newlines + bytes.Count(rest, []byte{'\n'}) + 1
+		}
+		if newlines >= skip && cut > pos {
+			from, to := int64(0), min(cut-pos, chunkSize)
+			if begin > pos {
+				from = begin - pos
+			}
+			chunks = append(chunks, chunk[from:to])
+		}
+	}
+	if begin < 0 {
+		begin = 0
+		atStart = true
+		total = newlines + 1
+	}
+	if newlines < skip {
+		return []string{}, atStart, total, nil
+	}
+
+	var buf []byte
+	for i := len(chunks) - 1; i >= 0; i-- {
+		buf = append(buf, chunks[i]...)
+	}
+	all := strings.Split(string(buf), "\n")
+	lines = make([]string, 0, len(all))
+	for _, line := range all {
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+	return lines, atStart, total, nil
+}
+
+// trimLineEnding removes the trailing "\n" or "\r\n" of a line.
+func trimLineEnding(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r")
+}
+
+// sidecarPath returns where the index of a file is cached.
+func sidecarPath(path string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(dir, "java-analyzer", "lineidx", hex.EncodeToString(sum[:16])+".idx"), nil
+}
+
+// loadSidecar reads a cached index from the cache directory.
+func loadSidecar(path string) (*lineIndex, error) {
+	sidecar, err := sidecarPath(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var idx lineIndex
+	if err := gob.NewDecoder(file).Decode(&idx); err != nil {
+		return nil, err
+	}
+	if len(idx.Offsets) == 0 {
+		return nil, errors.New("invalid line index")
+	}
+	return &idx, nil
+}
+
+// saveSidecar writes an index to the cache directory. Small files are not
+// worth caching on disk since they are re-indexed in milliseconds.
+func saveSidecar(idx *lineIndex) error {
+	if idx.Size < syncIndexSize {
+		return nil
+	}
+	sidecar, err := sidecarPath(idx.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(sidecar), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial index
+	tmp, err := os.CreateTemp(filepath.Dir(sidecar), ".idx-*")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), sidecar)
+}
@@ -0,0 +1,510 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression formats, detected by magic bytes rather than file extension.
+const (
+	compressionNone = ""
+	compressionGzip = "gzip"
+	compressionZstd = "zstd"
+	compressionZip  = "zip"
+)
+
+// archiveSeparator separates a zip archive from a member, as in "conf.zip!/application.yml".
+const archiveSeparator = "!/"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+
+	// compressionExts are stripped before looking at the rest of a file name.
+	compressionExts = []string{".gz", ".zst", ".zip"}
+
+	// errNoZstd is returned for zstd files when the zstd command is missing.
+	errNoZstd = errors.New("the zstd command is not installed")
+)
+
+// logSource is a single physical log: a plain file, a gzip or zstd
+// compressed file, or a member of a zip archive.
+type logSource struct {
+	Path        string // path shown to the model, archive.zip!/member for zip members
+	File        string // file on disk
+	Member      string // zip member name
+	Compression string
+	Info        os.FileInfo // of the file on disk
+}
+
+// resolveLogSource checks a path against the sandbox, stats it and detects
+// its compression. Zip archives must name a member with the archive.zip!/member syntax.
+func resolveLogSource(path string) (*logSource, error) {
+	if err := currentSandbox().Check(path); err != nil {
+		return nil, err
+	}
+	file, member := path, ""
+	if i := strings.Index(path, archiveSeparator); i >= 0 {
+		file, member = path[:i], path[i+len(archiveSeparator):]
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", path)
+	}
+	compression, err := sniffCompression(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	src := &logSource{Path: path, File: file, Member: member, Compression: compression, Info: info}
+	switch {
+	case member != "" && compression != compressionZip:
+		return nil, fmt.Errorf("not a zip archive: %s", file)
+	case member == "" && compression == compressionZip:
+		members, err := zipMembers(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip archive: %w", err)
+		}
+		if len(members) != 1 {
+			return nil, fmt.Errorf("%s is a zip archive, read a member as %s%s<member>; text members: %s",
+				path, path, archiveSeparator, strings.Join(members, ", "))
+		}
+		src.Member = members[0]
+		src.Path = file + archiveSeparator + members[0]
+	}
+	return src, nil
+}
+
+// openLogFile resolves a path and returns its decompressed content.
+func openLogFile(ctx context.Context, path string) (io.ReadCloser, *logSource, error) {
+	src, err := resolveLogSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := src.open(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return r, src, nil
+}
+
+// sniffCompression detects the compression of a file from its first bytes.
+func sniffCompression(path string) (string, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	n, err := io.ReadFull(file, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return compressionOf(magic[:n]), nil
+}
+
+// compressionOf returns the compression indicated by the magic bytes.
+func compressionOf(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return compressionGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		return compressionZstd
+	case bytes.HasPrefix(magic, zipMagic):
+		return compressionZip
+	default:
+		return compressionNone
+	}
+}
+
+// open returns the decompressed content of the source. ctx bounds the
+// lifetime of the external zstd process.
+func (s *logSource) open(ctx context.Context) (io.ReadCloser, error) {
+	switch s.Compression {
+	case compressionGzip:
+		file, err := openFile(s.File)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("invalid gzip file: %w", err)
+		}
+		return &multiCloser{Reader: gz, closers: []io.Closer{gz, file}}, nil
+
+	case compressionZstd:
+		return openZstd(ctx, s.File)
+
+	case compressionZip:
+		archive, file, err := openZip(s.File)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range archive.File {
+			if f.Name == s.Member {
+				rc, err := f.Open()
+				if err != nil {
+					file.Close()
+					return nil, err
+				}
+				return &multiCloser{Reader: rc, closers: []io.Closer{rc, file}}, nil
+			}
+		}
+		file.Close()
+		return nil, fmt.Errorf("member %s not found in %s", s.Member, s.File)
+
+	default:
+		return openFile(s.File)
+	}
+}
+
+// openZstd decompresses a file with the zstd command, the standard library
+// has no zstd decoder.
+func openZstd(ctx context.Context, path string) (io.ReadCloser, error) {
+	zstdPath, err := exec.LookPath("zstd")
+	if err != nil {
+		return nil, fmt.Errorf("%s is zstd compressed and cannot be read: %w (install zstd or decompress the file first)", path, errNoZstd)
+	}
+	// zstd reads the file we opened, not the path it could reopen
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := exec.CommandContext(ctx, zstdPath, "-dcq")
+	cmd.Stdin = file
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		file.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		file.Close()
+		return nil, err
+	}
+	return &zstdReader{ReadCloser: stdout, cmd: cmd, cancel: cancel, stderr: &stderr, file: file}, nil
+}
+
+// zstdReader reads the output of a zstd process.
+type zstdReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
+	stderr *bytes.Buffer
+	file   *os.File
+	once   sync.Once
+	err    error
+}
+
+// Read reports a failed decompression instead of a silent early EOF.
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.ReadCloser.Read(p)
+	if err == io.EOF {
+		if waitErr := z.wait(); waitErr != nil {
+			return n, fmt.Errorf("zstd: %v: %s", waitErr, strings.TrimSpace(z.stderr.String()))
+		}
+	}
+	return n, err
+}
+
+// Close stops the process if the output was not read to the end.
+func (z *zstdReader) Close() error {
+	z.cancel()
+	z.ReadCloser.Close()
+	z.wait()
+	z.file.Close()
+	return nil
+}
+
+func (z *zstdReader) wait() error {
+	z.once.Do(func() { z.err = z.cmd.Wait() })
+	return z.err
+}
+
+// multiCloser closes a decompressor together with the file beneath it.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var errs []error
+	for _, c := range m.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// zipMembers returns the names of the text members of a zip archive.
+func zipMembers(path string) ([]string, error) {
+	archive, file, err := openZip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var members []string
+	for _, f := range archive.File {
+		// Nested archives and compressed files are not searched
+		if f.FileInfo().IsDir() || isBinaryFile(f.Name) || stripCompressionExt(f.Name) != f.Name {
+			continue
+		}
+		members = append(members, f.Name)
+	}
+	return members, nil
+}
+
+// stripCompressionExt removes a trailing .gz, .zst or .zip from a file name.
+func stripCompressionExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range compressionExts {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Rotation kinds, in chronological order within a rotation set.
+const (
+	rotationDated   = iota // app.log.2025-09-22, app-2025-09-22.0.log.gz
+	rotationNumbers        // app.log.2.gz, app.log.1 (higher numbers are older)
+	rotationCurrent        // app.log
+)
+
+var (
+	// dateSuffix matches the date part of a time-based rotation, optionally
+	// followed by an index: 2025-09-22, 20250922, 2025-09-22.0, 2025-09-22_13
+	dateSuffix = `(\d{4}-?\d{2}-?\d{2}(?:[-_.]\d+)*)`
+
+	datedWithExtPattern = regexp.MustCompile(`^(.+?)[.-]` + dateSuffix + `(\.[A-Za-z]+)$`)
+	datedPattern        = regexp.MustCompile(`^(.+?)[.-]` + dateSuffix + `$`)
+	numberedPattern     = regexp.MustCompile(`^(.+?)\.(\d+)$`)
+	digitsPattern       = regexp.MustCompile(`\d+`)
+)
+
+// rotationKey orders the files of a rotation set.
+type rotationKey struct {
+	kind int
+	nums []int
+}
+
+// parseRotation returns the name of the current log a rotated file belongs
+// to, e.g. app.log for app.log.2.gz. ok is false for files that are not rotated.
+func parseRotation(name string) (current string, key rotationKey, ok bool) {
+	stripped := stripCompressionExt(name)
+	if m := datedWithExtPattern.FindStringSubmatch(stripped); m != nil {
+		return m[1] + m[3], rotationKey{rotationDated, parseDigits(m[2])}, true
+	}
+	if m := datedPattern.FindStringSubmatch(stripped); m != nil {
+		return m[1], rotationKey{rotationDated, parseDigits(m[2])}, true
+	}
+	if m := numberedPattern.FindStringSubmatch(stripped); m != nil {
+		return m[1], rotationKey{rotationNumbers, parseDigits(m[2])}, true
+	}
+	return name, rotationKey{kind: rotationCurrent}, false
+}
+
+// parseDigits returns the numbers in s.
+func parseDigits(s string) []int {
+	var nums []int
+	for _, d := range digitsPattern.FindAllString(s, -1) {
+		n, _ := strconv.Atoi(d)
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// olderThan reports whether a file with key k was rotated before one with key o.
+func (k rotationKey) olderThan(o rotationKey) bool {
+	if k.kind != o.kind {
+		return k.kind < o.kind
+	}
+	for i := 0; i < len(k.nums) && i < len(o.nums); i++ {
+		if k.nums[i] != o.nums[i] {
+			if k.kind == rotationNumbers {
+				return k.nums[i] > o.nums[i]
+			}
+			return k.nums[i] < o.nums[i]
+		}
+	}
+	return len(k.nums) < len(o.nums)
+}
+
+// rotationSet returns a log file and its rotated siblings in chronological
+// order, oldest first. The path itself is always the last element.
+func rotationSet(path string) ([]string, error) {
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type rotated struct {
+		path string
+		key  rotationKey
+	}
+	var files []rotated
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == base {
+			continue
+		}
+		if current, key, ok := parseRotation(entry.Name()); ok && current == base {
+			files = append(files, rotated{filepath.Join(dir, entry.Name()), key})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].key.olderThan(files[j].key) })
+
+	set := make([]string, 0, len(files)+1)
+	for _, f := range files {
+		set = append(set, f.path)
+	}
+	return append(set, path), nil
+}
+
+// sortRotations reorders paths so the files of each rotation set appear
+// oldest first, at the position of the set's first file.
+func sortRotations(paths []string) {
+	type entry struct {
+		path  string
+		group int
+		key   rotationKey
+	}
+	groups := make(map[string]int)
+	entries := make([]entry, len(paths))
+	for i, path := range paths {
+		current, key, _ := parseRotation(filepath.Base(path))
+		name := filepath.Join(filepath.Dir(path), current)
+		group, ok := groups[name]
+		if !ok {
+			group = len(groups)
+			groups[name] = group
+		}
+		entries[i] = entry{path, group, key}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].group != entries[j].group {
+			return entries[i].group < entries[j].group
+		}
+		return entries[i].key.olderThan(entries[j].key)
+	})
+	for i, e := range entries {
+		paths[i] = e.path
+	}
+}
+
+// streamLineCount is the cached number of lines of a compressed source.
+type streamLineCount struct {
+	size    int64
+	modTime int64
+	lines   int
+}
+
+var (
+	streamCountMu sync.Mutex
+	streamCounts  = make(map[string]streamLineCount)
+)
+
+// countLines returns the number of lines in the source. Plain files use the
+// line index; compressed sources are decompressed once and the count is cached.
+func (s *logSource) countLines(ctx context.Context) (int, error) {
+	if s.Compression == compressionNone {
+		idx, err := getLineIndex(s.File, s.Info)
+		if err != nil {
+			return 0, err
+		}
+		return idx.Lines, nil
+	}
+
+	streamCountMu.Lock()
+	cached, ok := streamCounts[s.Path]
+	streamCountMu.Unlock()
+	if ok && cached.size == s.Info.Size() && cached.modTime == s.Info.ModTime().UnixNano() {
+		return cached.lines, nil
+	}
+
+	lines, err := s.readLines(ctx, 0, -1, nil)
+	if err != nil {
+		return 0, err
+	}
+	streamCountMu.Lock()
+	streamCounts[s.Path] = streamLineCount{size: s.Info.Size(), modTime: s.Info.ModTime().UnixNano(), lines: lines}
+	streamCountMu.Unlock()
+	return lines, nil
+}
+
+// readLines calls fn for lines [start, end) of the source and returns the
+// number of lines read. An end of -1 reads to the end of the source.
+func (s *logSource) readLines(ctx context.Context, start, end int, fn func(string)) (int, error) {
+	if s.Compression == compressionNone && end >= 0 {
+		idx, err := getLineIndex(s.File, s.Info)
+		if err != nil {
+			return 0, err
+		}
+		file, err := openFile(s.File)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		lines, err := idx.readLines(file, start, min(end, idx.Lines))
+		if err != nil {
+			return 0, err
+		}
+		for _, line := range lines {
+			fn(line)
+		}
+		return start + len(lines), nil
+	}
+
+	r, err := s.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	reader := bufio.NewReaderSize(r, reverseChunkSize)
+	n := 0
+	for end < 0 || n < end {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if n%cancelCheckLines == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		if n >= start && fn != nil {
+			fn(trimLineEnding(line))
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,253 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		kind    int
+		nums    []int
+		ok      bool
+	}{
+		{"app.log", "app.log", rotationCurrent, nil, false},
+		{"app.log.1", "app.log", rotationNumbers, []int{1}, true},
+		{"app.log.2.gz", "app.log", rotationNumbers, []int{2}, true},
+		{"app.log.2025-09-22", "app.log", rotationDated, []int{2025, 9, 22}, true},
+		{"app.log.20250922.gz", "app.log", rotationDated, []int{20250922}, true},
+		{"app-2025-09-22.0.log.gz", "app.log", rotationDated, []int{2025, 9, 22, 0}, true},
+		{"app.2025-09-22_13.log.zst", "app.log", rotationDated, []int{2025, 9, 22, 13}, true},
+		// A compressed file without a rotation suffix is not part of a set
+		{"app.log.gz", "app.log.gz", rotationCurrent, nil, false},
+		{"catalina.out", "catalina.out", rotationCurrent, nil, false},
+	}
+	for _, tt := range tests {
+		current, key, ok := parseRotation(tt.name)
+		if current != tt.current || key.kind != tt.kind || !reflect.DeepEqual(key.nums, tt.nums) || ok != tt.ok {
+			t.Errorf("parseRotation(%q) = %q, %+v, %v, want %q, {%d %v}, %v", tt.name, current, key, ok, tt.current, tt.kind, tt.nums, tt.ok)
+		}
+	}
+}
+
+func TestSortRotations(t *testing.T) {
+	paths := []string{
+		"/logs/app.log",
+		"/logs/app.log.1",
+		"/logs/gc.log",
+		"/logs/app-2025-09-22.1.log.gz",
+		"/logs/app.log.2.gz",
+		"/logs/gc.log.0",
+		"/logs/app.log.2025-09-21.gz",
+		"/other/app.log.1",
+		"/logs/app-2025-09-22.0.log.gz",
+		"/logs/app-2025-09-21.1.log.gz",
+	}
+	sortRotations(paths)
+	want := []string{
+		// Dated files first in date order, then numbered files from the highest number, then the current log
+		"/logs/app.log.2025-09-21.gz",
+		"/logs/app-2025-09-21.1.log.gz",
+		"/logs/app-2025-09-22.0.log.gz",
+		"/logs/app-2025-09-22.1.log.gz",
+		"/logs/app.log.2.gz",
+		"/logs/app.log.1",
+		"/logs/app.log",
+		"/logs/gc.log.0",
+		"/logs/gc.log",
+		// The same name in another directory is another set
+		"/other/app.log.1",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(paths, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRotationSet(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app.log", "app.log.1", "app.log.2.gz", "app.log.2025-09-21.gz", "app.log.bak", "gc.log.1"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set, err := rotationSet(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, path := range set {
+		got = append(got, filepath.Base(path))
+	}
+	want := []string{"app.log.2025-09-21.gz", "app.log.2.gz", "app.log.1", "app.log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rotationSet = %v, want %v", got, want)
+	}
+}
+
+func TestReadRotated(t *testing.T) {
+	dir := t.TempDir()
+	writeGzip(t, filepath.Join(dir, "app.log.2.gz"), numberedLines(1, 3))
+	writeLog(t, filepath.Join(dir, "app.log.1"), numberedLines(4, 3))
+	writeLog(t, filepath.Join(dir, "app.log"), numberedLines(7, 3))
+
+	rotated := true
+	output, err := readFile(context.Background(), ReadFileInput{AbsolutePath: filepath.Join(dir, "app.log"), Rotated: &rotated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Content != strings.TrimSuffix(numberedLines(1, 9), "\n") || output.TotalLines != 9 {
+		t.Fatalf("unexpected rotated read: total=%d\n%s", output.TotalLines, output.Content)
+	}
+	want := []ReadSegment{
+		{FilePath: filepath.Join(dir, "app.log.2.gz"), StartLine: 1, Lines: 3},
+		{FilePath: filepath.Join(dir, "app.log.1"), StartLine: 1, Lines: 3},
+		{FilePath: filepath.Join(dir, "app.log"), StartLine: 1, Lines: 3},
+	}
+	if !reflect.DeepEqual(output.Segments, want) {
+		t.Errorf("segments = %+v, want %+v", output.Segments, want)
+	}
+}
+
+func TestReadArchives(t *testing.T) {
+	dir := t.TempDir()
+	gzPath := filepath.Join(dir, "app.log.1.gz")
+	writeGzip(t, gzPath, numberedLines(1, 5))
+	single := filepath.Join(dir, "single.zip")
+	writeZip(t, single, map[string]string{"app.log": numberedLines(1, 4)})
+	multi := filepath.Join(dir, "logs.zip")
+	writeZip(t, multi, map[string]string{
+		"app.log":         numberedLines(1, 2),
+		"logs/gc.log":     numberedLines(10, 3),
+		"app.log.1.gz":    "nested archives are not listed",
+		"classes/A.class": "\xca\xfe\xba\xbe",
+	})
+	plain := filepath.Join(dir, "plain.log")
+	writeLog(t, plain, numberedLines(1, 1))
+
+	tests := []struct {
+		path  string
+		first string
+		lines int
+	}{
+		{gzPath, "line 1", 5},
+		// A zip archive with a single text member reads that member
+		{single, "line 1", 4},
+		{multi + "!/logs/gc.log", "line 10", 3},
+	}
+	for _, tt := range tests {
+		output, err := readFile(context.Background(), ReadFileInput{AbsolutePath: tt.path})
+		if err != nil {
+			t.Errorf("readFile(%s): %v", tt.path, err)
+			continue
+		}
+		first, _, _ := strings.Cut(output.Content, "\n")
+		if first != tt.first || output.ReadLines != tt.lines || output.TotalLines != tt.lines {
+			t.Errorf("readFile(%s): first=%q lines=%d total=%d", tt.path, first, output.ReadLines, output.TotalLines)
+		}
+	}
+
+	errTests := []struct {
+		path string
+		want string
+	}{
+		{multi, "text members: app.log, logs/gc.log"},
+		{multi + "!/missing.log", "member missing.log not found"},
+		{plain + "!/app.log", "not a zip archive"},
+	}
+	for _, tt := range errTests {
+		_, err := readFile(context.Background(), ReadFileInput{AbsolutePath: tt.path})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("readFile(%s) error = %v, want %q", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestReadZstd(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := exec.LookPath("zstd"); err == nil {
+		path := filepath.Join(dir, "app.log.zst")
+		writeLog(t, filepath.Join(dir, "app.log"), numberedLines(1, 3))
+		if out, err := exec.Command("zstd", "-q", "-o", path, filepath.Join(dir, "app.log")).CombinedOutput(); err != nil {
+			t.Fatalf("zstd: %v\n%s", err, out)
+		}
+		output, err := readFile(context.Background(), ReadFileInput{AbsolutePath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.Content != strings.TrimSuffix(numberedLines(1, 3), "\n") {
+			t.Errorf("unexpected content: %q", output.Content)
+		}
+	}
+
+	// Without the zstd command the agent is told why the file cannot be read
+	path := filepath.Join(dir, "missing.log.zst")
+	if err := os.WriteFile(path, append(bytes.Clone(zstdMagic), 0, 0, 0, 0), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", t.TempDir())
+	_, err := readFile(context.Background(), ReadFileInput{AbsolutePath: path})
+	if !errors.Is(err, errNoZstd) {
+		t.Fatalf("expected a missing zstd error, got %v", err)
+	}
+	output, err := Sandboxed(ReadFileTool).InvokableRun(context.Background(), `{"absolute_path":"`+path+`"}`)
+	if err != nil || !strings.Contains(output, "zstd compressed and cannot be read: the zstd command is not installed") {
+		t.Errorf("a missing zstd command should be returned as output, got %s, %v", output, err)
+	}
+}
+
+// writeGzip writes content to a gzip compressed file.
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(content))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeZip writes a zip archive with the given members in name order.
+func writeZip(t *testing.T, path string, members map[string]string) {
+	t.Helper()
+	if err := os.WriteFile(path, zipBytes(t, members), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// zipBytes returns a zip archive with the given members in name order.
+func zipBytes(t *testing.T, members map[string]string) []byte {
+	t.Helper()
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(members[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
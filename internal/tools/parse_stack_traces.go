@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/logparse"
+)
+
+const (
+	defaultMaxTraces    = 10
+	defaultMaxFrames    = 8
+	maxExceptionMessage = 500
+)
+
+// ParseStackTracesInput represents the input parameters for the parse_stack_traces tool
+type ParseStackTracesInput struct {
+	AbsolutePath string `json:"absolute_path" description:"The absolute path to the log file to parse."`
+	MaxTraces    *int   `json:"max_traces,omitempty" description:"Optional: Maximum number of distinct stack traces to return, most recent first. Default: 10."`
+	MaxFrames    *int   `json:"max_frames,omitempty" description:"Optional: Maximum number of frames to return per exception. Default: 8."`
+}
+
+// ExceptionSummary represents one exception of a cause chain
+type ExceptionSummary struct {
+	Class       string   `json:"class" description:"Fully qualified exception class"`
+	Message     string   `json:"message,omitempty" description:"Exception message"`
+	LineNumber  int      `json:"line_number" description:"Line number of the exception header"`
+	Frames      []string `json:"frames,omitempty" description:"Top frames of the exception"`
+	TotalFrames int      `json:"total_frames" description:"Number of frames printed for the exception"`
+	Elided      int      `json:"elided,omitempty" description:"Frames elided by '... N more' because they are shared with the enclosing exception"`
+	Suppressed  []string `json:"suppressed,omitempty" description:"Suppressed exceptions with their deepest cause"`
+}
+
+// StackTraceSummary represents a distinct stack trace and how often it occurred
+type StackTraceSummary struct {
+	StartLine   int                `json:"start_line" description:"First line of the first occurrence"`
+	EndLine     int                `json:"end_line" description:"Last line of the first occurrence"`
+	LastLine    int                `json:"last_line" description:"First line of the most recent occurrence"`
+	Occurrences int                `json:"occurrences" description:"How many times this stack trace occurred"`
+	Thread      string             `json:"thread,omitempty" description:"Thread of an uncaught exception"`
+	Context     string             `json:"context,omitempty" description:"Log line preceding the stack trace"`
+	RootCause   ExceptionSummary   `json:"root_cause" description:"The deepest 'Caused by' exception, usually the real root cause"`
+	AppFrame    string             `json:"app_frame,omitempty" description:"First application (non JDK/framework) frame, searched from the deepest cause outwards"`
+	Chain       []ExceptionSummary `json:"chain" description:"The cause chain from the outermost exception to the deepest cause"`
+}
+
+// ParseStackTracesOutput represents the output of the parse_stack_traces tool
+type ParseStackTracesOutput struct {
+	FilePath         string              `json:"file_path" description:"The parsed log file"`
+	Traces           []StackTraceSummary `json:"traces" description:"Distinct stack traces ordered by their most recent occurrence"`
+	TotalTraces      int                 `json:"total_traces" description:"Number of distinct stack traces in the file"`
+	TotalOccurrences int                 `json:"total_occurrences" description:"Number of stack traces in the file including repeats"`
+	Truncated        bool                `json:"truncated" description:"Whether older distinct stack traces were omitted"`
+}
+
+// ParseStackTracesTool is a tool that extracts structured exceptions from a log file.
+var ParseStackTracesTool tool.InvokableTool
+
+func init() {
+	var err error
+	ParseStackTracesTool, err = utils.InferTool(
+		"parse_stack_traces",
+		"Extracts Java stack traces from a log file and returns them as structured cause chains: exception class, message, frames, 'Caused by:' chain, 'Suppressed:' blocks and '... N more' elisions. Identical traces are merged with an occurrence count. Each trace reports its deepest cause as root_cause, which is usually the real reason behind wrapper exceptions such as BeanCreationException.",
+		parseStackTraces,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create parse_stack_traces tool: %v", err))
+	}
+}
+
+// parseStackTraces parses a log file and summarizes its distinct stack traces.
+func parseStackTraces(ctx context.Context, input ParseStackTracesInput) (ParseStackTracesOutput, error) {
+	if !filepath.IsAbs(input.AbsolutePath) {
+		return ParseStackTracesOutput{}, fmt.Errorf("path must be absolute: %s", input.AbsolutePath)
+	}
+
+	file, _, err := openLogFile(ctx, input.AbsolutePath)
+	if err != nil {
+		return ParseStackTracesOutput{}, err
+	}
+	defer file.Close()
+
+	traces, err := logparse.Parse(file)
+	if err != nil {
+		return ParseStackTracesOutput{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	maxTraces := defaultMaxTraces
+	if input.MaxTraces != nil && *input.MaxTraces > 0 {
+		maxTraces = *input.MaxTraces
+	}
+	maxFrames := defaultMaxFrames
+	if input.MaxFrames != nil && *input.MaxFrames >= 0 {
+		maxFrames = *input.MaxFrames
+	}
+
+	// Merge repeated traces, keeping the first occurrence as the representative
+	var summaries []*StackTraceSummary
+	bySignature := make(map[string]*StackTraceSummary)
+	for _, trace := range traces {
+		signature := trace.Signature()
+		if summary, ok := bySignature[signature]; ok {
+			summary.Occurrences++
+			summary.LastLine = trace.StartLine
+			continue
+		}
+		summary := summarizeStackTrace(trace, maxFrames)
+		bySignature[signature] = summary
+		summaries = append(summaries, summary)
+	}
+
+	// The most recent traces are usually the ones that stopped the startup
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].LastLine > summaries[j].LastLine
+	})
+
+	output := ParseStackTracesOutput{
+		FilePath:         input.AbsolutePath,
+		Traces:           []StackTraceSummary{},
+		TotalTraces:      len(summaries),
+		TotalOccurrences: len(traces),
+	}
+	for i, summary := range summaries {
+		if i >= maxTraces {
+			output.Truncated = true
+			break
+		}
+		output.Traces = append(output.Traces, *summary)
+	}
+	return output, nil
+}
+
+// summarizeStackTrace converts a parsed stack trace into its tool representation.
+func summarizeStackTrace(trace *logparse.StackTrace, maxFrames int) *StackTraceSummary {
+	summary := &StackTraceSummary{
+		StartLine:   trace.StartLine,
+		EndLine:     trace.EndLine,
+		LastLine:    trace.StartLine,
+		Occurrences: 1,
+		Thread:      trace.Thread,
+		Context:     trace.Context,
+		RootCause:   summarizeException(trace.Exception.RootCause(), maxFrames),
+	}
+	if frame, ok := trace.Exception.AppFrame(); ok {
+		summary.AppFrame = frame.String()
+	}
+	for _, e := range trace.Exception.Chain() {
+		summary.Chain = append(summary.Chain, summarizeException(e, maxFrames))
+	}
+	return summary
+}
+
+// summarizeException converts a single exception, limiting frames and message length.
+func summarizeException(e *logparse.Exception, maxFrames int) ExceptionSummary {
+	summary := ExceptionSummary{
+		Class:       e.Class,
+		Message:     truncateRunes(e.Message, maxExceptionMessage),
+		LineNumber:  e.Line,
+		TotalFrames: len(e.Frames),
+		Elided:      e.Elided,
+	}
+	for i, f := range e.Frames {
+		if i >= maxFrames {
+			break
+		}
+		summary.Frames = append(summary.Frames, f.String())
+	}
+	for _, s := range e.Suppressed {
+		text := truncateRunes(s.Header(), maxExceptionMessage)
+		if root := s.RootCause(); root != s {
+			text += " (caused by " + truncateRunes(root.Header(), maxExceptionMessage) + ")"
+		}
+		summary.Suppressed = append(summary.Suppressed, text)
+	}
+	return summary
+}
+
+// truncateRunes shortens a string to at most n runes.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
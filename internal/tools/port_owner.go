@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/jvm"
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// PortOwnerInput represents the input parameters for the port_owner tool
+type PortOwnerInput struct {
+	Port int `json:"port" description:"The TCP port from the error, e.g. 8080 for 'Port 8080 was already in use'."`
+}
+
+// PortSocket represents a socket bound to the port and the process holding it
+type PortSocket struct {
+	Protocol            string `json:"protocol" description:"tcp or tcp6"`
+	LocalAddress        string `json:"local_address" description:"Bound address and port"`
+	RemoteAddress       string `json:"remote_address,omitempty" description:"Peer address of a connection"`
+	State               string `json:"state" description:"TCP state, LISTEN for a server socket"`
+	UID                 int    `json:"uid" description:"Owner user id of the socket"`
+	User                string `json:"user,omitempty" description:"Owner user name"`
+	PID                 int    `json:"pid,omitempty" description:"Process holding the socket, 0 if it is not visible to the analyzer"`
+	Command             string `json:"command,omitempty" description:"Command line of the process"`
+	Executable          string `json:"executable,omitempty" description:"Executable of the process"`
+	WorkingDir          string `json:"working_dir,omitempty" description:"Working directory of the process"`
+	Started             string `json:"started,omitempty" description:"When the process started"`
+	MatchesStartCommand bool   `json:"matches_start_command,omitempty" description:"Whether the process runs the same jar or main class as the configured start command, i.e. probably a stale instance of this application"`
+}
+
+// PortOwnerOutput represents the output of the port_owner tool
+type PortOwnerOutput struct {
+	Port        int            `json:"port" description:"The inspected port"`
+	Supported   bool           `json:"supported" description:"Whether port ownership can be inspected on this platform (Linux only)"`
+	Listeners   []PortSocket   `json:"listeners" description:"Sockets listening on the port with their owning processes"`
+	Connections []PortSocket   `json:"connections,omitempty" description:"Non-listening sockets using the port as local port, e.g. TIME_WAIT"`
+	StateCounts map[string]int `json:"state_counts,omitempty" description:"Number of sockets on the port per TCP state"`
+	Note        string         `json:"note,omitempty" description:"Why owners could not be determined"`
+}
+
+// PortOwnerTool is a tool that finds the process holding a TCP port.
+var PortOwnerTool tool.InvokableTool
+
+func init() {
+	var err error
+	PortOwnerTool, err = utils.InferTool(
+		"port_owner",
+		"Finds which process holds a TCP port on this machine (Linux): the listening sockets from /proc/net/tcp and tcp6 mapped through /proc/*/fd to the owning PID, command line, working directory and start time, and whether it runs the same jar or main class as the configured start command (a stale instance of the application). Use it when the log reports 'Port 8080 was already in use', 'Address already in use' or BindException.",
+		portOwner,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create port_owner tool: %v", err))
+	}
+}
+
+// portOwner lists the sockets bound to the port and their owners.
+func portOwner(ctx context.Context, input PortOwnerInput) (PortOwnerOutput, error) {
+	if input.Port <= 0 || input.Port > 65535 {
+		return PortOwnerOutput{}, fmt.Errorf("invalid port: %d", input.Port)
+	}
+	return findPortOwners(ctx, input.Port)
+}
+
+// javaValueOptions are the java launcher options whose value is the next argument.
+var javaValueOptions = map[string]bool{
+	"-cp": true, "-classpath": true, "--class-path": true,
+	"-p": true, "--module-path": true, "--upgrade-module-path": true,
+	"--add-modules": true, "--limit-modules": true, "--add-reads": true,
+	"--add-exports": true, "--add-opens": true, "--patch-module": true,
+}
+
+// startCommandTargets returns the jars and main class of the start command,
+// used to recognize another instance of the same application.
+func startCommandTargets(command string) []string {
+	var targets []string
+	for _, c := range shell.Parse(command) {
+		if !jvm.IsJava(c.Program) {
+			// A wrapper script, only the jars it is given are known
+			for _, arg := range c.Args {
+				if strings.HasSuffix(arg, ".jar") || strings.HasSuffix(arg, ".war") {
+					targets = append(targets, filepath.Base(arg))
+				}
+			}
+			continue
+		}
+		args := c.Args
+	options:
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch {
+			case arg == "-jar":
+				if i+1 < len(args) {
+					targets = append(targets, filepath.Base(args[i+1]))
+				}
+				break options
+			case arg == "-m" || arg == "--module":
+				if i+1 < len(args) {
+					targets = append(targets, moduleMain(args[i+1]))
+				}
+				break options
+			case strings.HasPrefix(arg, "--module="):
+				targets = append(targets, moduleMain(strings.TrimPrefix(arg, "--module=")))
+				break options
+			case javaValueOptions[arg]:
+				i++
+			case strings.HasPrefix(arg, "-"):
+			default:
+				// The first argument that is not an option is the main class,
+				// the rest are arguments of the application
+				targets = append(targets, arg)
+				break options
+			}
+		}
+	}
+	return targets
+}
+
+// moduleMain returns the main class of "module/mainclass", or the module.
+func moduleMain(module string) string {
+	if _, main, ok := strings.Cut(module, "/"); ok {
+		return main
+	}
+	return module
+}
+
+// matchesStartCommand reports whether a command line runs one of the targets.
+func matchesStartCommand(cmdline string, targets []string) bool {
+	for _, target := range targets {
+		if target != "" && strings.Contains(cmdline, target) {
+			return true
+		}
+	}
+	return false
+}
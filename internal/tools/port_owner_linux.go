@@ -0,0 +1,275 @@
+//go:build linux
+
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of process start times in /proc/<pid>/stat.
+// It is 100 on all mainstream Linux architectures.
+const clockTicks = 100
+
+// maxCommandLine caps the reported command line of a process.
+const maxCommandLine = 1000
+
+// tcpStates maps the state codes of /proc/net/tcp to their names.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED", "02": "SYN_SENT", "03": "SYN_RECV", "04": "FIN_WAIT1",
+	"05": "FIN_WAIT2", "06": "TIME_WAIT", "07": "CLOSE", "08": "CLOSE_WAIT",
+	"09": "LAST_ACK", "0A": "LISTEN", "0B": "CLOSING",
+}
+
+// procSocket is a socket entry of /proc/net/tcp{,6}.
+type procSocket struct {
+	PortSocket
+	inode string
+}
+
+// findPortOwners reads the TCP sockets on the port and resolves the
+// processes holding the listening ones.
+func findPortOwners(ctx context.Context, port int) (PortOwnerOutput, error) {
+	output := PortOwnerOutput{Port: port, Supported: true, Listeners: []PortSocket{}, StateCounts: make(map[string]int)}
+
+	var sockets []*procSocket
+	for _, proto := range []string{"tcp", "tcp6"} {
+		found, err := readProcNet(proto, port)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// No IPv6 support
+				continue
+			}
+			return output, err
+		}
+		sockets = append(sockets, found...)
+	}
+
+	wanted := make(map[string]*procSocket)
+	for _, s := range sockets {
+		output.StateCounts[s.State]++
+		if s.inode != "0" {
+			wanted[s.inode] = s
+		}
+	}
+	hidden := findSocketOwners(ctx, wanted)
+	if err := ctx.Err(); err != nil {
+		return output, err
+	}
+
+	targets := startCommandTargets(currentStartCommand())
+	for _, s := range sockets {
+		if s.PID > 0 {
+			s.MatchesStartCommand = matchesStartCommand(s.Command, targets)
+		}
+		if s.State == "LISTEN" {
+			output.Listeners = append(output.Listeners, s.PortSocket)
+		} else {
+			output.Connections = append(output.Connections, s.PortSocket)
+		}
+	}
+	for _, s := range output.Listeners {
+		if s.PID == 0 && hidden {
+			output.Note = "some processes could not be inspected; a listener without pid belongs to another user, run the analyzer as that user or root to see it"
+			break
+		}
+	}
+	if len(output.Listeners) == 0 && len(sockets) == 0 {
+		output.Note = "nothing is bound to the port now; the process holding it may have exited, or it runs in another network namespace (container)"
+	}
+	return output, nil
+}
+
+// readProcNet reads /proc/net/tcp or /proc/net/tcp6 and returns the
+// sockets whose local port is the given port.
+func readProcNet(proto string, port int) ([]*procSocket, error) {
+	file, err := os.Open(filepath.Join("/proc/net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseProcNet(file, proto, port)
+}
+
+// parseProcNet parses the socket table of /proc/net/tcp{,6}.
+func parseProcNet(r io.Reader, proto string, port int) ([]*procSocket, error) {
+	var sockets []*procSocket
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localIP, localPort, ok := parseProcAddress(fields[1])
+		if !ok || localPort != port {
+			continue
+		}
+		s := &procSocket{inode: fields[9]}
+		s.Protocol = proto
+		s.LocalAddress = net.JoinHostPort(localIP.String(), strconv.Itoa(localPort))
+		s.State = tcpStates[fields[3]]
+		if s.State == "" {
+			s.State = fields[3]
+		}
+		if s.State != "LISTEN" {
+			if remoteIP, remotePort, ok := parseProcAddress(fields[2]); ok {
+				s.RemoteAddress = net.JoinHostPort(remoteIP.String(), strconv.Itoa(remotePort))
+			}
+		}
+		s.UID, _ = strconv.Atoi(fields[7])
+		if u, err := user.LookupId(fields[7]); err == nil {
+			s.User = u.Username
+		}
+		sockets = append(sockets, s)
+	}
+	return sockets, scanner.Err()
+}
+
+// parseProcAddress parses "0100007F:1F90". The address is printed as 32-bit
+// words in host byte order, the port in big-endian hex.
+func parseProcAddress(s string) (net.IP, int, bool) {
+	addr, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, false
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, false
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return nil, 0, false
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	if v4 := ip.To4(); v4 != nil && len(raw) == 16 && !ip.IsUnspecified() {
+		// IPv4-mapped address of a dual-stack socket
+		ip = v4
+	}
+	return ip, int(port), true
+}
+
+// findSocketOwners scans /proc/*/fd for the socket inodes and fills in the
+// owning processes. It reports whether some processes could not be inspected.
+func findSocketOwners(ctx context.Context, sockets map[string]*procSocket) (hidden bool) {
+	if len(sockets) == 0 {
+		return false
+	}
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return true
+	}
+	remaining := len(sockets)
+	for _, entry := range entries {
+		if remaining == 0 || ctx.Err() != nil {
+			break
+		}
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			hidden = true
+			continue
+		}
+		var info *PortSocket
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			s, ok := sockets[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]
+			if !ok || s.PID != 0 {
+				continue
+			}
+			if info == nil {
+				info = processInfo(pid)
+			}
+			s.PID = pid
+			s.Command, s.Executable, s.WorkingDir, s.Started = info.Command, info.Executable, info.WorkingDir, info.Started
+			remaining--
+		}
+	}
+	return hidden
+}
+
+// processInfo reads the command line, executable, working directory and
+// start time of a process.
+func processInfo(pid int) *PortSocket {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	info := &PortSocket{PID: pid}
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		info.Command = truncateRunes(strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " ")), maxCommandLine)
+	}
+	info.Executable, _ = os.Readlink(filepath.Join(dir, "exe"))
+	info.WorkingDir, _ = os.Readlink(filepath.Join(dir, "cwd"))
+	if started, err := processStartTime(dir); err == nil {
+		info.Started = started.Format(time.RFC3339)
+	}
+	return info
+}
+
+// processStartTime computes when a process started from its start time in
+// clock ticks after boot and the boot time in /proc/stat.
+func processStartTime(dir string) (time.Time, error) {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The command name may contain spaces and parentheses, fields follow the last ')'
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return time.Time{}, fmt.Errorf("malformed %s/stat", dir)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// starttime is field 22 of stat, the 20th after the command name
+	if len(fields) < 20 {
+		return time.Time{}, fmt.Errorf("malformed %s/stat", dir)
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	boot, err := bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks), nil
+}
+
+// bootTime reads the system boot time from /proc/stat.
+func bootTime() (time.Time, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("btime not found in /proc/stat")
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package tools
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41235 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:D431 06 00000000:00000000 03:00000F2A 00000000     0        0 0 3 0000000000000000
+   2: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1234 1 0000000000000000 100 0 0 10 0
+`
+
+const procNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41240 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:1F90 00000000000000000000000001000000:B3E2 01 00000000:00000000 00:00000000 00000000  1000        0 41250 1 0000000000000000 20 4 30 10 -1
+   2: 0000000000000000FFFF00000100007F:1F90 0000000000000000FFFF00000200A8C0:C350 01 00000000:00000000 00:00000000 00000000  1000        0 41260 1 0000000000000000 20 4 30 10 -1
+   3: B80D0120000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41270 1 0000000000000000 100 0 0 10 0
+`
+
+func TestParseProcAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"0100007F:1F90", "127.0.0.1:8080"},
+		{"00000000:0050", "0.0.0.0:80"},
+		{"0200A8C0:C350", "192.168.0.2:50000"},
+		{"00000000000000000000000001000000:1F90", "[::1]:8080"},
+		{"00000000000000000000000000000000:1F90", "[::]:8080"},
+		// 2001:db8::1, every 32-bit word is byte swapped
+		{"B80D0120000000000000000001000000:1F90", "[2001:db8::1]:8080"},
+		// IPv4-mapped address of a dual-stack socket
+		{"0000000000000000FFFF00000100007F:1F90", "127.0.0.1:8080"},
+	}
+	for _, tt := range tests {
+		ip, port, ok := parseProcAddress(tt.addr)
+		if !ok {
+			t.Errorf("parseProcAddress(%q) failed", tt.addr)
+			continue
+		}
+		if got := net.JoinHostPort(ip.String(), strconv.Itoa(port)); got != tt.want {
+			t.Errorf("parseProcAddress(%q) = %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+	for _, addr := range []string{"0100007F", "0100007F:XYZ", "01007F:1F90", "0100007F:10000"} {
+		if _, _, ok := parseProcAddress(addr); ok {
+			t.Errorf("parseProcAddress(%q) should fail", addr)
+		}
+	}
+}
+
+func TestParseProcNet(t *testing.T) {
+	sockets, err := parseProcNet(strings.NewReader(procNetTCP), "tcp", 8080)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sockets) != 2 {
+		t.Fatalf("expected 2 sockets on port 8080, got %d", len(sockets))
+	}
+	listen, wait := sockets[0], sockets[1]
+	if listen.State != "LISTEN" || listen.LocalAddress != "0.0.0.0:8080" || listen.RemoteAddress != "" || listen.UID != 1000 || listen.inode != "41235" {
+		t.Errorf("unexpected listener: %+v %s", listen.PortSocket, listen.inode)
+	}
+	if wait.State != "TIME_WAIT" || wait.RemoteAddress != "127.0.0.1:54321" || wait.inode != "0" {
+		t.Errorf("unexpected connection: %+v %s", wait.PortSocket, wait.inode)
+	}
+
+	sockets, err = parseProcNet(strings.NewReader(procNetTCP6), "tcp6", 8080)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range sockets {
+		got = append(got, s.State+" "+s.LocalAddress+" "+s.RemoteAddress)
+	}
+	want := []string{
+		"LISTEN [::]:8080 ",
+		"ESTABLISHED [::1]:8080 [::1]:46050",
+		"ESTABLISHED 127.0.0.1:8080 192.168.0.2:50000",
+		"LISTEN [2001:db8::1]:8080 ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package tools
+
+import (
+	"context"
+	"runtime"
+)
+
+// findPortOwners is only implemented on Linux, where sockets and their
+// owners are visible through /proc.
+func findPortOwners(ctx context.Context, port int) (PortOwnerOutput, error) {
+	return PortOwnerOutput{
+		Port:      port,
+		Supported: false,
+		Listeners: []PortSocket{},
+		Note:      "port ownership is only available on Linux, this analyzer runs on " + runtime.GOOS + "; ask the user to run 'netstat -ano' (Windows) or 'lsof -nP -iTCP:<port> -sTCP:LISTEN' (macOS)",
+	}, nil
+}
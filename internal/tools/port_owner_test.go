@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStartCommandTargets(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"java -Xmx1g -jar /app/order-service.jar --server.port=8080", "order-service.jar"},
+		{`JAVA_OPTS="-Xmx1g" java -jar "/opt/my app/app.jar"`, "app.jar"},
+		{"java -cp app.jar:lib/* com.example.App", "com.example.App"},
+		// Options between the class path and the main class
+		{"java -cp 'lib/*' -Dspring.profiles.active=prod -Xmx512m com.example.App --debug", "com.example.App"},
+		{"java -Xmx512m --add-opens java.base/java.lang=ALL-UNNAMED -classpath lib/* com.example.App", "com.example.App"},
+		{"java -p mods -m com.example/com.example.App", "com.example.App"},
+		{"cd /app && exec java -jar app.jar", "app.jar"},
+		{"sh -c 'java -cp app.jar com.example.App > app.log 2>&1'", "com.example.App"},
+		{"./bin/start.sh --jar app.jar", "app.jar"},
+		{"./bin/start.sh", ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(startCommandTargets(tt.command), ","); got != tt.want {
+			t.Errorf("startCommandTargets(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesStartCommand(t *testing.T) {
+	targets := startCommandTargets("java -jar /app/order-service.jar")
+	if !matchesStartCommand("java -Xmx1g -jar order-service.jar", targets) {
+		t.Error("same jar should match")
+	}
+	if matchesStartCommand("java -jar user-service.jar", targets) || matchesStartCommand("java -jar app.jar", nil) {
+		t.Error("other jar should not match")
+	}
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"sync"
+
+	"github.com/user/java-startup-analyzer/internal/shell"
+)
+
+// project describes the analyzed application to the tools that inspect its
+// sources and configuration rather than its logs.
+var (
+	projectMu    sync.RWMutex
+	gitRepo      string
+	startCommand string
+	appEnviron   []string
+)
+
+// SetGitRepo sets the repository inspected by the git_history tool and used
+// as the default project directory of the build and configuration tools.
+func SetGitRepo(path string) {
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	gitRepo = path
+}
+
+// currentGitRepo returns the configured repository, or "".
+func currentGitRepo() string {
+	projectMu.RLock()
+	defer projectMu.RUnlock()
+	return gitRepo
+}
+
+// SetStartCommand sets the command that starts the application, from which
+// tools read JVM options, system properties and program arguments.
+func SetStartCommand(command string) {
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	startCommand = command
+}
+
+// currentStartCommand returns the configured start command, or "".
+func currentStartCommand() string {
+	projectMu.RLock()
+	defer projectMu.RUnlock()
+	return startCommand
+}
+
+// SetAppEnvironment sets the environment the application was started with,
+// as "NAME=value" entries. It is only known when the analyzer launched the
+// application itself; otherwise only assignments in the start command apply.
+func SetAppEnvironment(environ []string) {
+	projectMu.Lock()
+	defer projectMu.Unlock()
+	appEnviron = environ
+}
+
+// currentAppEnvironment returns the configured application environment.
+func currentAppEnvironment() map[string]string {
+	projectMu.RLock()
+	defer projectMu.RUnlock()
+	return shell.Environ(appEnviron)
+}
@@ -1,10 +1,11 @@
 package tools
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"math"
 	"path/filepath"
 	"strings"
 
@@ -12,20 +13,38 @@ import (
 	"github.com/cloudwego/eino/components/tool/utils"
 )
 
+const (
+	// defaultReadLines is the number of lines returned when neither an
+	// offset nor a limit is given.
+	defaultReadLines = 200
+	// allLines is the limit of a read to the end of the file.
+	allLines = math.MaxInt
+)
+
 // ReadFileInput represents the input parameters for the read_file tool
 type ReadFileInput struct {
-	AbsolutePath string `json:"absolute_path" description:"The absolute path to the file to read (e.g., '/home/user/project/file.txt'). Relative paths are not supported. You must provide an absolute path."`
-	Offset       *int   `json:"offset,omitempty" description:"Optional: 0-based line number to start reading from. When reverse=true, offset is counted from the end (0=last line, 1=second to last). When reverse=false, offset is from the beginning. Requires 'limit' to be set."`
-	Limit        *int   `json:"limit,omitempty" description:"Optional: Maximum number of lines to read. Recommended: 100 lines for initial log analysis. Use with 'offset' for pagination. If omitted, reads up to 200 lines."`
+	AbsolutePath string `json:"absolute_path" description:"The absolute path to the file to read (e.g., '/home/user/project/file.txt'). gzip and zstd compressed files are decompressed transparently (zstd needs the zstd command installed); read a zip member as '/path/archive.zip!/member'. Relative paths are not supported. You must provide an absolute path."`
+	Offset       *int   `json:"offset,omitempty" description:"Optional: 0-based line number to start reading from. When reverse=true, offset is counted from the end (0=last line, 1=second to last). When reverse=false, offset is from the beginning."`
+	Limit        *int   `json:"limit,omitempty" description:"Optional: Maximum number of lines to read. Recommended: 100 lines for initial log analysis. Use with 'offset' for pagination. If omitted, reads up to 200 lines, or with 'offset' all lines from the offset to the end in the reading direction."`
 	Reverse      *bool  `json:"reverse,omitempty" description:"Optional: If true, read from the end of the file backwards. RECOMMENDED for log analysis as recent errors appear at the end. Default: false (forward reading)."`
+	Rotated      *bool  `json:"rotated,omitempty" description:"Optional: If true, read the file together with its rotated siblings (e.g. app.log.2.gz, app.log.1, app.log) as one stream in chronological order. Offsets and line numbers then refer to the combined stream; 'segments' tells which file the lines came from."`
 }
 
 // ReadFileOutput represents the output of the read_file tool
 type ReadFileOutput struct {
-	Content    string `json:"content" description:"The content of the file"`
-	Truncated  bool   `json:"truncated" description:"Whether the content was truncated due to file size"`
-	TotalLines int    `json:"total_lines" description:"Total number of lines in the file (for text files)"`
-	ReadLines  int    `json:"read_lines" description:"Number of lines actually read"`
+	Content    string        `json:"content" description:"The content of the file"`
+	Truncated  bool          `json:"truncated" description:"Whether more lines exist beyond the returned range in the reading direction"`
+	TotalLines int           `json:"total_lines" description:"Total number of lines in the file, or -1 if not known yet (a very large file is still being indexed in the background)"`
+	ReadLines  int           `json:"read_lines" description:"Number of lines actually read"`
+	StartLine  int           `json:"start_line,omitempty" description:"1-based line number of the first returned line, omitted if not known yet"`
+	Segments   []ReadSegment `json:"segments,omitempty" description:"For rotated reads, which file each run of returned lines came from"`
+}
+
+// ReadSegment describes a run of returned lines that came from one file
+type ReadSegment struct {
+	FilePath  string `json:"file_path" description:"The file the lines came from"`
+	StartLine int    `json:"start_line,omitempty" description:"1-based line number of the first of these lines within that file, omitted if not known yet"`
+	Lines     int    `json:"lines" description:"Number of returned lines from this file"`
 }
 
 // ReadFileTool is a tool that reads file content.
@@ -35,7 +54,7 @@ func init() {
 	var err error
 	ReadFileTool, err = utils.InferTool(
 		"read_file",
-		"Reads and returns the content of a specified file. For log analysis, start with reverse=true and limit=100 to read the last 100 lines where recent errors typically appear. Supports forward/reverse reading, pagination, and automatic path resolution. Always use absolute paths.",
+		"Reads and returns the content of a specified file. For log analysis, start with reverse=true and limit=100 to read the last 100 lines where recent errors typically appear. Supports forward/reverse reading and pagination; multi-GB files are read through a cached line index and reverse reads seek backwards from the end of the file, so only the requested lines are loaded. Always use absolute paths.",
 		readFile,
 	)
 	if err != nil {
@@ -43,142 +62,197 @@ func init() {
 	}
 }
 
-// readFile reads a file and returns its content.
+// readFile reads a range of lines from a file without loading the whole file.
 func readFile(ctx context.Context, input ReadFileInput) (ReadFileOutput, error) {
 	// Validate absolute path
 	if !filepath.IsAbs(input.AbsolutePath) {
 		return ReadFileOutput{}, fmt.Errorf("path must be absolute: %s", input.AbsolutePath)
 	}
 
-	// Check if file exists
-	fileInfo, err := os.Stat(input.AbsolutePath)
-	if err != nil {
-		return ReadFileOutput{}, fmt.Errorf("failed to stat file: %w", err)
+	offset := 0
+	if input.Offset != nil && *input.Offset > 0 {
+		offset = *input.Offset
+	}
+	limit := defaultReadLines
+	if input.Limit != nil && *input.Limit > 0 {
+		limit = *input.Limit
+	} else if input.Offset != nil {
+		// Paging with an offset reads the rest of the file
+		limit = allLines
 	}
+	reverse := input.Reverse != nil && *input.Reverse
 
-	// Check if it's a directory
-	if fileInfo.IsDir() {
-		return ReadFileOutput{}, fmt.Errorf("path is a directory, not a file: %s", input.AbsolutePath)
+	if input.Rotated != nil && *input.Rotated {
+		return readRotated(ctx, input.AbsolutePath, offset, limit, reverse)
 	}
 
 	// For now, we'll focus on text files
 	// TODO: Add support for binary files (images, PDFs) in the future
-	file, err := os.Open(input.AbsolutePath)
+	src, err := resolveLogSource(input.AbsolutePath)
+	if err != nil {
+		return ReadFileOutput{}, err
+	}
+	if src.Compression != compressionNone {
+		return readSources(ctx, []*logSource{src}, offset, limit, reverse)
+	}
+	return readPlainFile(src, offset, limit, reverse)
+}
+
+// readPlainFile reads an uncompressed file through its line index, or
+// backwards from EOF for a reverse read of a large file not indexed yet.
+func readPlainFile(src *logSource, offset, limit int, reverse bool) (ReadFileOutput, error) {
+	file, err := openFile(src.File)
 	if err != nil {
 		return ReadFileOutput{}, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	// Read all lines first to get total count
-	var allLines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		allLines = append(allLines, scanner.Text())
+	var idx *lineIndex
+	if reverse && limit != allLines {
+		// Reverse reads only need the index to page far back, so a large file
+		// is read backwards from EOF while its index is built in the background
+		idx = cachedLineIndex(src.File, src.Info)
+		if idx == nil && src.Info.Size() <= syncIndexSize {
+			idx, err = getLineIndex(src.File, src.Info)
+		}
+	} else {
+		idx, err = getLineIndex(src.File, src.Info)
 	}
-
-	if err := scanner.Err(); err != nil {
+	if err != nil {
 		return ReadFileOutput{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	totalLines := len(allLines)
+	if idx == nil {
+		lines, atStart, total, err := readLastLines(file, src.Info.Size(), offset, limit)
+		if err != nil {
+			return ReadFileOutput{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		output := ReadFileOutput{
+			Content:    strings.Join(lines, "\n"),
+			Truncated:  !atStart,
+			TotalLines: -1,
+			ReadLines:  len(lines),
+		}
+		if total > 0 {
+			output.TotalLines = total
+			if len(lines) > 0 {
+				output.StartLine = total - offset - len(lines) + 1
+			}
+		} else {
+			buildLineIndexAsync(src.File, src.Info)
+		}
+		return output, nil
+	}
 
-	// Determine which lines to read
-	var startLine, endLine int
-	var truncated bool
+	startLine, endLine := lineRange(idx.Lines, offset, limit, reverse)
+	if startLine >= endLine {
+		return ReadFileOutput{TotalLines: idx.Lines}, nil
+	}
+	lines, err := idx.readLines(file, startLine, endLine)
+	if err != nil {
+		return ReadFileOutput{}, fmt.Errorf("failed to read file: %w", err)
+	}
 
-	// Check if reverse reading is requested
-	reverse := input.Reverse != nil && *input.Reverse
+	return ReadFileOutput{
+		Content:    strings.Join(lines, "\n"),
+		Truncated:  (reverse && startLine > 0) || (!reverse && endLine < idx.Lines),
+		TotalLines: idx.Lines,
+		ReadLines:  len(lines),
+		StartLine:  startLine + 1,
+	}, nil
+}
 
-	if input.Offset != nil && input.Limit != nil {
-		// Read specific range
-		if reverse {
-			// Reverse reading: offset is from the end
-			startLine = totalLines - *input.Offset - *input.Limit
-			if startLine < 0 {
-				startLine = 0
-			}
-			endLine = totalLines - *input.Offset
-			if endLine > totalLines {
-				endLine = totalLines
-			}
-		} else {
-			// Forward reading: offset is from the beginning
-			startLine = *input.Offset
-			if startLine < 0 {
-				startLine = 0
-			}
-			if startLine >= totalLines {
-				return ReadFileOutput{
-					Content:    "",
-					Truncated:  false,
-					TotalLines: totalLines,
-					ReadLines:  0,
-				}, nil
-			}
-			endLine = startLine + *input.Limit
-			if endLine > totalLines {
-				endLine = totalLines
+// readRotated reads a log together with its rotated siblings as one stream.
+func readRotated(ctx context.Context, path string, offset, limit int, reverse bool) (ReadFileOutput, error) {
+	set, err := rotationSet(path)
+	if err != nil {
+		return ReadFileOutput{}, fmt.Errorf("failed to list rotated files: %w", err)
+	}
+	var sources []*logSource
+	for _, p := range set {
+		src, err := resolveLogSource(p)
+		if err != nil {
+			// The current log may have just been rotated away
+			if p == path && len(set) > 1 && errors.Is(err, fs.ErrNotExist) {
+				continue
 			}
+			return ReadFileOutput{}, err
 		}
-	} else if input.Offset != nil {
-		// Read from offset to end
-		if reverse {
-			// Reverse reading: from offset to beginning
-			endLine = totalLines - *input.Offset
-			if endLine > totalLines {
-				endLine = totalLines
-			}
-			startLine = 0
-		} else {
-			// Forward reading: from offset to end
-			startLine = *input.Offset
-			if startLine < 0 {
-				startLine = 0
-			}
-			if startLine >= totalLines {
-				return ReadFileOutput{
-					Content:    "",
-					Truncated:  false,
-					TotalLines: totalLines,
-					ReadLines:  0,
-				}, nil
-			}
-			endLine = totalLines
+		sources = append(sources, src)
+	}
+
+	// Counting the lines of a multi-GB current log takes a full pass, so the
+	// most recent lines are read backwards when they are all in that file
+	last := sources[len(sources)-1]
+	if reverse && limit != allLines && last.Compression == compressionNone && last.Info.Size() > syncIndexSize && cachedLineIndex(last.File, last.Info) == nil {
+		output, err := readPlainFile(last, offset, limit, true)
+		if err != nil {
+			return ReadFileOutput{}, err
 		}
-	} else {
-		// Read entire file, but limit to reasonable size
-		const maxLines = 200
-		if reverse {
-			// Read last N lines
-			if totalLines > maxLines {
-				startLine = totalLines - maxLines
-				endLine = totalLines
-				truncated = true
-			} else {
-				startLine = 0
-				endLine = totalLines
+		if output.Truncated {
+			output.TotalLines = -1
+			output.Segments = []ReadSegment{{FilePath: last.Path, Lines: output.ReadLines}}
+			return output, nil
+		}
+	}
+	return readSources(ctx, sources, offset, limit, reverse)
+}
+
+// readSources reads a range of lines from sources concatenated in order.
+func readSources(ctx context.Context, sources []*logSource, offset, limit int, reverse bool) (ReadFileOutput, error) {
+	counts := make([]int, len(sources))
+	total := 0
+	for i, src := range sources {
+		n, err := src.countLines(ctx)
+		if err != nil {
+			return ReadFileOutput{}, fmt.Errorf("failed to read %s: %w", src.Path, err)
+		}
+		counts[i] = n
+		total += n
+	}
+
+	output := ReadFileOutput{TotalLines: total}
+	startLine, endLine := lineRange(total, offset, limit, reverse)
+	if startLine >= endLine {
+		return output, nil
+	}
+
+	var lines []string
+	base := 0
+	for i, src := range sources {
+		from, to := max(startLine-base, 0), min(endLine-base, counts[i])
+		if from < to {
+			before := len(lines)
+			if _, err := src.readLines(ctx, from, to, func(line string) { lines = append(lines, line) }); err != nil {
+				return ReadFileOutput{}, fmt.Errorf("failed to read %s: %w", src.Path, err)
 			}
-		} else {
-			// Read first N lines
-			startLine = 0
-			if totalLines > maxLines {
-				endLine = maxLines
-				truncated = true
-			} else {
-				endLine = totalLines
+			if len(sources) > 1 {
+				output.Segments = append(output.Segments, ReadSegment{FilePath: src.Path, StartLine: from + 1, Lines: len(lines) - before})
 			}
 		}
+		base += counts[i]
 	}
 
-	// Extract the requested lines
-	selectedLines := allLines[startLine:endLine]
-	content := strings.Join(selectedLines, "\n")
-	readLines := len(selectedLines)
+	output.Content = strings.Join(lines, "\n")
+	output.Truncated = (reverse && startLine > 0) || (!reverse && endLine < total)
+	output.ReadLines = len(lines)
+	output.StartLine = startLine + 1
+	return output, nil
+}
 
-	return ReadFileOutput{
-		Content:    content,
-		Truncated:  truncated,
-		TotalLines: totalLines,
-		ReadLines:  readLines,
-	}, nil
+// lineRange returns the 0-based range [start, end) of lines to read.
+func lineRange(total, offset, limit int, reverse bool) (int, int) {
+	if reverse {
+		// Reverse reading: offset is from the end
+		end := total - offset
+		if limit >= end {
+			return 0, end
+		}
+		return end - limit, end
+	}
+	// Forward reading: offset is from the beginning
+	if limit >= total-offset {
+		return offset, total
+	}
+	return offset, offset + limit
 }
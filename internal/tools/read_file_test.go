@@ -0,0 +1,321 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// numberedLines returns lines "line 1" to "line n", each ending in a newline.
+func numberedLines(from, n int) string {
+	var b strings.Builder
+	for i := from; i < from+n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+// writeLog writes a file and forgets any index built for an earlier file at the path.
+func writeLog(t *testing.T, path, content string) os.FileInfo {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		indexMu.Lock()
+		delete(indexCache, path)
+		indexMu.Unlock()
+	})
+	return info
+}
+
+func TestBuildLineIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   int
+		offsets int
+	}{
+		{"empty", "", 0, 1},
+		{"no trailing newline", "a", 1, 1},
+		{"trailing newline", "a\n", 1, 1},
+		{"crlf", "a\r\nb\r\n", 2, 1},
+		// The offset after the last line is EOF, not a line
+		{"exact stride", numberedLines(1, 2*indexStride), 2 * indexStride, 2},
+		{"partial last line", numberedLines(1, 2500) + "tail", 2501, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			info := writeLog(t, path, tt.content)
+			idx, err := buildLineIndex(path, info, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if idx.Lines != tt.lines || len(idx.Offsets) != tt.offsets {
+				t.Fatalf("got %d lines and %d offsets, want %d and %d", idx.Lines, len(idx.Offsets), tt.lines, tt.offsets)
+			}
+			for i, offset := range idx.Offsets {
+				if want := int64(strings.Index(tt.content, fmt.Sprintf("line %d\n", i*indexStride+1))); i > 0 && offset != want {
+					t.Errorf("offset %d = %d, want %d", i, offset, want)
+				}
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			all := strings.Split(strings.TrimSuffix(tt.content, "\n"), "\n")
+			for _, r := range [][2]int{{0, 1}, {998, 1003}, {1999, 2001}, {2499, 2501}, {0, tt.lines}} {
+				start, end := min(r[0], tt.lines), min(r[1], tt.lines)
+				lines, err := idx.readLines(file, start, end)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := all[start:end]
+				for i := range want {
+					want[i] = strings.TrimSuffix(want[i], "\r")
+				}
+				if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+					t.Errorf("lines [%d, %d) = %q, want %q", start, end, lines, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLineIndexInvalidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	info := writeLog(t, path, numberedLines(1, 2500))
+	idx, err := getLineIndex(path, info)
+	if err != nil || idx.Lines != 2500 {
+		t.Fatalf("index: %+v %v", idx, err)
+	}
+	if cachedLineIndex(path, info) != idx {
+		t.Fatal("index not cached")
+	}
+
+	// Lines appended by the application
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(numberedLines(2501, 1200)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cachedLineIndex(path, info) != nil {
+		t.Fatal("index of the old file state should not be used")
+	}
+	grown, err := getLineIndex(path, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := buildLineIndex(path, info, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(grown, full) {
+		t.Errorf("extended index differs from a full build:\n%+v\n%+v", grown, full)
+	}
+
+	// A rewritten file is indexed from the start
+	info = writeLog(t, path, "rotated\n"+numberedLines(1, 4000))
+	rewritten, err := getLineIndex(path, info)
+	if err != nil || rewritten.Lines != 4001 || rewritten.Offsets[1] != int64(len("rotated\n"+numberedLines(1, 999))) {
+		t.Errorf("rewritten file not re-indexed: %+v %v", rewritten, err)
+	}
+}
+
+func TestLineIndexExtendsFromLastOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	content := numberedLines(1, 2500)
+	info := writeLog(t, path, content)
+	prev, err := buildLineIndex(path, info, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the lines from the last recorded offset are scanned again, so a
+	// marker in an earlier offset survives
+	prev.Offsets[1] = -1
+
+	info = writeLog(t, path, content+numberedLines(2501, 1000))
+	idx, err := buildLineIndex(path, info, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Lines != 3500 || len(idx.Offsets) != 4 || idx.Offsets[1] != -1 || idx.Offsets[3] != int64(len(numberedLines(1, 3000))) {
+		t.Errorf("index not extended from the last offset: %+v", idx)
+	}
+
+	// A file that is not longer is indexed from the start
+	info = writeLog(t, path, content)
+	if idx, err := buildLineIndex(path, info, prev); err != nil || idx.Offsets[1] == -1 {
+		t.Errorf("index of a file that did not grow should be rebuilt: %+v %v", idx, err)
+	}
+}
+
+func TestLineIndexSidecar(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "big.log")
+	var b strings.Builder
+	for n := 1; b.Len() <= syncIndexSize; n++ {
+		fmt.Fprintf(&b, "2025-09-23 19:46:55.000  INFO 1 --- [main] com.example.App : line %d\n", n)
+	}
+	info := writeLog(t, path, b.String())
+	idx, err := getLineIndex(path, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sidecar, err := sidecarPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sidecar); err != nil {
+		t.Fatalf("sidecar not written: %v", err)
+	}
+
+	// A new process finds the index in the cache directory
+	indexMu.Lock()
+	delete(indexCache, path)
+	indexMu.Unlock()
+	cached := cachedLineIndex(path, info)
+	if cached == nil || !reflect.DeepEqual(cached, idx) {
+		t.Fatalf("sidecar not loaded: %+v", cached)
+	}
+
+	// The sidecar is only used for the file state it was built for
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if cachedLineIndex(path, info) != nil {
+		t.Error("sidecar of another file state should not be used")
+	}
+
+	// Small files are not cached on disk
+	small := filepath.Join(t.TempDir(), "small.log")
+	info = writeLog(t, small, numberedLines(1, 10))
+	if _, err := getLineIndex(small, info); err != nil {
+		t.Fatal(err)
+	}
+	if sidecar, _ := sidecarPath(small); func() bool { _, err := os.Stat(sidecar); return err == nil }() {
+		t.Error("sidecar written for a small file")
+	}
+}
+
+func TestReadLastLines(t *testing.T) {
+	// Lines longer than a chunk make the reads cross chunk boundaries
+	long := strings.Repeat("x", reverseChunkSize+10)
+	tests := []struct {
+		name    string
+		content string
+		skip, n int
+		want    string
+		atStart bool
+		total   int
+	}{
+		{name: "last lines", content: "a\nb\nc\nd\n", n: 2, want: "c,d", total: 4},
+		{name: "skip", content: "a\nb\nc\nd\n", skip: 1, n: 2, want: "b,c", total: 4},
+		{name: "all", content: "a\nb\nc\nd", n: 10, want: "a,b,c,d", atStart: true, total: 4},
+		{name: "skip past start", content: "a\nb\n", skip: 5, n: 2, want: "", atStart: true, total: 2},
+		{name: "crlf", content: "a\r\nb\r\n", n: 1, want: "b", total: 2},
+		{name: "long lines", content: "a\n" + long + "\nb\n", n: 2, want: long + ",b", total: 3},
+		// The total is only known once the start of the file is reached
+		{name: "partial", content: long + "\n" + long + "\nb\n", n: 1, want: "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, atStart, total, err := readLastLines(strings.NewReader(tt.content), int64(len(tt.content)), tt.skip, tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(lines, ",") != tt.want || atStart != tt.atStart || total != tt.total {
+				t.Errorf("got %.40q atStart=%v total=%d, want %.40q atStart=%v total=%d", strings.Join(lines, ","), atStart, total, tt.want, tt.atStart, tt.total)
+			}
+		})
+	}
+}
+
+func TestReadLastLinesSkip(t *testing.T) {
+	// Skipping lines across chunk boundaries returns the same lines as a full read
+	long := strings.Repeat("y", reverseChunkSize/3)
+	var all []string
+	for i := 0; i < 20; i++ {
+		all = append(all, fmt.Sprintf("%d %s", i, long[:i*reverseChunkSize/60]))
+	}
+	all = append(all, "", "last\r")
+	content := strings.Join(all, "\n") + "\n"
+	for skip := 0; skip <= len(all)+1; skip++ {
+		for _, n := range []int{1, 3, len(all)} {
+			lines, atStart, _, err := readLastLines(strings.NewReader(content), int64(len(content)), skip, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end := max(len(all)-skip, 0)
+			begin := max(end-n, 0)
+			var want []string
+			for _, line := range all[begin:end] {
+				want = append(want, strings.TrimSuffix(line, "\r"))
+			}
+			if strings.Join(lines, "|") != strings.Join(want, "|") || atStart != (begin == 0) {
+				t.Errorf("skip=%d n=%d: got %d lines atStart=%v, want %d lines atStart=%v", skip, n, len(lines), atStart, len(want), begin == 0)
+			}
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeLog(t, path, numberedLines(1, 500))
+	intPtr := func(n int) *int { return &n }
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name  string
+		input ReadFileInput
+		first string
+		lines int
+		start int
+		trunc bool
+	}{
+		{"default", ReadFileInput{}, "line 1", defaultReadLines, 1, true},
+		{"page", ReadFileInput{Offset: intPtr(10), Limit: intPtr(5)}, "line 11", 5, 11, true},
+		{"reverse", ReadFileInput{Limit: intPtr(3), Reverse: boolPtr(true)}, "line 498", 3, 498, true},
+		{"reverse page", ReadFileInput{Offset: intPtr(10), Limit: intPtr(5), Reverse: boolPtr(true)}, "line 486", 5, 486, true},
+		// An offset without a limit reads the rest of the file
+		{"offset only", ReadFileInput{Offset: intPtr(100)}, "line 101", 400, 101, false},
+		{"reverse offset only", ReadFileInput{Offset: intPtr(100), Reverse: boolPtr(true)}, "line 1", 400, 1, false},
+		{"past end", ReadFileInput{Offset: intPtr(600)}, "", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.AbsolutePath = path
+			output, err := readFile(context.Background(), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, _, _ := strings.Cut(output.Content, "\n")
+			if first != tt.first || output.ReadLines != tt.lines || output.StartLine != tt.start || output.Truncated != tt.trunc || output.TotalLines != 500 {
+				t.Errorf("got first=%q lines=%d start=%d truncated=%v total=%d", first, output.ReadLines, output.StartLine, output.Truncated, output.TotalLines)
+			}
+		})
+	}
+}
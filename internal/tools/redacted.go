@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/user/java-startup-analyzer/internal/redact"
+)
+
+// redactedTool replaces secrets in a tool's output before it reaches the model.
+type redactedTool struct {
+	tool.InvokableTool
+	redactor *redact.Redactor
+}
+
+// Redacted wraps a tool so that its output passes through the redactor.
+// A nil redactor returns the tool unchanged.
+func Redacted(t tool.InvokableTool, r *redact.Redactor) tool.InvokableTool {
+	if r == nil {
+		return t
+	}
+	return &redactedTool{InvokableTool: t, redactor: r}
+}
+
+func (t *redactedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	output, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		return "", err
+	}
+	return t.redactor.RedactJSON(output), nil
+}
@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// DefaultDenyPatterns are denied even inside the allowed roots, on top of
+// any configured patterns: credentials that often sit next to the logs.
+var DefaultDenyPatterns = []string{
+	"**/.auth/**",
+	"**/.ssh/**",
+	"**/*.pem",
+	"**/*.key",
+	"**/*.jks",
+	"**/*.p12",
+	"**/*.keystore",
+	"**/id_rsa*",
+}
+
+// AccessError reports a path the sandbox does not allow.
+type AccessError struct {
+	Path   string
+	Reason string
+}
+
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("access denied: %s %s", e.Path, e.Reason)
+}
+
+// Sandbox restricts the files the tools may access to a set of root
+// directories, minus the files matching deny patterns. Paths are resolved
+// through symlinks before they are checked, so a link cannot escape a root.
+type Sandbox struct {
+	roots []string
+	deny  []*sandboxPattern
+}
+
+// sandboxPattern is a compiled deny pattern.
+type sandboxPattern struct {
+	glob   string
+	filter *includeFilter
+}
+
+// NewSandbox creates a sandbox that allows the given roots and denies the
+// default patterns plus the given ones.
+func NewSandbox(roots, deny []string) (*Sandbox, error) {
+	s := &Sandbox{}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sandbox root %s: %w", root, err)
+		}
+		s.roots = append(s.roots, resolvePath(abs))
+	}
+	for _, glob := range append(append([]string{}, DefaultDenyPatterns...), deny...) {
+		filter, err := newIncludeFilter(glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sandbox deny pattern %s: %w", glob, err)
+		}
+		s.deny = append(s.deny, &sandboxPattern{glob: glob, filter: filter})
+	}
+	return s, nil
+}
+
+// Roots returns the resolved allowed roots.
+func (s *Sandbox) Roots() []string {
+	return s.roots
+}
+
+// Check returns an error if the path, after resolving symlinks, is outside
+// the allowed roots or matches a deny pattern. Zip members are checked by
+// their archive.
+func (s *Sandbox) Check(path string) error {
+	if s == nil {
+		return nil
+	}
+	if i := strings.Index(path, archiveSeparator); i >= 0 {
+		path = path[:i]
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return &AccessError{Path: path, Reason: "is not a valid path"}
+	}
+	return s.check(path, abs, resolvePath(abs))
+}
+
+// Open opens a file for reading and checks the path of the opened file, so
+// a symlink swapped between the check and the open cannot escape the roots.
+func (s *Sandbox) Open(path string) (*os.File, error) {
+	if err := s.Check(path); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil || s == nil {
+		return file, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		file.Close()
+		return nil, &AccessError{Path: path, Reason: "is not a valid path"}
+	}
+	opened := openedPath(file, abs)
+	if opened == "" {
+		file.Close()
+		return nil, &AccessError{Path: path, Reason: "changed while it was opened"}
+	}
+	if err := s.check(path, abs, opened); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// check checks the resolved form of a path.
+func (s *Sandbox) check(path, abs, resolved string) error {
+	allowed := false
+	for _, root := range s.roots {
+		if within(root, resolved) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		reason := "is outside the allowed directories " + strings.Join(s.roots, ", ")
+		if resolved != abs {
+			reason = "resolves to " + resolved + ", which " + reason
+		}
+		return &AccessError{Path: path, Reason: reason}
+	}
+	if glob := s.denied(resolved); glob != "" {
+		return &AccessError{Path: path, Reason: "matches the deny pattern " + glob}
+	}
+	return nil
+}
+
+// openedPath returns the path of an opened file. Without /proc the name is
+// resolved again and must still be the opened file; "" means it is not.
+func openedPath(file *os.File, abs string) string {
+	if link, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(int(file.Fd()))); err == nil && filepath.IsAbs(link) {
+		return link
+	}
+	resolved := resolvePath(abs)
+	openedInfo, err := file.Stat()
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !os.SameFile(openedInfo, info) {
+		return ""
+	}
+	return resolved
+}
+
+// openFile opens a file through the current sandbox.
+func openFile(path string) (*os.File, error) {
+	return currentSandbox().Open(path)
+}
+
+// openZip opens a zip archive through the current sandbox. Closing the
+// returned file closes the archive.
+func openZip(path string) (*zip.Reader, *os.File, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return reader, file, nil
+}
+
+// denied returns the first deny pattern matching a resolved path.
+func (s *Sandbox) denied(resolved string) string {
+	root := filepath.VolumeName(resolved) + string(filepath.Separator)
+	for _, p := range s.deny {
+		if p.filter.match(root, resolved) {
+			return p.glob
+		}
+	}
+	return ""
+}
+
+// within reports whether path is root or inside it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// resolvePath resolves symlinks in an absolute path. For a path that does not
+// exist yet, the longest existing prefix is resolved.
+func resolvePath(abs string) string {
+	rest := ""
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return abs
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+var (
+	sandboxMu sync.RWMutex
+	sandbox   *Sandbox
+)
+
+// SetSandbox sets the sandbox enforced by the file tools. A nil sandbox
+// allows access to every file.
+func SetSandbox(s *Sandbox) {
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+	sandbox = s
+}
+
+// currentSandbox returns the sandbox enforced by the file tools.
+func currentSandbox() *Sandbox {
+	sandboxMu.RLock()
+	defer sandboxMu.RUnlock()
+	return sandbox
+}
+
+// sandboxedTool reports sandbox denials and unreadable zstd files to the
+// agent as the tool result, so the agent can choose another path instead of
+// the whole run failing.
+type sandboxedTool struct {
+	tool.InvokableTool
+}
+
+// Sandboxed wraps a file tool so that access errors and a missing zstd
+// command are returned as {"error": "..."} output rather than as a tool failure.
+func Sandboxed(t tool.InvokableTool) tool.InvokableTool {
+	return &sandboxedTool{InvokableTool: t}
+}
+
+func (t *sandboxedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	output, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	var accessErr *AccessError
+	switch {
+	case errors.As(err, &accessErr):
+		err = accessErr
+	case !errors.Is(err, errNoZstd):
+		return output, err
+	}
+	result, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(result), nil
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestSandboxCheck(t *testing.T) {
+	base := testutil.WriteFiles(t, map[string]string{
+		"log/app/app.log":       "started\n",
+		"log/app/conf/tls.key":  "secret\n",
+		"log/app/conf/app.yml":  "server.port: 8080\n",
+		"log/app2/other.log":    "other\n",
+		"etc/passwd":            "root:x:0:0\n",
+		"log/app/archive.zip":   "PK",
+		"log/app/.ssh/id_rsa":   "secret\n",
+		"log/app/logs/gc.log.1": "gc\n",
+	})
+	base, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "log", "app")
+	if err := os.Symlink(filepath.Join(base, "etc", "passwd"), filepath.Join(root, "passwd.log")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(base, "log", "app2"), filepath.Join(root, "app2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "logs"), filepath.Join(base, "logs-link")); err != nil {
+		t.Fatal(err)
+	}
+	box, err := NewSandbox([]string{root, filepath.Join(base, "logs-link")}, []string{"**/*.yml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		path   string
+		reason string // "" if allowed
+	}{
+		{filepath.Join(root, "app.log"), ""},
+		{root, ""},
+		{filepath.Join(root, "not-yet-created.log"), ""},
+		{filepath.Join(root, "archive.zip") + "!/app.log", ""},
+		// A root given as a symlink is resolved
+		{filepath.Join(root, "logs", "gc.log.1"), ""},
+		// Relative paths are taken from the working directory
+		{"app.log", ""},
+		{"./logs/../app.log", ""},
+		{"../app/app.log", ""},
+		{"../app2/other.log", "outside"},
+		{filepath.Join(root, "..", "..", "etc", "passwd"), "outside"},
+		// A sibling directory sharing the root as a name prefix
+		{filepath.Join(base, "log", "app2", "other.log"), "outside"},
+		{filepath.Join(base, "log", "app2"), "outside"},
+		// Symlinks inside the root pointing out of it
+		{filepath.Join(root, "passwd.log"), "resolves to"},
+		{filepath.Join(root, "app2", "other.log"), "resolves to"},
+		{filepath.Join(root, "app2", "new.log"), "resolves to"},
+		// Deny patterns, default and configured
+		{filepath.Join(root, "conf", "tls.key"), "deny pattern **/*.key"},
+		{filepath.Join(root, ".ssh", "id_rsa"), "deny pattern **/.ssh/**"},
+		{filepath.Join(root, "conf", "app.yml"), "deny pattern **/*.yml"},
+	}
+	for _, tt := range tests {
+		err := box.Check(tt.path)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("Check(%q) = %v, want allowed", tt.path, err)
+			}
+			continue
+		}
+		var accessErr *AccessError
+		if !errors.As(err, &accessErr) || !strings.Contains(accessErr.Reason, tt.reason) {
+			t.Errorf("Check(%q) = %v, want %q", tt.path, err, tt.reason)
+		}
+	}
+
+	var nilBox *Sandbox
+	if err := nilBox.Check("/etc/passwd"); err != nil {
+		t.Errorf("a nil sandbox should allow everything: %v", err)
+	}
+}
+
+func TestSandboxOpen(t *testing.T) {
+	base := testutil.WriteFiles(t, map[string]string{
+		"app/app.log":     "started\n",
+		"app/server.key":  "secret\n",
+		"outside/app.log": "outside\n",
+	})
+	root := filepath.Join(base, "app")
+	box, err := NewSandbox([]string{root}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := box.Open(filepath.Join(root, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	link := filepath.Join(root, "current.log")
+	if err := os.Symlink(filepath.Join(base, "outside", "app.log"), link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	var accessErr *AccessError
+	if _, err := box.Open(link); !errors.As(err, &accessErr) {
+		t.Errorf("opening a link out of the root should fail, got %v", err)
+	}
+	if _, err := box.Open(filepath.Join(root, "server.key")); !errors.As(err, &accessErr) {
+		t.Errorf("opening a denied file should fail, got %v", err)
+	}
+
+	// The opened file is checked, not the name: a file opened through a name
+	// that is swapped afterwards still reports where it really is
+	file, err = os.Open(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "app.log"), link); err != nil {
+		t.Fatal(err)
+	}
+	opened := openedPath(file, link)
+	if opened != "" && box.check(link, link, opened) == nil {
+		t.Errorf("swapped file passed the check as %s", opened)
+	}
+}
+
+func TestSandboxedTool(t *testing.T) {
+	setTestSandbox(t, t.TempDir())
+	wrapped := Sandboxed(ReadFileTool)
+	output, err := wrapped.InvokableRun(context.Background(), `{"absolute_path":"/etc/passwd"}`)
+	if err != nil {
+		t.Fatalf("access errors should be returned as output, got %v", err)
+	}
+	if !strings.HasPrefix(output, `{"error":"access denied: /etc/passwd`) {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
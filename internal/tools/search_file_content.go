@@ -4,34 +4,54 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
 )
 
+const (
+	defaultMaxResults = 100
+	maxContextLines   = 10
+	maxMatchLineRune  = 500
+	// cancelCheckLines is how often a file scan checks for cancellation.
+	cancelCheckLines = 4096
+)
+
 // SearchFileContentInput represents the input parameters for the search_file_content tool
 type SearchFileContentInput struct {
-	Pattern string `json:"pattern" description:"The regular expression (regex) pattern to search for within file contents (e.g., 'function\\s+myFunction', 'import\\s+\\{.*\\}\\s+from\\s+.*')."`
-	Path    string `json:"path,omitempty" description:"Optional: The absolute path to the directory to search within. If omitted, searches the current working directory."`
-	Include string `json:"include,omitempty" description:"Optional: A glob pattern to filter which files are searched (e.g., '*.js', '*.{ts,tsx}', 'src/**'). If omitted, searches all files (respecting potential global ignores)."`
+	Pattern    string `json:"pattern" description:"The regular expression (regex) pattern to search for within file contents (e.g., 'function\\s+myFunction', 'import\\s+\\{.*\\}\\s+from\\s+.*')."`
+	Path       string `json:"path,omitempty" description:"Optional: The absolute path to the directory (or single file) to search within. If omitted, searches the current working directory."`
+	Include    string `json:"include,omitempty" description:"Optional: A glob pattern to filter which files are searched (e.g., '*.log', '*.{log,out}', 'logs/**/app-*.log'). Patterns without '/' match the file name at any depth; patterns with '/' match the path relative to 'path', where '**' matches any number of directories. If omitted, searches all files (respecting potential global ignores)."`
+	Before     *int   `json:"before,omitempty" description:"Optional: Number of lines of context to return before each match (max 10). Default: 0."`
+	After      *int   `json:"after,omitempty" description:"Optional: Number of lines of context to return after each match (max 10). Default: 0. Useful to see the first frames of a stack trace."`
+	MaxResults *int   `json:"max_results,omitempty" description:"Optional: Maximum number of matches to return. Default: 100. Matches beyond the limit are still counted per file."`
 }
 
 // SearchResult represents a single search match
 type SearchResult struct {
-	FilePath   string `json:"file_path" description:"The path of the file containing the match"`
-	LineNumber int    `json:"line_number" description:"The line number where the match was found"`
-	Content    string `json:"content" description:"The content of the line containing the match"`
+	FilePath   string   `json:"file_path" description:"The path of the file containing the match"`
+	LineNumber int      `json:"line_number" description:"The line number where the match was found"`
+	Content    string   `json:"content" description:"The content of the line containing the match"`
+	Before     []string `json:"before,omitempty" description:"Lines preceding the match, in file order"`
+	After      []string `json:"after,omitempty" description:"Lines following the match, in file order"`
 }
 
 // SearchFileContentOutput represents the output of the search_file_content tool
 type SearchFileContentOutput struct {
 	Results      []SearchResult `json:"results" description:"List of search results with file paths, line numbers, and content"`
 	TotalFiles   int            `json:"total_files" description:"Total number of files searched"`
-	TotalMatches int            `json:"total_matches" description:"Total number of matches found"`
+	TotalMatches int            `json:"total_matches" description:"Total number of matches found, including those not returned"`
+	Truncated    bool           `json:"truncated" description:"Whether more matches were found than max_results"`
+	FileMatches  map[string]int `json:"file_matches,omitempty" description:"Number of matches per file, only set when the results are truncated"`
+	Errors       []string       `json:"errors,omitempty" description:"Files that could not be searched"`
 }
 
 // SearchFileContentTool is a tool that searches for patterns in files.
@@ -41,7 +61,7 @@ func init() {
 	var err error
 	SearchFileContentTool, err = utils.InferTool(
 		"search_file_content",
-		"Searches for a regular expression pattern within the content of files in a specified directory. Can filter files by a glob pattern. Returns the lines containing matches, along with their file paths and line numbers. Useful for finding specific error patterns, configuration issues, or code references.",
+		"Searches for a regular expression pattern within the content of files in a specified directory. Can filter files by a glob pattern. Returns the matching lines with optional surrounding context lines, along with their file paths and line numbers, capped at max_results with per-file match counts when truncated. Useful for finding specific error patterns, configuration issues, or code references.",
 		searchFileContent,
 	)
 	if err != nil {
@@ -49,6 +69,21 @@ func init() {
 	}
 }
 
+// fileSearch is the result of searching a single file.
+type fileSearch struct {
+	results []SearchResult
+	matches int
+	err     error
+}
+
+// searchOptions controls how a single file is searched.
+type searchOptions struct {
+	regex      *regexp.Regexp
+	before     int
+	after      int
+	maxResults int
+}
+
 // searchFileContent searches for a pattern in files within a directory.
 func searchFileContent(ctx context.Context, input SearchFileContentInput) (SearchFileContentOutput, error) {
 	// Validate pattern
@@ -62,6 +97,23 @@ func searchFileContent(ctx context.Context, input SearchFileContentInput) (Searc
 		return SearchFileContentOutput{}, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
+	var include *includeFilter
+	if input.Include != "" {
+		if include, err = newIncludeFilter(input.Include); err != nil {
+			return SearchFileContentOutput{}, fmt.Errorf("invalid include pattern: %w", err)
+		}
+	}
+
+	opts := searchOptions{
+		regex:      regex,
+		before:     clampContext(input.Before),
+		after:      clampContext(input.After),
+		maxResults: defaultMaxResults,
+	}
+	if input.MaxResults != nil && *input.MaxResults > 0 {
+		opts.maxResults = *input.MaxResults
+	}
+
 	// Determine search directory
 	searchDir := input.Path
 	if searchDir == "" {
@@ -78,104 +130,331 @@ func searchFileContent(ctx context.Context, input SearchFileContentInput) (Searc
 	if _, err := os.Stat(absSearchDir); os.IsNotExist(err) {
 		return SearchFileContentOutput{}, fmt.Errorf("search directory does not exist: %s", absSearchDir)
 	}
+	if err := currentSandbox().Check(absSearchDir); err != nil {
+		return SearchFileContentOutput{}, err
+	}
 
-	var results []SearchResult
-	totalFiles := 0
+	files, err := collectSearchFiles(ctx, absSearchDir, include)
+	if err != nil {
+		return SearchFileContentOutput{}, err
+	}
+
+	searches := make([]fileSearch, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), 8, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				searches[i].results, searches[i].matches, searches[i].err = searchInFile(ctx, files[i], opts)
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return SearchFileContentOutput{}, err
+	}
 
-	// Walk through directory
-	err = filepath.Walk(absSearchDir, func(path string, info os.FileInfo, err error) error {
+	// Merge in walk order so the output is stable regardless of scheduling
+	output := SearchFileContentOutput{
+		Results:    []SearchResult{},
+		TotalFiles: len(files),
+	}
+	fileMatches := make(map[string]int)
+	for i, s := range searches {
+		if s.err != nil {
+			output.Errors = append(output.Errors, fmt.Sprintf("%s: %v", files[i].Path, s.err))
+			continue
+		}
+		if s.matches == 0 {
+			continue
+		}
+		fileMatches[files[i].Path] = s.matches
+		output.TotalMatches += s.matches
+		if room := opts.maxResults - len(output.Results); room > 0 {
+			output.Results = append(output.Results, s.results[:min(room, len(s.results))]...)
+		}
+	}
+	if output.TotalMatches > len(output.Results) {
+		output.Truncated = true
+		output.FileMatches = fileMatches
+	}
+	return output, nil
+}
+
+// collectSearchFiles walks the search directory and returns the files to
+// search in lexical order, with the files of a rotation set oldest first and
+// zip archives expanded into their text members.
+func collectSearchFiles(ctx context.Context, root string, include *includeFilter) ([]*logSource, error) {
+	box := currentSandbox()
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		// Skip directories
-		if info.IsDir() {
+		if d.IsDir() {
 			return nil
 		}
 
-		// Apply include filter if specified
-		if input.Include != "" {
-			matched, err := filepath.Match(input.Include, filepath.Base(path))
-			if err != nil {
-				return err
-			}
-			if !matched {
-				return nil
-			}
-		}
-
 		// Skip binary files and common non-text files
 		if isBinaryFile(path) {
 			return nil
 		}
 
-		totalFiles++
+		// Skip denied files and symlinks leading out of the allowed directories
+		if box.Check(path) != nil {
+			return nil
+		}
 
-		// Search in file
-		fileResults, err := searchInFile(path, regex)
-		if err != nil {
-			// Log error but continue with other files
-			fmt.Printf("Warning: failed to search in file %s: %v\n", path, err)
+		// Apply include filter if specified, zip archives are filtered by member
+		if include != nil && !isZipFile(path) && !include.match(root, path) {
 			return nil
 		}
 
-		results = append(results, fileResults...)
+		paths = append(paths, path)
 		return nil
 	})
-
 	if err != nil {
-		return SearchFileContentOutput{}, fmt.Errorf("failed to walk directory: %w", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
+	sortRotations(paths)
 
-	return SearchFileContentOutput{
-		Results:      results,
-		TotalFiles:   totalFiles,
-		TotalMatches: len(results),
-	}, nil
+	var files []*logSource
+	for _, path := range paths {
+		if !isZipFile(path) {
+			files = append(files, &logSource{Path: path, File: path})
+			continue
+		}
+		members, err := zipMembers(path)
+		if err != nil {
+			// Not a readable archive, search it like any other file
+			files = append(files, &logSource{Path: path, File: path})
+			continue
+		}
+		for _, member := range members {
+			memberPath := path + archiveSeparator + member
+			if include != nil && !include.match(root, memberPath) {
+				continue
+			}
+			files = append(files, &logSource{Path: memberPath, File: path, Member: member, Compression: compressionZip})
+		}
+	}
+	return files, nil
+}
+
+// isZipFile reports whether a file is a zip archive by its extension.
+func isZipFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
 }
 
-// searchInFile searches for a pattern in a specific file.
-func searchInFile(filePath string, regex *regexp.Regexp) ([]SearchResult, error) {
-	file, err := os.Open(filePath)
+// searchInFile searches for a pattern in a specific file. It returns up to
+// maxResults matches with their context and the total number of matches.
+func searchInFile(ctx context.Context, src *logSource, opts searchOptions) ([]SearchResult, int, error) {
+	if src.Member == "" {
+		compression, err := sniffCompression(src.File)
+		if err != nil {
+			return nil, 0, err
+		}
+		src.Compression = compression
+	}
+	file, err := src.open(ctx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer file.Close()
 
 	var results []SearchResult
-	scanner := bufio.NewScanner(file)
+	var recent []string // the last opts.before lines
+	var pending []int   // indexes of results still collecting after-context
+	matches := 0
 	lineNumber := 0
+	reader := bufio.NewReaderSize(file, 64*1024)
 
-	for scanner.Scan() {
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line == "" && readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.EOF {
+			return nil, 0, readErr
+		}
 		lineNumber++
-		line := scanner.Text()
+		if lineNumber%cancelCheckLines == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+		}
+		line = trimLineEnding(line)
+
+		// Append after-context before checking for a match, so a match inside
+		// the context of an earlier match is shown in both
+		if len(pending) > 0 {
+			kept := pending[:0]
+			for _, i := range pending {
+				results[i].After = append(results[i].After, truncateRunes(line, maxMatchLineRune))
+				if len(results[i].After) < opts.after {
+					kept = append(kept, i)
+				}
+			}
+			pending = kept
+		}
 
-		if regex.MatchString(line) {
-			results = append(results, SearchResult{
-				FilePath:   filePath,
-				LineNumber: lineNumber,
-				Content:    strings.TrimSpace(line),
-			})
+		if opts.regex.MatchString(line) {
+			matches++
+			if len(results) < opts.maxResults {
+				result := SearchResult{
+					FilePath:   src.Path,
+					LineNumber: lineNumber,
+					Content:    truncateRunes(strings.TrimSpace(line), maxMatchLineRune),
+				}
+				if len(recent) > 0 {
+					result.Before = append([]string(nil), recent...)
+				}
+				results = append(results, result)
+				if opts.after > 0 {
+					pending = append(pending, len(results)-1)
+				}
+			}
+		}
+
+		if opts.before > 0 {
+			if len(recent) == opts.before {
+				recent = recent[1:]
+			}
+			recent = append(recent, truncateRunes(line, maxMatchLineRune))
 		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	return results, matches, nil
+}
+
+// clampContext returns the number of context lines to include.
+func clampContext(n *int) int {
+	if n == nil || *n < 0 {
+		return 0
 	}
+	return min(*n, maxContextLines)
+}
 
-	if err := scanner.Err(); err != nil {
+// includeFilter matches files against an include glob. Globs without a '/'
+// match the file name, others match the slash-separated relative path.
+type includeFilter struct {
+	pattern   *regexp.Regexp
+	matchPath bool
+}
+
+// newIncludeFilter compiles an include glob.
+func newIncludeFilter(glob string) (*includeFilter, error) {
+	pattern, err := globToRegexp(glob)
+	if err != nil {
 		return nil, err
 	}
+	return &includeFilter{pattern: pattern, matchPath: strings.Contains(filepath.ToSlash(glob), "/")}, nil
+}
 
-	return results, nil
+// match reports whether a file under root passes the filter.
+func (f *includeFilter) match(root, path string) bool {
+	name := filepath.Base(path)
+	if f.matchPath {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return false
+		}
+		name = filepath.ToSlash(rel)
+	}
+	if f.pattern.MatchString(name) {
+		return true
+	}
+	// Rotated files match the pattern of the current log, e.g. *.log matches app.log.2.gz
+	current, _, ok := parseRotation(filepath.Base(path))
+	return ok && f.pattern.MatchString(strings.TrimSuffix(name, filepath.Base(path))+current)
+}
+
+// globToRegexp converts a glob into an anchored regular expression. It supports
+// '*', '?', character classes, '{a,b}' alternatives and '**' for any number of
+// directories.
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "./")
+	var b strings.Builder
+	b.WriteString("^")
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '{':
+			depth++
+			b.WriteString("(?:")
+		case c == '}' && depth > 0:
+			depth--
+			b.WriteString(")")
+		case c == ',' && depth > 0:
+			b.WriteString("|")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unterminated '{' in %q", glob)
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
 }
 
-// isBinaryFile checks if a file is likely to be binary.
+// isBinaryFile checks if a file is likely to be binary. Compressed files are
+// judged by the name inside the compression extension, e.g. app.log.gz is text.
 func isBinaryFile(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
+	ext := strings.ToLower(filepath.Ext(stripCompressionExt(filePath)))
 	binaryExts := map[string]bool{
 		".exe": true, ".dll": true, ".so": true, ".dylib": true,
 		".bin": true, ".dat": true, ".db": true, ".sqlite": true,
 		".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
 		".bmp": true, ".ico": true, ".svg": true, ".webp": true,
 		".mp3": true, ".mp4": true, ".avi": true, ".mov": true,
-		".zip": true, ".tar": true, ".gz": true, ".rar": true,
+		".tar": true, ".tgz": true, ".rar": true, ".7z": true,
+		".jar": true, ".war": true, ".class": true, ".hprof": true,
 		".pdf": true, ".doc": true, ".docx": true, ".xls": true,
 		".xlsx": true, ".ppt": true, ".pptx": true,
 	}
@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		match []string
+		miss  []string
+	}{
+		{"*.log", []string{"app.log", ".log"}, []string{"app.log.1", "logs/app.log", "app.txt"}},
+		{"app-?.log", []string{"app-1.log", "app-a.log"}, []string{"app-10.log", "app-.log", "app-/.log"}},
+		{"**/*.log", []string{"app.log", "logs/app.log", "a/b/c/app.log"}, []string{"logs/app.txt"}},
+		{"logs/**/app-*.log", []string{"logs/app-1.log", "logs/2025/09/app-1.log"}, []string{"app-1.log", "other/logs/app-1.log"}},
+		{"logs/**", []string{"logs/app.log", "logs/a/b.log"}, []string{"logs", "other/app.log"}},
+		{"logs/*", []string{"logs/app.log"}, []string{"logs/a/b.log"}},
+		{"*.{log,out}", []string{"app.log", "nohup.out"}, []string{"app.err"}},
+		{"app.[0-9].log", []string{"app.1.log"}, []string{"app.a.log"}},
+		{"app.[!0-9].log", []string{"app.a.log"}, []string{"app.1.log"}},
+		{"./gc(1).log", []string{"gc(1).log"}, []string{"gc1.log"}},
+		{`\*.log`, []string{"*.log"}, []string{"app.log"}},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Errorf("globToRegexp(%q): %v", tt.glob, err)
+			continue
+		}
+		for _, name := range tt.match {
+			if !re.MatchString(name) {
+				t.Errorf("%q should match %q (%s)", tt.glob, name, re)
+			}
+		}
+		for _, name := range tt.miss {
+			if re.MatchString(name) {
+				t.Errorf("%q should not match %q (%s)", tt.glob, name, re)
+			}
+		}
+	}
+	for _, glob := range []string{"app.[log", "*.{log,out"} {
+		if _, err := globToRegexp(glob); err == nil {
+			t.Errorf("globToRegexp(%q) should fail", glob)
+		}
+	}
+}
+
+func TestIncludeFilter(t *testing.T) {
+	root := filepath.FromSlash("/var/log/app")
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		// Globs without '/' match the name at any depth
+		{"*.log", "/var/log/app/2025/app.log", true},
+		// Rotated files match the pattern of the current log
+		{"*.log", "/var/log/app/app.log.2.gz", true},
+		{"*.log", "/var/log/app/app.log.2025-09-23", true},
+		{"*.log", "/var/log/app/app.txt.1", false},
+		// Globs with '/' match the path relative to the root
+		{"2025/*.log", "/var/log/app/2025/app.log", true},
+		{"2025/*.log", "/var/log/app/app.log", false},
+		{"**/gc*.log", "/var/log/app/jvm/gc.log.1", true},
+	}
+	for _, tt := range tests {
+		f, err := newIncludeFilter(tt.glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.match(root, filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("include %q on %s = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSearchInFileContext(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"app.log": "l1\nERROR a\nl3\nERROR b\nl5\nl6\nl7\nERROR c\r\n",
+	})
+	src := &logSource{Path: filepath.Join(root, "app.log"), File: filepath.Join(root, "app.log")}
+	opts := searchOptions{regex: regexp.MustCompile("ERROR"), before: 2, after: 2, maxResults: 10}
+	results, matches, err := searchInFile(context.Background(), src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matches != 3 || len(results) != 3 {
+		t.Fatalf("expected 3 matches, got %d %+v", matches, results)
+	}
+	// A match inside the context of another is shown in both
+	want := []struct {
+		line          int
+		before, after string
+	}{
+		{2, "l1", "l3,ERROR b"},
+		{4, "ERROR a,l3", "l5,l6"},
+		{8, "l6,l7", ""},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.LineNumber != w.line || strings.Join(r.Before, ",") != w.before || strings.Join(r.After, ",") != w.after {
+			t.Errorf("result %d = %+v, want %+v", i, r, w)
+		}
+	}
+	if results[2].Content != "ERROR c" {
+		t.Errorf("line ending not trimmed: %q", results[2].Content)
+	}
+
+	// Matches beyond the limit are counted but not returned
+	opts.maxResults = 1
+	results, matches, err = searchInFile(context.Background(), src, opts)
+	if err != nil || matches != 3 || len(results) != 1 || strings.Join(results[0].After, ",") != "l3,ERROR b" {
+		t.Errorf("limited search: %d %+v %v", matches, results, err)
+	}
+}
+
+func TestSearchFileContentTruncation(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"a.log":        "ERROR 1\nERROR 2\nERROR 3\n",
+		"b.log":        "ok\nERROR 4\n",
+		"c/c.log":      "ERROR 5\nERROR 6\n",
+		"skip.txt":     "ERROR 7\n",
+		"app.jar":      "ERROR 8\n",
+		"d.log.1":      "ERROR 9\n",
+		"quiet/ok.log": "ok\n",
+	})
+	setTestSandbox(t, root)
+	maxResults := 4
+	output, err := searchFileContent(context.Background(), SearchFileContentInput{Pattern: "ERROR", Path: root, Include: "*.log", MaxResults: &maxResults})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalFiles != 5 || output.TotalMatches != 7 || !output.Truncated || len(output.Results) != 4 {
+		t.Fatalf("unexpected totals: files=%d matches=%d truncated=%v results=%d", output.TotalFiles, output.TotalMatches, output.Truncated, len(output.Results))
+	}
+	var got []string
+	for _, r := range output.Results {
+		got = append(got, r.Content)
+	}
+	// Results are in walk order: the whole first file, then the next
+	if strings.Join(got, ",") != "ERROR 1,ERROR 2,ERROR 3,ERROR 4" {
+		t.Errorf("unexpected results: %v", got)
+	}
+	counts := map[string]int{"a.log": 3, "b.log": 1, "c/c.log": 2, "d.log.1": 1}
+	if len(output.FileMatches) != len(counts) {
+		t.Errorf("unexpected per-file counts: %v", output.FileMatches)
+	}
+	for name, n := range counts {
+		if output.FileMatches[filepath.Join(root, filepath.FromSlash(name))] != n {
+			t.Errorf("%s: %d matches, want %d", name, output.FileMatches[filepath.Join(root, name)], n)
+		}
+	}
+
+	// Per-file counts are only reported when results are left out
+	output, err = searchFileContent(context.Background(), SearchFileContentInput{Pattern: "ERROR 4", Path: root})
+	if err != nil || output.Truncated || output.FileMatches != nil || len(output.Results) != 1 {
+		t.Errorf("untruncated search: %+v %v", output, err)
+	}
+}
+
+func TestSearchFileContentCancel(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"app.log": strings.Repeat("ERROR line\n", 3*cancelCheckLines),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := searchFileContent(ctx, SearchFileContentInput{Pattern: "ERROR", Path: root}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled search should fail with context.Canceled, got %v", err)
+	}
+	// A file scan stops within cancelCheckLines lines
+	src := &logSource{Path: filepath.Join(root, "app.log"), File: filepath.Join(root, "app.log")}
+	opts := searchOptions{regex: regexp.MustCompile("ERROR"), maxResults: 10}
+	if _, _, err := searchInFile(ctx, src, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled file scan should fail with context.Canceled, got %v", err)
+	}
+}
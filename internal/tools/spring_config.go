@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/springconfig"
+)
+
+const (
+	// maxSpringProperties caps the properties returned for a prefix.
+	maxSpringProperties = 200
+	// maxSimilarKeys caps the suggestions for a property that is not set.
+	maxSimilarKeys = 20
+)
+
+// SpringConfigInput represents the input parameters for the spring_config tool
+type SpringConfigInput struct {
+	Path     string   `json:"path,omitempty" description:"Optional: Absolute path to the project directory or the deployed config directory. Default: the configured git repository."`
+	Property string   `json:"property,omitempty" description:"Optional: Property key or prefix, e.g. 'spring.datasource' or 'server.port'. Relaxed binding applies: driverClassName, driver-class-name and DRIVER_CLASS_NAME are the same key. Default: all properties."`
+	Profiles []string `json:"profiles,omitempty" description:"Optional: Active profiles. Default: the profiles the log reports as active, else spring.profiles.active from the start command, environment or config files."`
+	LogPath  string   `json:"log_path,omitempty" description:"Optional: Absolute path to the application log, used to find the active profiles ('The following profiles are active')."`
+	Module   string   `json:"module,omitempty" description:"Optional: Module directory relative to path in a multi-module project, e.g. 'order-service'. Default: the module matching the jar in the start command."`
+}
+
+// SpringProperty represents the effective value of a property
+type SpringProperty struct {
+	Key        string   `json:"key" description:"The key as written in the winning source"`
+	Value      string   `json:"value" description:"Effective value with placeholders resolved"`
+	Raw        string   `json:"raw,omitempty" description:"Value as written, when it contains placeholders"`
+	Source     string   `json:"source" description:"Winning source: 'file:line', command line argument, system property or environment variable"`
+	Overrides  []string `json:"overrides,omitempty" description:"Lower-precedence values hidden by the winning one, as 'source = value'"`
+	Unresolved []string `json:"unresolved,omitempty" description:"Placeholders without a value or default, which fail binding at startup"`
+}
+
+// SpringConfigOutput represents the output of the spring_config tool
+type SpringConfigOutput struct {
+	Root            string           `json:"root" description:"The searched directory"`
+	Modules         []string         `json:"modules,omitempty" description:"Modules with src/main/resources config found under root, '.' for root itself"`
+	Module          string           `json:"module,omitempty" description:"The module whose config was loaded"`
+	Files           []string         `json:"files" description:"Loaded config files from lowest to highest precedence"`
+	ActiveProfiles  []string         `json:"active_profiles" description:"Profiles applied"`
+	ProfilesFrom    string           `json:"profiles_from" description:"Where the active profiles came from"`
+	Properties      []SpringProperty `json:"properties" description:"Matching properties with their effective values"`
+	Similar         []string         `json:"similar,omitempty" description:"Existing keys close to the requested one when it is not set, e.g. misspelled or wrong prefix"`
+	TotalProperties int              `json:"total_properties" description:"Number of matching properties"`
+	Truncated       bool             `json:"truncated" description:"Whether properties were omitted, narrow the prefix"`
+	Errors          []string         `json:"errors,omitempty" description:"Config files that could not be parsed"`
+	Note            string           `json:"note,omitempty" description:"Hints about the result"`
+}
+
+// SpringConfigTool is a tool that resolves the effective Spring Boot configuration.
+var SpringConfigTool tool.InvokableTool
+
+func init() {
+	var err error
+	SpringConfigTool, err = utils.InferTool(
+		"spring_config",
+		"Resolves the effective Spring Boot configuration the way the application sees it: loads application/bootstrap .yml and .properties files including profile-specific files and multi-document profile sections, applies the active profiles, program arguments, -D system properties and environment variables in Spring Boot's precedence order, and resolves ${...} placeholders. Returns each property's effective value, the file and line it comes from, the values it overrides and unresolved placeholders. Environment variables are those assigned in the start command, plus the analyzer's own environment only when it launched the application. In a multi-module project only the target module's config is loaded. Use it for 'Failed to bind properties under ...', 'Could not resolve placeholder', 'Failed to configure a DataSource' and port or URL problems.",
+		springConfig,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create spring_config tool: %v", err))
+	}
+}
+
+// springConfig loads the configuration and returns the requested properties.
+func springConfig(ctx context.Context, input SpringConfigInput) (SpringConfigOutput, error) {
+	root := input.Path
+	if root == "" {
+		root = currentGitRepo()
+	}
+	if root == "" {
+		return SpringConfigOutput{}, errors.New("path is required when no git repository is configured")
+	}
+	if !filepath.IsAbs(root) {
+		return SpringConfigOutput{}, fmt.Errorf("path must be absolute: %s", root)
+	}
+	box := currentSandbox()
+	if err := box.Check(root); err != nil {
+		return SpringConfigOutput{}, err
+	}
+
+	profiles := input.Profiles
+	profilesFrom := ""
+	if len(profiles) == 0 && input.LogPath != "" {
+		file, _, err := openLogFile(ctx, input.LogPath)
+		if err != nil {
+			return SpringConfigOutput{}, err
+		}
+		profiles, err = springconfig.ProfilesFromLog(file)
+		file.Close()
+		if err != nil {
+			return SpringConfigOutput{}, fmt.Errorf("failed to read log: %w", err)
+		}
+		profilesFrom = input.LogPath
+	}
+
+	environment, err := springconfig.Load(springconfig.Options{
+		Root:     root,
+		Profiles: profiles,
+		Module:   input.Module,
+		Command:  currentStartCommand(),
+		Env:      currentAppEnvironment(),
+		Allow:    func(path string) bool { return box.Check(path) == nil },
+		Open:     func(path string) (io.ReadCloser, error) { return box.Open(path) },
+	})
+	if err != nil {
+		return SpringConfigOutput{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+	location := func(p springconfig.Property) string {
+		return strings.Replace(p.Location(), p.Source, relative(p.Source), 1)
+	}
+
+	output := SpringConfigOutput{
+		Root:           root,
+		Files:          []string{},
+		ActiveProfiles: environment.Profiles,
+		ProfilesFrom:   environment.ProfilesFrom,
+		Properties:     []SpringProperty{},
+		Modules:        environment.Modules,
+		Module:         environment.Module,
+		Errors:         environment.Errors,
+	}
+	if len(environment.Modules) > 1 && environment.Module == "" {
+		output.Note = "Config files of all modules were merged because the start command does not name a module's jar; pass module to load one"
+	}
+	if profilesFrom != "" && len(profiles) > 0 {
+		output.ProfilesFrom = profilesFrom
+	}
+	for _, file := range environment.Files {
+		output.Files = append(output.Files, relative(file))
+	}
+
+	keys := environment.Keys(input.Property)
+	output.TotalProperties = len(keys)
+	for _, key := range keys {
+		if len(output.Properties) >= maxSpringProperties {
+			output.Truncated = true
+			break
+		}
+		resolved, ok := environment.Get(key)
+		if !ok {
+			continue
+		}
+		property := SpringProperty{
+			Key:        resolved.Key,
+			Value:      resolved.Value,
+			Source:     location(resolved.Property),
+			Unresolved: resolved.Unresolved,
+		}
+		if resolved.Value != resolved.Property.Value {
+			property.Raw = resolved.Property.Value
+		}
+		for _, p := range resolved.Overridden {
+			property.Overrides = append(property.Overrides, location(p)+" = "+p.Value)
+		}
+		output.Properties = append(output.Properties, property)
+	}
+	if len(keys) == 0 && input.Property != "" {
+		output.Similar = environment.Similar(input.Property)
+		if len(output.Similar) > maxSimilarKeys {
+			output.Similar = output.Similar[:maxSimilarKeys]
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/user/java-startup-analyzer/internal/redact"
+	"github.com/user/java-startup-analyzer/internal/testutil"
+)
+
+func TestSpringConfigRedactsCredentials(t *testing.T) {
+	root := testutil.WriteFiles(t, map[string]string{
+		"src/main/resources/application.yml": "spring:\n" +
+			"  datasource:\n" +
+			"    url: jdbc:mysql://db:3306/app\n" +
+			"    username: app\n" +
+			"    password: hunter2xyz\n" +
+			"  redis:\n" +
+			"    password: ${REDIS_PASS:r3disdefault}\n",
+		"src/main/resources/application-prod.properties": "spring.datasource.password=pr0dsecret\n",
+	})
+	setTestSandbox(t, root)
+
+	redactor, err := redact.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := Redacted(SpringConfigTool, redactor).InvokableRun(context.Background(),
+		fmt.Sprintf(`{"path":%q,"property":"spring","profiles":["prod"]}`, root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2xyz", "pr0dsecret", "r3disdefault"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("output contains %q: %s", secret, output)
+		}
+	}
+
+	var decoded SpringConfigOutput
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]SpringProperty)
+	for _, p := range decoded.Properties {
+		values[p.Key] = p
+	}
+	// Non-secret values and the structure of the properties are kept
+	if values["spring.datasource.url"].Value != "jdbc:mysql://db:3306/app" || values["spring.datasource.username"].Value != "app" {
+		t.Errorf("unexpected properties: %+v", decoded.Properties)
+	}
+	password := values["spring.datasource.password"]
+	if !strings.HasPrefix(password.Value, "[REDACTED_") || len(password.Overrides) != 1 || !strings.HasSuffix(password.Source, ":1") {
+		t.Errorf("unexpected password property: %+v", password)
+	}
+	if redactor.Restore(password.Value) != "pr0dsecret" {
+		t.Errorf("placeholder %s does not restore the value", password.Value)
+	}
+}
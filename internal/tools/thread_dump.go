@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/user/java-startup-analyzer/internal/threaddump"
+)
+
+const (
+	// maxThreadGroups caps the thread groups listed.
+	maxThreadGroups = 15
+	// maxGroupThreads caps the thread names listed per group.
+	maxGroupThreads = 10
+	// maxGroupFrames caps the frames listed per group.
+	maxGroupFrames = 12
+	// maxMainFrames caps the frames of the main thread.
+	maxMainFrames = 40
+)
+
+// ThreadDumpInput represents the input parameters for the thread_dump tool
+type ThreadDumpInput struct {
+	AbsolutePaths []string `json:"absolute_paths" description:"Absolute paths of files with thread dumps (jstack, jcmd Thread.print, or a log/stdout file with dumps written by kill -3), in the order they were taken. All dumps in all files are read, the last one is analyzed and the earlier ones are used to check for progress."`
+}
+
+// ThreadGroup represents threads with the same state and stack
+type ThreadGroup struct {
+	State   string   `json:"state" description:"java.lang.Thread.State"`
+	Count   int      `json:"count" description:"Number of threads"`
+	Threads []string `json:"threads" description:"Thread names"`
+	Frames  []string `json:"frames" description:"Shared stack, top first"`
+}
+
+// DeadlockedThread represents a thread in a deadlock cycle
+type DeadlockedThread struct {
+	Thread     string `json:"thread" description:"Thread name"`
+	State      string `json:"state" description:"Thread state"`
+	Frame      string `json:"frame,omitempty" description:"Top frame"`
+	WaitingFor string `json:"waiting_for" description:"The lock the thread waits for"`
+	HeldBy     string `json:"held_by" description:"The thread holding that lock"`
+}
+
+// StartupHang represents where a startup thread is blocked and why
+type StartupHang struct {
+	Kind        string `json:"kind" description:"deadlock, dns, config_center (Nacos, Apollo, Spring Cloud Config, Consul), datasource (connection pool or JDBC driver), init_lock (lock wait in @PostConstruct/afterPropertiesSet), lock, network, waiting or running"`
+	Thread      string `json:"thread" description:"Thread name"`
+	State       string `json:"state" description:"Thread state"`
+	Frame       string `json:"frame,omitempty" description:"The frame showing the cause"`
+	Caller      string `json:"caller,omitempty" description:"Nearest application frame"`
+	Owner       string `json:"owner,omitempty" description:"The thread holding the lock this thread waits for"`
+	Stuck       bool   `json:"stuck" description:"The top frame is the same in every dump, no progress"`
+	Description string `json:"description" description:"What the thread is blocked on and what to check"`
+}
+
+// ThreadDumpOutput represents the output of the thread_dump tool
+type ThreadDumpOutput struct {
+	Dumps                int                  `json:"dumps" description:"Thread dumps found"`
+	Time                 string               `json:"time,omitempty" description:"When the analyzed dump was taken"`
+	Threads              int                  `json:"threads" description:"Java threads"`
+	VMThreads            int                  `json:"vm_threads" description:"JVM internal threads such as GC and compiler threads"`
+	States               map[string]int       `json:"states" description:"Java threads by state"`
+	Deadlocks            [][]DeadlockedThread `json:"deadlocks" description:"Lock wait cycles, each a list of threads"`
+	JVMReportedDeadlocks int                  `json:"jvm_reported_deadlocks,omitempty" description:"Deadlocks the JVM reported itself"`
+	StartupThreads       []StartupHang        `json:"startup_threads" description:"Where main (or restartedMain, localhost-startStop-N) is blocked and why"`
+	MainStack            []string             `json:"main_stack,omitempty" description:"Stack of the main thread, top first"`
+	Groups               []ThreadGroup        `json:"groups" description:"Threads grouped by state and stack, largest first"`
+}
+
+// ThreadDumpTool is a tool that analyzes thread dumps.
+var ThreadDumpTool tool.InvokableTool
+
+func init() {
+	var err error
+	ThreadDumpTool, err = utils.InferTool(
+		"thread_dump",
+		"Parses thread dumps from jstack, jcmd Thread.print, or logs with dumps written by kill -3, and analyzes the last one: threads grouped by state and stack, Java-level deadlock cycles with the locks and their owners, and where the startup thread (main) is blocked, e.g. a DataSource pool, a DNS lookup, a Nacos/Apollo config fetch or a lock in @PostConstruct. With several dumps it reports whether the startup thread made progress. Use it when the application hangs during startup or the log contains a thread dump.",
+		analyzeThreadDumps,
+	)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create thread_dump tool: %v", err))
+	}
+}
+
+// analyzeThreadDumps parses the dumps in the files and analyzes them.
+func analyzeThreadDumps(ctx context.Context, input ThreadDumpInput) (ThreadDumpOutput, error) {
+	if len(input.AbsolutePaths) == 0 {
+		return ThreadDumpOutput{}, errors.New("absolute_paths is required")
+	}
+
+	var dumps []*threaddump.Dump
+	for _, path := range input.AbsolutePaths {
+		if !filepath.IsAbs(path) {
+			return ThreadDumpOutput{}, fmt.Errorf("path must be absolute: %s", path)
+		}
+		file, _, err := openLogFile(ctx, path)
+		if err != nil {
+			return ThreadDumpOutput{}, err
+		}
+		found, err := threaddump.Parse(file)
+		file.Close()
+		if err != nil {
+			return ThreadDumpOutput{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		dumps = append(dumps, found...)
+	}
+	if len(dumps) == 0 {
+		return ThreadDumpOutput{}, fmt.Errorf("no thread dump found in %s", strings.Join(input.AbsolutePaths, ", "))
+	}
+
+	analysis := threaddump.Analyze(dumps)
+	output := ThreadDumpOutput{
+		Dumps:                analysis.Dumps,
+		Time:                 analysis.Time,
+		Threads:              analysis.Threads,
+		VMThreads:            analysis.VMThreads,
+		States:               analysis.States,
+		Deadlocks:            [][]DeadlockedThread{},
+		JVMReportedDeadlocks: analysis.ReportedDeadlocks,
+		StartupThreads:       []StartupHang{},
+		Groups:               []ThreadGroup{},
+	}
+	for _, deadlock := range analysis.Deadlocks {
+		var cycle []DeadlockedThread
+		for _, t := range deadlock.Threads {
+			cycle = append(cycle, DeadlockedThread{
+				Thread:     t.Name,
+				State:      t.State,
+				Frame:      t.Frame,
+				WaitingFor: fmt.Sprintf("<%s> (a %s)", t.Waiting.Address, t.Waiting.Class),
+				HeldBy:     t.HeldBy,
+			})
+		}
+		output.Deadlocks = append(output.Deadlocks, cycle)
+	}
+	for _, hang := range analysis.Hangs {
+		output.StartupThreads = append(output.StartupThreads, StartupHang{
+			Kind:        string(hang.Kind),
+			Thread:      hang.Thread,
+			State:       hang.State,
+			Frame:       hang.Frame,
+			Caller:      hang.Caller,
+			Owner:       hang.Owner,
+			Stuck:       hang.Stuck,
+			Description: hang.Description,
+		})
+	}
+	if analysis.Main != nil {
+		output.MainStack = analysis.Main.Frames[:min(len(analysis.Main.Frames), maxMainFrames)]
+	}
+	for _, group := range analysis.Groups[:min(len(analysis.Groups), maxThreadGroups)] {
+		output.Groups = append(output.Groups, ThreadGroup{
+			State:   group.State,
+			Count:   len(group.Threads),
+			Threads: group.Threads[:min(len(group.Threads), maxGroupThreads)],
+			Frames:  group.Frames[:min(len(group.Frames), maxGroupFrames)],
+		})
+	}
+	return output, nil
+}
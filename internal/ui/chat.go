@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cloudwego/eino/schema"
 	"github.com/user/java-startup-analyzer/internal/analyzer"
+	"github.com/user/java-startup-analyzer/internal/runner"
 )
 
 // Message 表示聊天中的一条消息
@@ -41,6 +42,8 @@ type ChatModel struct {
 	typingPos      int                                   // 当前已输出的位置（按rune计）
 	isFirst        bool                                  // 是否是第一次分析
 	streamReader   *schema.StreamReader[*schema.Message] // 流式读取器
+	runResult      *runner.Result                        // run 命令的进程运行结果 (可选)
+	hideSecrets    bool                                  // 显示脱敏占位符而不是还原后的原值
 }
 
 // AnalysisCompleteMsg 分析完成的消息
@@ -71,6 +74,7 @@ type StartTypingMsg struct {
 type StartStreamMsg struct {
 	StreamReader *schema.StreamReader[*schema.Message]
 	isFirst      bool
+	timeline     string // 首次分析时渲染好的启动阶段时间线
 }
 
 // 移除analysisDoneMsg类型定义 - 不再需要
@@ -97,6 +101,11 @@ func NewChatModel(config *analyzer.Config) (*ChatModel, error) {
 	}, nil
 }
 
+// SetRunResult 设置 run 命令的进程运行结果，自动分析时一并交给分析器
+func (m *ChatModel) SetRunResult(result *runner.Result) {
+	m.runResult = result
+}
+
 func (m ChatModel) Init() tea.Cmd {
 	// 启动时自动开始分析日志
 	return tea.Sequence(func() tea.Msg { return startProcessingMsg{} }, m.autoAnalyze())
@@ -138,6 +147,16 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.streamingMsg = ""
 		m.isFirst = msg.isFirst
 		m.streamReader = msg.StreamReader
+		if msg.timeline != "" {
+			m.messages = append(m.messages, Message{
+				Content: msg.timeline,
+				Sender:  "bot",
+				Time:    time.Now(),
+				Type:    "text",
+			})
+			m.viewport.SetContent(m.renderMessages())
+			m.viewport.GotoBottom()
+		}
 		// 启动流式读取
 		return m, m.startStreaming(msg.StreamReader)
 
@@ -146,6 +165,12 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" && m.isProcessing {
 			m.isProcessing = false
 			m.wasInterrupted = true
+			// 停止本轮代理执行并关闭流式读取器；分析器会丢弃这一轮未完成的工具调用
+			m.analyzer.Interrupt()
+			if m.streamReader != nil {
+				m.streamReader.Close()
+				m.streamReader = nil
+			}
 			// 清理打字机状态和临时内容
 			m.streamingMsg = ""
 			m.typingFull = ""
@@ -158,6 +183,11 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		switch msg.String() {
+		case "ctrl+r":
+			// 切换显示脱敏占位符（发送给模型的内容）或本地还原的原值
+			m.hideSecrets = !m.hideSecrets
+			m.viewport.SetContent(m.renderMessages())
+			return m, nil
 		case "ctrl+c":
 			if m.ctrlCPressed {
 				// 第二次按Ctrl+C，完全退出
@@ -270,6 +300,10 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.GotoBottom()
 
 	case StreamMsg:
+		if m.wasInterrupted && !m.isProcessing {
+			// 中断后仍在途的数据块直接丢弃
+			return m, nil
+		}
 		if msg.Error != nil {
 			m.isProcessing = false
 			m.messages = append(m.messages, Message{
@@ -289,7 +323,7 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Time:    time.Now(),
 					Type:    "analysis",
 				})
-				// MessageModifier 会自动管理对话历史，无需手动添加
+				// 分析器已在会话历史中记录本轮回答和工具调用，无需手动添加
 				m.streamingMsg = ""
 			}
 			// 移除第一次分析完成后的额外消息
@@ -397,7 +431,7 @@ func (m ChatModel) View() string {
 			Italic(true)
 		timeStr := timeStyle.Render(time.Now().Format("15:04:05"))
 		s.WriteString(streamingStyle.Render("🤖 分析器 ") + timeStr + "\n")
-		s.WriteString(m.streamingMsg)
+		s.WriteString(m.displayText(m.streamingMsg))
 		s.WriteString("\n")
 	}
 
@@ -424,9 +458,9 @@ func (m ChatModel) View() string {
 		interruptedStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")). // 红色
 			Bold(true)
-		s.WriteString(interruptedStyle.Render("💬 请输入您的问题 (Ctrl+C取消输入/退出, 方向键移动光标, Backspace/Delete删除): "))
+		s.WriteString(interruptedStyle.Render("💬 请输入您的问题 (Ctrl+C取消输入/退出, Ctrl+R切换脱敏显示, 方向键移动光标, Backspace/Delete删除): "))
 	} else {
-		s.WriteString(inputStyle.Render("💬 请输入您的问题 (Ctrl+C取消输入/退出, 方向键移动光标, Backspace/Delete删除): "))
+		s.WriteString(inputStyle.Render("💬 请输入您的问题 (Ctrl+C取消输入/退出, Ctrl+R切换脱敏显示, 方向键移动光标, Backspace/Delete删除): "))
 	}
 
 	// 显示输入内容，光标用下划线显示（不占用字符位置）
@@ -491,13 +525,15 @@ func (m *ChatModel) startStreaming(streamReader *schema.StreamReader[*schema.Mes
 
 // continueStreaming 继续流式处理
 func (m *ChatModel) continueStreaming() tea.Cmd {
+	// 中断时读取器会被置空，这里持有当前的读取器
+	streamReader := m.streamReader
 	return func() tea.Msg {
-		if m.streamReader == nil {
+		if streamReader == nil {
 			return StreamMsg{Error: fmt.Errorf("stream reader is nil"), Done: true}
 		}
 
 		// 读取下一个流式数据块
-		message, err := m.streamReader.Recv()
+		message, err := streamReader.Recv()
 		if err != nil {
 			if err == io.EOF {
 				// 流式输出完成
@@ -553,15 +589,23 @@ func (m ChatModel) renderMessage(msg Message) string {
 			content.WriteString(errorStyle.Render(msg.Content))
 		case "analysis":
 			// 对于分析结果，只显示内容，不显示额外的格式
-			content.WriteString(msg.Content)
+			content.WriteString(m.displayText(msg.Content))
 		default:
-			content.WriteString(msg.Content)
+			content.WriteString(m.displayText(msg.Content))
 		}
 	}
 
 	return content.String()
 }
 
+// displayText 返回模型输出的显示文本，默认将脱敏占位符还原为原值
+func (m ChatModel) displayText(text string) string {
+	if m.hideSecrets || m.analyzer == nil {
+		return text
+	}
+	return m.analyzer.RestoreSecrets(text)
+}
+
 // isValidInput 检查输入是否为有效的可打印字符（包括中文等多字节UTF-8字符）
 func isValidInput(s string) bool {
 	if s == "" {
@@ -617,7 +661,7 @@ func (m ChatModel) processJavaLog(input string) tea.Cmd {
 func (m ChatModel) autoAnalyze() tea.Cmd {
 	return func() tea.Msg {
 		// 获取日志文件路径
-		logPath, err := m.getLogFilePath()
+		logPaths, err := m.getLogFilePaths()
 		if err != nil {
 			return AnalysisCompleteMsg{
 				Error: fmt.Errorf("获取日志文件路径失败: %w", err),
@@ -626,22 +670,30 @@ func (m ChatModel) autoAnalyze() tea.Cmd {
 
 		// 使用流式调用分析器，传递文件路径让大模型自己使用工具读取
 		ctx := context.Background()
-		streamReader, err := m.analyzer.ChatStream(ctx, map[string]any{"log_path": logPath})
+		input := map[string]any{"log_paths": logPaths}
+		if m.runResult != nil {
+			input["run_result"] = m.runResult
+		}
+		streamReader, err := m.analyzer.ChatStream(ctx, input)
 		if err != nil {
 			return AnalysisCompleteMsg{
 				Error: err,
 			}
 		}
 
-		// 启动流式处理
-		return StartStreamMsg{StreamReader: streamReader, isFirst: true}
+		// 启动流式处理，同时展示分析器提取的启动时间线
+		timeline := renderTimelines(m.analyzer.StartupTimelines(logPaths))
+		return StartStreamMsg{StreamReader: streamReader, isFirst: true, timeline: timeline}
 	}
 }
 
-func (m ChatModel) getLogFilePath() (string, error) {
+func (m ChatModel) getLogFilePaths() ([]string, error) {
 	// 返回日志文件路径，让大模型自己使用工具读取
+	if len(m.config.LogPaths) > 0 {
+		return m.config.LogPaths, nil
+	}
 	if m.config.LogPath == "" {
-		return "", fmt.Errorf("日志文件路径未配置")
+		return nil, fmt.Errorf("日志文件路径未配置")
 	}
-	return m.config.LogPath, nil
+	return []string{m.config.LogPath}, nil
 }
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/user/java-startup-analyzer/internal/analyzer"
+)
+
+// renderTimelines 将启动时间线渲染为表格，每个日志文件一张
+func renderTimelines(timelines []analyzer.FileTimeline) string {
+	if len(timelines) == 0 {
+		return ""
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205")).Padding(0, 1)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	summaryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Italic(true)
+
+	var s strings.Builder
+	s.WriteString("⏱️ 启动阶段时间线\n")
+	for _, ft := range timelines {
+		t := ft.Timeline
+		s.WriteString("\n" + ft.Path + "\n")
+
+		tbl := table.New().
+			Border(lipgloss.NormalBorder()).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("240"))).
+			Headers("阶段", "开始时间", "距开始", "耗时", "行号").
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headerStyle
+				}
+				return cellStyle
+			})
+		for _, p := range t.Phases {
+			tbl.Row(
+				analyzer.PhaseLabel(p.Name),
+				p.Start.Format("15:04:05.000"),
+				"+"+analyzer.FormatDuration(p.Start.Sub(t.Start)),
+				analyzer.PhaseDuration(p),
+				fmt.Sprintf("%d", p.StartLine),
+			)
+		}
+		s.WriteString(tbl.Render() + "\n")
+
+		s.WriteString(summaryStyle.Render(analyzer.TimelineSummary(t)) + "\n")
+		if ft.Truncated {
+			s.WriteString(summaryStyle.Render("文件较大，只从开头部分提取了时间线") + "\n")
+		}
+		for _, g := range t.Gaps {
+			s.WriteString(summaryStyle.Render(fmt.Sprintf("日志静默 %s（第 %d 行 → 第 %d 行）",
+				analyzer.FormatDuration(g.Duration), g.BeforeLine, g.AfterLine)) + "\n")
+		}
+	}
+	return s.String()
+}